@@ -0,0 +1,51 @@
+package silo
+
+import (
+	"fmt"
+)
+
+// AppendFrom adds every entry of other to the end of doc. It fails without
+// modifying doc if any path in other already exists in doc or appears twice
+// in other. If the appended content collides with doc's delimiter, a new safe
+// delimiter is selected for the combined document; an error is returned if
+// none exists.
+func (doc *SiloDocument) AppendFrom(other *SiloDocument) error {
+	seen := make(map[string]bool, len(doc.Files)+len(other.Files))
+	for _, file := range doc.Files {
+		seen[file.Path] = true
+	}
+	for _, file := range other.Files {
+		if seen[file.Path] {
+			return fmt.Errorf("%w: %s", ErrDuplicatePath, file.Path)
+		}
+		seen[file.Path] = true
+	}
+
+	delimiter := doc.Delimiter
+	if delimiter != "" && hasDelimiterConflict(other, delimiter) {
+		combined := &SiloDocument{Files: append(append([]SiloFile{}, doc.Files...), other.Files...)}
+		safe, err := findSafeDelimiter(combined)
+		if err != nil {
+			return fmt.Errorf("appended files conflict with delimiter %q: %w", doc.Delimiter, err)
+		}
+		delimiter = safe
+	}
+
+	doc.Files = append(doc.Files, other.Files...)
+	doc.Delimiter = delimiter
+	return nil
+}
+
+// hasDelimiterConflict reports whether any content line in doc would be read
+// as a file declaration for delimiter.
+func hasDelimiterConflict(doc *SiloDocument, delimiter string) bool {
+	for _, file := range doc.Files {
+		if file.Base64 {
+			continue
+		}
+		if len(delimiterLines(file.text(), delimiter, 1)) > 0 {
+			return true
+		}
+	}
+	return false
+}
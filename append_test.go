@@ -0,0 +1,67 @@
+package silo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppendFrom(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files:     []SiloFile{{Path: "a.txt", Content: "alpha\n"}},
+	}
+	other := &SiloDocument{
+		Files: []SiloFile{{Path: "b.txt", Content: "beta\n"}},
+	}
+
+	if err := doc.AppendFrom(other); err != nil {
+		t.Fatalf("AppendFrom failed: %v", err)
+	}
+	if len(doc.Files) != 2 || doc.Files[1].Path != "b.txt" {
+		t.Errorf("Expected b.txt appended, got %+v", doc.Files)
+	}
+	if doc.Delimiter != ">" {
+		t.Errorf("Expected delimiter to stay '>', got %q", doc.Delimiter)
+	}
+}
+
+func TestAppendFromDuplicatePath(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "alpha\n"}}}
+	other := &SiloDocument{Files: []SiloFile{
+		{Path: "new.txt", Content: "new\n"},
+		{Path: "a.txt", Content: "again\n"},
+	}}
+
+	err := doc.AppendFrom(other)
+	if !errors.Is(err, ErrDuplicatePath) {
+		t.Fatalf("Expected ErrDuplicatePath, got %v", err)
+	}
+	if len(doc.Files) != 1 {
+		t.Errorf("Expected document to be unchanged, got %d files", len(doc.Files))
+	}
+}
+
+func TestAppendFromReselectsDelimiter(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files:     []SiloFile{{Path: "a.txt", Content: "= heading\n"}},
+	}
+	other := &SiloDocument{Files: []SiloFile{{Path: "b.txt", Content: "> quoted\n"}}}
+
+	if err := doc.AppendFrom(other); err != nil {
+		t.Fatalf("AppendFrom failed: %v", err)
+	}
+	if doc.Delimiter != "*" {
+		t.Errorf("Expected delimiter '*' avoiding both files, got %q", doc.Delimiter)
+	}
+
+	var buf strings.Builder
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil || len(parsed.Files) != 2 {
+		t.Errorf("Expected combined archive to round-trip, got %v files (%v)", parsed, err)
+	}
+}
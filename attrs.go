@@ -0,0 +1,84 @@
+package silo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// attrKeyPattern is the syntax of annotation keys. Values are any non-empty
+// text without whitespace.
+var attrKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// splitAttrs splits trailing "key=value" annotations off a header line, as
+// in "src/main.go mode=0755 lang=go". attrs is nil when there are none.
+func splitAttrs(header string) (rest string, attrs map[string]string) {
+	rest = header
+	for {
+		idx := strings.LastIndexAny(rest, " \t")
+		if idx < 0 {
+			return rest, attrs
+		}
+		key, value, ok := parseAttr(rest[idx+1:])
+		if !ok {
+			return rest, attrs
+		}
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		if _, dup := attrs[key]; !dup {
+			attrs[key] = value
+		}
+		rest = strings.TrimRight(rest[:idx], " \t")
+	}
+}
+
+// parseAttr parses a single "key=value" annotation.
+func parseAttr(token string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(token, "=")
+	if !ok || value == "" || !attrKeyPattern.MatchString(key) {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// validateAttrs checks that every annotation can be written to a header
+// line and read back unchanged.
+func validateAttrs(attrs map[string]string) error {
+	for key, value := range attrs {
+		if !attrKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid annotation key %q", key)
+		}
+		if value == "" || strings.ContainsAny(value, " \t\r\n") {
+			return fmt.Errorf("annotation %s must have a value without whitespace, got %q", key, value)
+		}
+	}
+	return nil
+}
+
+// formatAttrs renders annotations for a header line, sorted by key, each
+// preceded by a space.
+func formatAttrs(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(" " + key + "=" + attrs[key])
+	}
+	return b.String()
+}
+
+// endsWithAttr reports whether path ends in a word that a reader would take
+// for an annotation, so the entry could not be read back as written.
+func endsWithAttr(path string) bool {
+	rest, _ := splitAttrs(path)
+	return rest != path
+}
@@ -0,0 +1,113 @@
+package silo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAttrs(t *testing.T) {
+	input := "> src/main.go mode=0755 lang=go\n" +
+		"package main\n" +
+		"> my notes.txt\n" +
+		"notes\n" +
+		"> current -> main.go owner=ci\n" +
+		"> big.bin @file:blobs/big.bin sha256=abc\n" +
+		"> logo.png @base64 type=image/png\n" +
+		"iVBORw==\n"
+
+	doc, err := ParseSiloFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+
+	expected := []SiloFile{
+		{Path: "src/main.go", Content: "package main\n", Attrs: map[string]string{"mode": "0755", "lang": "go"}},
+		{Path: "my notes.txt", Content: "notes\n"},
+		{Path: "current", LinkTarget: "main.go", Attrs: map[string]string{"owner": "ci"}},
+		{Path: "big.bin", Ref: "file:blobs/big.bin", Attrs: map[string]string{"sha256": "abc"}},
+		{Path: "logo.png", Content: "\x89PNG", Base64: true, Attrs: map[string]string{"type": "image/png"}},
+	}
+	if !reflect.DeepEqual(doc.Files, expected) {
+		t.Errorf("Expected %+v\ngot %+v", expected, doc.Files)
+	}
+
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	want := "> src/main.go lang=go mode=0755\n"
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("Expected annotations sorted by key, got:\n%s", out.String())
+	}
+
+	reparsed, err := ParseSiloFile(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile of written output failed: %v", err)
+	}
+	if !reflect.DeepEqual(reparsed.Files, expected) {
+		t.Errorf("Annotations did not round-trip:\n%+v", reparsed.Files)
+	}
+}
+
+func TestSplitAttrs(t *testing.T) {
+	tests := []struct {
+		header string
+		rest   string
+		attrs  map[string]string
+	}{
+		{"main.go", "main.go", nil},
+		{"main.go a=1", "main.go", map[string]string{"a": "1"}},
+		{"a=b.txt", "a=b.txt", nil},
+		{"main.go x=1=2", "main.go", map[string]string{"x": "1=2"}},
+		{"main.go empty=", "main.go empty=", nil},
+		{"main.go 9key=1", "main.go 9key=1", nil},
+		{"main.go a=1 a=2", "main.go", map[string]string{"a": "2"}},
+	}
+
+	for _, test := range tests {
+		rest, attrs := splitAttrs(test.header)
+		if rest != test.rest || !reflect.DeepEqual(attrs, test.attrs) {
+			t.Errorf("splitAttrs(%q) = %q, %v; want %q, %v", test.header, rest, attrs, test.rest, test.attrs)
+		}
+	}
+}
+
+func TestWriteInvalidAttrs(t *testing.T) {
+	for _, attrs := range []map[string]string{
+		{"bad key": "1"},
+		{"key": "has space"},
+		{"key": ""},
+	} {
+		doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "a\n", Attrs: attrs}}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err == nil {
+			t.Errorf("Expected error writing annotations %v", attrs)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Nothing should be written for invalid annotations, got %q", out.String())
+		}
+		if problems := doc.Validate(); len(problems) == 0 {
+			t.Errorf("Validate should report annotations %v", attrs)
+		}
+	}
+}
+
+func TestValidateAttrLikePath(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "notes v=2", Content: "x\n"}}}
+	problems := doc.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0].Problem, "annotation") {
+		t.Errorf("Expected an annotation problem, got %v", problems)
+	}
+
+	for _, file := range []SiloFile{
+		{Path: "notes x=1.txt", Content: "x\n"},
+		{Path: "current", LinkTarget: "notes x=1.txt"},
+	} {
+		doc := &SiloDocument{Files: []SiloFile{file}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+			t.Errorf("Expected WriteTo to refuse %+v, wrote %q", file, out.String())
+		}
+	}
+}
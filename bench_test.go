@@ -0,0 +1,179 @@
+package silo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchShapes are the synthetic archives the benchmarks run over: many small
+// files, a few giant files, and content whose lines look like declarations
+// for every repetition of every default delimiter candidate.
+var benchShapes = []struct {
+	name string
+	doc  func() *SiloDocument
+}{
+	{"small-files", benchSmallFiles},
+	{"giant-files", benchGiantFiles},
+	{"adversarial", benchAdversarial},
+}
+
+func benchSmallFiles() *SiloDocument {
+	doc := &SiloDocument{}
+	for i := 0; i < 5000; i++ {
+		doc.Files = append(doc.Files, SiloFile{
+			Path:    fmt.Sprintf("pkg%02d/file%04d.go", i%50, i),
+			Content: fmt.Sprintf("package pkg%02d\n\n// Value%d is generated.\nconst Value%d = %d\n", i%50, i, i, i),
+		})
+	}
+	return doc
+}
+
+func benchGiantFiles() *SiloDocument {
+	line := strings.Repeat("lorem ipsum dolor sit amet ", 3) + "\n"
+	content := strings.Repeat(line, (8<<20)/len(line))
+	doc := &SiloDocument{}
+	for i := 0; i < 4; i++ {
+		doc.Files = append(doc.Files, SiloFile{Path: fmt.Sprintf("data/giant%d.txt", i), Content: content})
+	}
+	return doc
+}
+
+func benchAdversarial() *SiloDocument {
+	var b strings.Builder
+	for length := 1; length < maxDelimiterLength; length++ {
+		for _, candidate := range DefaultDelimiterCandidates {
+			fmt.Fprintf(&b, "%s not/a/file.txt\n", strings.Repeat(candidate, length))
+		}
+	}
+	content := strings.Repeat(b.String(), 20)
+	doc := &SiloDocument{}
+	for i := 0; i < 50; i++ {
+		doc.Files = append(doc.Files, SiloFile{Path: fmt.Sprintf("quotes/q%02d.md", i), Content: content})
+	}
+	return doc
+}
+
+func benchArchive(b *testing.B, doc *SiloDocument) []byte {
+	b.Helper()
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		b.Fatalf("WriteTo failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, shape := range benchShapes {
+		b.Run(shape.name, func(b *testing.B) {
+			data := benchArchive(b, shape.doc())
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseSiloFile(bytes.NewReader(data)); err != nil {
+					b.Fatalf("ParseSiloFile failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	for _, shape := range benchShapes {
+		b.Run(shape.name, func(b *testing.B) {
+			doc := shape.doc()
+			doc.Delimiter = strings.Repeat("=", maxDelimiterLength)
+			b.SetBytes(int64(len(benchArchive(b, doc))))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := doc.WriteTo(io.Discard); err != nil {
+					b.Fatalf("WriteTo failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFindSafeDelimiter(b *testing.B) {
+	for _, shape := range benchShapes {
+		b.Run(shape.name, func(b *testing.B) {
+			doc := shape.doc()
+			var size int64
+			for _, file := range doc.Files {
+				size += int64(len(file.Content))
+			}
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := FindSafeDelimiter(doc, DelimiterOptions{}); err != nil {
+					b.Fatalf("FindSafeDelimiter failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReadDirectoryTree(b *testing.B) {
+	for _, shape := range benchShapes {
+		b.Run(shape.name, func(b *testing.B) {
+			root := b.TempDir()
+			var size int64
+			for _, file := range shape.doc().Files {
+				path := filepath.Join(root, filepath.FromSlash(file.Path))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					b.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(file.Content), 0644); err != nil {
+					b.Fatal(err)
+				}
+				size += int64(len(file.Content))
+			}
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadDirectoryTree(root); err != nil {
+					b.Fatalf("ReadDirectoryTree failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteToDirectory(b *testing.B) {
+	doc := benchSmallFiles()
+	for _, parallelism := range []int{1, 0} {
+		b.Run(fmt.Sprintf("parallelism-%d", parallelism), func(b *testing.B) {
+			opts := UnpackOptions{Parallelism: parallelism}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := doc.WriteToDirectoryWithOptions(b.TempDir(), opts); err != nil {
+					b.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	doc := benchSmallFiles()
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			doc.Search("Value4242 ")
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		idx := doc.BuildIndex()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			idx.Search("Value4242 ")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package silo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BinaryPolicy controls what happens to files whose content looks binary
+// (see IsBinary) when packing.
+type BinaryPolicy int
+
+const (
+	// BinaryInclude packs binary content as it is.
+	BinaryInclude BinaryPolicy = iota
+	// BinarySkip leaves binary files out of the document.
+	BinarySkip
+	// BinaryError fails with an error matching ErrBinaryContent.
+	BinaryError
+	// BinaryBase64 marks binary files to be written base64-encoded, so the
+	// archive stays valid text and unpacks byte for byte.
+	BinaryBase64
+)
+
+// base64Marker follows an entry's path in the header line of a
+// base64-encoded entry, as in "> logo.png @base64".
+const base64Marker = "base64"
+
+// base64LineLength is the width of the base64 lines WriteTo emits.
+const base64LineLength = 76
+
+// ParseBinaryPolicy converts a policy name (include, skip, error, base64)
+// into a BinaryPolicy.
+func ParseBinaryPolicy(name string) (BinaryPolicy, error) {
+	switch name {
+	case "include":
+		return BinaryInclude, nil
+	case "skip":
+		return BinarySkip, nil
+	case "error":
+		return BinaryError, nil
+	case "base64":
+		return BinaryBase64, nil
+	}
+	return 0, fmt.Errorf("unknown binary policy %q (want include, skip, error or base64)", name)
+}
+
+// ApplyBinaryPolicy applies policy to every regular entry with binary
+// content, returning the paths of entries it removed under BinarySkip.
+// Under BinaryError the document is unchanged when an error is returned.
+func (doc *SiloDocument) ApplyBinaryPolicy(policy BinaryPolicy) (skipped []string, err error) {
+	if policy == BinaryInclude {
+		return nil, nil
+	}
+
+	kept := make([]SiloFile, 0, len(doc.Files))
+	for _, file := range doc.Files {
+		if file.LinkTarget != "" || file.Ref != "" || file.Base64 || !IsBinary(file.Bytes()) {
+			kept = append(kept, file)
+			continue
+		}
+		switch policy {
+		case BinarySkip:
+			skipped = append(skipped, file.Path)
+		case BinaryError:
+			return nil, fmt.Errorf("%w: %s", ErrBinaryContent, file.Path)
+		case BinaryBase64:
+			file.Base64 = true
+			kept = append(kept, file)
+		}
+	}
+	doc.Files = kept
+	return skipped, nil
+}
+
+// encodeBase64Content returns content base64-encoded in lines of
+// base64LineLength characters, each ending in a newline.
+func encodeBase64Content(content string) string {
+	var b strings.Builder
+	writeBase64Content(&b, content)
+	return b.String()
+}
+
+// writeBase64Content writes content to w as encodeBase64Content returns it,
+// encoding a chunk at a time instead of holding the whole encoding.
+func writeBase64Content(w io.Writer, content string) error {
+	lw := &lineWrapWriter{w: w, width: base64LineLength}
+	enc := base64.NewEncoder(base64.StdEncoding, lw)
+	buf := make([]byte, 3*1024)
+	for len(content) > 0 {
+		n := copy(buf, content)
+		if _, err := enc.Write(buf[:n]); err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if lw.col > 0 {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
+
+// lineWrapWriter passes writes on to w with a newline after every width
+// bytes.
+type lineWrapWriter struct {
+	w     io.Writer
+	width int
+	col   int
+}
+
+func (lw *lineWrapWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(lw.width-lw.col, len(p))
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+		lw.col += n
+		if lw.col == lw.width {
+			if _, err := io.WriteString(lw.w, "\n"); err != nil {
+				return written, err
+			}
+			lw.col = 0
+		}
+	}
+	return written, nil
+}
+
+// decodeBase64Content decodes the lines of a base64-encoded entry.
+func decodeBase64Content(text string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,134 @@
+package silo
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyBinaryPolicy(t *testing.T) {
+	newDoc := func() *SiloDocument {
+		return &SiloDocument{Files: []SiloFile{
+			{Path: "a.txt", Content: "text\n"},
+			{Path: "logo.png", Content: "\x89PNG\x00\x01"},
+		}}
+	}
+
+	doc := newDoc()
+	skipped, err := doc.ApplyBinaryPolicy(BinarySkip)
+	if err != nil || len(skipped) != 1 || skipped[0] != "logo.png" || len(doc.Files) != 1 {
+		t.Errorf("BinarySkip: skipped %v, files %v, err %v", skipped, docPaths(doc), err)
+	}
+
+	doc = newDoc()
+	if _, err := doc.ApplyBinaryPolicy(BinaryError); !errors.Is(err, ErrBinaryContent) {
+		t.Errorf("Expected ErrBinaryContent, got %v", err)
+	}
+	if len(doc.Files) != 2 {
+		t.Error("Document changed after BinaryError")
+	}
+
+	doc = newDoc()
+	if _, err := doc.ApplyBinaryPolicy(BinaryBase64); err != nil {
+		t.Fatalf("BinaryBase64 failed: %v", err)
+	}
+	if doc.Files[0].Base64 || !doc.Files[1].Base64 {
+		t.Errorf("Only the binary file should be marked base64: %+v", doc.Files)
+	}
+}
+
+func TestBase64RoundTrip(t *testing.T) {
+	binary := "\x89PNG\r\n\x1a\n\x00" + strings.Repeat("\xff\x00", 60)
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "text\n"},
+		{Path: "logo.png", Content: binary, Base64: true},
+	}}
+
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "> logo.png @base64\n") {
+		t.Errorf("Missing base64 header:\n%s", out.String())
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if len(line) > base64LineLength {
+			t.Errorf("Line longer than %d characters: %q", base64LineLength, line)
+		}
+	}
+
+	parsed, err := ParseSiloFile(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if parsed.Files[1].Content != binary || !parsed.Files[1].Base64 {
+		t.Errorf("Binary content did not round-trip: %+v", parsed.Files[1])
+	}
+
+	dir := t.TempDir()
+	if err := parsed.WriteToDirectoryWithOptions(dir, UnpackOptions{LineEndings: LineEndingsCRLF}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "logo.png"))
+	if string(data) != binary {
+		t.Error("Line ending conversion must not touch base64 entries")
+	}
+}
+
+func TestParseInvalidBase64(t *testing.T) {
+	if _, err := ParseSiloFile(strings.NewReader("> a.bin @base64\nnot base64!\n")); err == nil {
+		t.Error("Expected error for invalid base64 content")
+	}
+}
+
+func TestReadDirectoryTreeBinaryPolicy(t *testing.T) {
+	dir := setupFilterTree(t)
+
+	if _, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{Binary: BinaryError}); !errors.Is(err, ErrBinaryContent) {
+		t.Errorf("Expected ErrBinaryContent, got %v", err)
+	}
+
+	doc, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{Binary: BinaryBase64})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	for _, file := range doc.Files {
+		if file.Base64 != (file.Path == "image.png") {
+			t.Errorf("%s: unexpected Base64 = %v", file.Path, file.Base64)
+		}
+	}
+}
+
+func TestParseBinaryPolicy(t *testing.T) {
+	for _, name := range []string{"include", "skip", "error", "base64"} {
+		if _, err := ParseBinaryPolicy(name); err != nil {
+			t.Errorf("ParseBinaryPolicy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseBinaryPolicy("hex"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestWriteBase64ContentLineLengths(t *testing.T) {
+	for _, size := range []int{0, 1, 56, 57, 58, 114, 3 * 1024, 3*1024 + 1, 100000} {
+		content := strings.Repeat("\x00\xff\x10", size/3+1)[:size]
+		var b strings.Builder
+		if err := writeBase64Content(&b, content); err != nil {
+			t.Fatalf("writeBase64Content failed: %v", err)
+		}
+
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		if strings.ReplaceAll(b.String(), "\n", "") != encoded {
+			t.Errorf("size %d: encoding differs from base64.StdEncoding", size)
+		}
+		for _, line := range strings.SplitAfter(b.String(), "\n") {
+			if len(line) > base64LineLength+1 || (line != "" && !strings.HasSuffix(line, "\n")) {
+				t.Errorf("size %d: bad line %q", size, line)
+			}
+		}
+	}
+}
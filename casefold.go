@@ -0,0 +1,128 @@
+package silo
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// CaseCollisionPolicy controls how unpacking treats entries whose paths
+// differ only in case, such as README.md and Readme.md. On a
+// case-insensitive filesystem the later one would silently overwrite the
+// earlier.
+type CaseCollisionPolicy int
+
+const (
+	// CaseCollisionsAuto behaves like CaseCollisionsError on macOS and
+	// Windows, whose filesystems usually ignore case, and like
+	// CaseCollisionsAllow elsewhere.
+	CaseCollisionsAuto CaseCollisionPolicy = iota
+	// CaseCollisionsAllow writes every entry under its own path.
+	CaseCollisionsAllow
+	// CaseCollisionsError refuses to unpack a document with colliding paths.
+	CaseCollisionsError
+	// CaseCollisionsRename writes later colliding entries under a new name
+	// with a numeric suffix (Readme.md becomes Readme_2.md).
+	CaseCollisionsRename
+	// CaseCollisionsLastWins writes only the last of the colliding entries,
+	// as a case-insensitive filesystem would, but on every platform.
+	CaseCollisionsLastWins
+)
+
+// ParseCaseCollisionPolicy converts a policy name (auto, allow, error,
+// rename, last-wins) into a CaseCollisionPolicy.
+func ParseCaseCollisionPolicy(name string) (CaseCollisionPolicy, error) {
+	switch name {
+	case "auto":
+		return CaseCollisionsAuto, nil
+	case "allow":
+		return CaseCollisionsAllow, nil
+	case "error":
+		return CaseCollisionsError, nil
+	case "rename":
+		return CaseCollisionsRename, nil
+	case "last-wins":
+		return CaseCollisionsLastWins, nil
+	}
+	return 0, fmt.Errorf("unknown case collision policy %q (want auto, allow, error, rename or last-wins)", name)
+}
+
+// foldCase is the key under which paths collide on a case-insensitive
+// filesystem.
+func foldCase(path string) string {
+	return strings.ToLower(path)
+}
+
+// unpackPaths returns the path each entry is written to, after the Rename
+// function, Windows path policy, name checks and case collision policy in
+// opts. An entry dropped by Rename, or replaced by a later one under
+// CaseCollisionsLastWins, gets "". Two entries that would still be written
+// to the same path fail with ErrDuplicatePath.
+func unpackPaths(files []SiloFile, opts UnpackOptions) ([]string, error) {
+	policy := opts.CaseCollisions
+	if policy == CaseCollisionsAuto {
+		policy = CaseCollisionsAllow
+		if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+			policy = CaseCollisionsError
+		}
+	}
+
+	paths := make([]string, len(files))
+	written := make(map[string]int)
+	folded := make(map[string]int)
+	for i, file := range files {
+		name := file.Path
+		if opts.Rename != nil {
+			renamed, keep := opts.Rename(name)
+			if !keep {
+				continue
+			}
+			if err := validatePath(renamed); err != nil {
+				return nil, fmt.Errorf("renaming %s: %w", name, err)
+			}
+			name = renamed
+		}
+		name, err := resolveWindowsPath(name, opts.WindowsPaths)
+		if err != nil {
+			return nil, err
+		}
+		if name, err = resolveSpecialNames(name, opts.NameChecks, opts.SanitizePaths); err != nil {
+			return nil, err
+		}
+		if j, taken := written[name]; taken {
+			return nil, fmt.Errorf("%w: %s and %s both unpack to %s", ErrDuplicatePath, files[j].Path, file.Path, name)
+		}
+
+		if j, collides := folded[foldCase(name)]; collides && policy != CaseCollisionsAllow {
+			switch policy {
+			case CaseCollisionsError:
+				return nil, fmt.Errorf("%w: %s and %s differ only in case", ErrDuplicatePath, files[j].Path, file.Path)
+			case CaseCollisionsLastWins:
+				delete(written, paths[j])
+				paths[j] = ""
+			case CaseCollisionsRename:
+				name = renameCaseCollision(name, folded)
+			}
+		}
+
+		paths[i] = name
+		written[name] = i
+		folded[foldCase(name)] = i
+	}
+	return paths, nil
+}
+
+// renameCaseCollision adds the first numeric suffix to name, before its
+// extension, that does not collide with a path in folded.
+func renameCaseCollision(name string, folded map[string]int) string {
+	dir, base := path.Split(name)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s%s_%d%s", dir, stem, n, ext)
+		if _, taken := folded[foldCase(candidate)]; !taken {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func caseCollisionDoc() *SiloDocument {
+	return &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "README.md", Content: "one\n"},
+			{Path: "docs/a.txt", Content: "a\n"},
+			{Path: "Readme.md", Content: "two\n"},
+			{Path: "readme.MD", Content: "three\n"},
+		},
+	}
+}
+
+func TestCaseCollisionPolicies(t *testing.T) {
+	tests := []struct {
+		policy   CaseCollisionPolicy
+		expected map[string]string
+	}{
+		{CaseCollisionsAllow, map[string]string{"README.md": "one\n", "Readme.md": "two\n", "readme.MD": "three\n"}},
+		{CaseCollisionsRename, map[string]string{"README.md": "one\n", "Readme_2.md": "two\n", "readme_3.MD": "three\n"}},
+		{CaseCollisionsLastWins, map[string]string{"readme.MD": "three\n"}},
+	}
+
+	for _, test := range tests {
+		if test.policy == CaseCollisionsAllow && (runtime.GOOS == "darwin" || runtime.GOOS == "windows") {
+			continue // the filesystem itself folds case
+		}
+		dir := t.TempDir()
+		if err := caseCollisionDoc().WriteToDirectoryWithOptions(dir, UnpackOptions{CaseCollisions: test.policy}); err != nil {
+			t.Fatalf("policy %d: unexpected error: %v", test.policy, err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		var names []string
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		if len(names) != len(test.expected) {
+			t.Errorf("policy %d: expected %d files, got %v", test.policy, len(test.expected), names)
+		}
+		for name, content := range test.expected {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil || string(data) != content {
+				t.Errorf("policy %d: expected %s to hold %q, got %q (%v)", test.policy, name, content, data, err)
+			}
+		}
+	}
+}
+
+func TestCaseCollisionError(t *testing.T) {
+	err := caseCollisionDoc().WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{CaseCollisions: CaseCollisionsError})
+	if !errors.Is(err, ErrDuplicatePath) || !strings.Contains(err.Error(), "differ only in case") {
+		t.Errorf("Expected case collision error, got %v", err)
+	}
+
+	fsys := newMemWriteFS()
+	if err := caseCollisionDoc().WriteToFSWithOptions(fsys, UnpackOptions{CaseCollisions: CaseCollisionsError}); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected WriteToFS to report the collision, got %v", err)
+	}
+}
+
+func TestParseCaseCollisionPolicy(t *testing.T) {
+	for name, expected := range map[string]CaseCollisionPolicy{
+		"auto": CaseCollisionsAuto, "allow": CaseCollisionsAllow, "error": CaseCollisionsError,
+		"rename": CaseCollisionsRename, "last-wins": CaseCollisionsLastWins,
+	} {
+		if policy, err := ParseCaseCollisionPolicy(name); err != nil || policy != expected {
+			t.Errorf("ParseCaseCollisionPolicy(%q) = %v, %v", name, policy, err)
+		}
+	}
+	if _, err := ParseCaseCollisionPolicy("first-wins"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestExpandPatternsCaseInsensitive(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"README.md", "src/Main.GO", "src/util.go"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	for _, follow := range []bool{false, true} {
+		expander := &SecureGlobExpander{WorkingDir: dir, CaseInsensitive: true, FollowSymlinks: follow}
+		result, err := expander.ExpandPatterns([]string{"readme.*", "**/*.go", "!**/UTIL.go"}, BothGlobs)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		sort.Strings(result)
+		if strings.Join(result, ",") != "README.md,src/Main.GO" {
+			t.Errorf("follow=%v: expected README.md and src/Main.GO, got %v", follow, result)
+		}
+	}
+}
+
+func TestUnpackRename(t *testing.T) {
+	doc := caseCollisionDoc()
+	rename := func(path string) (string, bool) {
+		if strings.HasPrefix(path, "docs/") {
+			return "manual/" + strings.TrimPrefix(path, "docs/"), true
+		}
+		return path, path == "README.md"
+	}
+
+	dir := t.TempDir()
+	if err := doc.WriteToDirectoryWithOptions(dir, UnpackOptions{Rename: rename}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var written []string
+	filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			written = append(written, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	sort.Strings(written)
+	if strings.Join(written, ",") != "README.md,manual/a.txt" {
+		t.Errorf("Unexpected files written: %v", written)
+	}
+
+	escape := func(path string) (string, bool) { return "../" + path, true }
+	if err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{Rename: escape}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for a renamed path outside the root, got %v", err)
+	}
+
+	same := func(path string) (string, bool) { return "all.txt", true }
+	if err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{Rename: same}); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+func applyCmd(ctx context.Context, args []string) {
+	applyFlags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	dir := applyFlags.String("dir", ".", "Directory to apply the archive to")
+	yes := applyFlags.Bool("yes", false, "Apply without asking for confirmation")
+	applyFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo apply [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Show how the archive would change a directory as diffs, then write only the\n")
+		fmt.Fprintf(os.Stderr, "new and changed files once confirmed. Unchanged files are not touched, and\n")
+		fmt.Fprintf(os.Stderr, "files missing from the archive are kept\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		applyFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, applyFlags, args)
+
+	if applyFlags.NArg() != 1 {
+		applyFlags.Usage()
+		os.Exit(1)
+	}
+	siloFile := applyFlags.Arg(0)
+
+	// Content is compared and written exactly as stored.
+	file, err := os.Open(siloFile)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	doc, err := silo.ParseSiloFileWithOptions(file, silo.ParseOptions{LineEndings: silo.LineEndingsPreserve})
+	file.Close()
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	if err := doc.ResolveRefs(silo.DirRefResolver(filepath.Dir(siloFile))); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	var changed []silo.SiloFile
+	added, modified := 0, 0
+	for _, entry := range doc.Files {
+		switch entryStatus(entry, *dir) {
+		case pickUnchanged:
+			continue
+		case pickNew:
+			added++
+		default:
+			modified++
+		}
+		changed = append(changed, entry)
+		printEntryDiff(os.Stdout, entry, *dir)
+	}
+
+	if len(changed) == 0 {
+		if !quietMode {
+			fmt.Fprintf(os.Stderr, "%s is up to date with %s\n", *dir, siloFile)
+		}
+		return
+	}
+	if !quietMode {
+		fmt.Fprintf(os.Stderr, "%d new, %d modified, %d unchanged\n", added, modified, len(doc.Files)-len(changed))
+	}
+	if !*yes && !confirm(os.Stdin, os.Stderr, fmt.Sprintf("Apply these changes to %s?", *dir)) {
+		if !quietMode {
+			fmt.Fprintf(os.Stderr, "Nothing applied\n")
+		}
+		return
+	}
+
+	doc.Files = changed
+	opts := silo.UnpackOptions{LineEndings: silo.LineEndingsPreserve, Logger: logger}
+	if err := doc.WriteToDirectoryContext(ctx, *dir, opts); err != nil {
+		fatal(err, "Error writing to directory: %s", describeError(err))
+	}
+	logger.Info("applied", "files", len(changed), "dir", *dir)
+	if !quietMode {
+		fmt.Printf("Applied %d files to %s\n", len(changed), *dir)
+	}
+}
+
+// printEntryDiff writes how entry would change the file under dir, as a
+// unified diff for text and a one-line note for links and binary content.
+func printEntryDiff(w io.Writer, entry silo.SiloFile, dir string) {
+	target := filepath.Join(dir, filepath.FromSlash(entry.Path))
+	oldName, newName := "a/"+entry.Path, "b/"+entry.Path
+	info, err := os.Lstat(target)
+	exists := err == nil
+	if !exists {
+		oldName = "/dev/null"
+	}
+
+	if entry.LinkTarget != "" {
+		fmt.Fprintf(w, "Link %s -> %s\n", entry.Path, entry.LinkTarget)
+		return
+	}
+	var existing []byte
+	if exists {
+		if !info.Mode().IsRegular() {
+			fmt.Fprintf(w, "Replace %s, not a regular file\n", entry.Path)
+			return
+		}
+		if existing, err = os.ReadFile(target); err != nil {
+			fmt.Fprintf(w, "Overwrite %s, which cannot be read: %v\n", entry.Path, err)
+			return
+		}
+	}
+	if silo.IsBinary(existing) || silo.IsBinary(entry.Bytes()) {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return
+	}
+	fmt.Fprint(w, silo.UnifiedDiff(oldName, newName, string(existing), string(entry.Bytes())))
+}
+
+// confirm asks question on out and reports whether the answer read from in
+// is yes. End of input counts as no.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/escherize/go-silo"
+)
+
+// errNotFormatted is reported by silo batch fmt, without -w, for an
+// archive that silo fmt would change.
+var errNotFormatted = errors.New("not formatted")
+
+// batchResult is the outcome of a batch operation on one archive.
+type batchResult struct {
+	archive string
+	// status says what was done when the operation succeeded.
+	status string
+	// summary is filled in by stats.
+	summary silo.Summary
+	err     error
+}
+
+// batchOp runs one batch operation on an archive.
+type batchOp func(ctx context.Context, archive string) batchResult
+
+func batchCmd(ctx context.Context, args []string) {
+	batchFlags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	jobs := batchFlags.Int("j", runtime.GOMAXPROCS(0), "Number of archives to process at once")
+	keyFile := batchFlags.String("key", "", "verify: also require a valid signature from this ed25519 public key (PEM)")
+	tokenizer := batchFlags.String("tokenizer", "bytes", "stats: token estimate heuristic, bytes or words")
+	formatName := batchFlags.String("format", "text", "stats: output format, text, csv, tsv or json")
+	write := batchFlags.Bool("w", false, "fmt: rewrite archives that are not formatted instead of failing")
+	batchFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo batch [options] <verify|stats|fmt> <pattern...>\n")
+		fmt.Fprintf(os.Stderr, "Run an operation on every archive the patterns match, several at once, and report\n")
+		fmt.Fprintf(os.Stderr, "them together. Quote patterns to use ** (silo batch verify 'artifacts/**/*.silo')\n\n")
+		fmt.Fprintf(os.Stderr, "Operations:\n")
+		fmt.Fprintf(os.Stderr, "  verify  parse each archive and fail on the errors silo check reports\n")
+		fmt.Fprintf(os.Stderr, "  stats   print one summary row per archive, with totals\n")
+		fmt.Fprintf(os.Stderr, "  fmt     fail on archives silo fmt would change, or rewrite them with -w\n\n")
+		fmt.Fprintf(os.Stderr, "Exits non-zero if any archive fails, with the exit code of the first failure\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		batchFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, batchFlags, args)
+
+	if batchFlags.NArg() < 2 || *jobs < 1 {
+		batchFlags.Usage()
+		os.Exit(1)
+	}
+	format, err := parseOutputFormat(*formatName)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	var op batchOp
+	switch name := batchFlags.Arg(0); name {
+	case "verify":
+		op = batchVerify(*keyFile)
+	case "stats":
+		estimator, err := silo.ParseTokenEstimator(*tokenizer)
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		op = batchStats(silo.StatsOptions{Tokenizer: estimator})
+	case "fmt":
+		op = batchFmt(silo.FormatOptions{}, *write)
+	default:
+		fatal(nil, "Error: unknown batch operation %q (want verify, stats or fmt)", name)
+	}
+	if format != formatText && batchFlags.Arg(0) != "stats" {
+		fatal(nil, "Error: -format only applies to batch stats")
+	}
+
+	archives, err := expandArchives(batchFlags.Args()[1:])
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	results := runBatch(ctx, archives, *jobs, op)
+
+	if batchFlags.Arg(0) == "stats" {
+		err = writeBatchStats(os.Stdout, format, results)
+	} else {
+		err = writeBatchStatus(os.Stdout, results)
+	}
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	var first error
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.archive, result.err)
+			if first == nil {
+				first = result.err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		fatal(first, "%d of %s failed", failed, plural(len(results), "archive"))
+	}
+	if !quietMode && format == formatText {
+		fmt.Fprintf(os.Stderr, "%s ok\n", plural(len(results), "archive"))
+	}
+}
+
+// expandArchives returns the files the patterns match, sorted and without
+// duplicates. A pattern without glob characters names a file, which must
+// exist; any pattern that matches nothing is an error.
+func expandArchives(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var archives []string
+	for _, pattern := range patterns {
+		var matches []string
+		if !strings.ContainsAny(pattern, "*?[{") {
+			if _, err := os.Stat(pattern); err != nil {
+				return nil, err
+			}
+			matches = []string{pattern}
+		} else {
+			var err error
+			matches, err = doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", silo.ErrInvalidPattern, pattern)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%w: %s", errNoMatches, pattern)
+			}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				archives = append(archives, match)
+			}
+		}
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// runBatch runs op on every archive using up to jobs goroutines, and
+// returns the results in the order of archives. Archives not yet started
+// when ctx is done fail with its error.
+func runBatch(ctx context.Context, archives []string, jobs int, op batchOp) []batchResult {
+	results := make([]batchResult, len(archives))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(archives); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					results[i] = batchResult{archive: archives[i], err: err}
+					continue
+				}
+				results[i] = op(ctx, archives[i])
+				results[i].archive = archives[i]
+			}
+		}()
+	}
+	for i := range archives {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// batchVerify fails archives that cannot be read, that have the errors
+// silo check reports or, when keyFile is set, that are not signed by it.
+func batchVerify(keyFile string) batchOp {
+	return func(ctx context.Context, archive string) batchResult {
+		var doc *silo.SiloDocument
+		var err error
+		if keyFile != "" {
+			doc, err = readVerifiedArchive(archive, keyFile)
+		} else {
+			doc, err = readArchiveWithOptions(archive, exactParseOptions)
+		}
+		if err != nil {
+			return batchResult{err: err}
+		}
+		var problems []string
+		for _, issue := range doc.Lint() {
+			if issue.Severity == silo.LintError {
+				problems = append(problems, issue.String())
+			}
+		}
+		if len(problems) > 0 {
+			return batchResult{err: fmt.Errorf("%w: %s", errCheckFailed, strings.Join(problems, "; "))}
+		}
+		return batchResult{status: "ok"}
+	}
+}
+
+// batchStats summarizes each archive as silo stats does.
+func batchStats(opts silo.StatsOptions) batchOp {
+	return func(ctx context.Context, archive string) batchResult {
+		doc, err := readArchive(archive)
+		if err != nil {
+			return batchResult{err: err}
+		}
+		return batchResult{status: "ok", summary: doc.SummaryWithOptions(opts)}
+	}
+}
+
+// batchFmt fails archives that silo fmt would change or, with write,
+// rewrites them.
+func batchFmt(opts silo.FormatOptions, write bool) batchOp {
+	return func(ctx context.Context, archive string) batchResult {
+		src, err := os.ReadFile(archive)
+		if err != nil {
+			return batchResult{err: err}
+		}
+		out, err := silo.FormatWithOptions(src, opts)
+		if err != nil {
+			return batchResult{err: err}
+		}
+		switch {
+		case bytes.Equal(src, out):
+			return batchResult{status: "ok"}
+		case !write:
+			return batchResult{err: errNotFormatted}
+		}
+		if err := writeAtomic(archive, func(w io.Writer) error {
+			_, err := w.Write(out)
+			return err
+		}); err != nil {
+			return batchResult{err: err}
+		}
+		return batchResult{status: "formatted"}
+	}
+}
+
+// writeBatchStatus writes the status of every archive that succeeded, one
+// per line. Failures are reported separately.
+func writeBatchStatus(w io.Writer, results []batchResult) error {
+	for _, result := range results {
+		if result.err != nil || quietMode && result.status == "ok" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%-9s %s\n", result.status, result.archive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatchStats writes one summary per archive that could be read: as an
+// aligned table with totals, as CSV or TSV rows, or as a JSON array.
+func writeBatchStats(w io.Writer, format outputFormat, results []batchResult) error {
+	records := make([]summaryRecord, 0, len(results))
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		if result.err == nil {
+			record := newSummaryRecord(result.archive, result.summary)
+			records = append(records, record)
+			rows = append(rows, record.row())
+		}
+	}
+	if format != formatText {
+		return writeRecords(w, format, summaryHeader, rows, records)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "FILES\tBYTES\tLINES\tTOKENS\t\tARCHIVE\n")
+	var total summaryRecord
+	for _, record := range records {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t\t%s\n", record.Files, record.Bytes, record.Lines, record.Tokens, record.Archive)
+		total.Files += record.Files
+		total.Bytes += record.Bytes
+		total.Lines += record.Lines
+		total.Tokens += record.Tokens
+	}
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t\ttotal (%s)\n", total.Files, total.Bytes, total.Lines, total.Tokens, plural(len(records), "archive"))
+	return tw.Flush()
+}
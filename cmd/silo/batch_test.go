@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+// writeArchives creates the named archives under a temporary directory and
+// returns it.
+func writeArchives(t *testing.T, archives map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range archives {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandArchives(t *testing.T) {
+	dir := writeArchives(t, map[string]string{
+		"a.silo":       "> a\n",
+		"sub/b.silo":   "> b\n",
+		"sub/notes.md": "x\n",
+	})
+	got, err := expandArchives([]string{
+		filepath.Join(dir, "**", "*.silo"),
+		filepath.Join(dir, "a.silo"),
+	})
+	if err != nil {
+		t.Fatalf("expandArchives failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.silo"), filepath.Join(dir, "sub", "b.silo")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if _, err := expandArchives([]string{filepath.Join(dir, "*.jsonl")}); !errors.Is(err, errNoMatches) {
+		t.Errorf("Expected errNoMatches, got %v", err)
+	}
+	if _, err := expandArchives([]string{filepath.Join(dir, "missing.silo")}); err == nil {
+		t.Error("Expected an error for a missing archive")
+	}
+	if _, err := expandArchives([]string{filepath.Join(dir, "[")}); !errors.Is(err, silo.ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	archives := []string{"a", "b", "c", "d", "e"}
+	var running, most int32
+	results := runBatch(context.Background(), archives, 2, func(ctx context.Context, archive string) batchResult {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+		return batchResult{status: strings.ToUpper(archive)}
+	})
+	for i, result := range results {
+		if result.archive != archives[i] || result.status != strings.ToUpper(archives[i]) {
+			t.Errorf("Result %d: unexpected %+v", i, result)
+		}
+	}
+	if most > 2 {
+		t.Errorf("Expected at most 2 archives at once, got %d", most)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, result := range runBatch(ctx, archives, 2, nil) {
+		if !errors.Is(result.err, context.Canceled) {
+			t.Errorf("Expected %s to be cancelled, got %v", result.archive, result.err)
+		}
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	dir := writeArchives(t, map[string]string{
+		"good.silo":    "> a.txt\na\n",
+		"garbage.silo": "garbage\n",
+		"bad.silo":     "> a.txt\na\n> A.txt\nb\n",
+	})
+	verify := batchVerify("")
+	if result := verify(context.Background(), filepath.Join(dir, "good.silo")); result.err != nil || result.status != "ok" {
+		t.Errorf("Expected good.silo to pass, got %+v", result)
+	}
+	var parseErr *silo.ParseError
+	if result := verify(context.Background(), filepath.Join(dir, "garbage.silo")); !errors.As(result.err, &parseErr) {
+		t.Errorf("Expected a parse error, got %v", result.err)
+	}
+	if result := verify(context.Background(), filepath.Join(dir, "bad.silo")); !errors.Is(result.err, errCheckFailed) {
+		t.Errorf("Expected the case collision to fail, got %v", result.err)
+	}
+}
+
+func TestBatchFmt(t *testing.T) {
+	formatted := "> a.txt\na\n> b.txt\nb\n"
+	dir := writeArchives(t, map[string]string{
+		"ok.silo":       formatted,
+		"unsorted.silo": "> b.txt\nb\n> a.txt\na\n",
+	})
+	unsorted := filepath.Join(dir, "unsorted.silo")
+
+	if result := batchFmt(silo.FormatOptions{}, false)(context.Background(), filepath.Join(dir, "ok.silo")); result.err != nil || result.status != "ok" {
+		t.Errorf("Expected ok.silo to pass, got %+v", result)
+	}
+	if result := batchFmt(silo.FormatOptions{}, false)(context.Background(), unsorted); !errors.Is(result.err, errNotFormatted) {
+		t.Errorf("Expected errNotFormatted, got %v", result.err)
+	}
+	if result := batchFmt(silo.FormatOptions{}, true)(context.Background(), unsorted); result.err != nil || result.status != "formatted" {
+		t.Errorf("Expected unsorted.silo to be rewritten, got %+v", result)
+	}
+	if data, err := os.ReadFile(unsorted); err != nil || string(data) != formatted {
+		t.Errorf("Expected %q after -w, got %q, %v", formatted, data, err)
+	}
+}
+
+func TestWriteBatchStats(t *testing.T) {
+	one := (&silo.SiloDocument{Files: []silo.SiloFile{{Path: "a", Content: "a\n"}}}).Summary()
+	results := []batchResult{
+		{archive: "a.silo", summary: one},
+		{archive: "bad.silo", err: errors.New("broken")},
+		{archive: "b.silo", summary: one},
+	}
+
+	var buf bytes.Buffer
+	if err := writeBatchStats(&buf, formatTSV, results); err != nil {
+		t.Fatalf("writeBatchStats failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "a.silo\t1\t") || !strings.HasPrefix(lines[2], "b.silo\t1\t") {
+		t.Errorf("Expected a header and two rows, got\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeBatchStats(&buf, formatText, results); err != nil {
+		t.Fatalf("writeBatchStats failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "total (2 archives)") || strings.Contains(buf.String(), "bad.silo") {
+		t.Errorf("Unexpected table\n%s", buf.String())
+	}
+}
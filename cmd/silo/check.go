@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// errCheckFailed is reported when silo check finds errors, or warnings
+// under -strict.
+var errCheckFailed = errors.New("check failed")
+
+// checkIssue is a lint issue as printed by silo check -json.
+type checkIssue struct {
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func checkCmd(ctx context.Context, args []string) {
+	checkFlags := flag.NewFlagSet("check", flag.ContinueOnError)
+	maxFileSize := byteSize(1 << 20)
+	checkFlags.Var(&maxFileSize, "max-file-size", "Warn about entries larger than `size`, e.g. 1MB or 512KB (0: no limit)")
+	disable := checkFlags.String("disable", "", "Comma-separated rules to skip: "+strings.Join(silo.LintRules, ", "))
+	strict := checkFlags.Bool("strict", false, "Fail on warnings as well as errors")
+	asJSON := checkFlags.Bool("json", false, "Print the issues as a JSON array")
+	checkFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo check [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Validate an archive and warn about large, binary, duplicate or oddly named entries and mixed line endings\n")
+		fmt.Fprintf(os.Stderr, "Exits non-zero if any error (or, with -strict, warning) is found\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		checkFlags.PrintDefaults()
+	}
+	parseFlags(ctx, checkFlags, args)
+
+	if checkFlags.NArg() != 1 {
+		checkFlags.Usage()
+		os.Exit(1)
+	}
+
+	opts := silo.LintOptions{MaxFileSize: int64(maxFileSize)}
+	if opts.MaxFileSize == 0 {
+		opts.MaxFileSize = -1
+	}
+	for _, rule := range strings.Split(*disable, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		known := false
+		for _, name := range silo.LintRules {
+			known = known || name == rule
+		}
+		if !known {
+			fatal(nil, "Error: unknown rule %q (want %s)", rule, strings.Join(silo.LintRules, ", "))
+		}
+		opts.Disable = append(opts.Disable, rule)
+	}
+
+	// Content is read as it is stored, so mixed line endings can be seen.
+	file, err := os.Open(checkFlags.Arg(0))
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	doc, err := silo.ParseSiloFileWithOptions(file, silo.ParseOptions{LineEndings: silo.LineEndingsPreserve})
+	file.Close()
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+
+	issues := doc.LintWithOptions(opts)
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == silo.LintError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if *asJSON {
+		report := make([]checkIssue, 0, len(issues))
+		for _, issue := range issues {
+			report = append(report, checkIssue{Path: issue.Path, Line: issue.Line, Rule: issue.Rule, Severity: issue.Severity.String(), Message: issue.Message})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+
+	if errorCount > 0 || *strict && warningCount > 0 {
+		fatal(errCheckFailed, "%s: %d errors, %d warnings", checkFlags.Arg(0), errorCount, warningCount)
+	}
+	if !*asJSON && !quietMode {
+		fmt.Fprintf(os.Stderr, "%s: %d files, %d warnings\n", checkFlags.Arg(0), len(doc.Files), warningCount)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// command is a silo subcommand. run defines the command's flags and parses
+// them with parseFlags, which adds the shared options.
+type command struct {
+	name    string
+	args    string // synopsis shown after "silo <name>" in the usage
+	summary string
+	run     func(ctx context.Context, args []string)
+}
+
+// commands lists the subcommands in the order silo help shows them. Adding
+// a command is a matter of adding it here.
+var commands = []*command{
+	{"pack", "[options] <pattern1 pattern2 ...>", "Pack files into silo file", packCmd},
+	{"unpack", "[options] <file>", "Unpack silo file into directory", unpackCmd},
+	{"apply", "[options] <file>", "Preview and apply an archive's changes to a directory", applyCmd},
+	{"status", "[options] <file>", "List files in a directory that differ from an archive", statusCmd},
+	{"snapshot", "[options]", "Save a directory to a local, timestamped snapshot", snapshotCmd},
+	{"restore", "[options] [snapshot]", "Bring a directory back to a snapshot", restoreCmd},
+	{"from-patch", "[options] <patch>", "Pack the files a unified diff would change", fromPatchCmd},
+	{"list", "[options] <file>", "List the entries in a silo file", listCmd},
+	{"cat", "<file> <path...>", "Print entries from a silo file", catCmd},
+	{"grep", "[options] <text> <file>", "Search the content of a silo file's entries", grepCmd},
+	{"check", "[options] <file>", "Lint a silo file, failing on errors", checkCmd},
+	{"fmt", "[options] [file...]", "Rewrite silo files in canonical form", fmtCmd},
+	{"rm", "<file> <path...>", "Remove entries from a silo file", rmCmd},
+	{"mv", "<file> <old> <new>", "Rename an entry in a silo file", mvCmd},
+	{"sign", "<file> -key priv.pem", "Sign a silo file with an ed25519 key", signCmd},
+	{"scaffold", "[options] <template> <dir>", "Create a project from a template", scaffoldCmd},
+	{"stats", "[options] <file>", "Show sizes and estimated token counts", statsCmd},
+	{"batch", "[options] <op> <pattern...>", "Run verify, stats or fmt on many archives at once", batchCmd},
+	{"serve", "<file> [-addr host:port]", "Serve a silo file's contents over HTTP", serveCmd},
+	{"view", "[options] <file>", "Browse a silo file in a web browser", viewCmd},
+}
+
+// findCommand returns the command called name, or nil.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// sharedOptions holds the flags every command accepts, either before the
+// command name or among its own flags.
+type sharedOptions struct {
+	timeout    time.Duration
+	verbose    bool
+	quiet      bool
+	jsonLog    bool
+	jsonErrors bool
+}
+
+var shared sharedOptions
+
+// stopTimeout releases the -timeout context once the command is done.
+var stopTimeout context.CancelFunc = func() {}
+
+// register defines the shared flags on fs, skipping any name the command
+// already uses for an option of its own.
+func (o *sharedOptions) register(fs *flag.FlagSet) {
+	if fs.Lookup("timeout") == nil {
+		fs.DurationVar(&o.timeout, "timeout", o.timeout, "Abort the command after this long, e.g. 30s or 5m (default: no limit)")
+	}
+	if fs.Lookup("v") == nil {
+		fs.BoolVar(&o.verbose, "v", o.verbose, "Log each file as it is packed or unpacked")
+	}
+	if fs.Lookup("q") == nil {
+		fs.BoolVar(&o.quiet, "q", o.quiet, "Print nothing but errors")
+	}
+	if fs.Lookup("json-log") == nil {
+		fs.BoolVar(&o.jsonLog, "json-log", o.jsonLog, "Log events as JSON lines on stderr")
+	}
+	if fs.Lookup("json-errors") == nil {
+		fs.BoolVar(&o.jsonErrors, "json-errors", o.jsonErrors, "Report errors as JSON objects on stderr")
+	}
+}
+
+// apply configures logging and error output from the parsed options.
+func (o *sharedOptions) apply() {
+	logger = newLogger(os.Stderr, o.verbose, o.quiet, o.jsonLog)
+	quietMode = o.quiet
+	jsonErrors = o.jsonErrors
+}
+
+// parseFlags parses a command's args into fs, which must use
+// flag.ContinueOnError, and returns ctx bounded by -timeout. Options may
+// come before or after positional arguments (silo unpack a.silo -o out/);
+// everything after "--" is positional. A bad flag exits with exitFailure
+// rather than the flag package's 2, which is taken by exitPattern.
+func parseFlags(ctx context.Context, fs *flag.FlagSet, args []string) context.Context {
+	shared.register(fs)
+	checkFlagError(parseInterspersed(fs, args))
+	shared.apply()
+
+	if shared.timeout > 0 {
+		ctx, stopTimeout = context.WithTimeout(ctx, shared.timeout)
+	}
+	return ctx
+}
+
+// parseInterspersed is fs.Parse, except that it carries on past positional
+// arguments so that flags may follow them.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		// Parse consumes a "--" terminator and stops; the rest is positional.
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// checkFlagError exits after a flag parsing error, which the flag package
+// has already reported along with the usage. -h exits successfully.
+func checkFlagError(err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	os.Exit(exitFailure)
+}
+
+// printCommands writes the usage line of every command.
+func printCommands() {
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-46s %s\n", "silo "+cmd.name+" "+cmd.args, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "  %-46s %s\n", "silo help [command]", "Show this help message, or a command's options")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		output     string
+		verbose    bool
+		positional []string
+	}{
+		{"flags first", []string{"-o", "out", "a.silo"}, "out", false, []string{"a.silo"}},
+		{"flags after", []string{"a.silo", "-o", "out", "-v"}, "out", true, []string{"a.silo"}},
+		{"mixed", []string{"a", "-v", "b", "-o=out", "c"}, "out", true, []string{"a", "b", "c"}},
+		{"terminator", []string{"a", "--", "-v", "-o"}, "", false, []string{"a", "-v", "-o"}},
+		{"dash", []string{"-", "-v"}, "", true, []string{"-"}},
+		{"none", nil, "", false, []string{}},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		output := fs.String("o", "", "")
+		verbose := fs.Bool("v", false, "")
+		if err := parseInterspersed(fs, test.args); err != nil {
+			t.Errorf("%s: parseInterspersed failed: %v", test.name, err)
+			continue
+		}
+		if *output != test.output || *verbose != test.verbose || !reflect.DeepEqual(fs.Args(), test.positional) {
+			t.Errorf("%s: got -o %q -v %v args %q, want -o %q -v %v args %q",
+				test.name, *output, *verbose, fs.Args(), test.output, test.verbose, test.positional)
+		}
+	}
+}
+
+func TestParseInterspersedErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"a.silo", "-unknown"},
+		{"a.silo", "-o"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.String("o", "", "")
+		if err := parseInterspersed(fs, args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		if seen[cmd.name] {
+			t.Errorf("Command %s is registered twice", cmd.name)
+		}
+		seen[cmd.name] = true
+		if findCommand(cmd.name) != cmd {
+			t.Errorf("findCommand(%q) did not return the command", cmd.name)
+		}
+	}
+	if findCommand("nope") != nil {
+		t.Error("Expected no command called nope")
+	}
+}
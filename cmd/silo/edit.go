@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func rmCmd(ctx context.Context, args []string) {
+	rmFlags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	rmFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo rm <silo-file> <path> [path ...]\n")
+		fmt.Fprintf(os.Stderr, "Remove entries from a silo file in place\n")
+	}
+	parseFlags(ctx, rmFlags, args)
+
+	if rmFlags.NArg() < 2 {
+		rmFlags.Usage()
+		os.Exit(1)
+	}
+
+	archive := rmFlags.Arg(0)
+	doc, err := readArchiveWithOptions(archive, exactParseOptions)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+
+	for _, path := range rmFlags.Args()[1:] {
+		if err := doc.Remove(path); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	}
+
+	if err := writeArchive(archive, doc); err != nil {
+		fatal(err, "Error writing silo file: %v", err)
+	}
+}
+
+func mvCmd(ctx context.Context, args []string) {
+	mvFlags := flag.NewFlagSet("mv", flag.ContinueOnError)
+	mvFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo mv <silo-file> <old-path> <new-path>\n")
+		fmt.Fprintf(os.Stderr, "Rename an entry inside a silo file in place\n")
+	}
+	parseFlags(ctx, mvFlags, args)
+
+	if mvFlags.NArg() != 3 {
+		mvFlags.Usage()
+		os.Exit(1)
+	}
+
+	archive := mvFlags.Arg(0)
+	doc, err := readArchiveWithOptions(archive, exactParseOptions)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+
+	if err := doc.Rename(mvFlags.Arg(1), mvFlags.Arg(2)); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	if err := writeArchive(archive, doc); err != nil {
+		fatal(err, "Error writing silo file: %v", err)
+	}
+}
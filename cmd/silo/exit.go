@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// Exit codes, so scripts can tell failures apart without parsing messages.
+const (
+	exitFailure  = 1 // anything not listed below, including usage errors
+	exitPattern  = 2 // a glob, include or exclude pattern is invalid or matched nothing
+	exitParse    = 3 // an archive could not be read
+	exitConflict = 4 // the archive cannot be written as asked (delimiter or path collision, patch mismatch)
+	exitSecurity = 5 // an unsafe path, bad signature or detected secret
+)
+
+// exitKinds names each exit code in -json-errors output.
+var exitKinds = map[int]string{
+	exitFailure:  "error",
+	exitPattern:  "pattern",
+	exitParse:    "parse",
+	exitConflict: "conflict",
+	exitSecurity: "security",
+}
+
+// jsonErrors is set by the global -json-errors flag.
+var jsonErrors bool
+
+// errNoMatches is reported when patterns expand to no files.
+var errNoMatches = errors.New("no files matched")
+
+// errSecretFound is reported when pack -redact error finds a secret.
+var errSecretFound = errors.New("possible secret found")
+
+// exitCode classifies err into one of the exit codes above.
+func exitCode(err error) int {
+	var parseErr *silo.ParseError
+	switch {
+	case err == nil:
+		return exitFailure
+	case errors.Is(err, silo.ErrInvalidPath), errors.Is(err, silo.ErrBadSignature),
+		errors.Is(err, silo.ErrUnsigned), errors.Is(err, errSecretFound):
+		return exitSecurity
+	case errors.As(err, &parseErr), errors.Is(err, silo.ErrDecryption):
+		return exitParse
+	case errors.Is(err, silo.ErrDelimiterConflict), errors.Is(err, silo.ErrNoSafeDelimiter),
+		errors.Is(err, silo.ErrDuplicatePath), errors.Is(err, silo.ErrPatchConflict):
+		return exitConflict
+	case errors.Is(err, silo.ErrInvalidPattern), errors.Is(err, errNoMatches):
+		return exitPattern
+	}
+	return exitFailure
+}
+
+// jsonError is the object -json-errors writes to stderr.
+type jsonError struct {
+	Error    string `json:"error"`
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exit_code"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// fatal reports a failure and exits with the code for err, which may be nil
+// for usage errors. The message is built from format and args as for
+// fmt.Printf; with -json-errors it is written as a JSON object instead.
+func fatal(err error, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	code := exitCode(err)
+	if !jsonErrors {
+		fmt.Fprintln(os.Stderr, msg)
+		os.Exit(code)
+	}
+
+	report := jsonError{
+		Error:    strings.TrimPrefix(msg, "Error: "),
+		Kind:     exitKinds[code],
+		ExitCode: code,
+	}
+	var parseErr *silo.ParseError
+	var conflictErr *silo.DelimiterConflictError
+	var limitErr *silo.LimitError
+	switch {
+	case errors.As(err, &conflictErr):
+		report.Path, report.Line = conflictErr.Path, conflictErr.Line
+	case errors.As(err, &parseErr):
+		report.Line = parseErr.Line
+	case errors.As(err, &limitErr):
+		report.Path = limitErr.Path
+	}
+	data, _ := json.Marshal(report)
+	fmt.Fprintln(os.Stderr, string(data))
+	os.Exit(code)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/escherize/go-silo"
+)
+
+func fmtCmd(ctx context.Context, args []string) {
+	fmtFlags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fmtFlags.Bool("w", false, "Write the result back to each archive instead of to stdout")
+	list := fmtFlags.Bool("l", false, "List archives whose formatting differs instead of printing them")
+	keepDelimiter := fmtFlags.Bool("keep-delimiter", false, "Keep each archive's delimiter instead of the auto-selected one")
+	trimBlank := fmtFlags.Bool("trim-blank", false, "Remove blank lines at the end of each entry (they are part of the content)")
+	fmtFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo fmt [options] [silo-file ...]\n")
+		fmt.Fprintf(os.Stderr, "Rewrite archives in canonical form: sorted paths, auto-selected delimiter, no stray blank lines\n")
+		fmt.Fprintf(os.Stderr, "With no files, formats stdin to stdout\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmtFlags.PrintDefaults()
+	}
+	parseFlags(ctx, fmtFlags, args)
+
+	opts := silo.FormatOptions{KeepDelimiter: *keepDelimiter, TrimBlankLines: *trimBlank}
+
+	if fmtFlags.NArg() == 0 {
+		if *write {
+			fmtFlags.Usage()
+			os.Exit(1)
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal(err, "Error reading stdin: %v", err)
+		}
+		formatArchive("<stdin>", src, opts, *list, false)
+		return
+	}
+
+	for _, path := range fmtFlags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fatal(err, "Error reading silo file: %v", err)
+		}
+		formatArchive(path, src, opts, *list, *write)
+	}
+}
+
+// formatArchive formats src, read from path, and lists, rewrites or prints
+// the result.
+func formatArchive(path string, src []byte, opts silo.FormatOptions, list, write bool) {
+	out, err := silo.FormatWithOptions(src, opts)
+	if err != nil {
+		fatal(err, "Error formatting %s: %v", path, err)
+	}
+
+	changed := !bytes.Equal(src, out)
+	if list && changed {
+		fmt.Println(path)
+	}
+	switch {
+	case write && changed:
+		if err := writeAtomic(path, func(w io.Writer) error {
+			_, err := w.Write(out)
+			return err
+		}); err != nil {
+			fatal(err, "Error writing silo file: %v", err)
+		}
+	case !write && !list:
+		if _, err := os.Stdout.Write(out); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/escherize/go-silo"
+)
+
+func fromPatchCmd(ctx context.Context, args []string) {
+	patchFlags := flag.NewFlagSet("from-patch", flag.ContinueOnError)
+	dir := patchFlags.String("dir", ".", "Directory holding the files the patch applies to")
+	outputFile := patchFlags.String("o", "", "Output silo file (default: stdout)")
+	exactNewlines := patchFlags.Bool("exact-newlines", false, "Mark files that lack a final newline so unpacking restores them byte for byte")
+	patchFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo from-patch [options] <patch-file|->\n")
+		fmt.Fprintf(os.Stderr, "Apply a unified diff (diff -u or git diff) to a directory in memory and pack\n")
+		fmt.Fprintf(os.Stderr, "the patched version of every file it changes. The directory is not modified\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		patchFlags.PrintDefaults()
+	}
+	parseFlags(ctx, patchFlags, args)
+
+	if patchFlags.NArg() != 1 {
+		patchFlags.Usage()
+		os.Exit(1)
+	}
+
+	var patch io.Reader = os.Stdin
+	if name := patchFlags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			fatal(err, "Error reading patch: %v", err)
+		}
+		defer file.Close()
+		patch = file
+	}
+
+	doc, err := silo.ApplyPatchToDocument(patch, os.DirFS(*dir))
+	if err != nil {
+		fatal(err, "Error applying patch: %v", err)
+	}
+	for _, warning := range doc.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	doc.NormalizeWithOptions(silo.NormalizeOptions{KeepMissingNewlines: *exactNewlines})
+
+	if *outputFile == "" {
+		if err := doc.WriteTo(os.Stdout); err != nil {
+			fatal(err, "Error writing silo file: %v", err)
+		}
+		return
+	}
+	if err := writeAtomic(*outputFile, doc.WriteTo); err != nil {
+		fatal(err, "Error writing silo file: %v", err)
+	}
+	if !quietMode {
+		fmt.Fprintf(os.Stderr, "Wrote %d files to %s\n", len(doc.Files), *outputFile)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// Git file modes that are not regular files.
+const (
+	gitModeSymlink   = "120000"
+	gitModeSubmodule = "160000"
+)
+
+// gitChange is one file changed in a revision range, as listed by git diff
+// --raw: its mode and blob in the newer revision, and its status letter.
+type gitChange struct {
+	path   string
+	mode   string
+	blob   string
+	status byte
+}
+
+// gitRevisionFiles reads the files changed in rev from the git repository
+// at dir, with their content as committed in its newer revision. rev is a
+// range such as HEAD~3..HEAD or main...feature, or a single commit, which
+// means the changes it introduced. pathspecs, if any, limit the files to
+// those paths. Deleted files and submodules have no content to pack; they
+// are returned separately, each described as "path (reason)", along with
+// symlinks left out by policy. Other committed symlinks become link entries
+// unless policy is SymlinkError. A rev starting with "-" is refused, since
+// git would take it for an option.
+func gitRevisionFiles(ctx context.Context, dir, rev string, pathspecs []string, policy silo.SymlinkPolicy) (*silo.SiloDocument, []string, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return nil, nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	// --relative keeps paths, like the pathspecs, relative to dir.
+	args := []string{"diff", "--raw", "-z", "--no-renames", "--no-abbrev", "--relative", rev}
+	if !strings.Contains(rev, "..") {
+		args = []string{"diff-tree", "-r", "--root", "--no-commit-id", "--raw", "-z", "--no-renames", "--no-abbrev", "--relative", rev}
+	}
+	args = append(args, "--")
+	args = append(args, pathspecs...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	changes, err := parseGitRaw(out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var wanted []gitChange
+	var skipped []string
+	for _, change := range changes {
+		switch {
+		case change.status == 'D':
+			skipped = append(skipped, change.path+" (deleted)")
+		case change.mode == gitModeSubmodule:
+			skipped = append(skipped, change.path+" (submodule)")
+		case change.mode == gitModeSymlink && policy == silo.SymlinkSkip:
+			skipped = append(skipped, change.path+" (symlink)")
+		case change.mode == gitModeSymlink && policy == silo.SymlinkError:
+			return nil, nil, fmt.Errorf("%s is a symlink", change.path)
+		default:
+			wanted = append(wanted, change)
+		}
+	}
+
+	blobs, err := gitReadBlobs(ctx, dir, wanted)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc := &silo.SiloDocument{Delimiter: ">"}
+	for i, change := range wanted {
+		if err := silo.ValidatePath(change.path); err != nil {
+			return nil, nil, err
+		}
+		file := silo.SiloFile{Path: change.path, Content: string(blobs[i])}
+		if change.mode == gitModeSymlink {
+			file = silo.SiloFile{Path: change.path, LinkTarget: string(blobs[i])}
+		}
+		doc.Files = append(doc.Files, file)
+	}
+	return doc, skipped, nil
+}
+
+// parseGitRaw parses the NUL-separated output of git diff --raw -z
+// --no-renames, which alternates ":oldmode newmode oldblob newblob status"
+// records with paths.
+func parseGitRaw(out []byte) ([]gitChange, error) {
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var changes []gitChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) != 5 || meta[4] == "" {
+			return nil, fmt.Errorf("unexpected git diff output %q", fields[i])
+		}
+		changes = append(changes, gitChange{path: fields[i+1], mode: meta[1], blob: meta[3], status: meta[4][0]})
+	}
+	return changes, nil
+}
+
+// gitReadBlobs returns the content of each change's blob, read with a
+// single git cat-file --batch.
+func gitReadBlobs(ctx context.Context, dir string, changes []gitChange) ([][]byte, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	var request bytes.Buffer
+	for _, change := range changes {
+		fmt.Fprintln(&request, change.blob)
+	}
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = dir
+	cmd.Stdin = &request
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(out))
+	blobs := make([][]byte, len(changes))
+	for i, change := range changes {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading blob of %s: %w", change.path, err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("reading blob of %s: git cat-file said %q", change.path, strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("reading blob of %s: bad size %q", change.path, fields[2])
+		}
+		blobs[i] = make([]byte, size)
+		if _, err := io.ReadFull(reader, blobs[i]); err != nil {
+			return nil, fmt.Errorf("reading blob of %s: %w", change.path, err)
+		}
+		if _, err := reader.Discard(1); err != nil { // the newline after the content
+			return nil, fmt.Errorf("reading blob of %s: %w", change.path, err)
+		}
+	}
+	return blobs, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestParseGitRaw(t *testing.T) {
+	blob := strings.Repeat("a", 40)
+	zero := strings.Repeat("0", 40)
+	out := ":100644 100644 " + blob + " " + blob + " M\x00src/a.go\x00" +
+		":000000 100755 " + zero + " " + blob + " A\x00bin/run\x00" +
+		":100644 000000 " + blob + " " + zero + " D\x00old name.txt\x00"
+	changes, err := parseGitRaw([]byte(out))
+	if err != nil {
+		t.Fatalf("parseGitRaw failed: %v", err)
+	}
+	want := []gitChange{
+		{path: "src/a.go", mode: "100644", blob: blob, status: 'M'},
+		{path: "bin/run", mode: "100755", blob: blob, status: 'A'},
+		{path: "old name.txt", mode: "000000", blob: zero, status: 'D'},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+
+	if changes, err := parseGitRaw(nil); err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes for empty output, got %+v, %v", changes, err)
+	}
+	if _, err := parseGitRaw([]byte(":100644 100644 " + blob + "\x00a.go\x00")); err == nil {
+		t.Error("Expected an error for a short record")
+	}
+}
+
+func TestGitReadBlobs(t *testing.T) {
+	dir := newGitRepo(t, map[string]string{"a.txt": "hello\n"})
+	contents := []string{"hello\n", "", "two\nlines\x00and a NUL"}
+	var changes []gitChange
+	for i, content := range contents {
+		name := filepath.Join(dir, "blob")
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		blob := strings.TrimSpace(runGit(t, dir, "hash-object", "-w", "blob"))
+		changes = append(changes, gitChange{path: string(rune('a' + i)), blob: blob})
+	}
+
+	blobs, err := gitReadBlobs(context.Background(), dir, changes)
+	if err != nil {
+		t.Fatalf("gitReadBlobs failed: %v", err)
+	}
+	for i, content := range contents {
+		if string(blobs[i]) != content {
+			t.Errorf("Blob %d: expected %q, got %q", i, content, blobs[i])
+		}
+	}
+
+	if blobs, err := gitReadBlobs(context.Background(), dir, nil); err != nil || blobs != nil {
+		t.Errorf("Expected nothing for no changes, got %q, %v", blobs, err)
+	}
+	missing := []gitChange{{path: "x", blob: strings.Repeat("f", 40)}}
+	if _, err := gitReadBlobs(context.Background(), dir, missing); err == nil {
+		t.Error("Expected an error for a missing blob")
+	}
+}
+
+func TestGitRevisionFiles(t *testing.T) {
+	dir := newGitRepo(t, map[string]string{
+		"keep.txt":   "same\n",
+		"change.txt": "before\n",
+		"gone.txt":   "bye\n",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "change.txt"), []byte("after\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("keep.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "rm", "-q", "gone.txt")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "second")
+	// Uncommitted changes are not packed.
+	if err := os.WriteFile(filepath.Join(dir, "change.txt"), []byte("dirty\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, skipped, err := gitRevisionFiles(context.Background(), dir, "HEAD~1..HEAD", nil, silo.SymlinkPreserve)
+	if err != nil {
+		t.Fatalf("gitRevisionFiles failed: %v", err)
+	}
+	want := []silo.SiloFile{
+		{Path: "change.txt", Content: "after\n"},
+		{Path: "link", LinkTarget: "keep.txt"},
+		{Path: "new.txt", Content: "new\n"},
+	}
+	if !reflect.DeepEqual(doc.Files, want) {
+		t.Errorf("Expected %+v, got %+v", want, doc.Files)
+	}
+	if !reflect.DeepEqual(skipped, []string{"gone.txt (deleted)"}) {
+		t.Errorf("Expected gone.txt to be skipped, got %q", skipped)
+	}
+
+	// A single commit means the changes it introduced; pathspecs narrow them.
+	doc, _, err = gitRevisionFiles(context.Background(), dir, "HEAD", []string{"new.txt"}, silo.SymlinkSkip)
+	if err != nil {
+		t.Fatalf("gitRevisionFiles failed: %v", err)
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Path != "new.txt" {
+		t.Errorf("Expected only new.txt, got %+v", doc.Files)
+	}
+
+	if _, _, err := gitRevisionFiles(context.Background(), dir, "HEAD~1..HEAD", nil, silo.SymlinkError); err == nil {
+		t.Error("Expected an error for the symlink with SymlinkError")
+	}
+	for _, rev := range []string{"", "--output=/tmp/x", "-p"} {
+		if _, _, err := gitRevisionFiles(context.Background(), dir, rev, nil, silo.SymlinkPreserve); err == nil {
+			t.Errorf("Expected an error for revision %q", rev)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func grepCmd(ctx context.Context, args []string) {
+	grepFlags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	var queries stringList
+	grepFlags.Var(&queries, "e", "Text to search for (repeatable); several queries share one index")
+	filesOnly := grepFlags.Bool("l", false, "Print only the paths of entries with a match")
+	grepFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo grep [options] <text> <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "       silo grep [options] -e <text> [-e <text> ...] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Print the lines of entries containing text, as path:line:content, exiting with 1 if there are none\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		grepFlags.PrintDefaults()
+	}
+	parseFlags(ctx, grepFlags, args)
+
+	rest := grepFlags.Args()
+	if len(queries) == 0 && len(rest) == 2 {
+		queries, rest = stringList{rest[0]}, rest[1:]
+	}
+	if len(queries) == 0 || len(rest) != 1 {
+		grepFlags.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := readArchive(rest[0])
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	search := doc.Search
+	if len(queries) > 1 {
+		search = doc.BuildIndex().Search
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	found := false
+	printed := make(map[string]bool)
+	for _, query := range queries {
+		for _, match := range search(query) {
+			found = true
+			switch {
+			case !*filesOnly:
+				fmt.Fprintf(w, "%s:%d:%s\n", match.Path, match.Line, match.Text)
+			case !printed[match.Path]:
+				printed[match.Path] = true
+				fmt.Fprintln(w, match.Path)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	if !found {
+		os.Exit(exitFailure)
+	}
+}
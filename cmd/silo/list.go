@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/escherize/go-silo"
+)
+
+func listCmd(ctx context.Context, args []string) {
+	listFlags := flag.NewFlagSet("list", flag.ContinueOnError)
+	formatName := listFlags.String("format", "text", "Output format: text, or csv, tsv or json with each entry's kind and target")
+	listFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo list [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Print the path of every entry in a silo file without loading its content\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		listFlags.PrintDefaults()
+	}
+	parseFlags(ctx, listFlags, args)
+
+	if listFlags.NArg() != 1 {
+		listFlags.Usage()
+		os.Exit(1)
+	}
+	format, err := parseOutputFormat(*formatName)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	doc, err := silo.OpenSiloFile(listFlags.Arg(0))
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	defer doc.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	if format != formatText {
+		if err := writeListRecords(w, format, doc.Entries()); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		return
+	}
+	for _, entry := range doc.Entries() {
+		if entry.LinkTarget != "" {
+			fmt.Fprintf(w, "%s -> %s\n", entry.Path, entry.LinkTarget)
+			continue
+		}
+		fmt.Fprintln(w, entry.Path)
+	}
+	if err := w.Flush(); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+}
+
+func catCmd(ctx context.Context, args []string) {
+	catFlags := flag.NewFlagSet("cat", flag.ContinueOnError)
+	catFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo cat <silo-file> <path> [path ...]\n")
+		fmt.Fprintf(os.Stderr, "Write the content of entries to stdout, reading only those entries from the silo file\n")
+	}
+	parseFlags(ctx, catFlags, args)
+
+	if catFlags.NArg() < 2 {
+		catFlags.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := silo.OpenSiloFile(catFlags.Arg(0))
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	defer doc.Close()
+
+	for _, path := range catFlags.Args()[1:] {
+		content, err := doc.ReadFile(path)
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		if _, err := os.Stdout.WriteString(content); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+)
+
+// logger receives progress events from every command. It is configured by
+// the global -v, -q and -json-log flags.
+var logger = newLogger(io.Discard, false, false, false)
+
+// quietMode is set by the global -q flag: commands then print nothing but
+// errors.
+var quietMode bool
+
+// newLogger returns a logger writing to w. By default only warnings are
+// logged; -v adds a debug event per file, -json-log switches to one JSON
+// object per line and includes the per-command summary events, and -q
+// leaves only errors.
+func newLogger(w io.Writer, verbose, quiet, jsonLog bool) *slog.Logger {
+	level := slog.LevelWarn
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbose:
+		level = slog.LevelDebug
+	case jsonLog:
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if jsonLog {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	// Timestamps add little to interactive output.
+	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
@@ -1,40 +1,122 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/escherize/go-silo"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	globalFlags := flag.NewFlagSet("silo", flag.ContinueOnError)
+	shared.register(globalFlags)
+	globalFlags.Usage = printUsage
+	checkFlagError(globalFlags.Parse(os.Args[1:]))
+	shared.apply()
+	
+	if globalFlags.NArg() < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
+	name := globalFlags.Arg(0)
+	args := globalFlags.Args()[1:]
+	if name == "help" || name == "-h" || name == "--help" {
+		if len(args) == 0 {
+			printUsage()
+			return
+		}
+		// silo help pack is silo pack -h.
+		name, args = args[0], []string{"-h"}
+	}
 	
-	switch command {
-	case "pack":
-		packCmd()
-	case "unpack":
-		unpackCmd()
-	case "help", "-h", "--help":
-		printUsage()
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
 		printUsage()
 		os.Exit(1)
 	}
+	cmd.run(context.Background(), args)
+	stopTimeout()
 }
 
-func packCmd() {
-	packFlags := flag.NewFlagSet("pack", flag.ExitOnError)
+func packCmd(ctx context.Context, args []string) {
+	packFlags := flag.NewFlagSet("pack", flag.ContinueOnError)
 	outputFile := packFlags.String("o", "", "Output silo file (default: stdout)")
 	delimiter := packFlags.String("d", "", "Delimiter to use (auto-detected if not specified)")
-	useEnhanced := packFlags.Bool("enhanced", false, "Use enhanced glob support with ** patterns")
+	preferDelimiters := packFlags.String("delimiters", os.Getenv("SILO_DELIMITERS"), "Comma-separated delimiters auto-selection tries before > = * - (default: $SILO_DELIMITERS)")
+	useEnhanced := packFlags.Bool("enhanced", false, "No effect: ** patterns are always supported (kept for compatibility)")
+	parallelism := packFlags.Int("j", 0, "Number of files to read in parallel when packing a directory (default: number of CPUs)")
+	symlinks := packFlags.String("symlinks", "follow", "How to pack symlinks inside a directory: follow, skip, preserve or error")
+	irregular := packFlags.String("irregular", "skip", "What to do with named pipes, sockets and devices inside a directory, which are never read: skip (with a warning) or error")
+	linkHardLinks := packFlags.Bool("hardlinks", false, "Pack further names of a hard-linked file as links to the first instead of repeating its content")
+	quiet := packFlags.Bool("q", false, "Suppress the delimiter choice report on stderr")
+	explainDelimiter := packFlags.Bool("explain-delimiter", false, "Only report which delimiter would be chosen and why, without packing")
+	appendMode := packFlags.Bool("append", false, "Add the matched files to the existing archive given with -o")
+	readMembers := packFlags.Bool("members", true, "Pack the members of a lone .tar, .tar.gz, .tgz or .zip argument rather than the archive file itself")
+	ifChanged := packFlags.Bool("if-changed", false, "Leave the -o file untouched when its content would not change, as go:generate and make prefer")
+	outline := packFlags.Bool("outline", false, "Keep only declarations and signatures of Go, Python and C-family code, replacing function bodies with ...")
+	maxLines := packFlags.Int("max-lines-per-file", 0, "Keep only the first N lines of longer text files, ending them with a \"[... N lines truncated ...]\" line")
+	langHints := packFlags.Bool("lang-hints", false, "Annotate entries with the language inferred from their file name (lang=go) for syntax highlighting")
+	asJSONL := packFlags.Bool("jsonl", false, "Write JSON Lines, one {\"path\", \"content\"} object per file, instead of a silo archive")
+	withHeader := packFlags.Bool("header", false, "Start the archive with a format header line (version, delimiter, file count, creation time)")
+	reportFile := packFlags.String("report", "", "Write a JSON report of what was packed to this file")
+	manifestFile := packFlags.String("manifest", "", "Write a JSON manifest of the archive (paths, sizes, SHA-256 hashes, delimiter) to this file")
+	filesFrom := packFlags.String("files-from", "", "Read literal file paths, one per line, from this file (- for stdin)")
+	stdinContent := packFlags.String("stdin-content", "", "Pack standard input as an entry with this `path`")
+	nullSeparated := packFlags.Bool("null", false, "Paths read with -files-from are NUL-separated (as from find -print0)")
+	useGit := packFlags.Bool("git", false, "Pack the files tracked by git in the current directory (git ls-files)")
+	rev := packFlags.String("rev", "", "Pack the files changed in a git `range` such as HEAD~3..HEAD, as committed; patterns become git pathspecs")
+	maxTokens := packFlags.Int("max-tokens", 0, "Fail if the archive's estimated token count exceeds this budget (0: no limit)")
+	trim := packFlags.Bool("trim", false, "With -max-tokens, drop files from the end of the archive until it fits instead of failing")
+	var includes, excludes stringList
+	packFlags.Var(&includes, "include", "When packing a directory, only pack files matching this pattern (repeatable)")
+	packFlags.Var(&excludes, "exclude", "When packing a directory, leave out files and directories matching this pattern (repeatable)")
+	langs := packFlags.String("lang", "", "When packing a directory, only pack files of these comma-separated `languages` (go,python) or extensions (md); packs . when no pattern is given")
+	hidden := packFlags.Bool("hidden", false, "When packing a directory, also pack hidden files and directories (.env, .vscode/, ...)")
+	noDefaultExcludes := packFlags.Bool("no-default-excludes", false, "When packing a directory, also pack .git, .hg, .svn, node_modules, __pycache__, .DS_Store and *.pyc, which are left out by default")
+	excludeTests := packFlags.Bool("exclude-tests", false, "When packing a directory, leave out test files (*_test.go, test_*.py, *.spec.ts, ...) and testdata directories")
+	encrypt := packFlags.Bool("encrypt", false, "Encrypt the archive with a passphrase (see -passphrase-file)")
+	passphraseFile := packFlags.String("passphrase-file", "", "File holding the -encrypt passphrase (default: $SILO_PASSPHRASE)")
+	since := packFlags.String("since", "", "Reuse unchanged files from this earlier pack of the same directory (implies -header)")
+	sortOrder := packFlags.String("sort", "insertion", "Order of entries: insertion (as read, which is by path, with -append adding at the end), lexical, dirs-first or smart (README and manifests first, tests last)")
+	lineEndings := packFlags.String("line-endings", "preserve", "Line endings of file content in the archive: preserve, lf or crlf")
+	exactNewlines := packFlags.Bool("exact-newlines", false, "Mark files that lack a final newline so unpacking restores them byte for byte")
+	tokenizer := packFlags.String("tokenizer", "bytes", "Token estimate heuristic for -max-tokens and -report: bytes or words")
+	ignoreCase := packFlags.Bool("ignore-case", false, "Match patterns without regard to case")
+	cwd := packFlags.String("cwd", "", "Resolve patterns and read files relative to this directory instead of the current one")
+	binary := packFlags.String("binary", "include", "How to pack files with binary content: include, skip, error or base64")
+	skipBinary := packFlags.Bool("skip-binary", false, "Leave out files with binary content (same as -binary skip)")
+	var maxFileSize byteSize
+	redact := packFlags.String("redact", "", "Scan for secrets (API keys, tokens, private keys): mask replaces them, error aborts with a report")
+	var redactRules stringList
+	packFlags.Var(&redactRules, "redact-rule", "Extra secret pattern for -redact, as name=regexp (repeatable)")
+	packFlags.Var(&maxFileSize, "max-file-size", "Fail if any file to pack is larger than `size`, e.g. 1MB or 512KB (0: no limit)")
+	var splitSize byteSize
+	packFlags.Var(&splitSize, "split-size", "Write parts of at most `size` named <o>.001.silo, <o>.002.silo, ... instead of one archive")
+	splitTokens := packFlags.Int("split-tokens", 0, "Like -split-size, but write balanced parts of at most this many estimated tokens")
+	skipLarge := packFlags.Bool("skip-large", false, "Leave out files larger than -max-file-size instead of failing")
+	maxFiles := packFlags.Int("max-files", 10000, "Stop without packing if more than this many files match, in case of a mistaken directory such as ~ or node_modules (0: no limit)")
+	noLimit := packFlags.Bool("no-limit", false, "Pack however many files match (same as -max-files 0)")
+	yes := packFlags.Bool("yes", false, "Go on past -max-files (same as -no-limit)")
+	continueOnError := packFlags.Bool("continue-on-error", false, "Leave out files that cannot be read, such as ones without permission, instead of failing")
+	stripComponents := packFlags.Int("strip-components", 0, "Remove this many leading directories from each packed path, as tar does; shorter paths are left out")
+	prefix := packFlags.String("prefix", "", "Put every packed path under this `directory`, e.g. vendor/lib/")
 	
 	packFlags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: silo pack [options] <pattern1 pattern2 ...>\n")
@@ -44,30 +126,245 @@ func packCmd() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  silo pack src/                          Pack directory\n")
 		fmt.Fprintf(os.Stderr, "  silo pack \"*.go\" \"*.md\"                   Pack multiple patterns\n")
-		fmt.Fprintf(os.Stderr, "  silo pack -enhanced \"src/**/*.go\"         Pack with recursive ** pattern\n")
+		fmt.Fprintf(os.Stderr, "  silo pack \"src/**/*.{go,md}\"              Pack with recursive ** and {a,b} patterns\n")
 		fmt.Fprintf(os.Stderr, "  silo pack -d \"🌾\" -o out.silo \"*.txt\"     Pack with wheat emoji delimiter\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -delimiters \"🌾,---\" src/         Prefer these when auto-selecting\n")
 		fmt.Fprintf(os.Stderr, "  silo pack \"a/this\" \"b/that\"              Pack specific paths\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -explain-delimiter src/          Show why a delimiter would be chosen\n")
+		fmt.Fprintf(os.Stderr, "  git ls-files | silo pack -files-from -     Pack paths listed on stdin\n")
+		fmt.Fprintf(os.Stderr, "  git ls-files | silo pack -                 Same, shorter\n")
+		fmt.Fprintf(os.Stderr, "  make plan | silo pack -stdin-content plan.txt  Pack command output as an entry\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -git -o repo.silo                Pack all git-tracked files\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -o out.silo release.tar.gz      Pack a tarball's files without extracting it\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -rev HEAD~3..HEAD -o review.silo  Pack the files changed by the last 3 commits\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -report r.json -o out.silo src/  Also write a JSON pack report\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -manifest out.json -o out.silo src/  Also write a JSON index with hashes\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -jsonl src/ | jq -r .path        One JSON object per file, for jq\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -append -o out.silo new.go       Add files to an existing archive\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -q -if-changed -o assets.silo assets/  For //go:generate and go:embed\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -max-tokens 100000 -trim src/    Keep the archive within an LLM context budget\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -sort smart -o context.silo .     Order files for reading: README first, tests last\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -max-lines-per-file 300 src/     Cut long files short, marking what was left out\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -outline -o overview.silo .      Signatures only, for an overview of a large codebase\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -lang go,md -exclude-tests       Pack the Go and Markdown files under ., without tests\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -lang-hints -o out.silo src/     Mark each entry's language for highlighters\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -since old.silo -o new.silo src/ Only re-read files changed since old.silo\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -encrypt -o secrets.silo conf/   Encrypt with $SILO_PASSPHRASE\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -exclude dist -exclude \"*.log\" .  Leave out matching paths\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -hidden -o dotfiles.silo .       Also pack .env, .github/, ...\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -no-default-excludes .           Also pack .git, node_modules, ...\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -binary base64 -o site.silo www/  Keep images, base64-encoded\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -max-file-size 1MB src/          Fail fast on huge files such as logs\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -yes -o monorepo.silo .          Pack more than 10000 files\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -v -exclude \"*.log\" src/        List what was left out and why\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -split-size 500KB -o part src/   Write part.001.silo, part.002.silo, ...\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -split-tokens 50000 -o chunk .   One part per prompt for an LLM\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -continue-on-error /etc          Pack what is readable, listing the rest\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -hardlinks -o store.silo store/  Store hard-linked files once\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -prefix vendor/lib/ lib/         Store the files under vendor/lib/lib/\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -strip-components 1 src/         Store src/a.go as a.go\n")
+		fmt.Fprintf(os.Stderr, "  silo pack -redact mask -o llm.silo .        Mask credentials before sharing\n")
 		fmt.Fprintf(os.Stderr, "\nSecurity: Patterns with .. or absolute paths are rejected\n")
 	}
 	
-	packFlags.Parse(os.Args[2:])
+	ctx = parseFlags(ctx, packFlags, args)
+	if quietMode {
+		*quiet = true
+	}
+	
+	// Taken before any file is read, so that a header written with it is a
+	// safe baseline for a later -since.
+	started := time.Now()
 	
-	if packFlags.NArg() < 1 {
+	if packFlags.NArg() < 1 && *filesFrom == "" && !*useGit && *stdinContent == "" && *rev == "" && *langs == "" {
 		packFlags.Usage()
 		os.Exit(1)
 	}
 	
+	if *appendMode && *outputFile == "" {
+		fatal(nil, "Error: -append requires -o with the archive to extend")
+	}
+	if *asJSONL {
+		switch {
+		case *encrypt:
+			fatal(nil, "Error: -jsonl cannot be used with -encrypt")
+		case splitSize > 0 || *splitTokens > 0:
+			fatal(nil, "Error: -jsonl cannot be used with splitting")
+		case *appendMode:
+			fatal(nil, "Error: -jsonl cannot be used with -append")
+		}
+	}
+	if splitSize > 0 || *splitTokens > 0 {
+		switch {
+		case splitSize > 0 && *splitTokens > 0:
+			fatal(nil, "Error: -split-size cannot be combined with -split-tokens")
+		case *outputFile == "":
+			fatal(nil, "Error: splitting requires -o with the name of the parts")
+		case *appendMode:
+			fatal(nil, "Error: splitting cannot be used with -append")
+		case *encrypt:
+			fatal(nil, "Error: splitting cannot be used with -encrypt")
+		case *ifChanged:
+			fatal(nil, "Error: splitting cannot be used with -if-changed")
+		}
+	}
+	
+	symlinkPolicy, err := silo.ParseSymlinkPolicy(*symlinks)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	irregularPolicy, err := silo.ParseIrregularPolicy(*irregular)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	
+	estimator, err := silo.ParseTokenEstimator(*tokenizer)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	
+	lineEndingPolicy, err := silo.ParseLineEndingPolicy(*lineEndings)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	sortPolicy, err := silo.ParseSortPolicy(*sortOrder)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	writeOpts := silo.WriteOptions{LineEndings: lineEndingPolicy, MarkMissingNewline: *exactNewlines}
+	var delimiterOpts silo.DelimiterOptions
+	for _, candidate := range strings.Split(*preferDelimiters, ",") {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			writeOpts.DelimiterCandidates = append(writeOpts.DelimiterCandidates, candidate)
+		}
+	}
+	if len(writeOpts.DelimiterCandidates) > 0 {
+		delimiterOpts.Candidates = append(append([]string(nil), writeOpts.DelimiterCandidates...), silo.DefaultDelimiterCandidates...)
+	}
+	
+	if *redact != "" && *redact != "mask" && *redact != "error" {
+		fatal(nil, "Error: unknown -redact mode %q (want mask or error)", *redact)
+	}
+	if len(redactRules) > 0 && *redact == "" {
+		fatal(nil, "Error: -redact-rule requires -redact mask or -redact error")
+	}
+	rules := append([]silo.RedactionRule(nil), silo.DefaultRedactionRules...)
+	for _, spec := range redactRules {
+		rule, err := silo.ParseRedactionRule(spec)
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+	
+	binaryPolicy, err := silo.ParseBinaryPolicy(*binary)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	if *skipBinary {
+		if binaryPolicy != silo.BinaryInclude && binaryPolicy != silo.BinarySkip {
+			fatal(nil, "Error: -skip-binary cannot be combined with -binary %s", *binary)
+		}
+		binaryPolicy = silo.BinarySkip
+	}
+	
+	var passphrase string
+	if *encrypt {
+		if *appendMode {
+			fatal(nil, "Error: -append cannot be used with -encrypt")
+		}
+		if lineEndingPolicy != silo.LineEndingsPreserve {
+			fatal(nil, "Error: -line-endings cannot be used with -encrypt")
+		}
+		if *manifestFile != "" {
+			fatal(nil, "Error: -manifest cannot be used with -encrypt, since it would reveal what the archive holds")
+		}
+		if passphrase, err = readPassphrase(*passphraseFile); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	}
+	
 	// Create secure glob expander
 	globber, err := silo.NewSecureGlobExpander()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing glob expander: %v\n", err)
-		os.Exit(1)
+		fatal(err, "Error initializing glob expander: %v", err)
+	}
+	if *cwd != "" {
+		if info, statErr := os.Stat(*cwd); statErr != nil || !info.IsDir() {
+			fatal(nil, "Error: -cwd %s is not a directory", *cwd)
+		}
+		if globber.WorkingDir, err = filepath.Abs(*cwd); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+	}
+	globber.FollowSymlinks = symlinkPolicy == silo.SymlinkFollow
+	globber.CaseInsensitive = *ignoreCase
+	// inCwd locates a matched path on disk; matches stay relative to -cwd.
+	inCwd := func(path string) string {
+		return filepath.Join(globber.WorkingDir, path)
 	}
 	
 	// Collect all patterns
-	patterns := make([]string, packFlags.NArg())
-	for i := 0; i < packFlags.NArg(); i++ {
-		patterns[i] = packFlags.Arg(i)
+	patterns, err := splitStdinArg(packFlags.Args(), filesFrom)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	
+	if *langs != "" {
+		langPatterns, err := silo.LanguagePatterns(strings.Split(*langs, ",")...)
+		if err != nil {
+			fatal(err, "Error: -lang: %v", err)
+		}
+		includes = append(includes, langPatterns...)
+		if len(patterns) == 0 && *filesFrom == "" && !*useGit && *rev == "" && *stdinContent == "" {
+			patterns = []string{"."}
+		}
+	}
+	if *excludeTests {
+		excludes = append(excludes, silo.TestFilePatterns...)
+	}
+	
+	// With -rev, content comes from git and patterns limit which paths.
+	var revDoc *silo.SiloDocument
+	if *rev != "" {
+		switch {
+		case *useGit:
+			fatal(nil, "Error: -rev cannot be combined with -git")
+		case *filesFrom != "":
+			fatal(nil, "Error: -rev cannot be combined with a file list")
+		case *since != "":
+			fatal(nil, "Error: -rev cannot be combined with -since")
+		}
+		for _, pattern := range patterns {
+			if err := globber.ValidatePattern(pattern); err != nil {
+				fatal(err, "Error: %v", err)
+			}
+		}
+		var revSkipped []string
+		revDoc, revSkipped, err = gitRevisionFiles(ctx, globber.WorkingDir, *rev, patterns, symlinkPolicy)
+		if err != nil {
+			fatal(err, "Error reading git revisions: %v", err)
+		}
+		for _, skip := range revSkipped {
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "Skipped %s\n", skip)
+			}
+		}
+		if len(revDoc.Files) == 0 && *stdinContent == "" {
+			fatal(errNoMatches, "No files with content changed in %s", *rev)
+		}
+		patterns = nil
+	}
+	
+	var stdinEntry string
+	if *stdinContent != "" {
+		if *filesFrom == "-" {
+			fatal(nil, "Error: -stdin-content cannot be combined with a file list read from stdin")
+		}
+		if err := globber.ValidatePattern(*stdinContent); err != nil {
+			fatal(err, "Error: -stdin-content: %v", err)
+		}
+		stdinEntry = filepath.ToSlash(filepath.Clean(*stdinContent))
 	}
 	
 	// Choose glob option based on flags
@@ -75,111 +372,1037 @@ func packCmd() {
 	if *useEnhanced {
 		globOption = silo.EnhancedGlob
 	} else {
-		globOption = silo.BothGlobs // Try enhanced, fall back to standard
+		globOption = silo.BothGlobs
 	}
 	
 	// Expand patterns safely
 	filePaths, err := globber.ExpandPatterns(patterns, globOption)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error expanding patterns: %v\n", err)
-		os.Exit(1)
+		fatal(err, "Error expanding patterns: %v", err)
 	}
 	
-	if len(filePaths) == 0 {
-		fmt.Fprintf(os.Stderr, "No files matched the specified patterns\n")
-		os.Exit(1)
+	var listed []string
+	if *filesFrom != "" {
+		paths, err := readFileList(*filesFrom, *nullSeparated)
+		if err != nil {
+			fatal(err, "Error reading file list: %v", err)
+		}
+		listed = append(listed, paths...)
+	}
+	if *useGit {
+		paths, err := gitTrackedFiles(globber.WorkingDir)
+		if err != nil {
+			fatal(err, "Error listing git files: %v", err)
+		}
+		listed = append(listed, paths...)
+	}
+	
+	seen := make(map[string]bool)
+	for _, path := range filePaths {
+		seen[path] = true
+	}
+	for _, path := range listed {
+		if err := globber.ValidatePath(path); err != nil {
+			fatal(err, "Error in file list: %v", err)
+		}
+		if !seen[path] {
+			seen[path] = true
+			filePaths = append(filePaths, path)
+		}
+	}
+	
+	if len(filePaths) == 0 && stdinEntry == "" && revDoc == nil {
+		fatal(errNoMatches, "No files matched the specified patterns")
+	}
+	
+	if *noLimit || *yes {
+		*maxFiles = 0
+	}
+	if *maxFiles > 0 && len(filePaths) > *maxFiles {
+		tooManyFiles(len(filePaths), *maxFiles)
+	}
+	
+	var readReport silo.PackReport
+	treeOpts := silo.ReadDirectoryTreeOptions{
+		Parallelism:       *parallelism,
+		Symlinks:          symlinkPolicy,
+		Include:           includes,
+		Exclude:           excludes,
+		NoDefaultExcludes: *noDefaultExcludes,
+		SkipHidden:        !*hidden,
+		Irregular:         irregularPolicy,
+		LinkHardLinks:     *linkHardLinks,
+		MaxFileSize:       int64(maxFileSize),
+		SkipLarge:         *skipLarge,
+		MaxFiles:          *maxFiles,
+		Binary:            binaryPolicy,
+		Logger:            logger,
+		ContinueOnError:   *continueOnError,
+		Report:            &readReport,
+	}
+	
+	filesOpts := silo.ReadFilesOptions{
+		MaxFileSize:     int64(maxFileSize),
+		SkipLarge:       *skipLarge,
+		WorkingDir:      globber.WorkingDir,
+		ContinueOnError: *continueOnError,
+		Logger:          logger,
+		Report:          &readReport,
 	}
 	
 	// Check if we have a single directory
 	var doc *silo.SiloDocument
-	if len(filePaths) == 1 {
-		if info, statErr := os.Stat(filePaths[0]); statErr == nil && info.IsDir() {
-			doc, err = silo.ReadDirectoryTree(filePaths[0])
+	if revDoc != nil {
+		doc = revDoc
+	} else if len(filePaths) == 1 && *readMembers && *since == "" && isTarOrZip(filePaths[0]) {
+		doc, err = readTarOrZip(inCwd(filePaths[0]), treeOpts)
+		if err == nil {
+			for _, warning := range doc.Warnings {
+				if !*quiet {
+					fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", filePaths[0], warning)
+				}
+			}
+			doc.Warnings = nil
+		}
+	} else if len(filePaths) == 0 {
+		// Only -stdin-content: there is nothing to read from disk.
+		doc = &silo.SiloDocument{Delimiter: ">"}
+	} else if *since != "" {
+		if info, statErr := os.Stat(inCwd(filePaths[0])); len(filePaths) != 1 || statErr != nil || !info.IsDir() {
+			fatal(nil, "Error: -since requires a single directory to pack")
+		}
+		doc, err = readArchive(*since)
+		if err != nil {
+			fatal(err, "Error reading baseline archive: %v", err)
+		}
+		if doc.Header == nil && !*quiet {
+			fmt.Fprintf(os.Stderr, "Warning: %s has no format header, so every file will be re-read\n", *since)
+		}
+		err = doc.UpdateFromDirectoryContext(ctx, inCwd(filePaths[0]), treeOpts)
+	} else if len(filePaths) == 1 {
+		if info, statErr := os.Stat(inCwd(filePaths[0])); statErr == nil && info.IsDir() {
+			doc, err = silo.ReadDirectoryTreeContext(ctx, inCwd(filePaths[0]), treeOpts)
 		} else {
-			doc, err = silo.ReadFiles(filePaths)
+			doc, err = silo.ReadFilesContext(ctx, filePaths, filesOpts)
 		}
 	} else {
 		// Multiple files/patterns
-		doc, err = silo.ReadFiles(filePaths)
+		doc, err = silo.ReadFilesContext(ctx, filePaths, filesOpts)
 	}
 	
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-		os.Exit(1)
+		var limitErr *silo.LimitError
+		if errors.As(err, &limitErr) && limitErr.Limit == "MaxFileSize" {
+			fatal(limitErr, "Error: %s is larger than -max-file-size %s (leave it out with -exclude or -skip-large)", limitErr.Path, maxFileSize.String())
+		}
+		if errors.As(err, &limitErr) && limitErr.Limit == "MaxFiles" {
+			tooManyFiles(0, *maxFiles)
+		}
+		if errors.Is(err, silo.ErrBinaryContent) {
+			fatal(err, "Error reading input: %v (use -binary skip or -binary base64)", err)
+		}
+		fatal(err, "Error reading input: %s", describeError(err))
+	}
+	
+	var skipped []packReportSkip
+	for _, skip := range readReport.Skipped {
+		skipped = append(skipped, packReportSkip{Path: skip.Path, Reason: skip.Reason})
+		if *quiet {
+			continue
+		}
+		switch skip.Reason {
+		case silo.SkipBinary:
+			fmt.Fprintf(os.Stderr, "Skipped binary file %s\n", skip.Path)
+		case silo.SkipTooLarge:
+			fmt.Fprintf(os.Stderr, "Skipped %s, larger than -max-file-size %s\n", skip.Path, maxFileSize.String())
+		case silo.SkipIrregular:
+			fmt.Fprintf(os.Stderr, "Skipped %s: not a regular file (named pipe, socket or device)\n", skip.Path)
+		case silo.SkipUnreadable:
+			fmt.Fprintf(os.Stderr, "Skipped unreadable file %s: %v\n", skip.Path, skip.Err)
+		}
+	}
+	
+	if stdinEntry != "" {
+		if err := addStdinEntry(doc, stdinEntry, os.Stdin); err != nil {
+			fatal(err, "Error: -stdin-content: %v", err)
+		}
+	}
+	
+	if err := repath(doc, *stripComponents, *prefix); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	
+	// Directory reads apply the policy themselves; this covers file lists
+	// and entries reused by -since.
+	binarySkipped, err := doc.ApplyBinaryPolicy(binaryPolicy)
+	if err != nil {
+		fatal(err, "Error reading input: %v (use -binary skip or -binary base64)", err)
+	}
+	for _, path := range binarySkipped {
+		logger.Debug("skipped file", "path", path, "reason", silo.SkipBinary)
+		skipped = append(skipped, packReportSkip{Path: path, Reason: silo.SkipBinary})
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Skipped binary file %s\n", path)
+		}
+	}
+	
+	switch *redact {
+	case "error":
+		if secrets := doc.FindSecrets(rules); len(secrets) > 0 {
+			lines := make([]string, len(secrets))
+			for i, secret := range secrets {
+				lines[i] = "  " + secret.String()
+			}
+			fatal(errSecretFound, "Error: possible secrets found, so nothing was packed:\n%s", strings.Join(lines, "\n"))
+		}
+	case "mask":
+		for _, secret := range doc.Redact(rules) {
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "Redacted %s\n", secret)
+			}
+		}
+	}
+	
+	if *outline {
+		outlined := doc.Outline()
+		logger.Debug("outlined files", "count", len(outlined))
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Outlined %d of %d files\n", len(outlined), len(doc.Files))
+		}
+	}
+	for _, path := range doc.TruncateLines(*maxLines) {
+		logger.Debug("truncated file", "path", path, "max_lines", *maxLines)
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Truncated %s (-max-lines-per-file %d)\n", path, *maxLines)
+		}
+	}
+	
+	doc.NormalizeWithOptions(silo.NormalizeOptions{KeepMissingNewlines: *exactNewlines})
+	if *langHints {
+		doc.AnnotateLanguages()
+	}
+	
+	if *appendMode {
+		existing, err := readArchiveWithOptions(*outputFile, exactParseOptions)
+		if err != nil {
+			fatal(err, "Error reading archive to append to: %v", err)
+		}
+		if err := existing.AppendFrom(doc); err != nil {
+			fatal(err, "Error appending to %s: %v", *outputFile, err)
+		}
+		doc = existing
+		// Entries already in the archive keep their missing final
+		// newlines; new ones only lack one with -exact-newlines.
+		writeOpts.MarkMissingNewline = true
 	}
 	
 	if *delimiter != "" {
 		doc.Delimiter = *delimiter
+		// Checked here rather than left to the writer, so every colliding
+		// line is listed and not only the first.
+		if conflicts := doc.DelimiterConflicts(*delimiter); len(conflicts) > 0 {
+			fatal(silo.ErrDelimiterConflict, "Error: delimiter %q conflicts with %d content lines (remove -d to auto-select a delimiter):\n%s", *delimiter, len(conflicts), describeConflicts(conflicts, 10))
+		}
 	} else {
 		doc.Delimiter = ""
 	}
 	
-	if *outputFile == "" {
-		err = doc.WriteTo(os.Stdout)
-	} else {
-		file, err := os.Create(*outputFile)
+	if *explainDelimiter {
+		if *delimiter != "" {
+			fatal(nil, "Delimiter %q was set with -d; nothing to explain", *delimiter)
+		}
+		analysis, err := silo.AnalyzeDelimiterWithOptions(doc, delimiterOpts)
+		if err != nil {
+			fatal(err, "Error choosing delimiter: %v", err)
+		}
+		printDelimiterAnalysis(os.Stdout, analysis)
+		return
+	}
+	
+	if doc.Delimiter == "" {
+		analysis, err := silo.AnalyzeDelimiterWithOptions(doc, delimiterOpts)
+		if err != nil {
+			fatal(err, "Error writing silo file: %v", err)
+		}
+		doc.Delimiter = analysis.Chosen
+		if !*quiet {
+			printDelimiterAnalysis(os.Stderr, analysis)
+		}
+	}
+	
+	if *withHeader || *since != "" {
+		doc.Header = &silo.FormatHeader{Created: started}
+	}
+	
+	// Sorted before -trim and splitting, so both follow the written order.
+	if err := doc.SortFiles(sortPolicy); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	
+	if *maxTokens > 0 {
+		statsOpts := silo.StatsOptions{Tokenizer: estimator}
+		total := doc.StatsWithOptions(statsOpts).TotalTokens()
+		if total > *maxTokens && !*trim {
+			fatal(nil, "Error: archive is an estimated %d tokens, over the -max-tokens budget of %d (use -trim to drop files)", total, *maxTokens)
+		}
+		for total > *maxTokens && len(doc.Files) > 0 {
+			last := doc.Files[len(doc.Files)-1]
+			doc.Files = doc.Files[:len(doc.Files)-1]
+			skipped = append(skipped, packReportSkip{Path: last.Path, Reason: "over token budget"})
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "Trimmed %s to stay within %d tokens\n", last.Path, *maxTokens)
+			}
+			total = doc.StatsWithOptions(statsOpts).TotalTokens()
+		}
+		if total > *maxTokens {
+			fatal(nil, "Error: the archive overhead alone exceeds the -max-tokens budget of %d", *maxTokens)
+		}
+	}
+	
+	write := func(w io.Writer) error { return doc.WriteToWithOptions(w, writeOpts) }
+	if *encrypt {
+		write = func(w io.Writer) error { return doc.WriteToEncrypted(w, passphrase) }
+	}
+	if *asJSONL {
+		write = doc.WriteJSONL
+	}
+	parts := []*silo.SiloDocument{doc}
+	var partNames []string
+	switch {
+	case splitSize > 0:
+		if parts, err = doc.Split(int64(splitSize), writeOpts); err == nil {
+			partNames, err = writeParts(parts, *outputFile, writeOpts, *quiet)
+		}
+	case *splitTokens > 0:
+		parts = doc.SplitByTokensWithOptions(*splitTokens, silo.StatsOptions{Tokenizer: estimator})
+		partNames, err = writeParts(parts, *outputFile, writeOpts, *quiet)
+	case *outputFile == "":
+		err = write(os.Stdout)
+	case *ifChanged:
+		err = writeIfChanged(*outputFile, write)
+	default:
+		err = writeAtomic(*outputFile, write)
+	}
+	
+	if err != nil {
+		var limitErr *silo.LimitError
+		if errors.As(err, &limitErr) && limitErr.Limit == "Split" {
+			fatal(limitErr, "Error: %s alone is larger than -split-size %s", limitErr.Path, splitSize.String())
+		}
+		fatal(err, "Error writing silo file: %v", err)
+	}
+	
+	if *reportFile != "" {
+		if err := writePackReport(*reportFile, newPackReport(patterns, doc, estimator, skipped)); err != nil {
+			fatal(err, "Error writing report: %v", err)
+		}
+	}
+	if *manifestFile != "" {
+		if err := writeManifest(*manifestFile, newManifest(*outputFile, parts, partNames)); err != nil {
+			fatal(err, "Error writing manifest: %v", err)
+		}
+	}
+	if shared.verbose && !*quiet {
+		printPackSummary(doc, skipped)
+	}
+	logger.Info("packed", "files", len(doc.Files), "skipped", len(skipped), "output", *outputFile, "delimiter", doc.Delimiter)
+}
+
+// stringList collects the values of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// byteSize is a flag value holding a size in bytes, given either as a plain
+// number or with a KB, MB or GB suffix (powers of 1024).
+type byteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+func (s *byteSize) String() string {
+	for _, unit := range byteSizeUnits {
+		if int64(*s) >= unit.size && int64(*s)%unit.size == 0 {
+			return strconv.FormatInt(int64(*s)/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *byteSize) Set(value string) error {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 || n*float64(multiplier) > math.MaxInt64 {
+		return fmt.Errorf("invalid size %q (want e.g. 500KB, 1MB or 2GB)", value)
+	}
+	*s = byteSize(n * float64(multiplier))
+	return nil
+}
+
+// readArchive parses the silo file at path.
+func readArchive(path string) (*silo.SiloDocument, error) {
+	return readArchiveWithOptions(path, silo.ParseOptions{})
+}
+
+// exactParseOptions and exactWriteOptions keep every content byte, carriage
+// returns and missing final newlines included, so that rewriting an archive
+// in place leaves the entries a command does not touch as they were.
+var (
+	exactParseOptions = silo.ParseOptions{LineEndings: silo.LineEndingsPreserve}
+	exactWriteOptions = silo.WriteOptions{LineEndings: silo.LineEndingsPreserve, MarkMissingNewline: true}
+)
+
+// readArchiveWithOptions parses the silo file at path with opts. JSON Lines
+// files ignore opts.
+func readArchiveWithOptions(path string, opts silo.ParseOptions) (*silo.SiloDocument, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	if strings.HasSuffix(path, ".jsonl") {
+		return silo.ParseJSONL(file)
+	}
+	return silo.ParseSiloFileWithOptions(file, opts)
+}
+
+// writeParts writes the parts of a split archive named after output:
+// part.silo, or part, becomes part.001.silo, part.002.silo and so on. It
+// returns the names written.
+func writeParts(parts []*silo.SiloDocument, output string, opts silo.WriteOptions, quiet bool) ([]string, error) {
+	base := strings.TrimSuffix(output, ".silo")
+	partName := func(i int) string {
+		return fmt.Sprintf("%s.%03d.silo", base, i+1)
+	}
+	var names []string
+	for i, part := range parts {
+		part := part
+		if err := writeAtomic(partName(i), func(w io.Writer) error { return part.WriteToWithOptions(w, opts) }); err != nil {
+			return names, err
+		}
+		names = append(names, partName(i))
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Wrote %s (%d files)\n", partName(i), len(part.Files))
+		}
+	}
+	// Unpacking part.*.silo would pick up parts left from an earlier,
+	// longer split.
+	if _, err := os.Stat(partName(len(parts))); err == nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s and later parts are left from an earlier split; remove them before unpacking %s.*.silo\n", partName(len(parts)), base)
+	}
+	return names, nil
+}
+
+// writeArchive writes doc to path atomically: it is written to a temporary
+// file in the same directory and renamed over path only once complete, so an
+// existing archive is never left half-written. An existing file's permissions
+// are kept. Content is written with exactWriteOptions, for documents read
+// with exactParseOptions, and as JSON Lines when path ends in .jsonl.
+func writeArchive(path string, doc *silo.SiloDocument) error {
+	if strings.HasSuffix(path, ".jsonl") {
+		return writeAtomic(path, doc.WriteJSONL)
+	}
+	return writeAtomic(path, func(w io.Writer) error { return doc.WriteToWithOptions(w, exactWriteOptions) })
+}
+
+// writeAtomic writes path through a temporary file as described for
+// writeArchive, with write producing the content.
+func writeAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeIfChanged is writeAtomic, except that path is left untouched, with
+// its modification time, when it already holds exactly what write produces.
+func writeIfChanged(path string, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, buf.Bytes()) {
+		return nil
+	}
+	return writeAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// readPassphrase returns the passphrase stored in file, without its trailing
+// newline, or the SILO_PASSPHRASE environment variable if file is empty.
+func readPassphrase(file string) (string, error) {
+	if file == "" {
+		if passphrase := os.Getenv("SILO_PASSPHRASE"); passphrase != "" {
+			return passphrase, nil
+		}
+		return "", errors.New("no passphrase: use -passphrase-file or set SILO_PASSPHRASE")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase file %s is empty", file)
+	}
+	return passphrase, nil
+}
+
+// readFileList reads newline- or NUL-separated paths from name, where "-"
+// means stdin. Blank entries are skipped.
+func readFileList(name string, nullSeparated bool) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
-			os.Exit(1)
+			return nil, err
 		}
 		defer file.Close()
-		
-		err = doc.WriteTo(file)
+		r = file
 	}
 	
+	data, err := io.ReadAll(r)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing silo file: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	
+	separator := "\n"
+	if nullSeparated {
+		separator = "\x00"
+	}
+	
+	var paths []string
+	for _, entry := range strings.Split(string(data), separator) {
+		entry = strings.TrimSuffix(entry, "\r")
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		paths = append(paths, filepath.ToSlash(filepath.Clean(entry)))
+	}
+	return paths, nil
+}
+
+// splitStdinArg returns pack's patterns without "-", which stands for a file
+// list read from stdin as with -files-from -. It sets *filesFrom to "-" when
+// "-" is given.
+func splitStdinArg(args []string, filesFrom *string) ([]string, error) {
+	patterns := []string{}
+	for _, arg := range args {
+		if arg != "-" {
+			patterns = append(patterns, arg)
+			continue
+		}
+		if *filesFrom != "" && *filesFrom != "-" {
+			return nil, fmt.Errorf("- cannot be combined with -files-from %s", *filesFrom)
+		}
+		*filesFrom = "-"
 	}
+	return patterns, nil
 }
 
-func unpackCmd() {
-	unpackFlags := flag.NewFlagSet("unpack", flag.ExitOnError)
+// addStdinEntry adds what r holds to doc as an entry at path, keeping the
+// entries sorted by path as a directory read leaves them.
+func addStdinEntry(doc *silo.SiloDocument, path string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	if err := doc.AppendFrom(&silo.SiloDocument{Files: []silo.SiloFile{{Path: path, Content: string(content)}}}); err != nil {
+		return err
+	}
+	sort.Slice(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+	return nil
+}
+
+// gitTrackedFiles lists the files in git's index under dir. Submodules and
+// entries missing from the working tree are skipped, since there is no file
+// content to pack for them.
+func gitTrackedFiles(dir string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "-z")
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files failed: %w", err)
+	}
+	
+	var paths []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry == "" {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(dir, entry))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		paths = append(paths, entry)
+	}
+	return paths, nil
+}
+
+// describeError formats err, calling out when it was caused by -timeout.
+func describeError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("timed out (%v)", err)
+	}
+	return err.Error()
+}
+
+func printDelimiterAnalysis(w io.Writer, analysis *silo.DelimiterAnalysis) {
+	fmt.Fprintf(w, "Using delimiter %q\n", analysis.Chosen)
+	for _, rejected := range analysis.Rejected {
+		fmt.Fprintf(w, "  rejected %q: conflicts with %s line %d\n", rejected.Delimiter, rejected.Path, rejected.Line)
+	}
+}
+
+// describeConflicts lists up to max delimiter conflicts, one indented
+// "path:line: text" per line.
+func describeConflicts(conflicts []silo.DelimiterConflict, max int) string {
+	var lines []string
+	for i, conflict := range conflicts {
+		if i == max {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(conflicts)-max))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %s:%d: %s", conflict.Path, conflict.Line, conflict.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func unpackCmd(ctx context.Context, args []string) {
+	unpackFlags := flag.NewFlagSet("unpack", flag.ContinueOnError)
 	outputDir := unpackFlags.String("o", ".", "Output directory")
+	fileMode := unpackFlags.String("file-mode", "", "Permissions for written files, in octal (default 0644)")
+	dirMode := unpackFlags.String("dir-mode", "", "Permissions for created directories, in octal (default 0755)")
+	honorUmask := unpackFlags.Bool("umask", false, "Let the process umask narrow -file-mode and -dir-mode")
+	windowsPaths := unpackFlags.String("windows-paths", "auto", "Paths illegal on Windows (CON, aux.txt, a:b): auto, allow, error or rename")
+	caseCollisions := unpackFlags.String("case-collisions", "auto", "Paths differing only in case (README.md, Readme.md): auto, allow, error, rename or last-wins")
+	maxSize := unpackFlags.Int64("max-size", 256<<20, "Largest archive, in bytes, to download when unpacking from a URL")
+	toStdout := unpackFlags.Bool("stdout", false, "Write file contents to stdout instead of a directory")
+	toTar := unpackFlags.String("to-tar", "", "Write the files to this tar `archive` instead of a directory (.tar.gz or .tgz is gzipped; - for stdout)")
+	toZip := unpackFlags.String("to-zip", "", "Write the files to this zip `archive` instead of a directory (- for stdout)")
+	passphraseFile := unpackFlags.String("passphrase-file", "", "File holding the passphrase for an encrypted archive (default: $SILO_PASSPHRASE)")
+	verifyKey := unpackFlags.String("verify-key", "", "Refuse to unpack unless the archive is signed by this ed25519 public key (PEM)")
+	lineEndings := unpackFlags.String("line-endings", "auto", "Line endings of written files: auto (restore CRLF files), preserve, lf or crlf")
+	refsDir := unpackFlags.String("refs", "", "Directory that @file: and @sha256: references are resolved against (default: the archive's directory)")
+	interactive := unpackFlags.Bool("i", false, "Choose which files to extract from a checklist before writing anything")
+	parallelism := unpackFlags.Int("j", 0, "Number of files to write in parallel (default: number of CPUs)")
+	rejectNames := unpackFlags.String("reject-names", "control,length", "Special file names to refuse: device, dash, control, length, all or none")
+	sanitizePaths := unpackFlags.Bool("sanitize-paths", false, "Rewrite names refused by -reject-names instead of failing (control characters become _, -x becomes _-x)")
+	stripComponents := unpackFlags.Int("strip-components", 0, "Remove this many leading directories from each path, as tar does; shorter paths are not unpacked")
+	prefix := unpackFlags.String("prefix", "", "Unpack every path under this `directory` within -o")
+	var renames stringList
+	unpackFlags.Var(&renames, "rename", "Rewrite paths with a sed-style `s/regexp/replacement/[g]`; paths rewritten to nothing are not unpacked (repeatable)")
 	
 	unpackFlags.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: silo unpack [options] <silo-file>\n")
-		fmt.Fprintf(os.Stderr, "Unpack a silo file into a directory tree\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: silo unpack [options] <silo-file|url> [silo-file|url ...]\n")
+		fmt.Fprintf(os.Stderr, "       silo unpack -stdout <silo-file|url> [path ...]\n")
+		fmt.Fprintf(os.Stderr, "Unpack a silo file into a directory tree, or print its files\n")
+		fmt.Fprintf(os.Stderr, "Several files, such as the parts written by pack -split-size, are unpacked together\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		unpackFlags.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nWith -stdout, a single selected path is printed as-is; otherwise each file\n")
+		fmt.Fprintf(os.Stderr, "is preceded by a \"==> path <==\" line.\n")
+		fmt.Fprintf(os.Stderr, "\nWith -i, new files start selected and files that would be overwritten do not.\n")
+		fmt.Fprintf(os.Stderr, "\n-rename rules apply in order, after -strip-components and -prefix. Replacements\n")
+		fmt.Fprintf(os.Stderr, "refer to groups as $1, e.g. -rename 's|^lib/(.*)\\.js$|src/$1.ts|'.\n")
 	}
 	
-	unpackFlags.Parse(os.Args[2:])
+	ctx = parseFlags(ctx, unpackFlags, args)
 	
-	if unpackFlags.NArg() != 1 {
+	if unpackFlags.NArg() < 1 {
 		unpackFlags.Usage()
 		os.Exit(1)
 	}
+	if *interactive && *toStdout {
+		fatal(nil, "Error: -i cannot be used with -stdout")
+	}
+	switch {
+	case *toTar != "" && *toZip != "":
+		fatal(nil, "Error: -to-tar cannot be used with -to-zip")
+	case (*toTar != "" || *toZip != "") && (*toStdout || *interactive):
+		fatal(nil, "Error: -to-tar and -to-zip cannot be used with -stdout or -i")
+	}
 	
-	siloFile := unpackFlags.Arg(0)
+	windowsPolicy, err := silo.ParseWindowsPathPolicy(*windowsPaths)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
 	
-	file, err := os.Open(siloFile)
+	casePolicy, err := silo.ParseCaseCollisionPolicy(*caseCollisions)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening silo file: %v\n", err)
-		os.Exit(1)
+		fatal(err, "Error: %v", err)
+	}
+	nameChecks, err := silo.ParseNameChecks(*rejectNames)
+	if err != nil {
+		fatal(err, "Error: %v", err)
 	}
-	defer file.Close()
 	
-	doc, err := silo.ParseSiloFile(file)
+	lineEndingPolicy, err := silo.ParseLineEndingPolicy(*lineEndings)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing silo file: %v\n", err)
-		os.Exit(1)
+		fatal(err, "Error: %v", err)
 	}
+	parseOpts := silo.ParseOptions{LineEndings: lineEndingPolicy}
 	
-	if err := doc.WriteToDirectory(*outputDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to directory: %v\n", err)
-		os.Exit(1)
+	unpackOpts := silo.UnpackOptions{WindowsPaths: windowsPolicy, CaseCollisions: casePolicy, HonorUmask: *honorUmask, LineEndings: lineEndingPolicy, Logger: logger, Parallelism: *parallelism, NameChecks: nameChecks, SanitizePaths: *sanitizePaths}
+	if unpackOpts.FileMode, err = parseFileMode(*fileMode); err != nil {
+		fatal(err, "Error: invalid -file-mode: %v", err)
 	}
+	if unpackOpts.DirMode, err = parseFileMode(*dirMode); err != nil {
+		fatal(err, "Error: invalid -dir-mode: %v", err)
+	}
+	var renameRules []renameRule
+	for _, expr := range renames {
+		rule, err := parseRenameRule(expr)
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		renameRules = append(renameRules, rule)
+	}
+	
+	// With -stdout, arguments after the archive select paths; otherwise
+	// every argument is an archive to unpack.
+	archives := unpackFlags.Args()
+	if *toStdout {
+		archives = archives[:1]
+	}
+	siloFile := archives[0]
 	
-	fmt.Printf("Successfully unpacked %d files to %s\n", len(doc.Files), *outputDir)
+	load := func(siloFile string) *silo.SiloDocument {
+		if *verifyKey != "" {
+			if silo.IsURL(siloFile) {
+				fatal(nil, "Error: -verify-key is not supported for URLs; download the archive first")
+			}
+			doc, err := readVerifiedArchive(siloFile, *verifyKey)
+			if err != nil {
+				fatal(err, "Error verifying silo file: %v", err)
+			}
+			return doc
+		}
+		if silo.IsURL(siloFile) {
+			urlOpts := parseOpts
+			urlOpts.MaxTotalSize = *maxSize
+			doc, err := silo.ParseSiloURL(ctx, siloFile, urlOpts)
+			if err != nil {
+				fatal(err, "Error fetching silo file: %s", describeError(err))
+			}
+			return doc
+		}
+		
+		file, err := os.Open(siloFile)
+		if err != nil {
+			fatal(err, "Error opening silo file: %v", err)
+		}
+		defer file.Close()
+		
+		var doc *silo.SiloDocument
+		if strings.HasSuffix(siloFile, ".jsonl") {
+			doc, err = silo.ParseJSONL(file)
+		} else {
+			doc, err = silo.ParseSiloFileWithOptions(file, parseOpts)
+		}
+		if errors.Is(err, silo.ErrEncrypted) {
+			doc, err = parseEncryptedArchive(file, *passphraseFile)
+		}
+		if err != nil {
+			fatal(err, "Error parsing silo file: %v", err)
+		}
+		return doc
+	}
+	
+	doc := load(siloFile)
+	for _, warning := range doc.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	for _, extra := range archives[1:] {
+		part := load(extra)
+		for _, warning := range part.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", extra, warning)
+		}
+		if err := doc.AppendFrom(part); err != nil {
+			fatal(err, "Error merging %s: %v", extra, err)
+		}
+	}
+	
+	if err := repath(doc, *stripComponents, *prefix); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	if err := renamePaths(doc, renameRules); err != nil {
+		fatal(err, "Error: -rename: %v", err)
+	}
+	
+	if *refsDir != "" {
+		unpackOpts.ResolveRef = reportRefs(silo.DirRefResolver(*refsDir), *refsDir, os.Stderr)
+	} else if !silo.IsURL(siloFile) {
+		unpackOpts.ResolveRef = reportRefs(silo.DirRefResolver(filepath.Dir(siloFile)), filepath.Dir(siloFile), os.Stderr)
+	}
+	
+	if *toStdout {
+		if unpackOpts.ResolveRef != nil {
+			if err := doc.ResolveRefs(unpackOpts.ResolveRef); err != nil {
+				fatal(err, "Error: %v", err)
+			}
+		}
+		if err := writeFilesToStdout(doc, unpackFlags.Args()[1:]); err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		return
+	}
+	
+	if *toTar != "" || *toZip != "" {
+		output, err := writeUnpackArchive(doc, *toTar, *toZip, unpackOpts)
+		if err != nil {
+			fatal(err, "Error writing %s: %s", output, describeError(err))
+		}
+		logger.Info("unpacked", "files", len(doc.Files), "archive", output)
+		if !quietMode && output != "-" {
+			fmt.Fprintf(os.Stderr, "Successfully unpacked %d files to %s\n", len(doc.Files), output)
+		}
+		return
+	}
+	
+	if *interactive {
+		files, err := pickFiles(os.Stdin, os.Stderr, doc, *outputDir)
+		if err != nil {
+			fatal(err, "Error reading selection: %v", err)
+		}
+		if len(files) == 0 {
+			if !quietMode {
+				fmt.Printf("Nothing unpacked\n")
+			}
+			return
+		}
+		doc.Files = files
+	}
+	
+	if err := doc.WriteToDirectoryContext(ctx, *outputDir, unpackOpts); err != nil {
+		fatal(err, "Error writing to directory: %s", describeError(err))
+	}
+	
+	logger.Info("unpacked", "files", len(doc.Files), "dir", *outputDir)
+	if !quietMode {
+		fmt.Printf("Successfully unpacked %d files to %s\n", len(doc.Files), *outputDir)
+	}
+}
+
+// tooManyFiles stops pack when more files match than -max-files allows.
+// count is how many matched, or 0 when the walk stopped without counting
+// them all.
+func tooManyFiles(count, maxFiles int) {
+	matched := fmt.Sprintf("more than %d", maxFiles)
+	if count > 0 {
+		matched = fmt.Sprintf("%d", count)
+	}
+	fatal(&silo.LimitError{Limit: "MaxFiles", Max: int64(maxFiles)},
+		"Error: %s files to pack, over -max-files %d; check the paths, narrow them with -exclude, or rerun with -yes or -no-limit", matched, maxFiles)
+}
+
+// isTarOrZip reports whether name looks like a tar or zip archive whose
+// members pack reads in place of the file.
+func isTarOrZip(name string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// readTarOrZip reads the members of the tar or zip archive at path, with
+// the filters and limits in opts applied to them as to a directory.
+func readTarOrZip(path string, opts silo.ReadDirectoryTreeOptions) (*silo.SiloDocument, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if !strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return silo.ReadTarWithOptions(file, opts)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return silo.ReadZipWithOptions(file, info.Size(), opts)
+}
+
+// writeUnpackArchive writes doc as the tar archive toTar or, if that is
+// empty, the zip archive toZip, either of which may be "-" for stdout. It
+// returns the name written.
+func writeUnpackArchive(doc *silo.SiloDocument, toTar, toZip string, opts silo.UnpackOptions) (string, error) {
+	output := toTar
+	write := func(w io.Writer) error { return doc.WriteTar(w, opts) }
+	if toZip != "" {
+		output = toZip
+		write = func(w io.Writer) error { return doc.WriteZip(w, opts) }
+	} else if strings.HasSuffix(toTar, ".tar.gz") || strings.HasSuffix(toTar, ".tgz") {
+		write = func(w io.Writer) error {
+			zw := gzip.NewWriter(w)
+			if err := doc.WriteTar(zw, opts); err != nil {
+				return err
+			}
+			return zw.Close()
+		}
+	}
+	if output == "-" {
+		buffered := bufio.NewWriter(os.Stdout)
+		if err := write(buffered); err != nil {
+			return output, err
+		}
+		return output, buffered.Flush()
+	}
+	return output, writeAtomic(output, write)
+}
+
+// readVerifiedArchive parses the archive at path only if it carries a valid
+// signature from the public key in keyFile.
+func readVerifiedArchive(path, keyFile string) (*silo.SiloDocument, error) {
+	publicKey, err := loadPublicKey(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading key: %w", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return silo.VerifySignature(file, publicKey)
+}
+
+// parseEncryptedArchive rereads file from the start as an encrypted archive.
+func parseEncryptedArchive(file *os.File, passphraseFile string) (*silo.SiloDocument, error) {
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("archive is encrypted: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return silo.ParseEncryptedSiloFile(file, passphrase)
+}
+
+// parseFileMode parses an octal permission string such as "0600". An empty
+// string yields zero, meaning the library default.
+func parseFileMode(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%q is not an octal permission like 0600", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// reportRefs wraps resolve to say on w which files references read from
+// dir, since they come from outside the archive and an archive from
+// elsewhere could otherwise copy files from beside it unnoticed. -q keeps
+// it quiet.
+func reportRefs(resolve silo.RefResolver, dir string, w io.Writer) silo.RefResolver {
+	var mu sync.Mutex
+	return func(ref string) ([]byte, error) {
+		data, err := resolve(ref)
+		if err == nil && !quietMode {
+			mu.Lock()
+			fmt.Fprintf(w, "Read @%s from %s\n", ref, dir)
+			mu.Unlock()
+		}
+		return data, err
+	}
+}
+
+// writeFilesToStdout prints the selected entries, or all entries when paths
+// is empty. A single selected file is printed verbatim so it can be piped;
+// otherwise each file is preceded by a "==> path <==" boundary line.
+func writeFilesToStdout(doc *silo.SiloDocument, paths []string) error {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	
+	if len(paths) == 1 {
+		content, err := doc.ReadFile(paths[0])
+		if err != nil {
+			return err
+		}
+		_, err = out.WriteString(content)
+		return err
+	}
+	
+	if len(paths) == 0 {
+		for _, file := range doc.Files {
+			paths = append(paths, file.Path)
+		}
+	}
+	
+	for i, path := range paths {
+		content, err := doc.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(out, "==> %s <==\n%s", path, content)
+	}
+	return nil
 }
 
 func printUsage() {
 	fmt.Fprintf(os.Stderr, "silo - A tool for packing/unpacking directory trees and files\n\n")
 	fmt.Fprintf(os.Stderr, "Usage:\n")
-	fmt.Fprintf(os.Stderr, "  silo pack [options] <pattern1 pattern2 ...>    Pack files into silo file\n")
-	fmt.Fprintf(os.Stderr, "  silo unpack [options] <file>                   Unpack silo file into directory\n")
-	fmt.Fprintf(os.Stderr, "  silo help                                       Show this help message\n\n")
+	printCommands()
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Shared options (before the command, or among its options):\n")
+	fmt.Fprintf(os.Stderr, "  -timeout duration                               Abort the command after this long (e.g. 30s, 5m)\n")
+	fmt.Fprintf(os.Stderr, "  -v                                              Log each file as it is packed or unpacked\n")
+	fmt.Fprintf(os.Stderr, "  -q                                              Print nothing but errors\n")
+	fmt.Fprintf(os.Stderr, "  -json-log                                       Log events as JSON lines on stderr\n")
+	fmt.Fprintf(os.Stderr, "  -json-errors                                    Report errors as JSON objects on stderr\n\n")
+	fmt.Fprintf(os.Stderr, "Exit status: 0 success, 1 error, 2 pattern, 3 parse, 4 write conflict, 5 security\n\n")
 	fmt.Fprintf(os.Stderr, "Examples:\n")
 	fmt.Fprintf(os.Stderr, "  silo pack -o project.silo src/                  Pack 'src' directory (auto-detect delimiter)\n")
 	fmt.Fprintf(os.Stderr, "  silo pack \"*.go\" \"*.md\"                         Pack multiple patterns with auto-detected delimiter\n")
@@ -187,3 +1410,19 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "  silo unpack project.silo                        Unpack to current directory\n")
 	fmt.Fprintf(os.Stderr, "  silo unpack project.silo -o out/                Unpack to 'out' directory\n")
 }
+
+// repath applies -strip-components and then -prefix to every path in doc.
+func repath(doc *silo.SiloDocument, stripComponents int, prefix string) error {
+	if stripComponents < 0 {
+		return fmt.Errorf("-strip-components must not be negative")
+	}
+	if err := doc.StripComponents(stripComponents); err != nil {
+		return fmt.Errorf("-strip-components: %w", err)
+	}
+	if prefix != "" {
+		if err := doc.AddPrefix(prefix); err != nil {
+			return fmt.Errorf("-prefix: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestRewriteArchiveKeepsExactContent(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "a.silo")
+	input := "> mixed.txt\na\r\nb\n> version.txt\n1.2.3\n\\ No newline at end of file\n> gone.txt\nx\n"
+	if err := os.WriteFile(archive, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := readArchiveWithOptions(archive, exactParseOptions)
+	if err != nil {
+		t.Fatalf("readArchiveWithOptions failed: %v", err)
+	}
+	if err := doc.Remove("gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeArchive(archive, doc); err != nil {
+		t.Fatalf("writeArchive failed: %v", err)
+	}
+
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "> mixed.txt\na\r\nb\n> version.txt\n1.2.3\n\\ No newline at end of file\n"; string(data) != want {
+		t.Errorf("Expected untouched entries to be kept exactly:\n%q\ngot\n%q", want, data)
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		null bool
+		want []string
+	}{
+		{"lines", "a.go\r\n\n  \nsrc/./b.go\nc d.txt\n", false, []string{"a.go", "src/b.go", "c d.txt"}},
+		{"nul", "a.go\x00src/b.go\x00\x00with\nnewline.txt\x00", true, []string{"a.go", "src/b.go", "with\nnewline.txt"}},
+	}
+	for _, test := range tests {
+		list := filepath.Join(dir, test.name)
+		if err := os.WriteFile(list, []byte(test.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readFileList(list, test.null)
+		if err != nil {
+			t.Fatalf("%s: readFileList failed: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+
+	// "-" reads standard input.
+	stdin, err := os.Open(filepath.Join(dir, "lines"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	saved := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = saved }()
+	if got, err := readFileList("-", false); err != nil || len(got) != 3 {
+		t.Errorf("Expected 3 paths from stdin, got %q, %v", got, err)
+	}
+
+	if _, err := readFileList(filepath.Join(dir, "missing"), false); err == nil {
+		t.Error("Expected an error for a missing list")
+	}
+}
+
+// newGitRepo creates a git repository holding files, added to the index and
+// committed, or skips the test when git is not installed.
+func newGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+// runGit runs git in dir and returns its output, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func TestGitTrackedFiles(t *testing.T) {
+	dir := newGitRepo(t, map[string]string{
+		"a.go":        "package a\n",
+		"src/b.go":    "package src\n",
+		"src/gone.go": "package src\n",
+	})
+	if err := os.Remove(filepath.Join(dir, "src", "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := gitTrackedFiles(dir)
+	if err != nil {
+		t.Fatalf("gitTrackedFiles failed: %v", err)
+	}
+	if want := []string{"a.go", "src/b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if _, err := gitTrackedFiles(t.TempDir()); err == nil {
+		t.Error("Expected an error outside a repository")
+	}
+}
+
+func TestSplitStdinArg(t *testing.T) {
+	filesFrom := ""
+	patterns, err := splitStdinArg([]string{"src", "-", "docs"}, &filesFrom)
+	if err != nil || filesFrom != "-" || !reflect.DeepEqual(patterns, []string{"src", "docs"}) {
+		t.Errorf("Got %q, -files-from %q, %v", patterns, filesFrom, err)
+	}
+
+	filesFrom = "list.txt"
+	if _, err := splitStdinArg([]string{"-"}, &filesFrom); err == nil {
+		t.Error("Expected - to conflict with -files-from list.txt")
+	}
+	if patterns, err := splitStdinArg([]string{"src"}, &filesFrom); err != nil || filesFrom != "list.txt" || len(patterns) != 1 {
+		t.Errorf("Expected patterns without - to be left alone, got %q, %q, %v", patterns, filesFrom, err)
+	}
+}
+
+func TestAddStdinEntry(t *testing.T) {
+	doc := &silo.SiloDocument{Files: []silo.SiloFile{{Path: "a.txt", Content: "a\n"}, {Path: "z.txt", Content: "z\n"}}}
+	if err := addStdinEntry(doc, "m/plan.txt", strings.NewReader("step 1\n")); err != nil {
+		t.Fatalf("addStdinEntry failed: %v", err)
+	}
+	var paths []string
+	for _, file := range doc.Files {
+		paths = append(paths, file.Path)
+	}
+	if want := []string{"a.txt", "m/plan.txt", "z.txt"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %q, got %q", want, paths)
+	}
+	if doc.Files[1].Content != "step 1\n" {
+		t.Errorf("Unexpected content %q", doc.Files[1].Content)
+	}
+	if err := addStdinEntry(doc, "a.txt", strings.NewReader("again\n")); err == nil {
+		t.Error("Expected an error for a path already packed")
+	}
+}
+
+func TestReportRefs(t *testing.T) {
+	resolve := func(ref string) ([]byte, error) {
+		if ref == "file:missing" {
+			return nil, os.ErrNotExist
+		}
+		return []byte("x"), nil
+	}
+	var out strings.Builder
+	report := reportRefs(resolve, "blobs", &out)
+	if data, err := report("file:a.bin"); err != nil || string(data) != "x" {
+		t.Fatalf("Expected the resolved content, got %q, %v", data, err)
+	}
+	if _, err := report("file:missing"); err == nil {
+		t.Error("Expected the resolver's error")
+	}
+	if want := "Read @file:a.bin from blobs\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
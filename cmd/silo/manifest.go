@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"github.com/escherize/go-silo"
+)
+
+// manifest is the sidecar index written by pack -manifest, so other tools
+// can see what an archive holds without parsing the silo format.
+type manifest struct {
+	Archive    string         `json:"archive,omitempty"`
+	Parts      []string       `json:"parts,omitempty"`
+	Delimiter  string         `json:"delimiter"`
+	Files      []manifestFile `json:"files"`
+	TotalFiles int            `json:"total_files"`
+	TotalBytes int            `json:"total_bytes"`
+}
+
+// manifestFile describes one entry. SHA256 is the hex digest of its
+// content, and is left out for links and references, which have none.
+type manifestFile struct {
+	Path       string `json:"path"`
+	Bytes      int    `json:"bytes"`
+	SHA256     string `json:"sha256,omitempty"`
+	LinkTarget string `json:"link_target,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	Base64     bool   `json:"base64,omitempty"`
+	Part       string `json:"part,omitempty"`
+}
+
+// newManifest indexes the archive written to archive ("" for stdout) as
+// parts, each written to the matching partNames entry. An archive that was
+// not split is a single part with no name.
+func newManifest(archive string, parts []*silo.SiloDocument, partNames []string) *manifest {
+	m := &manifest{Archive: archive, Parts: partNames, Files: []manifestFile{}}
+	for i, part := range parts {
+		m.Delimiter = part.Delimiter
+		for _, file := range part.Files {
+			entry := manifestFile{
+				Path:       file.Path,
+				Bytes:      file.Size(),
+				LinkTarget: file.LinkTarget,
+				Ref:        file.Ref,
+				Base64:     file.Base64,
+			}
+			if file.LinkTarget == "" && file.Ref == "" {
+				sum := sha256.Sum256(file.Bytes())
+				entry.SHA256 = hex.EncodeToString(sum[:])
+			}
+			if partNames != nil {
+				entry.Part = partNames[i]
+			}
+			m.Files = append(m.Files, entry)
+			m.TotalBytes += entry.Bytes
+		}
+	}
+	m.TotalFiles = len(m.Files)
+	return m
+}
+
+func writeManifest(path string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
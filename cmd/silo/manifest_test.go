@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestNewManifest(t *testing.T) {
+	doc := &silo.SiloDocument{Delimiter: ">", Files: []silo.SiloFile{
+		{Path: "a.txt", Content: "hello\n"},
+		{Path: "current", LinkTarget: "a.txt"},
+		{Path: "big.bin", Ref: "file:blobs/big.bin"},
+	}}
+	m := newManifest("out.silo", []*silo.SiloDocument{doc}, nil)
+	want := &manifest{
+		Archive:   "out.silo",
+		Delimiter: ">",
+		Files: []manifestFile{
+			// sha256 of "hello\n".
+			{Path: "a.txt", Bytes: 6, SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+			{Path: "current", LinkTarget: "a.txt"},
+			{Path: "big.bin", Ref: "file:blobs/big.bin"},
+		},
+		TotalFiles: 3,
+		TotalBytes: 6,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Expected\n%+v\ngot\n%+v", want, m)
+	}
+}
+
+func TestNewManifestParts(t *testing.T) {
+	parts := []*silo.SiloDocument{
+		{Delimiter: ">", Files: []silo.SiloFile{{Path: "a.txt", Content: "a\n"}}},
+		{Delimiter: ">", Files: []silo.SiloFile{{Path: "b.txt", Content: "bb\n"}}},
+	}
+	m := newManifest("out.silo", parts, []string{"out.001.silo", "out.002.silo"})
+	if m.TotalFiles != 2 || m.TotalBytes != 5 || m.Files[0].Part != "out.001.silo" || m.Files[1].Part != "out.002.silo" {
+		t.Errorf("Unexpected manifest for parts: %+v", m)
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := writeManifest(path, m); err != nil {
+		t.Fatalf("writeManifest failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read manifest
+	if err := json.Unmarshal(data, &read); err != nil {
+		t.Fatalf("Manifest is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(&read, m) {
+		t.Errorf("Manifest did not survive JSON: %+v", read)
+	}
+}
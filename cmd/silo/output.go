@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// outputFormat is how list and stats print their results: aligned text for
+// people, or CSV, TSV or JSON for spreadsheets, awk and scripts.
+type outputFormat string
+
+const (
+	formatText outputFormat = "text"
+	formatCSV  outputFormat = "csv"
+	formatTSV  outputFormat = "tsv"
+	formatJSON outputFormat = "json"
+)
+
+// parseOutputFormat parses a -format value.
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(s)); format {
+	case formatText, formatCSV, formatTSV, formatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want text, csv, tsv or json)", s)
+}
+
+// writeRecords writes header and rows as CSV or TSV, or v as indented JSON.
+// Text output is left to each command.
+func writeRecords(w io.Writer, format outputFormat, header []string, rows [][]string, v any) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	cw := csv.NewWriter(w)
+	if format == formatTSV {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	return cw.WriteAll(rows)
+}
+
+// listRecord is one entry as silo list -format prints it.
+type listRecord struct {
+	Path string `json:"path"`
+	// Kind is "file", "base64", "link" or "ref".
+	Kind string `json:"kind"`
+	// Target is the link target or reference.
+	Target string `json:"target,omitempty"`
+}
+
+// writeListRecords writes the entries of an archive in format.
+func writeListRecords(w io.Writer, format outputFormat, entries []silo.EntryHeader) error {
+	records := make([]listRecord, 0, len(entries))
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		record := listRecord{Path: entry.Path, Kind: "file"}
+		switch {
+		case entry.LinkTarget != "":
+			record.Kind, record.Target = "link", entry.LinkTarget
+		case entry.Ref != "":
+			record.Kind, record.Target = "ref", entry.Ref
+		case entry.Base64:
+			record.Kind = "base64"
+		}
+		records = append(records, record)
+		rows = append(rows, []string{record.Path, record.Kind, record.Target})
+	}
+	return writeRecords(w, format, []string{"path", "kind", "target"}, rows, records)
+}
+
+// summaryRecord is an archive's summary as silo stats -format prints it.
+type summaryRecord struct {
+	Archive    string            `json:"archive"`
+	Files      int               `json:"files"`
+	Links      int               `json:"links"`
+	Refs       int               `json:"refs"`
+	Binary     int               `json:"binary"`
+	Bytes      int               `json:"bytes"`
+	Lines      int               `json:"lines"`
+	Tokens     int               `json:"tokens"`
+	Largest    []fileRecord      `json:"largest"`
+	Extensions []extensionRecord `json:"extensions"`
+}
+
+type fileRecord struct {
+	Path   string `json:"path"`
+	Bytes  int    `json:"bytes"`
+	Lines  int    `json:"lines"`
+	Tokens int    `json:"tokens"`
+}
+
+type extensionRecord struct {
+	Extension string `json:"extension"`
+	Files     int    `json:"files"`
+	Bytes     int    `json:"bytes"`
+	Lines     int    `json:"lines"`
+}
+
+// summaryHeader names the columns of summaryRecord.row. The largest files
+// and extensions are only in the JSON output.
+var summaryHeader = []string{"archive", "files", "links", "refs", "binary", "bytes", "lines", "tokens"}
+
+func newSummaryRecord(name string, summary silo.Summary) summaryRecord {
+	record := summaryRecord{
+		Archive:    name,
+		Files:      summary.Files,
+		Links:      summary.Links,
+		Refs:       summary.Refs,
+		Binary:     summary.Binary,
+		Bytes:      summary.Bytes,
+		Lines:      summary.Lines,
+		Tokens:     summary.Tokens,
+		Largest:    make([]fileRecord, 0, len(summary.Largest)),
+		Extensions: make([]extensionRecord, 0, len(summary.Extensions)),
+	}
+	for _, file := range summary.Largest {
+		record.Largest = append(record.Largest, fileRecord{file.Path, file.Bytes, file.Lines, file.Tokens})
+	}
+	for _, ext := range summary.Extensions {
+		record.Extensions = append(record.Extensions, extensionRecord{ext.Extension, ext.Files, ext.Bytes, ext.Lines})
+	}
+	return record
+}
+
+func (r summaryRecord) row() []string {
+	return []string{r.Archive, strconv.Itoa(r.Files), strconv.Itoa(r.Links), strconv.Itoa(r.Refs),
+		strconv.Itoa(r.Binary), strconv.Itoa(r.Bytes), strconv.Itoa(r.Lines), strconv.Itoa(r.Tokens)}
+}
+
+// writeSummaryRecords writes an archive's summary in format: one row for
+// CSV and TSV, one object for JSON.
+func writeSummaryRecords(w io.Writer, format outputFormat, name string, summary silo.Summary) error {
+	record := newSummaryRecord(name, summary)
+	return writeRecords(w, format, summaryHeader, [][]string{record.row()}, record)
+}
+
+// fileStatsRecord is silo stats -files as JSON: every file, then totals.
+type fileStatsRecord struct {
+	Files       []fileRecord `json:"files"`
+	Bytes       int          `json:"bytes"`
+	Lines       int          `json:"lines"`
+	Tokens      int          `json:"tokens"`
+	TotalTokens int          `json:"total_tokens"`
+}
+
+// writeFileStatsRecords writes per-file figures in format. CSV and TSV have
+// one row per file and leave the totals to the spreadsheet.
+func writeFileStatsRecords(w io.Writer, format outputFormat, stats silo.DocumentStats) error {
+	record := fileStatsRecord{
+		Files:       make([]fileRecord, 0, len(stats.Files)),
+		Bytes:       stats.Bytes,
+		Lines:       stats.Lines,
+		Tokens:      stats.Tokens,
+		TotalTokens: stats.TotalTokens(),
+	}
+	rows := make([][]string, 0, len(stats.Files))
+	for _, file := range stats.Files {
+		record.Files = append(record.Files, fileRecord{file.Path, file.Bytes, file.Lines, file.Tokens})
+		rows = append(rows, []string{file.Path, strconv.Itoa(file.Bytes), strconv.Itoa(file.Lines), strconv.Itoa(file.Tokens)})
+	}
+	return writeRecords(w, format, []string{"path", "bytes", "lines", "tokens"}, rows, record)
+}
+
+type duplicateRecord struct {
+	Bytes int      `json:"bytes"`
+	Paths []string `json:"paths"`
+}
+
+// writeDuplicateRecords writes groups of identical files in format. CSV and
+// TSV have one row per path, numbering the groups from 1.
+func writeDuplicateRecords(w io.Writer, format outputFormat, groups []silo.DuplicateGroup) error {
+	records := make([]duplicateRecord, 0, len(groups))
+	var rows [][]string
+	for i, group := range groups {
+		records = append(records, duplicateRecord{group.Bytes, group.Paths})
+		for _, path := range group.Paths {
+			rows = append(rows, []string{strconv.Itoa(i + 1), strconv.Itoa(group.Bytes), path})
+		}
+	}
+	return writeRecords(w, format, []string{"group", "bytes", "path"}, rows, records)
+}
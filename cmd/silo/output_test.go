@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, name := range []string{"text", "csv", "TSV", "json"} {
+		if _, err := parseOutputFormat(name); err != nil {
+			t.Errorf("parseOutputFormat(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := parseOutputFormat("xml"); err == nil {
+		t.Error("Expected an error for xml")
+	}
+}
+
+func TestWriteListRecords(t *testing.T) {
+	entries := []silo.EntryHeader{
+		{Path: "a,b.txt"},
+		{Path: "logo.png", Base64: true},
+		{Path: "current", LinkTarget: "a,b.txt"},
+		{Path: "big.bin", Ref: "file:blobs/big.bin"},
+	}
+	tests := []struct {
+		format outputFormat
+		want   string
+	}{
+		{formatCSV, "path,kind,target\n\"a,b.txt\",file,\nlogo.png,base64,\ncurrent,link,\"a,b.txt\"\nbig.bin,ref,file:blobs/big.bin\n"},
+		{formatTSV, "path\tkind\ttarget\na,b.txt\tfile\t\nlogo.png\tbase64\t\ncurrent\tlink\ta,b.txt\nbig.bin\tref\tfile:blobs/big.bin\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := writeListRecords(&buf, test.format, entries); err != nil {
+			t.Fatalf("%s: writeListRecords failed: %v", test.format, err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("%s: expected\n%q\ngot\n%q", test.format, test.want, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeListRecords(&buf, formatJSON, entries); err != nil {
+		t.Fatalf("json: writeListRecords failed: %v", err)
+	}
+	var records []listRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	want := []listRecord{
+		{Path: "a,b.txt", Kind: "file"},
+		{Path: "logo.png", Kind: "base64"},
+		{Path: "current", Kind: "link", Target: "a,b.txt"},
+		{Path: "big.bin", Kind: "ref", Target: "file:blobs/big.bin"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %+v, got %+v", want, records)
+	}
+}
+
+func TestWriteSummaryRecords(t *testing.T) {
+	doc := &silo.SiloDocument{Files: []silo.SiloFile{
+		{Path: "a.go", Content: "package a\n"},
+		{Path: "b.md", Content: "# B\n\ntext\n"},
+	}}
+	summary := doc.Summary()
+
+	var buf bytes.Buffer
+	if err := writeSummaryRecords(&buf, formatCSV, "p.silo", summary); err != nil {
+		t.Fatalf("writeSummaryRecords failed: %v", err)
+	}
+	want := "archive,files,links,refs,binary,bytes,lines,tokens\np.silo,2,0,0,0,20,4," + strconv.Itoa(summary.Tokens) + "\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeSummaryRecords(&buf, formatJSON, "p.silo", summary); err != nil {
+		t.Fatalf("writeSummaryRecords failed: %v", err)
+	}
+	var record summaryRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(record, newSummaryRecord("p.silo", summary)) || len(record.Largest) != 2 || len(record.Extensions) != 2 {
+		t.Errorf("Unexpected summary %+v", record)
+	}
+}
+
+func TestWriteFileStatsRecords(t *testing.T) {
+	doc := &silo.SiloDocument{Files: []silo.SiloFile{{Path: "a.txt", Content: "one\ntwo\n"}}}
+	stats := doc.Stats()
+
+	var buf bytes.Buffer
+	if err := writeFileStatsRecords(&buf, formatTSV, stats); err != nil {
+		t.Fatalf("writeFileStatsRecords failed: %v", err)
+	}
+	if want := "path\tbytes\tlines\ttokens\na.txt\t8\t2\t" + strconv.Itoa(stats.Files[0].Tokens) + "\n"; buf.String() != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeFileStatsRecords(&buf, formatJSON, stats); err != nil {
+		t.Fatalf("writeFileStatsRecords failed: %v", err)
+	}
+	var record fileStatsRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(record.Files) != 1 || record.Bytes != 8 || record.TotalTokens != stats.TotalTokens() {
+		t.Errorf("Unexpected stats %+v", record)
+	}
+}
+
+func TestWriteDuplicateRecords(t *testing.T) {
+	groups := []silo.DuplicateGroup{{Paths: []string{"a", "b"}, Bytes: 3}, {Paths: []string{"c", "d"}, Bytes: 1}}
+	var buf bytes.Buffer
+	if err := writeDuplicateRecords(&buf, formatCSV, groups); err != nil {
+		t.Fatalf("writeDuplicateRecords failed: %v", err)
+	}
+	if want := "group,bytes,path\n1,3,a\n1,3,b\n2,1,c\n2,1,d\n"; buf.String() != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeDuplicateRecords(&buf, formatJSON, nil); err != nil {
+		t.Fatalf("writeDuplicateRecords failed: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", buf.String())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// pickStatus describes how an archive entry relates to what is already in
+// the output directory.
+type pickStatus string
+
+const (
+	pickNew       pickStatus = "new"
+	pickUnchanged pickStatus = "unchanged"
+	pickOverwrite pickStatus = "overwrite"
+)
+
+// pickFiles shows a checklist of the archive's entries on out and lets the
+// user toggle which ones to extract by reading commands from in. New files
+// start selected; files that would overwrite something do not. It returns
+// the selected entries, or nil if the user quit.
+func pickFiles(in io.Reader, out io.Writer, doc *silo.SiloDocument, outputDir string) ([]silo.SiloFile, error) {
+	statuses := make([]pickStatus, len(doc.Files))
+	selected := make([]bool, len(doc.Files))
+	for i, file := range doc.Files {
+		statuses[i] = entryStatus(file, outputDir)
+		selected[i] = statuses[i] == pickNew
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		for i, file := range doc.Files {
+			mark := " "
+			if selected[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "[%s] %3d  %-9s  %s\n", mark, i+1, statuses[i], file.Path)
+		}
+		fmt.Fprintf(out, "Toggle files by number or range (1-3,5), a: all, n: none, Enter: extract selected, q: quit\n> ")
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		switch answer {
+		case "":
+			var files []silo.SiloFile
+			for i, file := range doc.Files {
+				if selected[i] {
+					files = append(files, file)
+				}
+			}
+			return files, nil
+		case "q":
+			return nil, nil
+		case "a", "n":
+			for i := range selected {
+				selected[i] = answer == "a"
+			}
+		default:
+			indexes, err := parseSelection(answer, len(doc.Files))
+			if err != nil {
+				fmt.Fprintf(out, "%v\n", err)
+				continue
+			}
+			for _, i := range indexes {
+				selected[i] = !selected[i]
+			}
+		}
+	}
+}
+
+// entryStatus reports whether writing file under outputDir would create,
+// leave unchanged, or replace what is there.
+func entryStatus(file silo.SiloFile, outputDir string) pickStatus {
+	target := filepath.Join(outputDir, filepath.FromSlash(file.Path))
+	info, err := os.Lstat(target)
+	if err != nil {
+		return pickNew
+	}
+	if file.LinkTarget != "" {
+		if existing, err := os.Readlink(target); err == nil && existing == file.LinkTarget {
+			return pickUnchanged
+		}
+		return pickOverwrite
+	}
+	if file.Ref == "" && info.Mode().IsRegular() && info.Size() == int64(file.Size()) {
+		if existing, err := os.ReadFile(target); err == nil && bytes.Equal(existing, file.Bytes()) {
+			return pickUnchanged
+		}
+	}
+	return pickOverwrite
+}
+
+// parseSelection parses a comma- or space-separated list of 1-based numbers
+// and ranges such as "1-3,5" into 0-based indexes below n.
+func parseSelection(text string, n int) ([]int, error) {
+	var indexes []int
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, field := range fields {
+		first, last, isRange := strings.Cut(field, "-")
+		if !isRange {
+			last = first
+		}
+		lo, err1 := strconv.Atoi(first)
+		hi, err2 := strconv.Atoi(last)
+		if err1 != nil || err2 != nil || lo < 1 || hi > n || lo > hi {
+			return nil, fmt.Errorf("invalid selection %q: want numbers from 1 to %d", field, n)
+		}
+		for i := lo; i <= hi; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	return indexes, nil
+}
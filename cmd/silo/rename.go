@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+// renameRule is one sed-style s/pattern/replacement/ expression given to
+// unpack -rename.
+type renameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+// parseRenameRule parses s/pattern/replacement/flags, where any character
+// may stand in for the slashes and the only flag is g, replacing every
+// match rather than the first. The pattern is a Go regular expression and
+// the replacement may refer to groups as $1 or ${name}.
+func parseRenameRule(expr string) (renameRule, error) {
+	if len(expr) < 2 || expr[0] != 's' {
+		return renameRule{}, fmt.Errorf("invalid rename %q (want s/pattern/replacement/)", expr)
+	}
+	sep := expr[1:2]
+	parts := strings.Split(expr[2:], sep)
+	if len(parts) != 3 {
+		return renameRule{}, fmt.Errorf("invalid rename %q (want s/pattern/replacement/)", expr)
+	}
+	if parts[2] != "" && parts[2] != "g" {
+		return renameRule{}, fmt.Errorf("invalid rename %q: unknown flags %q", expr, parts[2])
+	}
+	pattern, err := regexp.Compile(parts[0])
+	if err != nil {
+		return renameRule{}, fmt.Errorf("invalid rename %q: %w", expr, err)
+	}
+	return renameRule{pattern: pattern, replacement: parts[1], global: parts[2] == "g"}, nil
+}
+
+// apply returns path with the rule's substitution made.
+func (r renameRule) apply(path string) string {
+	if r.global {
+		return r.pattern.ReplaceAllString(path, r.replacement)
+	}
+	match := r.pattern.FindStringSubmatchIndex(path)
+	if match == nil {
+		return path
+	}
+	replaced := r.pattern.ExpandString(nil, r.replacement, path, match)
+	return path[:match[0]] + string(replaced) + path[match[1]:]
+}
+
+// renamePaths applies rules in order to every entry path in doc. Entries
+// whose path becomes empty are dropped.
+func renamePaths(doc *silo.SiloDocument, rules []renameRule) error {
+	files := doc.Files[:0:0]
+	seen := make(map[string]string, len(doc.Files))
+	for _, file := range doc.Files {
+		path := file.Path
+		for _, rule := range rules {
+			path = rule.apply(path)
+		}
+		if path == "" {
+			continue
+		}
+		if err := silo.ValidatePath(path); err != nil {
+			return fmt.Errorf("renaming %s: %w", file.Path, err)
+		}
+		if other, taken := seen[path]; taken {
+			return fmt.Errorf("%w: %s and %s are both renamed to %s", silo.ErrDuplicatePath, other, file.Path, path)
+		}
+		seen[path] = file.Path
+		file.Path = path
+		files = append(files, file)
+	}
+	doc.Files = files
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/escherize/go-silo"
+)
+
+// packReport is the machine-readable record written by pack -report.
+type packReport struct {
+	Patterns        []string         `json:"patterns"`
+	Delimiter       string           `json:"delimiter"`
+	Included        []packReportFile `json:"included"`
+	Skipped         []packReportSkip `json:"skipped"`
+	TotalFiles      int              `json:"total_files"`
+	TotalBytes      int              `json:"total_bytes"`
+	EstimatedTokens int              `json:"estimated_tokens"`
+}
+
+type packReportFile struct {
+	Path            string `json:"path"`
+	Bytes           int    `json:"bytes"`
+	Lines           int    `json:"lines"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+}
+
+type packReportSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func newPackReport(patterns []string, doc *silo.SiloDocument, estimator silo.TokenEstimator, skipped []packReportSkip) *packReport {
+	report := &packReport{
+		Patterns:  patterns,
+		Delimiter: doc.Delimiter,
+		Included:  []packReportFile{},
+		Skipped:   skipped,
+	}
+	if report.Patterns == nil {
+		report.Patterns = []string{}
+	}
+	if report.Skipped == nil {
+		report.Skipped = []packReportSkip{}
+	}
+
+	stats := doc.StatsWithOptions(silo.StatsOptions{Tokenizer: estimator})
+	for _, file := range stats.Files {
+		report.Included = append(report.Included, packReportFile{
+			Path:            file.Path,
+			Bytes:           file.Bytes,
+			Lines:           file.Lines,
+			EstimatedTokens: file.Tokens,
+		})
+	}
+	report.TotalFiles = len(report.Included)
+	report.TotalBytes = stats.Bytes
+	report.EstimatedTokens = stats.Tokens
+
+	return report
+}
+
+func writePackReport(path string, report *packReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// printPackSummary tells the user, on stderr, how many files pack kept and
+// every file it left out with the reason, for -v.
+func printPackSummary(doc *silo.SiloDocument, skipped []packReportSkip) {
+	report := silo.PackReport{}
+	for _, file := range doc.Files {
+		report.Included = append(report.Included, file.Path)
+	}
+	for _, skip := range skipped {
+		report.Skipped = append(report.Skipped, silo.SkipReason{Path: skip.Path, Reason: skip.Reason})
+	}
+
+	fmt.Fprintln(os.Stderr, report.Summary())
+	for _, skip := range report.Skipped {
+		fmt.Fprintf(os.Stderr, "  %s\n", skip)
+	}
+}
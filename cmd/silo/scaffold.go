@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/escherize/go-silo"
+)
+
+//go:embed templates/*.silo
+var templateFS embed.FS
+
+// varFlags collects repeated -var name=value options.
+type varFlags map[string]string
+
+func (v varFlags) String() string { return "" }
+
+func (v varFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	v[name] = val
+	return nil
+}
+
+func scaffoldCmd(ctx context.Context, args []string) {
+	scaffoldFlags := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	vars := varFlags{}
+	scaffoldFlags.Var(vars, "var", "Set a template variable as name=value (repeatable)")
+	force := scaffoldFlags.Bool("f", false, "Unpack into the target directory even if it is not empty")
+	scaffoldFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo scaffold [options] <template> <directory>\n")
+		fmt.Fprintf(os.Stderr, "Create a project from a template archive, replacing {{name}} placeholders\n\n")
+		fmt.Fprintf(os.Stderr, "<template> is a built-in template name, a .silo file, or an http(s) URL.\n")
+		fmt.Fprintf(os.Stderr, "The variables name (directory name) and module (defaults to name) are always set.\n\n")
+		fmt.Fprintf(os.Stderr, "Built-in templates: %s\n\n", strings.Join(builtinTemplates(), ", "))
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		scaffoldFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, scaffoldFlags, args)
+
+	if scaffoldFlags.NArg() != 2 {
+		scaffoldFlags.Usage()
+		os.Exit(1)
+	}
+	template, target := scaffoldFlags.Arg(0), scaffoldFlags.Arg(1)
+
+	doc, err := loadTemplate(ctx, template)
+	if err != nil {
+		fatal(err, "Error loading template %s: %s", template, describeError(err))
+	}
+
+	if _, ok := vars["name"]; !ok {
+		absTarget, err := filepath.Abs(target)
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		vars["name"] = filepath.Base(absTarget)
+	}
+	if _, ok := vars["module"]; !ok {
+		vars["module"] = vars["name"]
+	}
+
+	if err := doc.Substitute(vars); err != nil {
+		fatal(err, "Error applying variables: %v", err)
+	}
+
+	if !*force {
+		if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+			fatal(nil, "Error: %s is not empty (use -f to scaffold into it anyway)", target)
+		}
+	}
+
+	if err := doc.WriteToDirectoryContext(ctx, target, silo.UnpackOptions{}); err != nil {
+		fatal(err, "Error writing files: %s", describeError(err))
+	}
+	fmt.Fprintf(os.Stderr, "Created %s from %s (%d files)\n", target, template, len(doc.Files))
+}
+
+// loadTemplate resolves a template argument: a URL, an existing file, or
+// the name of a built-in template.
+func loadTemplate(ctx context.Context, name string) (*silo.SiloDocument, error) {
+	if silo.IsURL(name) {
+		return silo.ParseSiloURL(ctx, name, silo.ParseOptions{MaxTotalSize: 64 << 20})
+	}
+	if _, err := os.Stat(name); err == nil {
+		return readArchive(name)
+	}
+
+	file, err := templateFS.Open("templates/" + name + ".silo")
+	if err != nil {
+		return nil, fmt.Errorf("no such file or built-in template (available: %s)", strings.Join(builtinTemplates(), ", "))
+	}
+	defer file.Close()
+	return silo.ParseSiloFile(file)
+}
+
+func builtinTemplates() []string {
+	entries, _ := templateFS.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+func serveCmd(ctx context.Context, args []string) {
+	serveFlags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := serveFlags.String("addr", "localhost:8080", "Address to listen on (use :8080 to serve on all interfaces)")
+	serveFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo serve <silo-file> [-addr host:port]\n")
+		fmt.Fprintf(os.Stderr, "Serve the files in a silo file over HTTP without unpacking it\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		serveFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, serveFlags, args)
+
+	if serveFlags.NArg() != 1 {
+		serveFlags.Usage()
+		os.Exit(1)
+	}
+	archive := serveFlags.Arg(0)
+
+	doc, err := readArchive(archive)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: http.FileServer(http.FS(doc.FS())),
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving %d files from %s on http://%s/\n", len(doc.Files), archive, *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal(err, "Error: %v", err)
+	}
+}
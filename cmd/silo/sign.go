@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func signCmd(ctx context.Context, args []string) {
+	signFlags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyFile := signFlags.String("key", "", "PEM file with an ed25519 private key (PKCS #8)")
+	signFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo sign <silo-file> -key priv.pem\n")
+		fmt.Fprintf(os.Stderr, "Append an ed25519 signature trailer to a silo file in place\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		signFlags.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nCreate a key pair with:\n")
+		fmt.Fprintf(os.Stderr, "  openssl genpkey -algorithm ed25519 -out priv.pem\n")
+		fmt.Fprintf(os.Stderr, "  openssl pkey -in priv.pem -pubout -out pub.pem\n")
+	}
+	parseFlags(ctx, signFlags, args)
+
+	if signFlags.NArg() != 1 || *keyFile == "" {
+		signFlags.Usage()
+		os.Exit(1)
+	}
+	archive := signFlags.Arg(0)
+
+	key, err := loadPrivateKey(*keyFile)
+	if err != nil {
+		fatal(err, "Error loading key: %v", err)
+	}
+
+	doc, err := readArchiveWithOptions(archive, exactParseOptions)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	doc.Sign(key)
+
+	if err := writeArchive(archive, doc); err != nil {
+		fatal(err, "Error writing silo file: %v", err)
+	}
+}
+
+// loadPrivateKey reads an ed25519 private key from a PKCS #8 PEM file, as
+// written by openssl genpkey.
+func loadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 private key", path)
+	}
+	return edKey, nil
+}
+
+// loadPublicKey reads an ed25519 public key from a PKIX PEM file, as written
+// by openssl pkey -pubout.
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 public key", path)
+	}
+	return edKey, nil
+}
+
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/escherize/go-silo"
+)
+
+// snapshotDir is the directory, inside the directory being versioned, that
+// silo snapshot keeps its archives in.
+const snapshotDir = ".silo-snapshots"
+
+// latestFile names the file in snapshotDir holding the name of the newest
+// snapshot.
+const latestFile = "latest"
+
+// snapshotTreeOptions are the read options snapshot and restore share, so
+// that restore compares the same files a snapshot packs.
+func snapshotTreeOptions(excludes []string, hidden bool) silo.ReadDirectoryTreeOptions {
+	return silo.ReadDirectoryTreeOptions{
+		Exclude:    append(append([]string(nil), excludes...), snapshotDir),
+		SkipHidden: !hidden,
+		Binary:     silo.BinaryBase64,
+		Logger:     logger,
+	}
+}
+
+// readSnapshot parses a snapshot exactly as stored.
+func readSnapshot(path string) (*silo.SiloDocument, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return silo.ParseSiloFileWithOptions(file, silo.ParseOptions{LineEndings: silo.LineEndingsPreserve})
+}
+
+// latestSnapshot returns the name of the newest snapshot in store, or ""
+// if none has been taken.
+func latestSnapshot(store string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(store, latestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+func snapshotCmd(ctx context.Context, args []string) {
+	snapshotFlags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	dir := snapshotFlags.String("dir", ".", "Directory to snapshot")
+	hidden := snapshotFlags.Bool("hidden", false, "Also snapshot hidden files and directories")
+	list := snapshotFlags.Bool("list", false, "List the snapshots taken, oldest first, instead of taking one")
+	var excludes stringList
+	snapshotFlags.Var(&excludes, "exclude", "Leave out files and directories matching this pattern, as pack does (repeatable)")
+	snapshotFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo snapshot [options]\n")
+		fmt.Fprintf(os.Stderr, "Pack a directory into %s/<time>.silo inside it and record that as the\n", snapshotDir)
+		fmt.Fprintf(os.Stderr, "latest snapshot, for local versioning without git. Nothing is written when\n")
+		fmt.Fprintf(os.Stderr, "no file has changed since the latest snapshot. See silo restore\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		snapshotFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, snapshotFlags, args)
+
+	if snapshotFlags.NArg() != 0 {
+		snapshotFlags.Usage()
+		os.Exit(1)
+	}
+	store := filepath.Join(*dir, snapshotDir)
+	latest, err := latestSnapshot(store)
+	if err != nil {
+		fatal(err, "Error reading snapshots: %v", err)
+	}
+
+	if *list {
+		names, err := filepath.Glob(filepath.Join(store, "*.silo"))
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			name = filepath.Base(name)
+			if name == latest {
+				name += " (latest)"
+			}
+			fmt.Println(name)
+		}
+		return
+	}
+
+	started := time.Now()
+	opts := snapshotTreeOptions(excludes, *hidden)
+	var doc *silo.SiloDocument
+	var diff silo.DocumentDiff
+	if latest == "" {
+		if doc, err = silo.ReadDirectoryTreeContext(ctx, *dir, opts); err != nil {
+			fatal(err, "Error reading directory: %s", describeError(err))
+		}
+		diff.Added = make([]string, len(doc.Files))
+		for i, file := range doc.Files {
+			diff.Added[i] = file.Path
+		}
+	} else {
+		// Files unchanged since the latest snapshot are taken from it
+		// rather than read again.
+		if doc, err = readSnapshot(filepath.Join(store, latest)); err != nil {
+			fatal(err, "Error reading latest snapshot %s: %v", latest, err)
+		}
+		if diff, err = doc.CompareToDirectoryContext(ctx, *dir, opts); err != nil {
+			fatal(err, "Error reading directory: %s", describeError(err))
+		}
+		if diff.Empty() {
+			if !quietMode {
+				fmt.Fprintf(os.Stderr, "No changes since %s\n", latest)
+			}
+			return
+		}
+		if err := doc.UpdateFromDirectoryContext(ctx, *dir, opts); err != nil {
+			fatal(err, "Error reading directory: %s", describeError(err))
+		}
+	}
+
+	name := started.UTC().Format("20060102T150405Z")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(store, name+".silo")); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", started.UTC().Format("20060102T150405Z"), i)
+	}
+	name += ".silo"
+
+	doc.Delimiter = ""
+	doc.Header = &silo.FormatHeader{Created: started}
+	if err := os.MkdirAll(store, 0755); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	err = writeAtomic(filepath.Join(store, name), func(w io.Writer) error {
+		return doc.WriteToWithOptions(w, silo.WriteOptions{LineEndings: silo.LineEndingsPreserve, MarkMissingNewline: true})
+	})
+	if err != nil {
+		fatal(err, "Error writing snapshot: %v", err)
+	}
+	err = writeAtomic(filepath.Join(store, latestFile), func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, name)
+		return err
+	})
+	if err != nil {
+		fatal(err, "Error recording latest snapshot: %v", err)
+	}
+	logger.Info("snapshot", "name", name, "files", len(doc.Files))
+	if !quietMode {
+		fmt.Printf("Saved %s: %d files (%d added, %d modified, %d removed)\n",
+			filepath.Join(store, name), len(doc.Files), len(diff.Added), len(diff.Modified), len(diff.Removed))
+	}
+}
+
+func restoreCmd(ctx context.Context, args []string) {
+	restoreFlags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dir := restoreFlags.String("dir", ".", "Directory to restore")
+	hidden := restoreFlags.Bool("hidden", false, "Also consider hidden files, for snapshots taken with -hidden")
+	deleteExtra := restoreFlags.Bool("delete", false, "Also delete files the snapshot does not have")
+	yes := restoreFlags.Bool("yes", false, "Restore without asking for confirmation")
+	var excludes stringList
+	restoreFlags.Var(&excludes, "exclude", "Leave files and directories matching this pattern alone (repeatable)")
+	restoreFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo restore [options] [snapshot]\n")
+		fmt.Fprintf(os.Stderr, "Bring a directory back to a snapshot taken with silo snapshot: the latest,\n")
+		fmt.Fprintf(os.Stderr, "or the one named as listed by silo snapshot -list. Modified and missing files\n")
+		fmt.Fprintf(os.Stderr, "are rewritten once confirmed; files added since are kept unless -delete is\n")
+		fmt.Fprintf(os.Stderr, "given. Take a snapshot first to keep the current state\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		restoreFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, restoreFlags, args)
+
+	if restoreFlags.NArg() > 1 {
+		restoreFlags.Usage()
+		os.Exit(1)
+	}
+	store := filepath.Join(*dir, snapshotDir)
+	name := restoreFlags.Arg(0)
+	if name == "" || name == latestFile {
+		latest, err := latestSnapshot(store)
+		if err != nil {
+			fatal(err, "Error reading snapshots: %v", err)
+		}
+		if latest == "" {
+			fatal(nil, "Error: no snapshots in %s (take one with silo snapshot)", store)
+		}
+		name = latest
+	}
+	if !strings.HasSuffix(name, ".silo") {
+		name += ".silo"
+	}
+	doc, err := readSnapshot(filepath.Join(store, filepath.Base(name)))
+	if err != nil {
+		fatal(err, "Error reading snapshot: %v", err)
+	}
+
+	diff, err := doc.CompareToDirectoryContext(ctx, *dir, snapshotTreeOptions(excludes, *hidden))
+	if err != nil {
+		fatal(err, "Error reading directory: %s", describeError(err))
+	}
+	restore := append(append([]string(nil), diff.Modified...), diff.Removed...)
+	sort.Strings(restore)
+	for _, path := range restore {
+		fmt.Printf("restore: %s\n", path)
+	}
+	if *deleteExtra {
+		for _, path := range diff.Added {
+			fmt.Printf("delete:  %s\n", path)
+		}
+	} else if len(diff.Added) > 0 && !quietMode {
+		fmt.Fprintf(os.Stderr, "Keeping %d files added since the snapshot (remove them with -delete)\n", len(diff.Added))
+	}
+	if len(restore) == 0 && (!*deleteExtra || len(diff.Added) == 0) {
+		if !quietMode {
+			fmt.Fprintf(os.Stderr, "%s already matches %s\n", *dir, name)
+		}
+		return
+	}
+	if !*yes && !confirm(os.Stdin, os.Stderr, fmt.Sprintf("Restore %s to %s?", *dir, name)) {
+		if !quietMode {
+			fmt.Fprintf(os.Stderr, "Nothing restored\n")
+		}
+		return
+	}
+
+	restored, deleted, err := restoreFiles(ctx, *dir, doc, diff, *deleteExtra)
+	if err != nil {
+		fatal(err, "Error writing to directory: %s", describeError(err))
+	}
+	logger.Info("restored", "snapshot", name, "files", restored, "deleted", deleted)
+	if !quietMode {
+		fmt.Printf("Restored %d files from %s", restored, name)
+		if deleted > 0 {
+			fmt.Printf(" and deleted %d", deleted)
+		}
+		fmt.Println()
+	}
+}
+
+// restoreFiles rewrites the entries of doc that diff found modified or
+// removed in dir and, when deleteExtra is set, deletes the files diff found
+// added since. It returns how many files were restored and deleted.
+func restoreFiles(ctx context.Context, dir string, doc *silo.SiloDocument, diff silo.DocumentDiff, deleteExtra bool) (restored, deleted int, err error) {
+	wanted := make(map[string]bool, len(diff.Modified)+len(diff.Removed))
+	for _, path := range diff.Modified {
+		wanted[path] = true
+	}
+	for _, path := range diff.Removed {
+		wanted[path] = true
+	}
+	var files []silo.SiloFile
+	for _, file := range doc.Files {
+		if wanted[file.Path] {
+			files = append(files, file)
+		}
+	}
+	subset := &silo.SiloDocument{Delimiter: doc.Delimiter, Files: files}
+	opts := silo.UnpackOptions{LineEndings: silo.LineEndingsPreserve, Logger: logger}
+	if err := subset.WriteToDirectoryContext(ctx, dir, opts); err != nil {
+		return 0, 0, err
+	}
+	if deleteExtra {
+		for _, path := range diff.Added {
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+				return len(files), deleted, err
+			}
+			deleted++
+		}
+	}
+	return len(files), deleted, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestRestoreFiles(t *testing.T) {
+	for _, deleteExtra := range []bool{false, true} {
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"keep.txt":    "same\n",
+			"changed.txt": "edited\n",
+			"extra.txt":   "added since\n",
+		} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		doc := &silo.SiloDocument{Delimiter: ">", Files: []silo.SiloFile{
+			{Path: "changed.txt", Content: "original\r\n"},
+			{Path: "keep.txt", Content: "same\n"},
+			{Path: "sub/gone.txt", Content: "gone\n"},
+		}}
+		diff, err := doc.CompareToDirectoryContext(context.Background(), dir, snapshotTreeOptions(nil, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restored, deleted, err := restoreFiles(context.Background(), dir, doc, diff, deleteExtra)
+		if err != nil {
+			t.Fatalf("restoreFiles failed: %v", err)
+		}
+		wantDeleted := 0
+		if deleteExtra {
+			wantDeleted = 1
+		}
+		if restored != 2 || deleted != wantDeleted {
+			t.Errorf("delete=%v: expected 2 restored and %d deleted, got %d and %d", deleteExtra, wantDeleted, restored, deleted)
+		}
+		if len(doc.Files) != 3 {
+			t.Errorf("delete=%v: restoreFiles changed the snapshot's entries", deleteExtra)
+		}
+
+		for name, want := range map[string]string{
+			"keep.txt":     "same\n",
+			"changed.txt":  "original\r\n",
+			"sub/gone.txt": "gone\n",
+		} {
+			data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil || string(data) != want {
+				t.Errorf("delete=%v: expected %s to hold %q, got %q, %v", deleteExtra, name, want, data, err)
+			}
+		}
+		_, err = os.Stat(filepath.Join(dir, "extra.txt"))
+		if deleteExtra && !os.IsNotExist(err) {
+			t.Errorf("Expected -delete to remove extra.txt, got %v", err)
+		}
+		if !deleteExtra && err != nil {
+			t.Errorf("Expected extra.txt to be kept without -delete, got %v", err)
+		}
+	}
+}
+
+func TestRestoreFilesKeepsSnapshotDir(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, snapshotDir)
+	if err := os.MkdirAll(store, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(store, latestFile), []byte("a.silo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &silo.SiloDocument{Delimiter: ">"}
+	diff, err := doc.CompareToDirectoryContext(context.Background(), dir, snapshotTreeOptions(nil, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Empty() {
+		t.Errorf("Expected the snapshot store and hidden files to be ignored, got %+v", diff)
+	}
+	if _, _, err := restoreFiles(context.Background(), dir, doc, diff, true); err != nil {
+		t.Fatalf("restoreFiles failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store, latestFile)); err != nil {
+		t.Errorf("Expected -delete to leave the snapshot store alone, got %v", err)
+	}
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	store := t.TempDir()
+	if name, err := latestSnapshot(store); err != nil || name != "" {
+		t.Errorf("Expected no snapshot in an empty store, got %q, %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(store, latestFile), []byte("20261016-120000.silo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := latestSnapshot(store); err != nil || name != "20261016-120000.silo" {
+		t.Errorf("Expected the latest snapshot, got %q, %v", name, err)
+	}
+}
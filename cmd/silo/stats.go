@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/escherize/go-silo"
+)
+
+func statsCmd(ctx context.Context, args []string) {
+	statsFlags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	tokenizer := statsFlags.String("tokenizer", "bytes", "Token estimate heuristic: bytes or words")
+	duplicates := statsFlags.Bool("duplicates", false, "List groups of files with identical content instead of sizes")
+	perFile := statsFlags.Bool("files", false, "List the size and estimated tokens of every file instead of a summary")
+	formatName := statsFlags.String("format", "text", "Output format: text, csv, tsv or json")
+	statsFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo stats [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Summarize an archive: file count, sizes, largest files, extensions and estimated LLM tokens\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		statsFlags.PrintDefaults()
+	}
+	parseFlags(ctx, statsFlags, args)
+
+	if statsFlags.NArg() != 1 {
+		statsFlags.Usage()
+		os.Exit(1)
+	}
+
+	estimator, err := silo.ParseTokenEstimator(*tokenizer)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	format, err := parseOutputFormat(*formatName)
+	if err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	doc, err := readArchive(statsFlags.Arg(0))
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+
+	statsOpts := silo.StatsOptions{Tokenizer: estimator}
+	if format != formatText {
+		var err error
+		switch {
+		case *duplicates:
+			err = writeDuplicateRecords(os.Stdout, format, doc.FindDuplicates())
+		case *perFile:
+			err = writeFileStatsRecords(os.Stdout, format, doc.StatsWithOptions(statsOpts))
+		default:
+			err = writeSummaryRecords(os.Stdout, format, statsFlags.Arg(0), doc.SummaryWithOptions(statsOpts))
+		}
+		if err != nil {
+			fatal(err, "Error: %v", err)
+		}
+		return
+	}
+
+	if *duplicates {
+		printDuplicates(doc)
+		return
+	}
+
+	if !*perFile {
+		printSummary(statsFlags.Arg(0), doc.SummaryWithOptions(statsOpts))
+		return
+	}
+
+	stats := doc.StatsWithOptions(statsOpts)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "BYTES\tLINES\tTOKENS\t\tPATH\n")
+	for _, file := range stats.Files {
+		fmt.Fprintf(w, "%d\t%d\t%d\t\t%s\n", file.Bytes, file.Lines, file.Tokens, file.Path)
+	}
+	fmt.Fprintf(w, "%d\t%d\t%d\t\ttotal (%d files, ~%d tokens with delimiters)\n",
+		stats.Bytes, stats.Lines, stats.Tokens, len(stats.Files), stats.TotalTokens())
+	w.Flush()
+}
+
+func printSummary(name string, summary silo.Summary) {
+	fmt.Printf("%s: %s", name, plural(summary.Files, "file"))
+	var kinds []string
+	if summary.Links > 0 {
+		kinds = append(kinds, plural(summary.Links, "link"))
+	}
+	if summary.Refs > 0 {
+		kinds = append(kinds, plural(summary.Refs, "reference"))
+	}
+	if summary.Binary > 0 {
+		kinds = append(kinds, fmt.Sprintf("%d binary", summary.Binary))
+	}
+	if len(kinds) > 0 {
+		fmt.Printf(" (%s)", strings.Join(kinds, ", "))
+	}
+	fmt.Printf(", %d bytes, %d lines, ~%d tokens\n", summary.Bytes, summary.Lines, summary.Tokens)
+
+	if len(summary.Largest) > 0 {
+		fmt.Printf("\nLargest files:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, file := range summary.Largest {
+			fmt.Fprintf(w, "  %d\t%d lines\t\t%s\n", file.Bytes, file.Lines, file.Path)
+		}
+		w.Flush()
+	}
+
+	if len(summary.Extensions) > 0 {
+		fmt.Printf("\nBy extension:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, ext := range summary.Extensions {
+			name := ext.Extension
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Fprintf(w, "  %s\t%d bytes\t%d lines\t\t%s\n", plural(ext.Files, "file"), ext.Bytes, ext.Lines, name)
+		}
+		w.Flush()
+	}
+}
+
+// plural formats n with noun, adding an "s" unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func printDuplicates(doc *silo.SiloDocument) {
+	groups := doc.FindDuplicates()
+	if len(groups) == 0 {
+		fmt.Println("No duplicate files")
+		return
+	}
+
+	wasted := 0
+	for _, group := range groups {
+		fmt.Printf("%d copies of %d bytes:\n", len(group.Paths), group.Bytes)
+		for _, path := range group.Paths {
+			fmt.Printf("  %s\n", path)
+		}
+		wasted += group.Wasted()
+	}
+	fmt.Printf("%d bytes could be saved by storing each content once\n", wasted)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/escherize/go-silo"
+)
+
+func statusCmd(ctx context.Context, args []string) {
+	statusFlags := flag.NewFlagSet("status", flag.ContinueOnError)
+	dir := statusFlags.String("dir", ".", "Directory to compare the archive with")
+	hidden := statusFlags.Bool("hidden", false, "Also compare hidden files and directories, as pack -hidden packs them")
+	var excludes stringList
+	statusFlags.Var(&excludes, "exclude", "Leave out files and directories matching this pattern, as pack does (repeatable)")
+	statusFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo status [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "List the files in a directory that differ from an archive of it: modified,\n")
+		fmt.Fprintf(os.Stderr, "missing from disk, or extra on disk. Files older than an archive with a\n")
+		fmt.Fprintf(os.Stderr, "header (pack -header) and of the same size are not read. Exits with 1 if\n")
+		fmt.Fprintf(os.Stderr, "anything differs\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		statusFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, statusFlags, args)
+
+	if statusFlags.NArg() != 1 {
+		statusFlags.Usage()
+		os.Exit(1)
+	}
+	siloFile := statusFlags.Arg(0)
+
+	// Content is compared exactly as stored, as apply does.
+	file, err := os.Open(siloFile)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	doc, err := silo.ParseSiloFileWithOptions(file, silo.ParseOptions{LineEndings: silo.LineEndingsPreserve})
+	file.Close()
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	if err := doc.ResolveRefs(silo.DirRefResolver(filepath.Dir(siloFile))); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+
+	diff, err := doc.CompareToDirectoryContext(ctx, *dir, silo.ReadDirectoryTreeOptions{
+		Exclude:    excludes,
+		SkipHidden: !*hidden,
+		Logger:     logger,
+	})
+	if err != nil {
+		fatal(err, "Error reading directory: %s", describeError(err))
+	}
+
+	if diff.Empty() {
+		if !quietMode {
+			fmt.Fprintf(os.Stderr, "%s matches %s\n", *dir, siloFile)
+		}
+		return
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, path := range diff.Modified {
+		fmt.Fprintf(w, "modified: %s\n", path)
+	}
+	for _, path := range diff.Removed {
+		fmt.Fprintf(w, "missing:  %s\n", path)
+	}
+	for _, path := range diff.Added {
+		fmt.Fprintf(w, "extra:    %s\n", path)
+	}
+	if err := w.Flush(); err != nil {
+		fatal(err, "Error: %v", err)
+	}
+	if !quietMode {
+		fmt.Fprintf(os.Stderr, "%d modified, %d missing, %d extra\n", len(diff.Modified), len(diff.Removed), len(diff.Added))
+	}
+	os.Exit(exitFailure)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/escherize/go-silo"
+)
+
+//go:embed view.html
+var viewHTML string
+
+var viewTemplate = template.Must(template.New("view").Funcs(template.FuncMap{"rawURL": rawURL}).Parse(viewHTML))
+
+// rawURL returns the URL rawHandler serves an entry at, with each path
+// segment escaped so "?", "#" and "%" in names stay part of the path.
+func rawURL(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/raw/" + strings.Join(segments, "/")
+}
+
+// viewNode is a directory or file in the sidebar tree of silo view.
+type viewNode struct {
+	Name     string
+	Path     string // entry path; "" for directories
+	Children []*viewNode
+}
+
+// viewFile is the entry shown in the content pane of silo view.
+type viewFile struct {
+	Path    string
+	Lang    string
+	Content string
+	Lines   []int
+	Note    string // shown instead of content for links, references and binary files
+	Size    int
+}
+
+// viewPage is the data the view template renders.
+type viewPage struct {
+	Archive string
+	Count   int
+	Tree    *viewNode
+	File    *viewFile
+}
+
+func viewCmd(ctx context.Context, args []string) {
+	viewFlags := flag.NewFlagSet("view", flag.ContinueOnError)
+	addr := viewFlags.String("addr", "localhost:8080", "Address to listen on")
+	open := viewFlags.Bool("open", false, "Open the viewer in the default web browser")
+	viewFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: silo view [options] <silo-file>\n")
+		fmt.Fprintf(os.Stderr, "Browse a silo file in a web browser: a file tree and highlighted content, without unpacking\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		viewFlags.PrintDefaults()
+	}
+	ctx = parseFlags(ctx, viewFlags, args)
+
+	if viewFlags.NArg() != 1 {
+		viewFlags.Usage()
+		os.Exit(1)
+	}
+	archive := viewFlags.Arg(0)
+
+	doc, err := readArchive(archive)
+	if err != nil {
+		fatal(err, "Error reading silo file: %v", err)
+	}
+	entries := make(map[string]silo.SiloFile, len(doc.Files))
+	for _, file := range doc.Files {
+		entries[file.Path] = file
+	}
+	tree := buildViewTree(doc.Files)
+
+	mux := http.NewServeMux()
+	mux.Handle("/raw/", http.StripPrefix("/raw/", rawHandler(doc.FS())))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		page := viewPage{Archive: archive, Count: len(entries), Tree: tree}
+		if path := r.URL.Query().Get("path"); path != "" {
+			file, ok := entries[path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			page.File = newViewFile(file)
+		}
+		var buf bytes.Buffer
+		if err := viewTemplate.Execute(&buf, page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+
+	server := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	url := "http://" + *addr + "/"
+	fmt.Fprintf(os.Stderr, "Viewing %d files from %s on %s\n", len(entries), archive, url)
+	if *open {
+		if err := openBrowser(url); err != nil {
+			logger.Warn("could not open a browser", "error", err)
+		}
+	}
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal(err, "Error: %v", err)
+	}
+}
+
+// rawHandler serves entry content from fsys as plain text that the browser
+// may not sniff, script or frame, so HTML or JavaScript in an untrusted
+// archive is shown rather than run on the viewer's origin.
+func rawHandler(fsys fs.FS) http.Handler {
+	files := http.FileServer(http.FS(fsys))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// buildViewTree arranges entry paths into a directory tree, directories
+// before files and each sorted by name. A path that appears more than once
+// is listed once.
+func buildViewTree(files []silo.SiloFile) *viewNode {
+	root := &viewNode{}
+	dirs := map[string]*viewNode{"": root}
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		if seen[file.Path] {
+			continue
+		}
+		seen[file.Path] = true
+		parent := root
+		parts := strings.Split(file.Path, "/")
+		for i, name := range parts[:len(parts)-1] {
+			dir := strings.Join(parts[:i+1], "/")
+			node, ok := dirs[dir]
+			if !ok {
+				node = &viewNode{Name: name}
+				dirs[dir] = node
+				parent.Children = append(parent.Children, node)
+			}
+			parent = node
+		}
+		parent.Children = append(parent.Children, &viewNode{Name: parts[len(parts)-1], Path: file.Path})
+	}
+	for _, dir := range dirs {
+		sort.Slice(dir.Children, func(i, j int) bool {
+			a, b := dir.Children[i], dir.Children[j]
+			if (a.Path == "") != (b.Path == "") {
+				return a.Path == ""
+			}
+			return a.Name < b.Name
+		})
+	}
+	return root
+}
+
+// newViewFile prepares file for the content pane. Content that is not
+// UTF-8 text is offered as a download instead.
+func newViewFile(file silo.SiloFile) *viewFile {
+	view := &viewFile{Path: file.Path, Lang: file.Lang()}
+	switch {
+	case file.LinkTarget != "":
+		view.Note = "Link to " + file.LinkTarget
+		return view
+	case file.Ref != "":
+		view.Note = "Reference to " + file.Ref + " (not stored in the archive)"
+		return view
+	}
+	data := file.Bytes()
+	view.Size = len(data)
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		view.Note = fmt.Sprintf("Binary file, %d bytes", len(data))
+		return view
+	}
+	view.Content = string(data)
+	lines := strings.Count(view.Content, "\n")
+	if !strings.HasSuffix(view.Content, "\n") {
+		lines++
+	}
+	view.Lines = make([]int, lines)
+	for i := range view.Lines {
+		view.Lines[i] = i + 1
+	}
+	return view
+}
+
+// openBrowser opens url in the user's default web browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/escherize/go-silo"
+)
+
+func TestRawHandlerServesPlainText(t *testing.T) {
+	doc := &silo.SiloDocument{Files: []silo.SiloFile{
+		{Path: "evil.html", Content: "<script>alert(1)</script>\n"},
+	}}
+	server := httptest.NewServer(http.StripPrefix("/raw/", rawHandler(doc.FS())))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/raw/evil.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %s", resp.Status)
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Expected text/plain, got %q", got)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("Expected nosniff, got %q", got)
+	}
+	if got := resp.Header.Get("Content-Security-Policy"); !strings.Contains(got, "sandbox") {
+		t.Errorf("Expected a sandboxing policy, got %q", got)
+	}
+}
+
+func TestBuildViewTree(t *testing.T) {
+	tree := buildViewTree([]silo.SiloFile{
+		{Path: "z.txt"},
+		{Path: "src/b.go"},
+		{Path: "src/a.go"},
+		{Path: "z.txt"},
+	})
+	var names []string
+	var walk func(node *viewNode, prefix string)
+	walk = func(node *viewNode, prefix string) {
+		for _, child := range node.Children {
+			names = append(names, prefix+child.Name)
+			walk(child, prefix+"  ")
+		}
+	}
+	walk(tree, "")
+	if got, want := strings.Join(names, "\n"), "src\n  a.go\n  b.go\nz.txt"; got != want {
+		t.Errorf("Expected tree\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestViewRawLinkEscapesPath(t *testing.T) {
+	file := silo.SiloFile{Path: "dir?x/a#b 100%.txt", Content: "hello\n"}
+	var buf bytes.Buffer
+	if err := viewTemplate.Execute(&buf, viewPage{Archive: "a.silo", Tree: &viewNode{}, File: newViewFile(file)}); err != nil {
+		t.Fatal(err)
+	}
+	href := "/raw/dir%3Fx/a%23b%20100%25.txt"
+	if !strings.Contains(buf.String(), `href="`+href+`"`) {
+		t.Fatalf("Expected a raw link to %s in\n%s", href, buf.String())
+	}
+
+	doc := &silo.SiloDocument{Files: []silo.SiloFile{file}}
+	server := httptest.NewServer(http.StripPrefix("/raw/", rawHandler(doc.FS())))
+	defer server.Close()
+	resp, err := http.Get(server.URL + href)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != file.Content {
+		t.Errorf("Expected the raw link to serve %q, got %s %q", file.Content, resp.Status, body)
+	}
+}
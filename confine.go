@@ -0,0 +1,61 @@
+package silo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// target returns the filesystem path the entry path unpacks to. It checks
+// path again rather than trusting that the document was parsed, and checks
+// that joining it to the output directory stays inside it. Errors match
+// ErrInvalidPath.
+func (u *unpacker) target(path string) (string, error) {
+	if err := validatePath(path); err != nil {
+		return "", err
+	}
+	native := filepath.FromSlash(path)
+	if !filepath.IsLocal(native) {
+		return "", invalidPathError("path %s does not stay inside the output directory", path)
+	}
+	full := filepath.Join(u.absRoot, native)
+	if full == u.absRoot || !withinRoot(full, u.absRoot) {
+		return "", invalidPathError("path %s does not stay inside the output directory", path)
+	}
+	return full, nil
+}
+
+// confine checks, before anything is created for full, that the deepest
+// existing directory above it resolves inside the output directory, so no
+// directory or file is written through a symlink leading out of it. A
+// symlink already at full itself is removed, so the entry replaces the link
+// instead of writing to where it points. Resolving the real path also
+// expands Windows short (8.3) names. Errors match ErrInvalidPath.
+func (u *unpacker) confine(path, full string) error {
+	dir := filepath.Dir(full)
+	u.mu.Lock()
+	created := u.dirs[dir]
+	u.mu.Unlock()
+
+	if !created {
+		for {
+			if _, err := os.Lstat(dir); err == nil || filepath.Dir(dir) == dir {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		if !withinRoot(resolved, u.realRoot) {
+			return invalidPathError("path %s would be written outside the output directory through a symlink", path)
+		}
+	}
+
+	if info, err := os.Lstat(full); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(full); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// traversalDirs returns an output directory and a sibling directory that an
+// attack would try to write into.
+func traversalDirs(t *testing.T) (out, outside string) {
+	base := t.TempDir()
+	out, outside = filepath.Join(base, "out"), filepath.Join(base, "outside")
+	for _, dir := range []string{out, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out, outside
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("Expected %s to stay empty, found %s", dir, entries[0].Name())
+	}
+}
+
+func TestUnpackTraversalPaths(t *testing.T) {
+	paths := []string{
+		"../outside/pwned.txt",
+		"a/../../outside/pwned.txt",
+		"a/b/../../../outside/pwned.txt",
+		"..",
+		"/tmp/pwned.txt",
+		"..\\outside\\pwned.txt",
+		"a/\x00/pwned.txt",
+		".",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			archive := "> ok.txt\nfine\n> " + path + "\npwned\n"
+			if _, err := ParseSiloFile(strings.NewReader(archive)); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("ParseSiloFile: expected ErrInvalidPath, got %v", err)
+			}
+
+			// Documents built in code are not parsed, so unpacking must
+			// check again.
+			out, outside := traversalDirs(t)
+			doc := &SiloDocument{Files: []SiloFile{{Path: path, Content: "pwned\n"}}}
+			if err := doc.WriteToDirectory(out); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("WriteToDirectory: expected ErrInvalidPath, got %v", err)
+			}
+			assertEmptyDir(t, outside)
+		})
+	}
+}
+
+func TestUnpackLookalikePathsStayInside(t *testing.T) {
+	paths := []string{
+		"\uff0e\uff0e/pwned.txt", // fullwidth full stops
+		"\u2024\u2024/pwned.txt", // one dot leaders
+		"a/...b/pwned.txt",
+		"C:/pwned.txt",
+	}
+	if runtime.GOOS == "windows" {
+		paths = paths[:3]
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			out, outside := traversalDirs(t)
+			doc := &SiloDocument{Files: []SiloFile{{Path: path, Content: "inside\n"}}}
+			err := doc.WriteToDirectory(out)
+			if err != nil && !errors.Is(err, ErrInvalidPath) {
+				t.Fatalf("WriteToDirectory failed: %v", err)
+			}
+			if err == nil {
+				if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(path))); err != nil {
+					t.Errorf("Expected %s inside the output directory: %v", path, err)
+				}
+			}
+			assertEmptyDir(t, outside)
+		})
+	}
+}
+
+func TestUnpackThroughExistingSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+
+	t.Run("directory link", func(t *testing.T) {
+		out, outside := traversalDirs(t)
+		if err := os.Symlink(outside, filepath.Join(out, "link")); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"link/pwned.txt", "link/deep/er/pwned.txt"} {
+			doc := &SiloDocument{Files: []SiloFile{{Path: path, Content: "pwned\n"}}}
+			if err := doc.WriteToDirectory(out); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("%s: expected ErrInvalidPath, got %v", path, err)
+			}
+		}
+		assertEmptyDir(t, outside)
+	})
+
+	t.Run("file link", func(t *testing.T) {
+		out, outside := traversalDirs(t)
+		target := filepath.Join(outside, "target.txt")
+		if err := os.WriteFile(target, []byte("original\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(out, "a.txt")); err != nil {
+			t.Fatal(err)
+		}
+
+		doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "replaced\n"}}}
+		if err := doc.WriteToDirectory(out); err != nil {
+			t.Fatalf("WriteToDirectory failed: %v", err)
+		}
+		if content, _ := os.ReadFile(target); string(content) != "original\n" {
+			t.Errorf("Link target was overwritten: %q", content)
+		}
+		info, err := os.Lstat(filepath.Join(out, "a.txt"))
+		if err != nil || !info.Mode().IsRegular() {
+			t.Errorf("Expected a.txt to be replaced by a regular file, got %v, %v", info, err)
+		}
+	})
+
+	t.Run("link inside the output directory", func(t *testing.T) {
+		out, _ := traversalDirs(t)
+		if err := os.Mkdir(filepath.Join(out, "real"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("real", filepath.Join(out, "alias")); err != nil {
+			t.Fatal(err)
+		}
+		doc := &SiloDocument{Files: []SiloFile{{Path: "alias/f.txt", Content: "ok\n"}}}
+		if err := doc.WriteToDirectory(out); err != nil {
+			t.Fatalf("WriteToDirectory failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "real", "f.txt")); err != nil {
+			t.Errorf("Expected f.txt under real/: %v", err)
+		}
+	})
+
+	t.Run("archive link then file", func(t *testing.T) {
+		out, outside := traversalDirs(t)
+		archive := "> escape -> ../outside\n> escape/pwned.txt\npwned\n"
+		doc, err := ParseSiloFile(strings.NewReader(archive))
+		if err != nil {
+			t.Fatalf("ParseSiloFile failed: %v", err)
+		}
+		if err := doc.WriteToDirectory(out); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected ErrInvalidPath, got %v", err)
+		}
+		assertEmptyDir(t, outside)
+	})
+
+	t.Run("archive link through archive link", func(t *testing.T) {
+		for _, archive := range []string{
+			"> x -> .\n> x/y -> ..\n",
+			"> x -> .\n> y -> x/..\n",
+		} {
+			out, _ := traversalDirs(t)
+			doc, err := ParseSiloFile(strings.NewReader(archive))
+			if err != nil {
+				t.Fatalf("ParseSiloFile failed: %v", err)
+			}
+			if err := doc.WriteToDirectory(out); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("%q: expected ErrInvalidPath, got %v", archive, err)
+			}
+			if _, err := os.Lstat(filepath.Join(out, "y")); err == nil {
+				t.Errorf("%q: expected no link at y", archive)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package silo
+
+import "unsafe"
+
+// Bytes returns the file's content: ContentBytes itself when it is non-nil,
+// otherwise a copy of Content.
+func (f SiloFile) Bytes() []byte {
+	if f.ContentBytes != nil {
+		return f.ContentBytes
+	}
+	return []byte(f.Content)
+}
+
+// Text returns the file's content: Content, or a copy of ContentBytes when
+// it is non-nil.
+func (f SiloFile) Text() string {
+	if f.ContentBytes != nil {
+		return string(f.ContentBytes)
+	}
+	return f.Content
+}
+
+// Size returns the length of the file's content in bytes.
+func (f SiloFile) Size() int {
+	if f.ContentBytes != nil {
+		return len(f.ContentBytes)
+	}
+	return len(f.Content)
+}
+
+// SetBytes makes b the file's content without copying it, clearing
+// Content. The file keeps b, so the caller must not modify it afterwards.
+func (f *SiloFile) SetBytes(b []byte) {
+	if b == nil {
+		b = []byte{}
+	}
+	f.ContentBytes, f.Content = b, ""
+}
+
+// SetText makes s the file's content, clearing ContentBytes.
+func (f *SiloFile) SetText(s string) {
+	f.ContentBytes, f.Content = nil, s
+}
+
+// text returns the file's content as a string that shares memory with
+// ContentBytes instead of copying it. It is for reading during a single
+// operation: the result must not be retained past it.
+func (f SiloFile) text() string {
+	if f.ContentBytes != nil {
+		return unsafe.String(unsafe.SliceData(f.ContentBytes), len(f.ContentBytes))
+	}
+	return f.Content
+}
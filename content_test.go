@@ -0,0 +1,109 @@
+package silo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSiloFileContentAccessors(t *testing.T) {
+	file := SiloFile{Path: "a.txt", Content: "text\n"}
+	if got := string(file.Bytes()); got != "text\n" {
+		t.Errorf("Bytes() = %q", got)
+	}
+
+	data := []byte("bytes\n")
+	file.SetBytes(data)
+	if file.Content != "" {
+		t.Errorf("SetBytes left Content = %q", file.Content)
+	}
+	if got := file.Bytes(); &got[0] != &data[0] {
+		t.Error("Bytes() copied ContentBytes")
+	}
+	if got := file.Text(); got != "bytes\n" {
+		t.Errorf("Text() = %q", got)
+	}
+	if got := file.Size(); got != len(data) {
+		t.Errorf("Size() = %d, want %d", got, len(data))
+	}
+
+	file.SetBytes(nil)
+	if file.ContentBytes == nil || file.Size() != 0 {
+		t.Errorf("SetBytes(nil) should hold empty content, got %#v", file.ContentBytes)
+	}
+
+	file.SetText("again\n")
+	if file.ContentBytes != nil || file.Text() != "again\n" {
+		t.Errorf("SetText left ContentBytes = %q, Text() = %q", file.ContentBytes, file.Text())
+	}
+}
+
+func TestWriteToContentBytes(t *testing.T) {
+	build := func(asBytes bool) *SiloDocument {
+		doc := &SiloDocument{}
+		for _, file := range []SiloFile{
+			{Path: "a.txt", Content: "> looks like a header\nplain\n"},
+			{Path: "b.txt", Content: "no newline"},
+			{Path: "c.bin", Content: "\x00\x01\x02", Base64: true},
+		} {
+			if asBytes {
+				file.SetBytes([]byte(file.Content))
+			}
+			doc.Files = append(doc.Files, file)
+		}
+		return doc
+	}
+
+	var want, got bytes.Buffer
+	if err := build(false).WriteTo(&want); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if err := build(true).WriteTo(&got); err != nil {
+		t.Fatalf("WriteTo with ContentBytes failed: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("ContentBytes output differs:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+
+	doc := build(true)
+	doc.Delimiter = ">"
+	if err := doc.WriteTo(&got); err == nil {
+		t.Error("Expected a delimiter conflict in ContentBytes content")
+	}
+}
+
+func TestReadDirectoryTreeContentBytes(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.bin"), []byte{0, 1, 2}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{ContentBytes: true, Binary: BinaryBase64})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if len(doc.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(doc.Files))
+	}
+	for _, file := range doc.Files {
+		if file.Content != "" || file.ContentBytes == nil {
+			t.Errorf("%s: expected content only in ContentBytes, got Content %q", file.Path, file.Content)
+		}
+	}
+	if !doc.Files[1].Base64 {
+		t.Error("Expected binary file to be detected from ContentBytes")
+	}
+
+	out := t.TempDir()
+	if err := doc.WriteToDirectory(out); err != nil {
+		t.Fatalf("WriteToDirectory failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "a.txt"))
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf("Unpacked a.txt = %q, %v", data, err)
+	}
+}
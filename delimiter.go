@@ -0,0 +1,231 @@
+package silo
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDelimiterLength is the longest delimiter auto-selection will try.
+const maxDelimiterLength = 50
+
+// DefaultDelimiterCandidates lists the delimiters tried by auto-selection,
+// most preferred first.
+var DefaultDelimiterCandidates = []string{">", "=", "*", "-"}
+
+// defaultRandomDelimiters is the number of random delimiters tried once the
+// candidates are exhausted, unless DelimiterOptions.Random says otherwise.
+const defaultRandomDelimiters = 64
+
+// randomDelimiterRunes is the length of each random delimiter. Its runes are
+// drawn from the Yi Syllables block, which ordinary content rarely uses.
+const (
+	randomDelimiterRunes = 3
+	randomDelimiterFirst = 0xA000
+	randomDelimiterLast  = 0xA48C
+)
+
+// DelimiterOptions configures FindSafeDelimiter and
+// AnalyzeDelimiterWithOptions.
+type DelimiterOptions struct {
+	// Candidates lists delimiters in preference order. Each is tried
+	// repeated once, then every candidate repeated twice, and so on up to
+	// MaxLength repetitions. Any non-whitespace text works, including
+	// emoji. Nil uses DefaultDelimiterCandidates.
+	Candidates []string
+	// MaxLength is the most repetitions of a candidate to try. Zero uses 50.
+	MaxLength int
+	// Random is the number of random high-codepoint delimiters to try once
+	// the candidates are exhausted. They come from a fixed seed, so output
+	// stays reproducible. Zero uses 64; a negative value disables them.
+	Random int
+}
+
+// DelimiterConflict records a content line that rules out a delimiter.
+type DelimiterConflict struct {
+	Delimiter string
+	Path      string
+	// Line is the 1-based line within the file's content.
+	Line int
+	// Text is the line itself.
+	Text string
+}
+
+// DelimiterAnalysis describes how an automatic delimiter was chosen.
+type DelimiterAnalysis struct {
+	// Chosen is the delimiter auto-selection settled on.
+	Chosen string
+	// Rejected lists the candidates preferred over Chosen that collide with
+	// file content, in preference order.
+	Rejected []DelimiterConflict
+}
+
+// AnalyzeDelimiter performs delimiter auto-selection for doc and reports the
+// chosen delimiter along with why each more preferred candidate was rejected.
+func AnalyzeDelimiter(doc *SiloDocument) (*DelimiterAnalysis, error) {
+	return AnalyzeDelimiterWithOptions(doc, DelimiterOptions{})
+}
+
+// AnalyzeDelimiterWithOptions is AnalyzeDelimiter with the candidates set in
+// opts.
+func AnalyzeDelimiterWithOptions(doc *SiloDocument, opts DelimiterOptions) (*DelimiterAnalysis, error) {
+	candidates := opts.Candidates
+	if candidates == nil {
+		candidates = DefaultDelimiterCandidates
+	}
+	maxLength := opts.MaxLength
+	if maxLength <= 0 {
+		maxLength = maxDelimiterLength
+	}
+	random := opts.Random
+	if random == 0 {
+		random = defaultRandomDelimiters
+	}
+
+	maxBytes := randomDelimiterRunes * utf8.UTFMax
+	for _, candidate := range candidates {
+		if candidate == "" || strings.IndexFunc(candidate, func(r rune) bool { return !isValidDelimiterChar(r) }) >= 0 {
+			return nil, fmt.Errorf("invalid delimiter candidate %q: must be non-empty and contain no whitespace", candidate)
+		}
+		if n := len(candidate) * maxLength; n > maxBytes {
+			maxBytes = n
+		}
+	}
+
+	conflicts := make(map[string]DelimiterConflict)
+	for _, file := range doc.Files {
+		if file.Base64 {
+			continue
+		}
+		forEachLine(file.text(), func(n int, line string) {
+			delimiter := candidatePrefix(line, maxBytes)
+			if delimiter == "" {
+				return
+			}
+			if _, seen := conflicts[delimiter]; !seen {
+				conflicts[delimiter] = DelimiterConflict{Delimiter: delimiter, Path: file.Path, Line: n, Text: line}
+			}
+		})
+	}
+
+	analysis := &DelimiterAnalysis{}
+	try := func(delimiter string) bool {
+		conflict, found := conflicts[delimiter]
+		if !found {
+			analysis.Chosen = delimiter
+			return true
+		}
+		analysis.Rejected = append(analysis.Rejected, conflict)
+		return false
+	}
+
+	for length := 1; length <= maxLength; length++ {
+		for _, candidate := range candidates {
+			if try(strings.Repeat(candidate, length)) {
+				return analysis, nil
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < random; i++ {
+		var b strings.Builder
+		for j := 0; j < randomDelimiterRunes; j++ {
+			b.WriteRune(rune(randomDelimiterFirst + rng.Intn(randomDelimiterLast-randomDelimiterFirst+1)))
+		}
+		if try(b.String()) {
+			return analysis, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: all %d candidates up to %d repetitions conflict with file content", ErrNoSafeDelimiter, len(candidates), maxLength)
+}
+
+// FindSafeDelimiter returns the most preferred delimiter in opts that no
+// content line in doc would be mistaken for.
+func FindSafeDelimiter(doc *SiloDocument, opts DelimiterOptions) (string, error) {
+	analysis, err := AnalyzeDelimiterWithOptions(doc, opts)
+	if err != nil {
+		return "", err
+	}
+	return analysis.Chosen, nil
+}
+
+// DelimiterConflicts returns every content line in doc that would be read
+// as a file declaration if doc were written with delimiter, in document
+// order, so a tool can show exactly what rules a delimiter out. Base64
+// entries never conflict.
+func (doc *SiloDocument) DelimiterConflicts(delimiter string) []DelimiterConflict {
+	var conflicts []DelimiterConflict
+	prefix := delimiter + " "
+	for _, file := range doc.Files {
+		if file.Base64 || file.LinkTarget != "" || file.Ref != "" {
+			continue
+		}
+		forEachLine(file.text(), func(n int, line string) {
+			if strings.HasPrefix(line, prefix) {
+				conflicts = append(conflicts, DelimiterConflict{Delimiter: delimiter, Path: file.Path, Line: n, Text: line})
+			}
+		})
+	}
+	return conflicts
+}
+
+// candidatePrefix returns the text before the first space of line, which is
+// the delimiter line would be mistaken for as a file declaration, or "" if
+// there is none of at most maxBytes bytes.
+func candidatePrefix(line string, maxBytes int) string {
+	if len(line) > maxBytes+1 {
+		line = line[:maxBytes+1]
+	}
+	idx := strings.IndexByte(line, ' ')
+	if idx <= 0 || idx > maxBytes {
+		return ""
+	}
+	return line[:idx]
+}
+
+// forEachLine calls fn with the 1-based number and text of each line of
+// content, as strings.Split(content, "\n") would yield them, without
+// allocating the slice.
+func forEachLine(content string, fn func(n int, line string)) {
+	for n := 1; ; n++ {
+		idx := strings.IndexByte(content, '\n')
+		if idx < 0 {
+			fn(n, content)
+			return
+		}
+		fn(n, content[:idx])
+		content = content[idx+1:]
+	}
+}
+
+// delimiterLines returns the 1-based numbers of the lines of content that
+// would be read as a declaration for delimiter, at most max of them when max
+// is positive. It searches for the declaration prefix rather than examining
+// every line, so content without conflicts is scanned once and not copied.
+func delimiterLines(content, delimiter string, max int) []int {
+	prefix := delimiter + " "
+	var lines []int
+	if strings.HasPrefix(content, prefix) {
+		lines = append(lines, 1)
+	}
+
+	needle := "\n" + prefix
+	line, pos := 1, 0
+	for max <= 0 || len(lines) < max {
+		idx := strings.Index(content[pos:], needle)
+		if idx < 0 {
+			break
+		}
+		line += strings.Count(content[pos:pos+idx], "\n") + 1
+		lines = append(lines, line)
+		pos += idx + 1
+	}
+	return lines
+}
+
+func findSafeDelimiter(doc *SiloDocument) (string, error) {
+	return FindSafeDelimiter(doc, DelimiterOptions{})
+}
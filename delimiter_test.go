@@ -0,0 +1,193 @@
+package silo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFindSafeDelimiterCandidates(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.md", Content: "🌾 harvest notes\n> quoted\n"}}}
+
+	tests := []struct {
+		name     string
+		opts     DelimiterOptions
+		expected string
+	}{
+		{"defaults", DelimiterOptions{}, "="},
+		{"emoji first", DelimiterOptions{Candidates: []string{"🌾", "§"}}, "§"},
+		{"emoji repeated", DelimiterOptions{Candidates: []string{"🌾"}}, "🌾🌾"},
+		{"multi-rune candidate", DelimiterOptions{Candidates: []string{"::"}}, "::"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FindSafeDelimiter(doc, test.opts)
+			if err != nil {
+				t.Fatalf("FindSafeDelimiter failed: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindSafeDelimiterRandomFallback(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "> x\n>> y\n"}}}
+
+	first, err := FindSafeDelimiter(doc, DelimiterOptions{Candidates: []string{">"}, MaxLength: 2})
+	if err != nil {
+		t.Fatalf("FindSafeDelimiter failed: %v", err)
+	}
+	if utf8.RuneCountInString(first) != randomDelimiterRunes {
+		t.Errorf("Expected a %d-rune random delimiter, got %q", randomDelimiterRunes, first)
+	}
+	for _, r := range first {
+		if r < randomDelimiterFirst || r > randomDelimiterLast {
+			t.Errorf("Random delimiter %q has rune %U outside the expected block", first, r)
+		}
+	}
+
+	// The same document and options always yield the same delimiter.
+	again, _ := FindSafeDelimiter(doc, DelimiterOptions{Candidates: []string{">"}, MaxLength: 2})
+	if again != first {
+		t.Errorf("Random fallback is not reproducible: %q then %q", first, again)
+	}
+
+	// A document that collides with the first random choice gets the next.
+	doc.Files[0].Content += first + " collides\n"
+	next, err := FindSafeDelimiter(doc, DelimiterOptions{Candidates: []string{">"}, MaxLength: 2})
+	if err != nil || next == first {
+		t.Errorf("Expected a different random delimiter, got %q, %v", next, err)
+	}
+}
+
+func TestFindSafeDelimiterInvalidCandidate(t *testing.T) {
+	doc := &SiloDocument{}
+	for _, candidate := range []string{"", "a b", "\t"} {
+		if _, err := FindSafeDelimiter(doc, DelimiterOptions{Candidates: []string{candidate}}); err == nil {
+			t.Errorf("Expected error for candidate %q", candidate)
+		}
+	}
+}
+
+func TestAnalyzeDelimiterWithOptionsRejected(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "intro\n🌾 one\n"}}}
+
+	analysis, err := AnalyzeDelimiterWithOptions(doc, DelimiterOptions{Candidates: []string{"🌾", ">"}})
+	if err != nil {
+		t.Fatalf("AnalyzeDelimiterWithOptions failed: %v", err)
+	}
+	if analysis.Chosen != ">" || len(analysis.Rejected) != 1 {
+		t.Fatalf("Unexpected analysis: %+v", analysis)
+	}
+	if rejected := analysis.Rejected[0]; rejected.Delimiter != "🌾" || rejected.Line != 2 || rejected.Text != "🌾 one" {
+		t.Errorf("Unexpected rejection: %+v", rejected)
+	}
+}
+
+func TestDelimiterConflicts(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.md", Content: "> quote\nplain\n>not a declaration\n> another\n"},
+		{Path: "b.bin", Content: "> encoded\n", Base64: true},
+		{Path: "c.txt", Content: "= other\n> last"},
+	}}
+
+	got := doc.DelimiterConflicts(">")
+	expected := []DelimiterConflict{
+		{Delimiter: ">", Path: "a.md", Line: 1, Text: "> quote"},
+		{Delimiter: ">", Path: "a.md", Line: 4, Text: "> another"},
+		{Delimiter: ">", Path: "c.txt", Line: 2, Text: "> last"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("DelimiterConflicts(\">\") = %+v, want %+v", got, expected)
+	}
+
+	if got := doc.DelimiterConflicts("🌾"); len(got) != 0 {
+		t.Errorf("Expected no conflicts for 🌾, got %+v", got)
+	}
+}
+
+func TestDelimiterLines(t *testing.T) {
+	tests := []struct {
+		content  string
+		max      int
+		expected []int
+	}{
+		{"plain\ntext\n", 0, nil},
+		{"> first\nok\n> third\n>no space\n", 0, []int{1, 3}},
+		{"a\n\n\n> four\n> five", 0, []int{4, 5}},
+		{"> one\n> two\n", 1, []int{1}},
+		{"", 0, nil},
+	}
+
+	for _, test := range tests {
+		got := delimiterLines(test.content, ">", test.max)
+		if fmt.Sprint(got) != fmt.Sprint(test.expected) {
+			t.Errorf("delimiterLines(%q, %d) = %v, expected %v", test.content, test.max, got, test.expected)
+		}
+
+		// Must agree with a line-by-line check.
+		if test.max == 0 {
+			var want []int
+			for i, line := range strings.Split(test.content, "\n") {
+				if strings.HasPrefix(line, "> ") {
+					want = append(want, i+1)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("delimiterLines(%q) = %v, but splitting gives %v", test.content, got, want)
+			}
+		}
+	}
+}
+
+func TestWriteToAllocations(t *testing.T) {
+	content := strings.Repeat("some fairly ordinary line of source code\n", 1<<20) // 41 MiB
+	doc := &SiloDocument{Delimiter: ">", Files: []SiloFile{{Path: "big.txt", Content: content}}}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := doc.WriteTo(io.Discard); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+
+	// The content must be neither split into lines nor copied.
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("WriteTo allocated %d bytes for %d bytes of content", allocated, len(content))
+	}
+}
+
+func TestWriteToDelimiterCandidates(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "🌾 harvest\n"}}}
+	opts := WriteOptions{DelimiterCandidates: []string{"🌾", "---"}}
+
+	var buf strings.Builder
+	if err := doc.WriteToWithOptions(&buf, opts); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "--- a.txt\n") {
+		t.Errorf("Expected the second house delimiter, got %q", buf.String())
+	}
+
+	// Once the house style is ruled out at a length, the default ladder
+	// is tried before longer house delimiters.
+	doc = &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "🌾 one\n--- two\n"}}}
+	buf.Reset()
+	if err := doc.WriteToWithOptions(&buf, opts); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "> a.txt\n") {
+		t.Errorf("Expected the default delimiter, got %q", buf.String())
+	}
+
+	doc = &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "x\n"}}}
+	if err := doc.WriteToWithOptions(io.Discard, WriteOptions{DelimiterCandidates: []string{"a b"}}); err == nil {
+		t.Error("Expected an error for a candidate with whitespace")
+	}
+}
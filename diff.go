@@ -0,0 +1,176 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines UnifiedDiff shows around
+// each change.
+const diffContext = 3
+
+// UnifiedDiff returns the changes from oldText to newText as a unified diff
+// with three lines of context, as diff -u prints it, labelled with oldName
+// and newName. Use "/dev/null" as a name for a file that does not exist. A
+// missing final newline is marked with "\ No newline at end of file". It
+// returns "" if the texts are equal.
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a, b := splitDiffLines(oldText), splitDiffLines(newText)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the run of ops its hunk covers: changes
+		// closer than twice the context share a hunk.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+		to := last + diffContext + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+		writeHunk(&sb, ops[from:to])
+		start = to
+	}
+	return sb.String()
+}
+
+// diffOp is one line of a line diff: ' ' kept, '-' removed or '+' added.
+// oldLine and newLine are the 0-based positions of the line, or of where it
+// would be, in each text.
+type diffOp struct {
+	kind             byte
+	text             string
+	oldLine, newLine int
+}
+
+// writeHunk writes ops as one hunk with its @@ header.
+func writeHunk(sb *strings.Builder, ops []diffOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].oldLine, oldCount), hunkRange(ops[0].newLine, newCount))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(strings.TrimSuffix(op.text, "\n"))
+		sb.WriteByte('\n')
+		if !strings.HasSuffix(op.text, "\n") {
+			sb.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the start and length of one side of a hunk. An empty
+// range starts at the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitDiffLines splits text into lines, each keeping its "\n".
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script from a to b using Myers'
+// algorithm, with removals ordered before additions within each change.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d..d] as it was before step d.
+	var trace [][]int
+	var x, y int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var ops []diffOp
+	x, y = n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{kind: ' ', text: a[x], oldLine: x, newLine: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{kind: '+', text: b[y], oldLine: x, newLine: y})
+		} else {
+			x--
+			ops = append(ops, diffOp{kind: '-', text: a[x], oldLine: x, newLine: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		ops = append(ops, diffOp{kind: ' ', text: a[x], oldLine: x, newLine: y})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
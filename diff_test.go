@@ -0,0 +1,36 @@
+package silo
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{"equal", "a\n", "a\n", ""},
+		{
+			"change with context",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			"--- old\n+++ new\n" +
+				"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			"new file",
+			"", "a\nb\n",
+			"--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"missing final newline",
+			"a\nb\n", "a\nb",
+			"--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, c := range cases {
+		if got := UnifiedDiff("old", "new", c.old, c.new); got != c.expected {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package silo
+
+import "sort"
+
+// DuplicateGroup lists entries whose content is identical.
+type DuplicateGroup struct {
+	// Paths holds the entries in document order.
+	Paths []string
+	// Bytes is the size of the shared content.
+	Bytes int
+}
+
+// Wasted is the number of bytes the archive would save by storing the
+// content once.
+func (g DuplicateGroup) Wasted() int {
+	return g.Bytes * (len(g.Paths) - 1)
+}
+
+// FindDuplicates groups regular entries with identical, non-empty content.
+// Groups are ordered by wasted bytes, largest first, then by first path.
+func (doc *SiloDocument) FindDuplicates() []DuplicateGroup {
+	byContent := make(map[string]*DuplicateGroup)
+	var order []string
+	for _, file := range doc.Files {
+		if file.Size() == 0 || file.LinkTarget != "" || file.Ref != "" {
+			continue
+		}
+		group, ok := byContent[file.text()]
+		if !ok {
+			content := file.Text()
+			group = &DuplicateGroup{Bytes: len(content)}
+			byContent[content] = group
+			order = append(order, content)
+		}
+		group.Paths = append(group.Paths, file.Path)
+	}
+
+	var groups []DuplicateGroup
+	for _, content := range order {
+		if group := byContent[content]; len(group.Paths) > 1 {
+			groups = append(groups, *group)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
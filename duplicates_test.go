@@ -0,0 +1,37 @@
+package silo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	license := "MIT License\n\nPermission is hereby granted...\n"
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a/LICENSE", Content: license},
+		{Path: "a/stub.go", Content: "package a\n"},
+		{Path: "b/LICENSE", Content: license},
+		{Path: "b/stub.go", Content: "package a\n"},
+		{Path: "c/LICENSE", Content: license},
+		{Path: "empty1", Content: ""},
+		{Path: "empty2", Content: ""},
+		{Path: "link", LinkTarget: "a/LICENSE"},
+		{Path: "unique.txt", Content: "only once\n"},
+	}}
+
+	groups := doc.FindDuplicates()
+	expected := []DuplicateGroup{
+		{Paths: []string{"a/LICENSE", "b/LICENSE", "c/LICENSE"}, Bytes: len(license)},
+		{Paths: []string{"a/stub.go", "b/stub.go"}, Bytes: 10},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("FindDuplicates = %+v, expected %+v", groups, expected)
+	}
+	if groups[0].Wasted() != 2*len(license) {
+		t.Errorf("Wasted = %d, expected %d", groups[0].Wasted(), 2*len(license))
+	}
+
+	if dups := (&SiloDocument{}).FindDuplicates(); len(dups) != 0 {
+		t.Errorf("Expected no duplicates in an empty document, got %v", dups)
+	}
+}
@@ -0,0 +1,55 @@
+package silo
+
+import "fmt"
+
+// indexOf returns the index of the entry with the given path, or -1.
+func (doc *SiloDocument) indexOf(path string) int {
+	for i, file := range doc.Files {
+		if file.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReadFile returns the content of the entry with the given path, or an error
+// matching ErrNotFound if there is none. Reference entries fail with
+// ErrUnresolvedRef until ResolveRefs is called.
+func (doc *SiloDocument) ReadFile(path string) (string, error) {
+	i := doc.indexOf(path)
+	if i < 0 {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if doc.Files[i].Ref != "" {
+		return "", fmt.Errorf("%w: %s @%s", ErrUnresolvedRef, path, doc.Files[i].Ref)
+	}
+	return doc.Files[i].Text(), nil
+}
+
+// Remove deletes the entry with the given path, returning an error matching
+// ErrNotFound if there is none.
+func (doc *SiloDocument) Remove(path string) error {
+	i := doc.indexOf(path)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	doc.Files = append(doc.Files[:i], doc.Files[i+1:]...)
+	return nil
+}
+
+// Rename changes the path of the entry at oldPath to newPath, keeping its
+// position in the document. newPath must be a valid path not already in use.
+func (doc *SiloDocument) Rename(oldPath, newPath string) error {
+	i := doc.indexOf(oldPath)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldPath)
+	}
+	if err := validatePath(newPath); err != nil {
+		return err
+	}
+	if newPath != oldPath && doc.indexOf(newPath) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicatePath, newPath)
+	}
+	doc.Files[i].Path = newPath
+	return nil
+}
@@ -0,0 +1,61 @@
+package silo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "b.txt", Content: "b\n"},
+		{Path: "c.txt", Content: "c\n"},
+	}}
+
+	if err := doc.Remove("b.txt"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if len(doc.Files) != 2 || doc.Files[0].Path != "a.txt" || doc.Files[1].Path != "c.txt" {
+		t.Errorf("Unexpected files after remove: %+v", doc.Files)
+	}
+
+	if err := doc.Remove("missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "b.txt", Content: "b\n"},
+	}}
+
+	if err := doc.Rename("a.txt", "dir/renamed.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if doc.Files[0].Path != "dir/renamed.txt" || doc.Files[0].Content != "a\n" {
+		t.Errorf("Unexpected entry after rename: %+v", doc.Files[0])
+	}
+
+	if err := doc.Rename("dir/renamed.txt", "b.txt"); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath, got %v", err)
+	}
+	if err := doc.Rename("b.txt", "../escape.txt"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+	if err := doc.Rename("missing.txt", "x.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "alpha\n"}}}
+
+	content, err := doc.ReadFile("a.txt")
+	if err != nil || content != "alpha\n" {
+		t.Errorf("Expected alpha content, got %q (%v)", content, err)
+	}
+	if _, err := doc.ReadFile("missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package silo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptedPrefix starts the text header line of an encrypted archive, e.g.
+//
+//	silo-encrypted/1 kdf=pbkdf2-sha256 iter=600000 salt=<base64>
+//
+// The header is followed by the AES-256-GCM nonce and ciphertext of the
+// archive as WriteTo would write it, with the header line as associated data.
+const encryptedPrefix = "silo-encrypted/"
+
+const (
+	encryptedVersion  = 1
+	encryptionKDF     = "pbkdf2-sha256"
+	defaultIterations = 600000
+	saltSize          = 16
+	keySize           = 32
+)
+
+// maxIterations bounds the iteration count read from a header, which an
+// untrusted archive could otherwise set high enough to tie up a CPU core
+// deriving the key.
+const maxIterations = 2000000
+
+// WriteToEncrypted writes doc like WriteTo, encrypted with a key derived from
+// passphrase. Read it back with ParseEncryptedSiloFile.
+func (doc *SiloDocument) WriteToEncrypted(w io.Writer, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+
+	var plaintext bytes.Buffer
+	if err := doc.WriteTo(&plaintext); err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	header := fmt.Sprintf("%s%d kdf=%s iter=%d salt=%s\n", encryptedPrefix, encryptedVersion,
+		encryptionKDF, defaultIterations, base64.RawStdEncoding.EncodeToString(salt))
+
+	aead, err := newArchiveCipher(passphrase, salt, defaultIterations)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return err
+	}
+	_, err = w.Write(aead.Seal(nil, nonce, plaintext.Bytes(), []byte(header)))
+	return err
+}
+
+// ParseEncryptedSiloFile decrypts an archive written by WriteToEncrypted and
+// parses it. A wrong passphrase and a tampered archive both fail with
+// ErrDecryption.
+func ParseEncryptedSiloFile(r io.Reader, passphrase string) (*SiloDocument, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading encryption header: %w", err)
+	}
+	salt, iterations, err := parseEncryptedHeader(strings.TrimSuffix(header, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newArchiveCipher(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: archive is truncated", ErrDecryption)
+	}
+	plaintext, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], []byte(header))
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong passphrase or tampered archive", ErrDecryption)
+	}
+
+	return ParseSiloFile(bytes.NewReader(plaintext))
+}
+
+// isEncryptedHeader reports whether line begins an encrypted archive.
+func isEncryptedHeader(line string) bool {
+	return strings.HasPrefix(line, encryptedPrefix)
+}
+
+func parseEncryptedHeader(line string) (salt []byte, iterations int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !isEncryptedHeader(fields[0]) {
+		return nil, 0, fmt.Errorf("not an encrypted silo archive")
+	}
+	if version := strings.TrimPrefix(fields[0], encryptedPrefix); version != strconv.Itoa(encryptedVersion) {
+		return nil, 0, fmt.Errorf("unsupported encrypted archive version %s", version)
+	}
+
+	values := make(map[string]string)
+	for _, field := range fields[1:] {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			values[key] = value
+		}
+	}
+	if values["kdf"] != encryptionKDF {
+		return nil, 0, fmt.Errorf("unsupported key derivation %q", values["kdf"])
+	}
+	iterations, err = strconv.Atoi(values["iter"])
+	if err != nil || iterations < 1 {
+		return nil, 0, fmt.Errorf("invalid iteration count %q", values["iter"])
+	}
+	if iterations > maxIterations {
+		return nil, 0, fmt.Errorf("iteration count %d is over the maximum of %d", iterations, maxIterations)
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(values["salt"])
+	if err != nil || len(salt) == 0 {
+		return nil, 0, fmt.Errorf("invalid salt %q", values["salt"])
+	}
+	return salt, iterations, nil
+}
+
+func newArchiveCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,67 @@
+package silo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "config/secrets.env", Content: "API_KEY=hunter2\n"},
+		{Path: "README.md", Content: "# Config\n"},
+	}}
+
+	var buf bytes.Buffer
+	if err := doc.WriteToEncrypted(&buf, "correct horse"); err != nil {
+		t.Fatalf("WriteToEncrypted failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "silo-encrypted/1 kdf=pbkdf2-sha256 ") {
+		t.Errorf("Missing recognizable header: %q", buf.String()[:40])
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) {
+		t.Error("Plaintext leaked into the encrypted archive")
+	}
+
+	parsed, err := ParseEncryptedSiloFile(bytes.NewReader(buf.Bytes()), "correct horse")
+	if err != nil {
+		t.Fatalf("ParseEncryptedSiloFile failed: %v", err)
+	}
+	if len(parsed.Files) != 2 || parsed.Files[0].Content != "API_KEY=hunter2\n" {
+		t.Errorf("Unexpected decrypted document: %+v", parsed.Files)
+	}
+
+	if _, err := ParseEncryptedSiloFile(bytes.NewReader(buf.Bytes()), "wrong"); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for wrong passphrase, got %v", err)
+	}
+
+	tampered := append([]byte(nil), buf.Bytes()...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := ParseEncryptedSiloFile(bytes.NewReader(tampered), "correct horse"); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for tampered archive, got %v", err)
+	}
+
+	if _, err := ParseSiloFile(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected ParseSiloFile to report ErrEncrypted, got %v", err)
+	}
+}
+
+func TestWriteToEncryptedRejectsEmptyPassphrase(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "a\n"}}}
+	if err := doc.WriteToEncrypted(&bytes.Buffer{}, ""); err == nil {
+		t.Error("Expected error for empty passphrase")
+	}
+}
+
+func TestParseEncryptedHeaderIterations(t *testing.T) {
+	for _, iter := range []string{"0", "-1", "x", "2000001", "2147483647"} {
+		header := "silo-encrypted/1 kdf=pbkdf2-sha256 iter=" + iter + " salt=c2FsdA"
+		if _, _, err := parseEncryptedHeader(header); err == nil {
+			t.Errorf("Expected iter=%s to be rejected", iter)
+		}
+	}
+	if _, iterations, err := parseEncryptedHeader("silo-encrypted/1 kdf=pbkdf2-sha256 iter=600000 salt=c2FsdA"); err != nil || iterations != 600000 {
+		t.Errorf("Expected 600000 iterations, got %d, %v", iterations, err)
+	}
+}
@@ -0,0 +1,119 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntryHeader is a parsed file declaration line, such as
+// "> src/main.go lang=go", "> current -> main.go" or
+// "> big.bin @file:blobs/big.bin".
+type EntryHeader struct {
+	// Delimiter is the run of non-whitespace characters before the first
+	// space.
+	Delimiter string
+	Path      string
+	// LinkTarget, Ref, Base64 and Attrs are set as in SiloFile.
+	LinkTarget string
+	Ref        string
+	Base64     bool
+	Attrs      map[string]string
+}
+
+// ParseHeaderLine parses one file declaration line the way ParseSiloFile
+// does. Surrounding whitespace is ignored. Trailing "key=value" annotations
+// are split off first, then a " -> target" link, then a trailing "@file:",
+// "@sha256:" or "@base64" marker; what remains is the path, which must pass
+// ValidatePath.
+//
+// A line is only a declaration if it starts with the document's delimiter,
+// which ParseHeaderLine cannot know: it reports whatever delimiter the line
+// starts with, and callers parsing a whole document should compare it.
+// Errors for bad paths match ErrInvalidPath.
+func ParseHeaderLine(line string) (EntryHeader, error) {
+	delim, rest, err := detectDelimiter(line)
+	if err != nil {
+		return EntryHeader{}, err
+	}
+	header, err := parseEntryHeader(rest)
+	if err != nil {
+		return EntryHeader{}, err
+	}
+	header.Delimiter = delim
+	return header, nil
+}
+
+// ValidatePath reports whether path can be used as an entry path: it must be
+// non-empty and relative, and contain no "..", NUL characters, line breaks
+// or " -> ", which a header line reads as a link. Errors match
+// ErrInvalidPath.
+func ValidatePath(path string) error {
+	return validatePath(path)
+}
+
+// parseEntryHeader parses the part of a declaration line after the
+// delimiter and its space.
+func parseEntryHeader(text string) (EntryHeader, error) {
+	text, attrs := splitAttrs(text)
+	path, target := splitLinkTarget(text)
+	if err := checkLinkText(target); err != nil {
+		return EntryHeader{}, err
+	}
+	header := EntryHeader{LinkTarget: target, Attrs: attrs}
+	if target == "" {
+		path, header.Ref = splitRef(path)
+		if header.Ref == base64Marker {
+			header.Ref, header.Base64 = "", true
+		} else if header.Ref != "" {
+			if err := validateRef(header.Ref); err != nil {
+				return EntryHeader{}, err
+			}
+		}
+	}
+	if err := validatePath(path); err != nil {
+		return EntryHeader{}, fmt.Errorf("invalid path: %w", err)
+	}
+	header.Path = path
+	return header, nil
+}
+
+// checkEntry reports why file could not be written as a header line and
+// content and read back unchanged: annotations and front matter that cannot
+// be written, a path or link target that would be read back as another
+// kind of header, and content whose last line would be read back as a
+// marker.
+func checkEntry(file SiloFile) error {
+	if err := validateAttrs(file.Attrs); err != nil {
+		return fmt.Errorf("%s: %w", file.Path, err)
+	}
+	if strings.Contains(file.Path, linkArrow) {
+		return fmt.Errorf("%s: %q in path would be read back as a link", file.Path, linkArrow)
+	}
+	if err := checkLinkText(file.LinkTarget); err != nil {
+		return fmt.Errorf("%s: %w", file.Path, err)
+	}
+	if endsWithAttr(file.Path) || endsWithAttr(file.LinkTarget) {
+		return fmt.Errorf("%s: path ends in a word that would be read back as an annotation", file.Path)
+	}
+	if _, ref := splitRef(file.Path); ref != "" {
+		return fmt.Errorf("%s: path ends in %q, which would be read back as a marker", file.Path, refMarker+ref)
+	}
+	if file.Ref != "" {
+		if err := validateRef(file.Ref); err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+	}
+	if endsWithAttr(file.Ref) {
+		return fmt.Errorf("%s: reference %s ends in a word that would be read back as an annotation", file.Path, file.Ref)
+	}
+	if err := validateMeta(file.Meta); err != nil {
+		return fmt.Errorf("%s: %w", file.Path, err)
+	}
+	if !file.Base64 && file.LinkTarget == "" && file.Ref == "" && endsWithNoNewlineMarker(file.text()) {
+		return fmt.Errorf("%s: last line would be read back as the missing-newline marker (write the entry as base64)", file.Path)
+	}
+	if !file.Base64 && endsWithSignatureTrailer(file.text()) {
+		return fmt.Errorf("%s: last line would be read back as a signature trailer (write the entry as base64)", file.Path)
+	}
+	return nil
+}
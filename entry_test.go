@@ -0,0 +1,55 @@
+package silo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeaderLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected EntryHeader
+	}{
+		{"> main.go", EntryHeader{Delimiter: ">", Path: "main.go"}},
+		{"  🌾 src/my file.txt  ", EntryHeader{Delimiter: "🌾", Path: "src/my file.txt"}},
+		{"== current -> releases/v2.txt", EntryHeader{Delimiter: "==", Path: "current", LinkTarget: "releases/v2.txt"}},
+		{"> big.bin @file:blobs/big.bin", EntryHeader{Delimiter: ">", Path: "big.bin", Ref: "file:blobs/big.bin"}},
+		{"> logo.png @base64 type=image/png", EntryHeader{Delimiter: ">", Path: "logo.png", Base64: true, Attrs: map[string]string{"type": "image/png"}}},
+		{"> src/main.go mode=0755 lang=go", EntryHeader{Delimiter: ">", Path: "src/main.go", Attrs: map[string]string{"mode": "0755", "lang": "go"}}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseHeaderLine(test.line)
+		if err != nil {
+			t.Errorf("ParseHeaderLine(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("ParseHeaderLine(%q) = %+v, want %+v", test.line, got, test.expected)
+		}
+	}
+}
+
+func TestParseHeaderLineErrors(t *testing.T) {
+	for _, line := range []string{"", ">", ">main.go", "> ", "> big.bin @file:../outside"} {
+		if _, err := ParseHeaderLine(line); err == nil {
+			t.Errorf("Expected error for %q", line)
+		}
+	}
+
+	if _, err := ParseHeaderLine("> ../etc/passwd"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func TestValidatePathExported(t *testing.T) {
+	if err := ValidatePath("src/main.go"); err != nil {
+		t.Errorf("ValidatePath failed: %v", err)
+	}
+	for _, path := range []string{"", ".", "/etc/passwd", "../x", "a/../../x", "a\x00b", "a\nb", "a\rb", "a -> b"} {
+		if err := ValidatePath(path); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("ValidatePath(%q) = %v, want ErrInvalidPath", path, err)
+		}
+	}
+}
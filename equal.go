@@ -0,0 +1,96 @@
+package silo
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// DocumentDiff lists how the entries of one document differ from another's,
+// each list sorted by path.
+type DocumentDiff struct {
+	// Added holds paths only in the other document.
+	Added []string
+	// Removed holds paths only in the first document.
+	Removed []string
+	// Modified holds paths in both whose entries differ.
+	Modified []string
+}
+
+// Empty reports whether the documents had no differences.
+func (d DocumentDiff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Modified) == 0
+}
+
+// String lists the differences one per line, as "+ path", "- path" or
+// "~ path".
+func (d DocumentDiff) String() string {
+	var lines []string
+	for _, path := range d.Added {
+		lines = append(lines, "+ "+path)
+	}
+	for _, path := range d.Removed {
+		lines = append(lines, "- "+path)
+	}
+	for _, path := range d.Modified {
+		lines = append(lines, "~ "+path)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff compares doc's entries with other's by path. Two entries with the same
+// path are equal when they are the same kind (file, link or reference) with
+// the same content bytes, link target or reference. Entry order, the
+// delimiter, headers, attributes, whether content is stored as base64 and
+// the CRLF flag are not compared. A path that appears more than once counts
+// with its last entry, as when unpacking.
+func (doc *SiloDocument) Diff(other *SiloDocument) DocumentDiff {
+	mine, theirs := entriesByPath(doc), entriesByPath(other)
+	var diff DocumentDiff
+	for path, file := range mine {
+		otherFile, ok := theirs[path]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, path)
+		case !sameEntry(file, otherFile):
+			diff.Modified = append(diff.Modified, path)
+		}
+	}
+	for path := range theirs {
+		if _, ok := mine[path]; !ok {
+			diff.Added = append(diff.Added, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// Equal reports whether doc and other hold the same entries, as Diff
+// compares them.
+func (doc *SiloDocument) Equal(other *SiloDocument) bool {
+	return doc.Diff(other).Empty()
+}
+
+// entriesByPath indexes doc's entries by path, later entries replacing
+// earlier ones.
+func entriesByPath(doc *SiloDocument) map[string]SiloFile {
+	entries := make(map[string]SiloFile, len(doc.Files))
+	for _, file := range doc.Files {
+		entries[file.Path] = file
+	}
+	return entries
+}
+
+// sameEntry reports whether a and b are the same kind of entry with the same
+// content, link target or reference.
+func sameEntry(a, b SiloFile) bool {
+	switch {
+	case a.LinkTarget != "" || b.LinkTarget != "":
+		return a.LinkTarget == b.LinkTarget
+	case a.Ref != "" || b.Ref != "":
+		return a.Ref == b.Ref
+	}
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
@@ -0,0 +1,54 @@
+package silo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	doc := &SiloDocument{Delimiter: ">", Files: []SiloFile{
+		{Path: "same.txt", Content: "same\n"},
+		{Path: "changed.txt", Content: "old\n"},
+		{Path: "removed.txt", Content: "gone\n"},
+		{Path: "link", LinkTarget: "same.txt"},
+	}}
+	other := &SiloDocument{Delimiter: "🌾", Files: []SiloFile{
+		{Path: "link", LinkTarget: "same.txt"},
+		{Path: "added.txt", Content: "new\n"},
+		{Path: "changed.txt", Content: "new\n"},
+		{Path: "same.txt", ContentBytes: []byte("same\n"), Base64: true},
+	}}
+
+	diff := doc.Diff(other)
+	expected := "+ added.txt\n- removed.txt\n~ changed.txt"
+	if got := diff.String(); got != expected {
+		t.Errorf("Diff = %q, want %q", got, expected)
+	}
+	if doc.Equal(other) {
+		t.Error("Expected documents to differ")
+	}
+}
+
+func TestEqualRoundTrip(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "b.txt", Content: "b\n"},
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "ref.bin", Ref: "file:blobs/ref.bin"},
+	}}
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	parsed, err := ParseSiloFile(&buf)
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if !doc.Equal(parsed) {
+		t.Errorf("Expected round trip to be equal, got:\n%s", doc.Diff(parsed))
+	}
+
+	link := &SiloDocument{Files: []SiloFile{{Path: "ref.bin", LinkTarget: "b.txt"}}}
+	if diff := parsed.Diff(link); diff.String() != "- a.txt\n- b.txt\n~ ref.bin" {
+		t.Errorf("Expected a reference and a link at the same path to differ, got:\n%s", diff)
+	}
+}
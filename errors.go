@@ -0,0 +1,92 @@
+package silo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for use with errors.Is. Errors returned by this package
+// wrap or match these while keeping their descriptive messages.
+var (
+	// ErrInvalidPath marks entry paths that are empty, absolute, contain
+	// parent directory references, or otherwise cannot be unpacked safely.
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrDuplicatePath marks a path that appears more than once in a document.
+	ErrDuplicatePath = errors.New("duplicate path")
+	// ErrNotFound marks a lookup of a path that has no entry in a document.
+	ErrNotFound = errors.New("no such entry")
+	// ErrDelimiterConflict marks a delimiter that collides with file content.
+	// Use errors.As with *DelimiterConflictError for the details.
+	ErrDelimiterConflict = errors.New("delimiter conflicts with content")
+	// ErrNoSafeDelimiter is returned when auto-selection finds no delimiter
+	// that avoids every content line.
+	ErrNoSafeDelimiter = errors.New("unable to find safe delimiter")
+	// ErrUnresolvedRef marks a reference entry that was unpacked without a
+	// RefResolver to fetch its content.
+	ErrUnresolvedRef = errors.New("unresolved content reference")
+	// ErrEncrypted is returned when ParseSiloFile is given an encrypted
+	// archive; use ParseEncryptedSiloFile instead.
+	ErrEncrypted = errors.New("archive is encrypted")
+	// ErrDecryption marks an encrypted archive that could not be opened,
+	// because the passphrase is wrong or the data was modified.
+	ErrDecryption = errors.New("decryption failed")
+	// ErrUnsigned is returned by VerifySignature for an archive without a
+	// signature trailer.
+	ErrUnsigned = errors.New("archive is not signed")
+	// ErrBadSignature is returned by VerifySignature when the archive does
+	// not match its signature or was signed with a different key.
+	ErrBadSignature = errors.New("signature verification failed")
+	// ErrBinaryContent marks a file rejected by BinaryError.
+	ErrBinaryContent = errors.New("binary content")
+	// ErrInvalidPattern marks a glob, include or exclude pattern with bad
+	// syntax. Patterns that would reach outside the working directory match
+	// ErrInvalidPath instead.
+	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrPatchConflict marks a patch hunk whose lines do not match the file
+	// it applies to.
+	ErrPatchConflict = errors.New("patch does not apply")
+)
+
+// DelimiterConflictError is returned by WriteTo when an explicitly chosen
+// delimiter collides with a content line.
+type DelimiterConflictError struct {
+	Delimiter string
+	Path      string
+	// Line is the 1-based line within the file's content.
+	Line int
+	// Suggestion is a delimiter auto-selection would use instead, if any.
+	Suggestion string
+	// SuggestionErr explains why no Suggestion could be found.
+	SuggestionErr error
+}
+
+func (e *DelimiterConflictError) Error() string {
+	if e.Suggestion == "" {
+		return fmt.Sprintf("delimiter %q conflicts with content in file %s, and no safe delimiter could be auto-generated: %v", e.Delimiter, e.Path, e.SuggestionErr)
+	}
+	return fmt.Sprintf("delimiter %q conflicts with content in file %s. Try using auto-generated delimiter %q (remove -d flag) or choose a different delimiter", e.Delimiter, e.Path, e.Suggestion)
+}
+
+// Is reports whether target is ErrDelimiterConflict.
+func (e *DelimiterConflictError) Is(target error) bool {
+	return target == ErrDelimiterConflict
+}
+
+// markedError carries a descriptive message while matching a sentinel error
+// under errors.Is.
+type markedError struct {
+	sentinel error
+	msg      string
+}
+
+func (e *markedError) Error() string {
+	return e.msg
+}
+
+func (e *markedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func invalidPathError(format string, args ...interface{}) error {
+	return &markedError{sentinel: ErrInvalidPath, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,83 @@
+package silo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorsMatchSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sentinel error
+	}{
+		{"absolute path", "> /etc/passwd\n", ErrInvalidPath},
+		{"parent reference", "> a.txt\n\n> ../b.txt\n", ErrInvalidPath},
+		{"duplicate path", "> a.txt\none\n> a.txt\ntwo\n", ErrDuplicatePath},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSiloFile(strings.NewReader(test.input))
+			if !errors.Is(err, test.sentinel) {
+				t.Errorf("Expected errors.Is(%v, %v)", err, test.sentinel)
+			}
+		})
+	}
+}
+
+func TestWriteToDelimiterConflictError(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "ok.txt", Content: "fine\n"},
+			{Path: "conflict.txt", Content: "first\n> second\n"},
+		},
+	}
+
+	var buf strings.Builder
+	err := doc.WriteTo(&buf)
+	if !errors.Is(err, ErrDelimiterConflict) {
+		t.Fatalf("Expected ErrDelimiterConflict, got %v", err)
+	}
+
+	var conflict *DelimiterConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected *DelimiterConflictError, got %T", err)
+	}
+	if conflict.Delimiter != ">" || conflict.Path != "conflict.txt" || conflict.Line != 2 || conflict.Suggestion != "=" {
+		t.Errorf("Unexpected conflict details: %+v", conflict)
+	}
+}
+
+func TestNoSafeDelimiterSentinel(t *testing.T) {
+	content := ""
+	for _, candidate := range DefaultDelimiterCandidates {
+		for length := 1; length <= maxDelimiterLength; length++ {
+			content += strings.Repeat(candidate, length) + " conflicts\n"
+		}
+	}
+	doc := &SiloDocument{Files: []SiloFile{{Path: "impossible.txt", Content: content}}}
+
+	if _, err := FindSafeDelimiter(doc, DelimiterOptions{Random: -1}); !errors.Is(err, ErrNoSafeDelimiter) {
+		t.Errorf("Expected ErrNoSafeDelimiter, got %v", err)
+	}
+}
+
+func TestPatternErrorsMatchSentinels(t *testing.T) {
+	expander, err := NewSecureGlobExpander()
+	if err != nil {
+		t.Fatalf("Failed to create expander: %v", err)
+	}
+
+	if _, err := expander.ExpandPatterns([]string{"../*.txt"}, StandardGlob); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected traversal pattern to match ErrInvalidPath, got %v", err)
+	}
+	if _, err := expander.ExpandPatterns([]string{"src/[a"}, EnhancedGlob); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected malformed pattern to match ErrInvalidPattern, got %v", err)
+	}
+	if err := validateFilterPatterns([]string{"["}); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected malformed filter to match ErrInvalidPattern, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package silo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// FetchTimeout bounds how long ParseSiloURL may take to download and parse
+// an archive, whatever deadline ctx sets.
+const FetchTimeout = 5 * time.Minute
+
+// fetchClient is the HTTP client ParseSiloURL uses.
+var fetchClient = &http.Client{Timeout: FetchTimeout}
+
+// ParseSiloURL downloads and parses a silo file from an http or https URL.
+// Cancellation and shorter timeouts come from ctx, and the download fails
+// after FetchTimeout in any case; opts bounds the size of what is read, so
+// setting opts.MaxTotalSize is recommended for untrusted sources.
+func ParseSiloURL(ctx context.Context, rawURL string, opts ParseOptions) (*SiloDocument, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q: only http and https are allowed", parsed.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	if opts.MaxTotalSize > 0 && resp.ContentLength > opts.MaxTotalSize {
+		return nil, &LimitError{Limit: "MaxTotalSize", Max: opts.MaxTotalSize}
+	}
+
+	return ParseSiloFileWithOptions(resp.Body, opts)
+}
+
+// IsURL reports whether s looks like an http or https URL rather than a
+// local path.
+func IsURL(s string) bool {
+	parsed, err := url.Parse(s)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,94 @@
+package silo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSiloURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/project.silo":
+			w.Write([]byte("> a.txt\nhello\n"))
+		case "/big.silo":
+			w.Write([]byte("> a.txt\n" + strings.Repeat("x", 1000) + "\n"))
+		case "/slow.silo":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("> a.txt\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	doc, err := ParseSiloURL(context.Background(), server.URL+"/project.silo", ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseSiloURL failed: %v", err)
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Content != "hello\n" {
+		t.Errorf("Unexpected document: %+v", doc.Files)
+	}
+
+	if _, err := ParseSiloURL(context.Background(), server.URL+"/missing.silo", ParseOptions{}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected 404 error, got %v", err)
+	}
+
+	_, err = ParseSiloURL(context.Background(), server.URL+"/big.silo", ParseOptions{MaxTotalSize: 100})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Expected *LimitError, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := ParseSiloURL(ctx, server.URL+"/slow.silo", ParseOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestParseSiloURLRejectsOtherSchemes(t *testing.T) {
+	for _, rawURL := range []string{"file:///etc/passwd", "ftp://example.com/a.silo"} {
+		if _, err := ParseSiloURL(context.Background(), rawURL, ParseOptions{}); err == nil {
+			t.Errorf("Expected error for %s", rawURL)
+		}
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/a.silo": true,
+		"http://localhost:8080/x":    true,
+		"project.silo":               false,
+		"dir/http.silo":              false,
+		"file:///a.silo":             false,
+	}
+	for input, expected := range tests {
+		if IsURL(input) != expected {
+			t.Errorf("IsURL(%q) = %v, expected %v", input, !expected, expected)
+		}
+	}
+}
+
+func TestParseSiloURLDefaultTimeout(t *testing.T) {
+	if fetchClient.Timeout != FetchTimeout {
+		t.Errorf("Expected the fetch client to time out after %v, got %v", FetchTimeout, fetchClient.Timeout)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("> a.txt\n"))
+	}))
+	defer server.Close()
+	saved := fetchClient
+	fetchClient = &http.Client{Timeout: 20 * time.Millisecond}
+	defer func() { fetchClient = saved }()
+
+	if _, err := ParseSiloURL(context.Background(), server.URL+"/slow.silo", ParseOptions{}); err == nil {
+		t.Error("Expected a timeout without a deadline on the context")
+	}
+}
@@ -0,0 +1,85 @@
+package silo
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// binarySniffLen is how much of a file IsBinary inspects.
+const binarySniffLen = 8000
+
+// IsBinary reports whether content looks like binary data rather than text:
+// it contains a NUL byte or is not valid UTF-8 within its first 8000 bytes.
+func IsBinary(content []byte) bool {
+	sniff := content
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+		// Don't count a multi-byte character cut off at the boundary.
+		for i := 1; i < utf8.UTFMax; i++ {
+			start := len(sniff) - i
+			if utf8.RuneStart(sniff[start]) {
+				if !utf8.FullRune(sniff[start:]) {
+					sniff = sniff[:start]
+				}
+				break
+			}
+		}
+	}
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(sniff)
+}
+
+// DefaultExcludes are the version control directories, dependency caches
+// and editor and OS droppings that ReadDirectoryTree leaves out unless
+// ReadDirectoryTreeOptions.NoDefaultExcludes is set. They are matched like
+// Exclude patterns, so each applies at any depth.
+var DefaultExcludes = []string{
+	".git",
+	".hg",
+	".svn",
+	"node_modules",
+	"__pycache__",
+	".DS_Store",
+	"*.pyc",
+}
+
+// isHidden reports whether a file or directory name is hidden by the Unix
+// convention of a leading dot.
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// validateFilterPatterns checks Include and Exclude patterns up front, so a
+// typo is reported instead of silently matching nothing.
+func validateFilterPatterns(lists ...[]string) error {
+	for _, patterns := range lists {
+		for _, pattern := range patterns {
+			if !doublestar.ValidatePattern(pattern) {
+				return fmt.Errorf("%w %q", ErrInvalidPattern, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesAnyPattern reports whether relPath matches one of patterns. Patterns
+// use doublestar syntax and are matched against the slash-separated path
+// relative to the tree root; a pattern without a slash also matches the
+// final path element, so "*.log" matches "logs/app.log".
+func matchesAnyPattern(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(pattern, relPath); ok {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := doublestar.Match(pattern, path.Base(relPath)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
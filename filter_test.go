@@ -0,0 +1,236 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := map[string]struct {
+		content []byte
+		binary  bool
+	}{
+		"text":          {[]byte("hello\n"), false},
+		"unicode":       {[]byte("héllo 🌾\n"), false},
+		"empty":         {nil, false},
+		"nul":           {[]byte("PNG\x00\x01"), true},
+		"invalid utf-8": {[]byte{0xff, 0xfe, 'a'}, true},
+		// A multi-byte rune cut by the sniff boundary is not binary.
+		"boundary": {[]byte(strings.Repeat("a", binarySniffLen-1) + "é"), false},
+	}
+	for name, test := range tests {
+		if got := IsBinary(test.content); got != test.binary {
+			t.Errorf("%s: IsBinary = %v, expected %v", name, got, test.binary)
+		}
+	}
+}
+
+func setupFilterTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":                 "package main\n",
+		"util/util.go":            "package util\n",
+		"util/util_test.go":       "package util\n",
+		"README.md":               "# readme\n",
+		"logs/app.log":            "log line\n",
+		"node_modules/x/index.js": "module.exports = 1\n",
+		"image.png":               "\x89PNG\r\n\x1a\n\x00\x00",
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadDirectoryTreeIncludeExclude(t *testing.T) {
+	dir := setupFilterTree(t)
+
+	skipped := map[string]string{}
+	doc, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{
+		Include: []string{"**/*.go", "*.md"},
+		Exclude: []string{"*_test.go", "node_modules"},
+		OnSkip:  func(path, reason string) { skipped[path] = reason },
+	})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+
+	expected := []string{"README.md", "main.go", "util/util.go"}
+	if paths := docPaths(doc); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+	if skipped["util/util_test.go"] != "excluded" || skipped["logs/app.log"] != "not included" {
+		t.Errorf("Unexpected skip reasons: %v", skipped)
+	}
+	if _, ok := skipped["node_modules/x/index.js"]; ok {
+		t.Error("Files in excluded directories should be pruned, not reported one by one")
+	}
+
+	if _, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{Exclude: []string{"[unclosed"}}); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestReadDirectoryTreeSkipBinary(t *testing.T) {
+	dir := setupFilterTree(t)
+
+	var skipped []string
+	doc, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{
+		SkipBinary: true,
+		OnSkip:     func(path, reason string) { skipped = append(skipped, path+": "+reason) },
+	})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	for _, path := range docPaths(doc) {
+		if path == "image.png" {
+			t.Error("Binary file should have been skipped")
+		}
+	}
+	if !reflect.DeepEqual(skipped, []string{"image.png: binary"}) {
+		t.Errorf("Unexpected skips: %v", skipped)
+	}
+}
+
+func TestReadDirectoryTreeMaxFileSize(t *testing.T) {
+	dir := setupFilterTree(t)
+
+	_, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{MaxFileSize: 15, NoDefaultExcludes: true})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected *LimitError, got %v", err)
+	}
+	if limitErr.Limit != "MaxFileSize" || limitErr.Path != "node_modules/x/index.js" {
+		t.Errorf("Unexpected limit error: %+v", limitErr)
+	}
+}
+
+func TestReadDirectoryTreeDefaultExcludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		".git/HEAD",
+		"sub/.svn/entries",
+		"web/node_modules/x/index.js",
+		"app/__pycache__/app.cpython-312.pyc",
+		"app/stale.pyc",
+		"assets/.DS_Store",
+		".github/workflows/ci.yml",
+	} {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := ReadDirectoryTree(dir)
+	if err != nil {
+		t.Fatalf("ReadDirectoryTree failed: %v", err)
+	}
+	if got, want := docPaths(doc), []string{".github/workflows/ci.yml", "main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	doc, err = ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{NoDefaultExcludes: true})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if len(doc.Files) != 8 {
+		t.Errorf("Expected all 8 files with NoDefaultExcludes, got %v", docPaths(doc))
+	}
+
+	// The root itself is never excluded.
+	doc, err = ReadDirectoryTree(filepath.Join(dir, ".git"))
+	if err != nil {
+		t.Fatalf("ReadDirectoryTree failed: %v", err)
+	}
+	if got := docPaths(doc); !reflect.DeepEqual(got, []string{"HEAD"}) {
+		t.Errorf("Expected [HEAD] when packing .git itself, got %v", got)
+	}
+}
+
+func TestReadDirectoryTreeSkipHidden(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", ".env", ".vscode/settings.json", "web/.eslintrc", "web/app.js"} {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := ReadDirectoryTree(dir)
+	if err != nil {
+		t.Fatalf("ReadDirectoryTree failed: %v", err)
+	}
+	if len(doc.Files) != 5 {
+		t.Errorf("Expected hidden files to be packed by default, got %v", docPaths(doc))
+	}
+
+	var report PackReport
+	doc, err = ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{SkipHidden: true, Report: &report})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if got, want := docPaths(doc), []string{"main.go", "web/app.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	wantSkipped := []SkipReason{
+		{Path: ".env", Reason: SkipHidden},
+		{Path: ".vscode/", Reason: SkipHidden},
+		{Path: "web/.eslintrc", Reason: SkipHidden},
+	}
+	if !reflect.DeepEqual(report.Skipped, wantSkipped) {
+		t.Errorf("Expected skips %v, got %v", wantSkipped, report.Skipped)
+	}
+
+	// A hidden root is still read.
+	doc, err = ReadDirectoryTreeWithOptions(filepath.Join(dir, ".vscode"), ReadDirectoryTreeOptions{SkipHidden: true})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if got := docPaths(doc); !reflect.DeepEqual(got, []string{"settings.json"}) {
+		t.Errorf("Expected [settings.json] for a hidden root, got %v", got)
+	}
+}
+
+func TestReadDirectoryTreeMaxFiles(t *testing.T) {
+	dir := setupFilterTree(t)
+
+	_, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{MaxFiles: 2})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected *LimitError, got %v", err)
+	}
+	if limitErr.Limit != "MaxFiles" || limitErr.Max != 2 {
+		t.Errorf("Unexpected limit error: %+v", limitErr)
+	}
+
+	all, err := ReadDirectoryTree(dir)
+	if err != nil {
+		t.Fatalf("ReadDirectoryTree failed: %v", err)
+	}
+	doc, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{MaxFiles: len(all.Files)})
+	if err != nil {
+		t.Fatalf("Expected a read of exactly MaxFiles files to succeed, got %v", err)
+	}
+	if len(doc.Files) != len(all.Files) {
+		t.Errorf("Expected %d files, got %d", len(all.Files), len(doc.Files))
+	}
+}
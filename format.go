@@ -0,0 +1,60 @@
+package silo
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FormatOptions configures FormatWithOptions.
+type FormatOptions struct {
+	// KeepDelimiter keeps the archive's delimiter instead of the one
+	// auto-selection would choose.
+	KeepDelimiter bool
+	// TrimBlankLines removes blank lines at the end of each text entry.
+	// Hand-written archives often use them to separate entries, but they
+	// are part of the entry's content, so they are kept by default.
+	TrimBlankLines bool
+}
+
+// Format parses the archive in src and returns it in canonical form:
+// entries sorted by cleaned path as Normalize leaves them, the delimiter
+// auto-selection would choose, and no blank lines before the first entry or
+// extra spaces in declaration lines. A format header is kept and updated.
+// Content is kept byte for byte, including a missing final newline, so
+// formatting never changes what unpacks; formatting a formatted archive
+// returns it unchanged. A signature trailer is dropped, since the signed
+// bytes change.
+func Format(src []byte) ([]byte, error) {
+	return FormatWithOptions(src, FormatOptions{})
+}
+
+// FormatWithOptions is Format with the choices in opts.
+func FormatWithOptions(src []byte, opts FormatOptions) ([]byte, error) {
+	doc, err := ParseSiloFile(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+
+	doc.NormalizeWithOptions(NormalizeOptions{KeepMissingNewlines: true})
+	if opts.TrimBlankLines {
+		for i := range doc.Files {
+			file := &doc.Files[i]
+			if file.Base64 || !strings.HasSuffix(file.Content, "\n") {
+				continue
+			}
+			file.Content = strings.TrimRight(file.Content, "\n")
+			if file.Content != "" {
+				file.Content += "\n"
+			}
+		}
+	}
+	if !opts.KeepDelimiter {
+		doc.Delimiter = ""
+	}
+
+	var buf bytes.Buffer
+	if err := doc.WriteToWithOptions(&buf, WriteOptions{MarkMissingNewline: true}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
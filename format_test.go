@@ -0,0 +1,71 @@
+package silo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src := "\n\n>   b.txt  \nsecond\n\n> ./a.txt\nfirst\n> c.txt\nno newline\n\\ No newline at end of file\n"
+	expected := "> a.txt\nfirst\n> b.txt\nsecond\n\n> c.txt\nno newline\n\\ No newline at end of file\n"
+
+	got, err := Format([]byte(src))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(got) != expected {
+		t.Errorf("Format() =\n%q\nwant\n%q", got, expected)
+	}
+
+	again, err := Format(got)
+	if err != nil {
+		t.Fatalf("Format of formatted archive failed: %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("Format is not idempotent:\n%q\nthen\n%q", got, again)
+	}
+}
+
+func TestFormatDelimiter(t *testing.T) {
+	src := "=== a.txt\n> quoted\n"
+
+	got, err := Format([]byte(src))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if !strings.HasPrefix(string(got), "= a.txt\n") {
+		t.Errorf("Expected the auto-selected delimiter, got %q", got)
+	}
+
+	kept, err := FormatWithOptions([]byte(src), FormatOptions{KeepDelimiter: true})
+	if err != nil {
+		t.Fatalf("FormatWithOptions failed: %v", err)
+	}
+	if string(kept) != src {
+		t.Errorf("Expected %q unchanged, got %q", src, kept)
+	}
+}
+
+func TestFormatTrimBlankLines(t *testing.T) {
+	src := "> a.txt\nfirst\n\n\n> b.txt\n\n\n> c.txt\nlast\n"
+
+	got, err := FormatWithOptions([]byte(src), FormatOptions{TrimBlankLines: true})
+	if err != nil {
+		t.Fatalf("FormatWithOptions failed: %v", err)
+	}
+	if expected := "> a.txt\nfirst\n> b.txt\n> c.txt\nlast\n"; string(got) != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatKeepsHeaderAndCRLF(t *testing.T) {
+	src := "silo/1 delimiter=> files=2\n> b.txt\r\nwindows\r\n> a.txt\nunix\n"
+
+	got, err := Format([]byte(src))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if expected := "silo/1 delimiter=> files=2\n> a.txt\nunix\n> b.txt\nwindows\r\n"; string(got) != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
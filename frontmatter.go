@@ -0,0 +1,141 @@
+package silo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// attrMeta is the annotation marking an entry whose content starts with a
+// front-matter block, as in "> docs/intro.md meta=yaml".
+const attrMeta = "meta"
+
+// metaYAML is the only attrMeta value.
+const metaYAML = "yaml"
+
+// frontMatterFence opens and closes a front-matter block.
+const frontMatterFence = "---"
+
+// takeMetaAttr removes a meta=yaml annotation read from an entry's header
+// line and reports whether there was one. Other values stay ordinary
+// annotations.
+func (f *SiloFile) takeMetaAttr() bool {
+	if f.Attrs[attrMeta] != metaYAML {
+		return false
+	}
+	attrs := make(map[string]string, len(f.Attrs)-1)
+	for key, value := range f.Attrs {
+		if key != attrMeta {
+			attrs[key] = value
+		}
+	}
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	f.Attrs = attrs
+	return true
+}
+
+// takeFrontMatter parses the front-matter block at the start of an entry's
+// content lines: a "---" line, "key: value" lines, and a closing "---".
+// It returns the metadata and the content lines after the block.
+func takeFrontMatter(lines []contentLine) (map[string]string, []contentLine, error) {
+	if len(lines) == 0 || lines[0].text != frontMatterFence {
+		return nil, nil, errors.New("front matter must start with a --- line")
+	}
+	meta := make(map[string]string)
+	for i := 1; i < len(lines); i++ {
+		line := lines[i].text
+		if line == frontMatterFence {
+			return meta, lines[i+1:], nil
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, err := parseMetaLine(line)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, dup := meta[key]; dup {
+			return nil, nil, fmt.Errorf("duplicate front matter key %q", key)
+		}
+		meta[key] = value
+	}
+	return nil, nil, errors.New("front matter is missing its closing --- line")
+}
+
+// parseMetaLine parses a "key: value" front-matter line. The value may be
+// plain, ending at a " #" comment, or quoted in single or double quotes.
+func parseMetaLine(line string) (key, value string, err error) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok || !attrKeyPattern.MatchString(key) {
+		return "", "", fmt.Errorf("front matter line %q is not \"key: value\"", line)
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if value, err = strconv.Unquote(value); err != nil {
+			return "", "", fmt.Errorf("front matter %s: bad double-quoted value", key)
+		}
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", "", fmt.Errorf("front matter %s: bad single-quoted value", key)
+		}
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return key, value, nil
+}
+
+// validateMeta checks that every metadata key and value can be written as
+// front matter and read back unchanged.
+func validateMeta(meta map[string]string) error {
+	for key, value := range meta {
+		if !attrKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid front matter key %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("front matter %s must be a single line", key)
+		}
+	}
+	return nil
+}
+
+// writeFrontMatter writes meta as a front-matter block, keys sorted. It
+// writes nothing for empty meta.
+func writeFrontMatter(w io.StringWriter, meta map[string]string) error {
+	if len(meta) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(frontMatterFence + "\n")
+	for _, key := range keys {
+		b.WriteString(key + ": " + formatMetaValue(meta[key]) + "\n")
+	}
+	b.WriteString(frontMatterFence + "\n")
+	_, err := w.WriteString(b.String())
+	return err
+}
+
+// formatMetaValue writes value plainly when parseMetaLine reads it back
+// unchanged, and double-quoted otherwise.
+func formatMetaValue(value string) string {
+	plain := value != "" && value == strings.TrimSpace(value) &&
+		!strings.ContainsAny(value[:1], `"'#[{&*!|>%@`+"`") &&
+		!strings.Contains(value, " #") && !strings.Contains(value, ": ")
+	if plain {
+		return value
+	}
+	return strconv.Quote(value)
+}
@@ -0,0 +1,123 @@
+package silo
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFrontMatterRoundTrip(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{
+				Path:    "docs/intro.md",
+				Content: "---\ntitle: kept as content\n---\n# Intro\n",
+				Attrs:   map[string]string{"lang": "markdown"},
+				Meta: map[string]string{
+					"description": "How to get started: read this first",
+					"owner":       "docs-team",
+					"tags":        "[onboarding, guide]",
+					"note":        " padded ",
+					"empty":       "",
+				},
+			},
+			{Path: "plain.md", Content: "---\nnot: meta\n---\n"},
+			{Path: "current.txt", LinkTarget: "releases/v2.txt", Meta: map[string]string{"owner": "ops"}},
+			{Path: "logo.png", Content: "\x00PNG", Base64: true, Meta: map[string]string{"description": "logo"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	written := buf.String()
+	want := "> docs/intro.md lang=markdown meta=yaml\n---\n" +
+		"description: \"How to get started: read this first\"\n" +
+		"empty: \"\"\n" +
+		"note: \" padded \"\n" +
+		"owner: docs-team\n" +
+		"tags: \"[onboarding, guide]\"\n" +
+		"---\n"
+	if !strings.HasPrefix(written, want) {
+		t.Errorf("Expected the archive to start with\n%s\ngot\n%s", want, written)
+	}
+	if !strings.Contains(written, "> plain.md\n---\nnot: meta\n") {
+		t.Errorf("Expected content starting with --- to stay content, got\n%s", written)
+	}
+
+	parsed, err := ParseSiloFile(strings.NewReader(written))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Files, doc.Files) {
+		t.Errorf("Round trip changed the entries:\n%+v\nexpected\n%+v", parsed.Files, doc.Files)
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	input := "> a.md meta=yaml\n---\n# who looks after it\nowner: alice # inline comment\nquoted: 'it''s'\n\nescaped: \"tab\\there\"\n---\nbody\n"
+	doc, err := ParseSiloFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	file := doc.Files[0]
+	want := map[string]string{"owner": "alice", "quoted": "it's", "escaped": "tab\there"}
+	if !reflect.DeepEqual(file.Meta, want) {
+		t.Errorf("Expected meta %v, got %v", want, file.Meta)
+	}
+	if file.Content != "body\n" || file.Attrs != nil {
+		t.Errorf("Expected content %q and no annotations, got %q and %v", "body\n", file.Content, file.Attrs)
+	}
+
+	// Other values of the annotation are ordinary annotations.
+	doc, err = ParseSiloFile(strings.NewReader("> a.md meta=toml\n---\n"))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Files[0].Meta != nil || doc.Files[0].Attrs["meta"] != "toml" || doc.Files[0].Content != "---\n" {
+		t.Errorf("Expected meta=toml to be left alone, got %+v", doc.Files[0])
+	}
+}
+
+func TestParseFrontMatterErrors(t *testing.T) {
+	tests := map[string]string{
+		"no opening fence":  "> a.md meta=yaml\nowner: a\n---\n",
+		"no closing fence":  "> a.md meta=yaml\n---\nowner: a\n> b.md\nb\n",
+		"not key value":     "> a.md meta=yaml\n---\n- item\n---\n",
+		"bad key":           "> a.md meta=yaml\n---\nbad key: a\n---\n",
+		"duplicate key":     "> a.md meta=yaml\n---\nowner: a\nowner: b\n---\n",
+		"bad double quotes": "> a.md meta=yaml\n---\nowner: \"a\n---\n",
+		"bad single quotes": "> a.md meta=yaml\n---\nowner: 'a\n---\n",
+	}
+	for name, input := range tests {
+		_, err := ParseSiloFile(strings.NewReader(input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != 1 {
+			t.Errorf("%s: expected a *ParseError on line 1, got %v", name, err)
+		}
+	}
+}
+
+func TestWriteInvalidMeta(t *testing.T) {
+	for _, meta := range []map[string]string{
+		{"bad key": "a"},
+		{"owner": "two\nlines"},
+	} {
+		doc := &SiloDocument{Delimiter: ">", Files: []SiloFile{{Path: "a.md", Content: "a\n", Meta: meta}}}
+		if err := doc.WriteTo(&bytes.Buffer{}); err == nil {
+			t.Errorf("Expected an error writing meta %q", meta)
+		}
+	}
+}
+
+func TestWriteMetaLikePath(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "notes meta=yaml", Content: "x\n"}}}
+	var out bytes.Buffer
+	if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+		t.Errorf("Expected WriteTo to refuse the path, wrote %q", out.String())
+	}
+}
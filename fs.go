@@ -0,0 +1,176 @@
+package silo
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLinkHops bounds how many link entries FS follows when resolving a path,
+// so that link cycles inside an archive fail instead of looping.
+const maxLinkHops = 40
+
+// FS returns a read-only fs.FS view of the document. Directories are implied
+// by entry paths, and link entries are followed when their target is another
+// entry in the document. Reference entries cannot be opened until the
+// document's ResolveRefs has been called. The view is a snapshot: later changes to doc.Files
+// are not reflected.
+func (doc *SiloDocument) FS() fs.FS {
+	fsys := &docFS{
+		files: make(map[string]*SiloFile),
+		dirs:  map[string]map[string]bool{".": {}},
+	}
+	for i := range doc.Files {
+		file := doc.Files[i]
+		fsys.files[file.Path] = &file
+
+		child := file.Path
+		for {
+			parent := path.Dir(child)
+			if fsys.dirs[parent] == nil {
+				fsys.dirs[parent] = make(map[string]bool)
+			}
+			fsys.dirs[parent][path.Base(child)] = true
+			if parent == "." {
+				break
+			}
+			child = parent
+		}
+	}
+	return fsys
+}
+
+type docFS struct {
+	files map[string]*SiloFile
+	dirs  map[string]map[string]bool
+}
+
+func (fsys *docFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	resolved, ok := fsys.resolve(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if file, ok := fsys.files[resolved]; ok {
+		if file.Ref != "" {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: ErrUnresolvedRef}
+		}
+		return &docFile{
+			info:   docFileInfo{name: path.Base(name), size: int64(file.Size())},
+			Reader: strings.NewReader(file.text()),
+		}, nil
+	}
+
+	names := make([]string, 0, len(fsys.dirs[resolved]))
+	for child := range fsys.dirs[resolved] {
+		names = append(names, child)
+	}
+	sort.Strings(names)
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, child := range names {
+		info, ok := fsys.stat(path.Join(resolved, child))
+		if !ok {
+			// Dangling or cyclic link; leave it out of listings.
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return &docDir{info: docFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// resolve follows link entries in name until it reaches a regular entry or
+// a directory, reporting false if nothing exists there.
+func (fsys *docFS) resolve(name string) (string, bool) {
+	for hops := 0; hops <= maxLinkHops; hops++ {
+		if _, isDir := fsys.dirs[name]; isDir {
+			return name, true
+		}
+		file, ok := fsys.files[name]
+		if !ok {
+			return "", false
+		}
+		if file.LinkTarget == "" {
+			return name, true
+		}
+		name = path.Join(path.Dir(name), file.LinkTarget)
+		if !fs.ValidPath(name) {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func (fsys *docFS) stat(name string) (fs.FileInfo, bool) {
+	resolved, ok := fsys.resolve(name)
+	if !ok {
+		return nil, false
+	}
+	if file, ok := fsys.files[resolved]; ok {
+		return docFileInfo{name: path.Base(name), size: int64(file.Size())}, true
+	}
+	return docFileInfo{name: path.Base(name), dir: true}, true
+}
+
+type docFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi docFileInfo) Name() string       { return fi.name }
+func (fi docFileInfo) Size() int64        { return fi.size }
+func (fi docFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi docFileInfo) IsDir() bool        { return fi.dir }
+func (fi docFileInfo) Sys() any           { return nil }
+
+func (fi docFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+type docFile struct {
+	info docFileInfo
+	*strings.Reader
+}
+
+func (f *docFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *docFile) Close() error               { return nil }
+
+type docDir struct {
+	info    docFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *docDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *docDir) Close() error               { return nil }
+
+func (d *docDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *docDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,66 @@
+package silo
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDocumentFS(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "index.html", Content: "<h1>hi</h1>\n"},
+		{Path: "css/site.css", Content: "body {}\n"},
+		{Path: "css/deep/theme.css", Content: "a {}\n"},
+		{Path: "latest.html", LinkTarget: "index.html"},
+	}}
+	fsys := doc.FS()
+
+	if err := fstest.TestFS(fsys, "index.html", "css/site.css", "css/deep/theme.css", "latest.html"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fsys, "latest.html")
+	if err != nil {
+		t.Fatalf("ReadFile through link failed: %v", err)
+	}
+	if string(data) != "<h1>hi</h1>\n" {
+		t.Errorf("Expected link to resolve to index.html, got %q", data)
+	}
+
+	if _, err := fs.ReadFile(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("../escape"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected fs.ErrInvalid, got %v", err)
+	}
+}
+
+func TestDocumentFSDanglingLinks(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a", LinkTarget: "b"},
+		{Path: "b", LinkTarget: "a"},
+		{Path: "out", LinkTarget: "../../etc/passwd"},
+		{Path: "ok.txt", Content: "ok\n"},
+	}}
+	fsys := doc.FS()
+
+	for _, name := range []string{"a", "out"} {
+		if _, err := fsys.Open(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "ok.txt" {
+		t.Errorf("Expected only ok.txt to be listed, got %v", names)
+	}
+}
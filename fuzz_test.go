@@ -0,0 +1,94 @@
+package silo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are archives that exercise the parser's corners: unicode and
+// repeated delimiters, content that collides with a delimiter, long lines,
+// CRLF and missing newlines, attributes, links, references and base64.
+// Inputs that once failed are kept in testdata/fuzz.
+var fuzzSeeds = []string{
+	"",
+	">",
+	"> a.txt\nhello\n",
+	"> a.txt\nhello",
+	">>> a.txt\n> not a declaration\n>>> b.txt\n",
+	"🌾 a.txt\nwheat\n🌾 dir/b.txt\n🌾\n",
+	"🌾🌾 a.txt\n🌾 b.txt\n",
+	"> a.txt\r\nline one\r\nline two\r\n",
+	"> mixed.txt\r\none\ntwo\r\n",
+	"> long.txt\n" + strings.Repeat("x", 1<<16) + "\n",
+	strings.Repeat(">", 1<<16) + " long-delimiter.txt\n",
+	"> a.txt\n\n\n\n> b.txt\n",
+	"> src/main.go mode=0755 lang=go\npackage main\n",
+	"> link -> target.txt\n> target.txt\nt\n",
+	"> big.bin @file:blobs/big.bin\n",
+	"> img.png @base64\niVBORw0KGgo=\n",
+	"> a.txt\n> a.txt\n",
+	"> ../escape.txt\nx\n",
+	"> /abs.txt\nx\n",
+	"> a\x00b\nx\n",
+	"--- a.txt\n-- looks like a delimiter\n--- b.txt\n",
+	"#silo v0.2 delimiter=> files=1\n> a.txt\nx\n",
+	"> a.txt \n",
+	" > indented.txt\nx\n",
+	"\xff\xfe> a.txt\n",
+	"> a.txt meta=yaml\n---\nowner: me\n---\nbody\n",
+	"> a.txt truncated=3\nfirst\n",
+}
+
+func FuzzParseSiloFile(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, policy := range []LineEndingPolicy{LineEndingsAuto, LineEndingsPreserve} {
+			doc, err := ParseSiloFileWithOptions(bytes.NewReader(data), ParseOptions{LineEndings: policy})
+			if err != nil {
+				continue
+			}
+			doc.Validate()
+			doc.Lint()
+		}
+	})
+}
+
+// FuzzRoundTrip checks that writing a parsed archive and parsing the result
+// gives back the same entries.
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := ParseSiloFile(bytes.NewReader(data))
+		if err != nil || len(doc.Validate()) > 0 {
+			return
+		}
+		var buf bytes.Buffer
+		if err := doc.WriteToWithOptions(&buf, WriteOptions{MarkMissingNewline: true}); err != nil {
+			t.Fatalf("writing a valid parsed archive failed: %v\ninput: %q", err, data)
+		}
+		reparsed, err := ParseSiloFile(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("reparsing failed: %v\ninput: %q\nwritten: %q", err, data, buf.Bytes())
+		}
+		if len(reparsed.Files) != len(doc.Files) {
+			t.Fatalf("expected %d files after round trip, got %d\ninput: %q\nwritten: %q", len(doc.Files), len(reparsed.Files), data, buf.Bytes())
+		}
+		for i, file := range doc.Files {
+			got := reparsed.Files[i]
+			// CRLF means nothing for content without line endings.
+			crlfChanged := got.CRLF != file.CRLF && strings.Contains(file.Text(), "\n")
+			if got.Path != file.Path || got.Text() != file.Text() || got.LinkTarget != file.LinkTarget ||
+				got.Ref != file.Ref || got.Base64 != file.Base64 || crlfChanged || got.Truncated != file.Truncated ||
+				len(got.Attrs)+len(file.Attrs) > 0 && !reflect.DeepEqual(got.Attrs, file.Attrs) ||
+				len(got.Meta)+len(file.Meta) > 0 && !reflect.DeepEqual(got.Meta, file.Meta) {
+				t.Fatalf("entry %d changed in round trip: %+v became %+v\ninput: %q\nwritten: %q", i, file, got, data, buf.Bytes())
+			}
+		}
+	})
+}
@@ -1,11 +1,14 @@
 package silo
 
 import (
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 	"os"
 	"net/url"
+	"runtime"
 	
 	"github.com/bmatcuk/doublestar/v4"
 )
@@ -14,8 +17,20 @@ import (
 type SecureGlobExpander struct {
 	// AllowAbsolute controls whether absolute paths are allowed (default: false)
 	AllowAbsolute bool
-	// WorkingDir is the base directory for relative path validation
+	// AllowedRoots lists directories, besides WorkingDir, whose absolute
+	// paths are allowed in patterns and results when AllowAbsolute is false,
+	// such as a shared template directory.
+	AllowedRoots []string
+	// WorkingDir is the directory relative patterns are expanded in and
+	// results must stay inside. Matches are returned relative to it. Empty
+	// means the process working directory.
 	WorkingDir string
+	// FollowSymlinks makes ** descend into symlinked directories, reporting
+	// a cycle as an error. By default they are matched but not descended.
+	FollowSymlinks bool
+	// CaseInsensitive matches patterns without regard to case, as the
+	// filesystems of macOS and Windows usually do.
+	CaseInsensitive bool
 }
 
 // NewSecureGlobExpander creates a new expander with default security settings
@@ -38,127 +53,196 @@ func (sge *SecureGlobExpander) ValidatePattern(pattern string) error {
 		if decoded, err := url.QueryUnescape(pattern); err == nil {
 			// Recursively validate the decoded pattern
 			if err := sge.ValidatePattern(decoded); err != nil {
-				return fmt.Errorf("URL-encoded pattern contains forbidden content: %s", pattern)
+				return invalidPathError("URL-encoded pattern contains forbidden content: %s", pattern)
 			}
 		}
 	}
 	
-	// Check for absolute paths (forbidden by spec)
-	if filepath.IsAbs(pattern) && !sge.AllowAbsolute {
-		return fmt.Errorf("absolute paths not allowed: %s", pattern)
+	// Check for absolute paths (forbidden by spec, unless under an allowed root)
+	absoluteAllowed := sge.absoluteAllowed(pattern)
+	if filepath.IsAbs(pattern) && !absoluteAllowed {
+		return invalidPathError("absolute paths not allowed: %s", pattern)
 	}
 	
 	// Check for parent directory references (forbidden by spec)
 	if strings.Contains(pattern, "..") {
-		return fmt.Errorf("parent directory references not allowed: %s", pattern)
+		return invalidPathError("parent directory references not allowed: %s", pattern)
 	}
 	
 	// Check for leading slash on non-Windows (indicates absolute path)
-	if strings.HasPrefix(pattern, "/") && !sge.AllowAbsolute {
-		return fmt.Errorf("absolute paths not allowed: %s", pattern)
+	if strings.HasPrefix(pattern, "/") && !absoluteAllowed {
+		return invalidPathError("absolute paths not allowed: %s", pattern)
 	}
 	
 	// Check for Windows drive letters (C:, D:, etc.)
-	if len(pattern) >= 2 && pattern[1] == ':' && 
+	if len(pattern) >= 2 && pattern[1] == ':' && !absoluteAllowed &&
 		((pattern[0] >= 'A' && pattern[0] <= 'Z') || (pattern[0] >= 'a' && pattern[0] <= 'z')) {
-		return fmt.Errorf("drive letters not allowed: %s", pattern)
+		return invalidPathError("drive letters not allowed: %s", pattern)
 	}
 	
 	// Additional checks for dangerous patterns
 	if strings.Contains(pattern, "\\..\\") || strings.Contains(pattern, "/../") {
-		return fmt.Errorf("path traversal attempt detected: %s", pattern)
+		return invalidPathError("path traversal attempt detected: %s", pattern)
 	}
 	
 	return nil
 }
 
-// ValidatePath checks if a resolved path is safe according to Silo spec
+// ValidatePath checks if a resolved path is safe according to Silo spec.
+// Absolute paths must be inside WorkingDir or one of AllowedRoots, unless
+// AllowAbsolute is set, and so must the real path of an existing path once
+// its symlinks are resolved.
 func (sge *SecureGlobExpander) ValidatePath(path string) error {
+	if filepath.IsAbs(path) {
+		if sge.absoluteAllowed(path) {
+			return sge.validateSymlinks(path)
+		}
+		
+		absWorkingDir, err := filepath.Abs(sge.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve working directory: %w", err)
+		}
+		if !withinRoot(path, absWorkingDir) {
+			return invalidPathError("path %s resolves outside working directory", path)
+		}
+		return sge.validateSymlinks(path)
+	}
+	
 	// First check the pattern itself for obvious violations
 	if err := sge.ValidatePattern(path); err != nil {
 		return err
 	}
 	
-	// For relative paths, we need to be more permissive during expansion
-	// The main goal is to prevent escaping the working directory tree
-	if !filepath.IsAbs(path) {
-		// Check if relative path contains unsafe components
-		parts := strings.Split(filepath.ToSlash(path), "/")
-		for _, part := range parts {
-			if part == ".." {
-				return fmt.Errorf("path %s contains parent directory reference", path)
-			}
+	// Check if relative path contains unsafe components
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for _, part := range parts {
+		if part == ".." {
+			return invalidPathError("path %s contains parent directory reference", path)
 		}
+	}
+	return sge.validateSymlinks(path)
+}
+
+// validateSymlinks rejects a path that exists and resolves, through
+// symlinks, outside WorkingDir and the allowed roots. Paths that do not
+// exist are left to the reader to report.
+func (sge *SecureGlobExpander) validateSymlinks(path string) error {
+	if sge.AllowAbsolute || sge.WorkingDir == "" {
 		return nil
 	}
-	
-	// For absolute paths, ensure they're within the working directory
-	absWorkingDir, err := filepath.Abs(sge.WorkingDir)
-	if err != nil {
-		return fmt.Errorf("failed to resolve working directory: %w", err)
+	full := path
+	if !filepath.IsAbs(path) {
+		full = filepath.Join(sge.WorkingDir, path)
 	}
-	
-	// Check if the absolute path is within the working directory tree
-	relPath, err := filepath.Rel(absWorkingDir, path)
+	real, err := filepath.EvalSymlinks(full)
 	if err != nil {
-		return fmt.Errorf("failed to compute relative path: %w", err)
+		return nil
 	}
-	
-	// If relPath starts with "..", it's outside the working directory
-	if strings.HasPrefix(relPath, "..") {
-		return fmt.Errorf("path %s resolves outside working directory", path)
+	if realWorkingDir, err := filepath.EvalSymlinks(sge.WorkingDir); err == nil && withinRoot(real, realWorkingDir) {
+		return nil
 	}
-	
-	return nil
+	for _, root := range sge.AllowedRoots {
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil && withinRoot(real, realRoot) {
+			return nil
+		}
+	}
+	return invalidPathError("path %s is a symlink to %s, outside the working directory", path, real)
 }
 
-// GlobOption represents different glob expansion strategies
+// absoluteAllowed reports whether the absolute path or pattern p may be
+// used: always with AllowAbsolute, otherwise only under an allowed root.
+func (sge *SecureGlobExpander) absoluteAllowed(p string) bool {
+	if !filepath.IsAbs(p) {
+		return false
+	}
+	if sge.AllowAbsolute {
+		return true
+	}
+	for _, root := range sge.AllowedRoots {
+		if abs, err := filepath.Abs(root); err == nil && withinRoot(p, abs) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinRoot reports whether the absolute path p is root or below it.
+func withinRoot(p, root string) bool {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// GlobOption represents different glob expansion strategies. They are
+// kept for compatibility: every option now expands with the same matcher,
+// described on ExpandPatterns, so a pattern means the same thing whichever
+// is chosen.
 type GlobOption int
 
 const (
-	// StandardGlob uses Go's built-in filepath.Glob
+	// StandardGlob was Go's built-in filepath.Glob
 	StandardGlob GlobOption = iota
 	// EnhancedGlob uses doublestar for ** support and more features
 	EnhancedGlob
-	// BothGlobs tries enhanced first, falls back to standard
+	// BothGlobs tried enhanced first, falling back to standard
 	BothGlobs
 )
 
-// ExpandPatterns expands multiple glob patterns safely
+// ExpandPatterns expands multiple glob patterns safely. Patterns use
+// doublestar syntax, matched against slash-separated paths:
+//
+//   - * matches any run of characters except /, and ? any one of them
+//   - ** as a whole path component matches zero or more directories
+//   - [abc], [a-z] and the negated [!abc] or [^abc] match one character
+//   - {a,b} matches either alternative; alternatives may nest
+//   - \ makes the next character literal (except on Windows)
+//
+// A pattern that matches nothing is taken as a literal path if it exists.
+// A pattern starting with ! removes the paths matched so far that it
+// matches, as in .gitignore, so later patterns can add them back; write \!
+// for a name that starts with !. Negation applies to the paths returned, not
+// to files inside a returned directory. Results are deduplicated and keep
+// the order of the patterns.
 func (sge *SecureGlobExpander) ExpandPatterns(patterns []string, option GlobOption) ([]string, error) {
 	var allFiles []string
 	seenFiles := make(map[string]bool) // deduplicate results
 	
 	for _, pattern := range patterns {
+		if negated := strings.TrimPrefix(pattern, "!"); negated != pattern {
+			if err := sge.ValidatePattern(negated); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			var err error
+			if allFiles, err = subtractMatches(allFiles, seenFiles, negated, sge.CaseInsensitive); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		
 		// First validate the pattern itself
 		if err := sge.ValidatePattern(pattern); err != nil {
 			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
 		}
 		
-		var matches []string
-		var err error
-		
-		switch option {
-		case StandardGlob:
-			matches, err = sge.expandStandardGlob(pattern)
-		case EnhancedGlob:
-			matches, err = sge.expandEnhancedGlob(pattern)
-		case BothGlobs:
-			// Try enhanced first, fall back to standard
-			matches, err = sge.expandEnhancedGlob(pattern)
-			if err != nil {
-				matches, err = sge.expandStandardGlob(pattern)
-			}
+		matches, err := sge.expand(pattern)
+		if errors.Is(err, doublestar.ErrBadPattern) {
+			return nil, fmt.Errorf("failed to expand pattern %q: %w: %w", pattern, ErrInvalidPattern, err)
 		}
-		
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand pattern %q: %w", pattern, err)
 		}
 		
 		// If no matches found, treat as literal path (if it exists)
 		if len(matches) == 0 {
-			if _, statErr := os.Stat(pattern); statErr == nil {
-				matches = []string{pattern}
+			name := unescapeGlob(pattern)
+			literal := name
+			if sge.WorkingDir != "" && !filepath.IsAbs(name) {
+				literal = filepath.Join(sge.WorkingDir, name)
+			}
+			if _, statErr := os.Stat(literal); statErr == nil {
+				matches = []string{name}
 			}
 		}
 		
@@ -189,13 +273,175 @@ func (sge *SecureGlobExpander) ExpandPatterns(patterns []string, option GlobOpti
 	return allFiles, nil
 }
 
-// expandStandardGlob uses Go's built-in filepath.Glob
-func (sge *SecureGlobExpander) expandStandardGlob(pattern string) ([]string, error) {
-	return filepath.Glob(pattern)
+// subtractMatches removes the files matching pattern, forgetting them in
+// seen so that a later pattern may add them again.
+func subtractMatches(files []string, seen map[string]bool, pattern string, caseInsensitive bool) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidPattern, "!"+pattern)
+	}
+	if caseInsensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	
+	kept := files[:0]
+	for _, file := range files {
+		name := file
+		if caseInsensitive {
+			name = strings.ToLower(file)
+		}
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			delete(seen, file)
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept, nil
 }
 
-// expandEnhancedGlob uses doublestar for enhanced glob support
-func (sge *SecureGlobExpander) expandEnhancedGlob(pattern string) ([]string, error) {
-	// Use doublestar for enhanced glob support with ** and other features
-	return doublestar.FilepathGlob(pattern)
-}
\ No newline at end of file
+// expand matches pattern with doublestar, the single matcher behind every
+// GlobOption.
+func (sge *SecureGlobExpander) expand(pattern string) ([]string, error) {
+	resolved := sge.resolve(pattern)
+	var opts []doublestar.GlobOption
+	if sge.CaseInsensitive {
+		opts = append(opts, doublestar.WithCaseInsensitive())
+	}
+	if !sge.FollowSymlinks {
+		matches, err := doublestar.FilepathGlob(resolved, append(opts, doublestar.WithNoFollow())...)
+		return sge.relative(pattern, matches), err
+	}
+	if !strings.Contains(pattern, "**") {
+		matches, err := doublestar.FilepathGlob(resolved, opts...)
+		return sge.relative(pattern, matches), err
+	}
+	matches, err := walkGlob(resolved, sge.CaseInsensitive)
+	return sge.relative(pattern, matches), err
+}
+
+// walkGlob expands a ** pattern by walking the directory tree below its
+// literal prefix, following symlinked directories. A link back to a
+// directory already being walked is reported as a cycle instead of being
+// walked again.
+func walkGlob(pattern string, caseInsensitive bool) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, doublestar.ErrBadPattern
+	}
+	base, _ := doublestar.SplitPattern(pattern)
+	root := filepath.FromSlash(unescapeGlob(base))
+	match := func(name string) bool {
+		name = filepath.ToSlash(name)
+		if caseInsensitive {
+			ok, _ := doublestar.Match(strings.ToLower(pattern), strings.ToLower(name))
+			return ok
+		}
+		ok, _ := doublestar.Match(pattern, name)
+		return ok
+	}
+	
+	var matches []string
+	var walk func(dir string, followed []string) error
+	walk = func(dir string, followed []string) error {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil
+		}
+		for _, seen := range followed {
+			if seen == real {
+				return fmt.Errorf("symlink cycle at %s", dir)
+			}
+		}
+		followed = append(followed, real)
+		
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil
+		}
+		for _, entry := range entries {
+			full := filepath.Join(dir, entry.Name())
+			if match(full) {
+				matches = append(matches, full)
+			}
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(full); err == nil {
+					isDir = info.IsDir()
+				}
+			}
+			if isDir {
+				if err := walk(full, followed); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	
+	// ** also matches the base directory itself.
+	if match(root) {
+		matches = append(matches, root)
+	}
+	if err := walk(root, nil); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// resolve anchors a relative pattern at WorkingDir, escaping any glob
+// syntax in the directory's own name.
+func (sge *SecureGlobExpander) resolve(pattern string) string {
+	if sge.WorkingDir == "" || filepath.IsAbs(pattern) {
+		return pattern
+	}
+	return filepath.Join(escapeGlob(sge.WorkingDir), pattern)
+}
+
+// relative undoes resolve on the matches of a relative pattern, so that
+// they are relative to WorkingDir.
+func (sge *SecureGlobExpander) relative(pattern string, matches []string) []string {
+	if sge.WorkingDir == "" || filepath.IsAbs(pattern) {
+		return matches
+	}
+	for i, match := range matches {
+		if rel, err := filepath.Rel(sge.WorkingDir, match); err == nil {
+			matches[i] = rel
+		}
+	}
+	return matches
+}
+
+// escapeGlob backslash-escapes glob metacharacters in a literal path. On
+// Windows, where backslash separates paths, the path is returned as is.
+func escapeGlob(path string) string {
+	if runtime.GOOS == "windows" {
+		return path
+	}
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`\*?[]{}`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unescapeGlob undoes escapeGlob, and any other backslash escapes, so that
+// an escaped pattern can be tried as a literal path.
+func unescapeGlob(pattern string) string {
+	if runtime.GOOS == "windows" || !strings.Contains(pattern, `\`) {
+		return pattern
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package silo
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -198,7 +199,7 @@ func TestExpandPatternsIntegration(t *testing.T) {
 			name:          "enhanced recursive pattern",
 			patterns:      []string{"**/*.go"},
 			option:        EnhancedGlob,
-			expectedCount: 4, // file1.go, file2.go, src/main.go, src/util.go, test/unit_test.go
+			expectedCount: 5, // file1.go, file2.go, src/main.go, src/util.go, test/unit_test.go
 		},
 		{
 			name:        "invalid parent reference",
@@ -223,8 +224,8 @@ func TestExpandPatternsIntegration(t *testing.T) {
 			name:          "mixed patterns and literals",
 			patterns:      []string{"*.md", "src/main.go"},
 			option:        StandardGlob,
-			expectedCount: 3,
-			expectedFiles: []string{"README.md", "docs/guide.md", "src/main.go"},
+			expectedCount: 2, // * does not cross /, so docs/guide.md is not matched
+			expectedFiles: []string{"README.md", "src/main.go"},
 		},
 	}
 
@@ -329,4 +330,197 @@ func TestSecurityEscapeAttempts(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestExpandPatternsWorkingDir(t *testing.T) {
+	// Glob syntax in the directory's own name must not be interpreted.
+	tempDir := filepath.Join(t.TempDir(), "proj[1]")
+	os.MkdirAll(filepath.Join(tempDir, "src"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# Test"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.go"), []byte("package main"), 0644)
+	
+	expander := &SecureGlobExpander{WorkingDir: tempDir}
+	result, err := expander.ExpandPatterns([]string{"src/*.go", "README.md", "**/*.go"}, BothGlobs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	
+	expected := []string{"src/main.go", "README.md"}
+	if strings.Join(result, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+	
+	if _, err := expander.ExpandPatterns([]string{"../*"}, BothGlobs); err == nil {
+		t.Error("Expected error for pattern escaping the working directory")
+	}
+}
+
+func TestSecureGlobExpanderAllowedRoots(t *testing.T) {
+	workDir := t.TempDir()
+	shared := t.TempDir()
+	other := t.TempDir()
+	os.WriteFile(filepath.Join(shared, "service.tmpl"), []byte("template"), 0644)
+	os.WriteFile(filepath.Join(other, "secret.txt"), []byte("secret"), 0644)
+	
+	expander := &SecureGlobExpander{WorkingDir: workDir, AllowedRoots: []string{shared}}
+	
+	result, err := expander.ExpandPatterns([]string{filepath.Join(shared, "*.tmpl")}, StandardGlob)
+	if err != nil {
+		t.Fatalf("Expected pattern under an allowed root to expand, got: %v", err)
+	}
+	if len(result) != 1 || result[0] != filepath.ToSlash(filepath.Join(shared, "service.tmpl")) {
+		t.Errorf("Unexpected result: %v", result)
+	}
+	
+	rejected := []string{
+		filepath.Join(other, "*.txt"),
+		filepath.Join(shared, "..", "*"),
+		filepath.Dir(shared) + string(filepath.Separator) + "*",
+	}
+	for _, pattern := range rejected {
+		if _, err := expander.ExpandPatterns([]string{pattern}, StandardGlob); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected ErrInvalidPath for %q, got: %v", pattern, err)
+		}
+	}
+	
+	if err := expander.ValidatePath(filepath.Join(other, "secret.txt")); err == nil {
+		t.Error("Expected path outside the allowed roots to be rejected")
+	}
+}
+
+func TestExpandPatternsSyntax(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, path := range []string{"a.go", "b.go", "c.md", "x.txt", "src/d.go", "src/deep/e.go"} {
+		full := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte("x"), 0644)
+	}
+	
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"*.go", []string{"a.go", "b.go"}},
+		{"?.md", []string{"c.md"}},
+		{"*.{go,md}", []string{"a.go", "b.go", "c.md"}},
+		{"{a,src/{d,deep/e}}.go", []string{"a.go", "src/d.go", "src/deep/e.go"}},
+		{"[ab].go", []string{"a.go", "b.go"}},
+		{"[!a].go", []string{"b.go"}},
+		{"[^a].go", []string{"b.go"}},
+		{"**/*.go", []string{"a.go", "b.go", "src/d.go", "src/deep/e.go"}},
+		{"src/**", []string{"src", "src/d.go", "src/deep", "src/deep/e.go"}},
+		{`\[ab\].go`, nil},
+	}
+	
+	expander := &SecureGlobExpander{WorkingDir: tempDir}
+	for _, option := range []GlobOption{StandardGlob, EnhancedGlob, BothGlobs} {
+		for _, test := range tests {
+			result, err := expander.ExpandPatterns([]string{test.pattern}, option)
+			if err != nil {
+				t.Errorf("option %d, pattern %q: unexpected error: %v", option, test.pattern, err)
+				continue
+			}
+			if strings.Join(result, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("option %d, pattern %q: expected %v, got %v", option, test.pattern, test.expected, result)
+			}
+		}
+	}
+	
+	if _, err := expander.ExpandPatterns([]string{"[a"}, StandardGlob); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern for an unclosed class, got %v", err)
+	}
+}
+
+func TestExpandPatternsNegation(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, path := range []string{"main.go", "main_test.go", "src/util.go", "src/util_test.go", "!odd.txt"} {
+		full := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte("x"), 0644)
+	}
+	
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{"subtract", []string{"**/*.go", "!**/*_test.go"}, []string{"main.go", "src/util.go"}},
+		{"subtract then add back", []string{"**/*.go", "!**/*_test.go", "src/util_test.go"}, []string{"main.go", "src/util.go", "src/util_test.go"}},
+		{"order matters", []string{"!**/*_test.go", "**/*.go"}, []string{"main.go", "main_test.go", "src/util.go", "src/util_test.go"}},
+		{"only negation", []string{"!*.go"}, nil},
+		{"escaped bang", []string{`\!odd.txt`}, []string{"!odd.txt"}},
+	}
+	
+	expander := &SecureGlobExpander{WorkingDir: tempDir}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := expander.ExpandPatterns(test.patterns, BothGlobs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.Join(result, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+	
+	if _, err := expander.ExpandPatterns([]string{"*.go", "!../*.go"}, BothGlobs); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for negated traversal, got %v", err)
+	}
+	if _, err := expander.ExpandPatterns([]string{"*.go", "![a"}, BothGlobs); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern for malformed negation, got %v", err)
+	}
+}
+
+func TestExpandPatternsSymlinks(t *testing.T) {
+	root := setupSymlinkTree(t)
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "escape.txt")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	
+	expander := &SecureGlobExpander{WorkingDir: root}
+	result, err := expander.ExpandPatterns([]string{"**/*.txt", "!escape.txt"}, BothGlobs)
+	if err == nil || !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for a link leaving the working directory, got %v (%v)", err, result)
+	}
+	
+	// By default symlinked directories are matched but not descended.
+	result, err = expander.ExpandPatterns([]string{"**/target.txt"}, BothGlobs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(result, ",") != "dir/target.txt" {
+		t.Errorf("Expected only dir/target.txt, got %v", result)
+	}
+	
+	expander.FollowSymlinks = true
+	result, err = expander.ExpandPatterns([]string{"**/target.txt"}, BothGlobs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(result, ",") != "dir/target.txt,dirlink/target.txt" {
+		t.Errorf("Expected the target through both paths, got %v", result)
+	}
+}
+
+func TestExpandPatternsSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Symlink("..", filepath.Join(root, "a", "up")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	
+	expander := &SecureGlobExpander{WorkingDir: root}
+	if _, err := expander.ExpandPatterns([]string{"**"}, BothGlobs); err != nil {
+		t.Errorf("Expected no-follow expansion to ignore the cycle, got %v", err)
+	}
+	
+	expander.FollowSymlinks = true
+	_, err := expander.ExpandPatterns([]string{"**"}, BothGlobs)
+	if err == nil || !strings.Contains(err.Error(), "symlink cycle") {
+		t.Errorf("Expected symlink cycle error, got %v", err)
+	}
+}
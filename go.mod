@@ -2,4 +2,7 @@ module github.com/escherize/go-silo
 
 go 1.21
 
-require github.com/bmatcuk/doublestar/v4 v4.9.1 // indirect
+require (
+	github.com/bmatcuk/doublestar/v4 v4.9.1
+	golang.org/x/crypto v0.31.0
+)
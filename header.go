@@ -0,0 +1,102 @@
+package silo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the newest format header version this package reads and
+// writes.
+const FormatVersion = 1
+
+// formatHeaderPrefix starts an optional format header line, e.g.
+// "silo/1 delimiter=> files=2 created=2024-01-02T15:04:05Z".
+const formatHeaderPrefix = "silo/"
+
+// FormatHeader is the optional first line of a silo file recording the format
+// version and a summary of the document. Unknown keys are ignored when
+// parsing so later versions can add metadata without breaking readers.
+type FormatHeader struct {
+	Version   int
+	Delimiter string
+	Files     int
+	Created   time.Time
+}
+
+// String renders h as a header line, without the trailing newline.
+func (h *FormatHeader) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%d delimiter=%s files=%d", formatHeaderPrefix, h.Version, h.Delimiter, h.Files)
+	if !h.Created.IsZero() {
+		fmt.Fprintf(&b, " created=%s", h.Created.UTC().Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// isFormatHeader reports whether line is a "silo/N ..." format header.
+func isFormatHeader(line string) bool {
+	if !strings.HasPrefix(line, formatHeaderPrefix) {
+		return false
+	}
+	versionField := strings.Fields(line)[0][len(formatHeaderPrefix):]
+	_, err := strconv.Atoi(versionField)
+	return err == nil
+}
+
+// isReservedDelimiter reports whether a first line starting with delim
+// would be read as a format header, or as the header of an encrypted
+// archive, rather than as a file declaration.
+func isReservedDelimiter(delim string) bool {
+	return isFormatHeader(delim+" ") || isEncryptedHeader(delim)
+}
+
+// parseFormatHeader parses a line accepted by isFormatHeader and rejects
+// versions newer than FormatVersion.
+func parseFormatHeader(line string) (*FormatHeader, error) {
+	fields := strings.Fields(line)
+	version, _ := strconv.Atoi(fields[0][len(formatHeaderPrefix):])
+	if version < 1 || version > FormatVersion {
+		return nil, fmt.Errorf("unsupported silo format version %d (this reader supports up to %d)", version, FormatVersion)
+	}
+
+	header := &FormatHeader{Version: version, Files: -1}
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			return nil, fmt.Errorf("malformed header field %q", field)
+		}
+
+		switch key {
+		case "delimiter":
+			header.Delimiter = value
+		case "files":
+			files, err := strconv.Atoi(value)
+			if err != nil || files < 0 {
+				return nil, fmt.Errorf("invalid header file count %q", value)
+			}
+			header.Files = files
+		case "created":
+			created, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid header creation time %q", value)
+			}
+			header.Created = created
+		}
+	}
+
+	return header, nil
+}
+
+// checkFormatHeader verifies that a parsed document agrees with its header.
+func checkFormatHeader(doc *SiloDocument) error {
+	header := doc.Header
+	if header.Delimiter != "" && len(doc.Files) > 0 && header.Delimiter != doc.Delimiter {
+		return fmt.Errorf("header declares delimiter %q but entries use %q", header.Delimiter, doc.Delimiter)
+	}
+	if header.Files >= 0 && header.Files != len(doc.Files) {
+		return fmt.Errorf("header declares %d files but %d were found", header.Files, len(doc.Files))
+	}
+	return nil
+}
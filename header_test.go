@@ -0,0 +1,110 @@
+package silo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatHeaderRoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	doc := &SiloDocument{
+		Delimiter: "🐢",
+		Header:    &FormatHeader{Created: created},
+		Files: []SiloFile{
+			{Path: "a.txt", Content: "alpha\n"},
+			{Path: "b.txt", Content: "beta\n"},
+		},
+	}
+
+	var buf strings.Builder
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	expectedFirstLine := "silo/1 delimiter=🐢 files=2 created=2024-01-02T15:04:05Z\n"
+	if !strings.HasPrefix(buf.String(), expectedFirstLine) {
+		t.Fatalf("Expected header line %q, got:\n%s", expectedFirstLine, buf.String())
+	}
+
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if parsed.Header == nil {
+		t.Fatal("Expected parsed header")
+	}
+	if parsed.Header.Version != 1 || parsed.Header.Files != 2 || !parsed.Header.Created.Equal(created) {
+		t.Errorf("Unexpected header: %+v", parsed.Header)
+	}
+	if parsed.Delimiter != "🐢" || len(parsed.Files) != 2 {
+		t.Errorf("Unexpected document: delimiter %q, %d files", parsed.Delimiter, len(parsed.Files))
+	}
+}
+
+func TestFormatHeaderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		errText string
+	}{
+		{"unsupported version", "silo/2 delimiter=> files=1\n> a.txt\n", "unsupported silo format version 2"},
+		{"delimiter mismatch", "silo/1 delimiter== files=1\n> a.txt\n", "header declares delimiter"},
+		{"file count mismatch", "silo/1 delimiter=> files=3\n> a.txt\n", "header declares 3 files"},
+		{"malformed field", "silo/1 delimiter\n> a.txt\n", "malformed header field"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSiloFile(strings.NewReader(test.input))
+			if err == nil || !strings.Contains(err.Error(), test.errText) {
+				t.Errorf("Expected error containing %q, got %v", test.errText, err)
+			}
+		})
+	}
+}
+
+func TestFormatHeaderOptional(t *testing.T) {
+	doc, err := ParseSiloFile(strings.NewReader("silo/1 delimiter=> future=yes\n\n> a.txt\nhello\n"))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Header == nil || len(doc.Files) != 1 {
+		t.Errorf("Expected header and one file, got header %v and %d files", doc.Header, len(doc.Files))
+	}
+
+	doc, err = ParseSiloFile(strings.NewReader("> a.txt\nhello\n"))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Header != nil {
+		t.Errorf("Expected no header for headerless input, got %+v", doc.Header)
+	}
+}
+
+func TestWriteReservedDelimiter(t *testing.T) {
+	for _, delim := range []string{"silo/1", "silo/99", "silo-encrypted/1", "silo-encrypted/x"} {
+		doc := &SiloDocument{Delimiter: delim, Files: []SiloFile{{Path: "a.txt", Content: "hi\n"}}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+			t.Errorf("Expected WriteTo to refuse delimiter %q, wrote %q", delim, out.String())
+		}
+		if problems := doc.Validate(); len(problems) != 1 {
+			t.Errorf("Expected Validate to report delimiter %q, got %v", delim, problems)
+		}
+	}
+
+	// Delimiters that only look similar are fine.
+	for _, delim := range []string{"silo/", "silo/v1", "silo", "silo-encrypted"} {
+		doc := &SiloDocument{Delimiter: delim, Files: []SiloFile{{Path: "a.txt", Content: "hi\n"}}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err != nil {
+			t.Errorf("Expected delimiter %q to be written, got %v", delim, err)
+			continue
+		}
+		read, err := ParseSiloFile(strings.NewReader(out.String()))
+		if err != nil || len(read.Files) != 1 || read.Files[0].Content != "hi\n" {
+			t.Errorf("Expected delimiter %q to round-trip, got %+v, %v", delim, read, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package silo
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// IrregularPolicy controls how ReadDirectoryTreeWithOptions handles files
+// that are neither regular files, directories nor symlinks: named pipes,
+// sockets and devices. Reading one can block forever or never end, so they
+// are never read.
+type IrregularPolicy int
+
+const (
+	// IrregularSkip leaves irregular files out, reporting them with the
+	// SkipIrregular reason.
+	IrregularSkip IrregularPolicy = iota
+	// IrregularError fails the read when an irregular file is found.
+	IrregularError
+)
+
+// ParseIrregularPolicy converts a policy name (skip, error) into an
+// IrregularPolicy.
+func ParseIrregularPolicy(name string) (IrregularPolicy, error) {
+	switch name {
+	case "skip":
+		return IrregularSkip, nil
+	case "error":
+		return IrregularError, nil
+	}
+	return 0, fmt.Errorf("unknown irregular file policy %q (want skip or error)", name)
+}
+
+// fileKind names the type of an irregular file for messages.
+func fileKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	}
+	return "irregular file"
+}
+
+// hardLinks remembers the files a read has packed so that further names
+// for the same file, hard links, can be recognized.
+type hardLinks struct {
+	// bySize holds packed files by size, since only files of equal size
+	// can be the same file.
+	bySize map[int64][]linkedFile
+}
+
+type linkedFile struct {
+	relPath string
+	info    os.FileInfo
+}
+
+// target returns the path of an earlier packed name for the file info
+// describes, relative to relPath's directory, or records relPath as the
+// first name for it.
+func (h *hardLinks) target(relPath string, info os.FileInfo) (string, bool) {
+	if h.bySize == nil {
+		h.bySize = make(map[int64][]linkedFile)
+	}
+	for _, seen := range h.bySize[info.Size()] {
+		if os.SameFile(seen.info, info) {
+			target, err := filepath.Rel(path.Dir(relPath), seen.relPath)
+			if err != nil {
+				return "", false
+			}
+			return filepath.ToSlash(target), true
+		}
+	}
+	h.bySize[info.Size()] = append(h.bySize[info.Size()], linkedFile{relPath, info})
+	return "", false
+}
@@ -0,0 +1,109 @@
+package silo
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIrregularPolicy(t *testing.T) {
+	for name, want := range map[string]IrregularPolicy{"skip": IrregularSkip, "error": IrregularError} {
+		got, err := ParseIrregularPolicy(name)
+		if err != nil || got != want {
+			t.Errorf("ParseIrregularPolicy(%q) = %v, %v; expected %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseIrregularPolicy("read"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestReadDirectoryTreeIrregular(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(root, "app.sock"))
+	if err != nil {
+		t.Skipf("Unix sockets not supported: %v", err)
+	}
+	defer listener.Close()
+
+	var report PackReport
+	doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Report: &report})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if got := docPaths(doc); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Errorf("Expected only main.go, got %v", got)
+	}
+	if want := []SkipReason{{Path: "app.sock", Reason: SkipIrregular}}; !reflect.DeepEqual(report.Skipped, want) {
+		t.Errorf("Expected skips %v, got %v", want, report.Skipped)
+	}
+
+	_, err = ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Irregular: IrregularError})
+	if err == nil || !strings.Contains(err.Error(), "app.sock is a socket") {
+		t.Errorf("Expected a socket error, got %v", err)
+	}
+}
+
+func TestReadDirectoryTreeLinkHardLinks(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "c.txt"), []byte("shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(root, "a.txt"), filepath.Join(root, "b", "a.txt")); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	doc, err := ReadDirectoryTree(root)
+	if err != nil {
+		t.Fatalf("ReadDirectoryTree failed: %v", err)
+	}
+	for _, file := range doc.Files {
+		if file.LinkTarget != "" || file.Content != "shared\n" {
+			t.Errorf("Expected hard links to be read as files by default, got %+v", file)
+		}
+	}
+
+	doc, err = ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{LinkHardLinks: true})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	want := []SiloFile{
+		{Path: "a.txt", Content: "shared\n"},
+		{Path: "b/a.txt", LinkTarget: "../a.txt"},
+		// Equal content alone is not a hard link.
+		{Path: "c.txt", Content: "shared\n"},
+	}
+	if !reflect.DeepEqual(doc.Files, want) {
+		t.Errorf("Expected %+v, got %+v", want, doc.Files)
+	}
+
+	out := t.TempDir()
+	if err := doc.WriteToDirectory(out); err != nil {
+		t.Fatalf("WriteToDirectory failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(out, "b", "a.txt"))
+	if err != nil || string(content) != "shared\n" {
+		t.Errorf("Expected the unpacked link to read as the file, got %q, %v", content, err)
+	}
+}
+
+func TestFileKind(t *testing.T) {
+	if got := fileKind(os.ModeNamedPipe); got != "named pipe" {
+		t.Errorf("Expected named pipe, got %q", got)
+	}
+	if got := fileKind(os.ModeDevice | os.ModeCharDevice); got != "character device" {
+		t.Errorf("Expected character device, got %q", got)
+	}
+}
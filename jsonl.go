@@ -0,0 +1,152 @@
+package silo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// jsonlEntry is one line of the JSON Lines form of a document.
+type jsonlEntry struct {
+	Path string `json:"path"`
+	// Content is omitted for links and references.
+	Content *string `json:"content,omitempty"`
+	// Encoding is "base64" when Content is base64-encoded, for binary
+	// content that a JSON string cannot hold.
+	Encoding  string            `json:"encoding,omitempty"`
+	Link      string            `json:"link,omitempty"`
+	Ref       string            `json:"ref,omitempty"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Truncated int               `json:"truncated,omitempty"`
+}
+
+// jsonlBase64 is the jsonlEntry Encoding of base64 content.
+const jsonlBase64 = "base64"
+
+// WriteJSONL writes doc's entries to w as JSON Lines, one object per entry,
+// for tools such as jq and streaming pipelines:
+//
+//	{"path":"src/main.go","content":"package main\n","attrs":{"lang":"go"}}
+//	{"path":"current.txt","link":"releases/v2.txt"}
+//	{"path":"logo.png","content":"iVBORw0KGgo...","encoding":"base64"}
+//
+// Content is written as a file unpacks under LineEndingsAuto. Entries
+// marked Base64, and content that is not valid UTF-8, are base64-encoded.
+// The header, delimiter and signature are not written.
+func (doc *SiloDocument) WriteJSONL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, file := range doc.Files {
+		entry := jsonlEntry{
+			Path:      file.Path,
+			Link:      file.LinkTarget,
+			Ref:       file.Ref,
+			Attrs:     file.Attrs,
+			Meta:      file.Meta,
+			Truncated: file.Truncated,
+		}
+		if file.LinkTarget == "" && file.Ref == "" {
+			content := unpackedContent(file, LineEndingsAuto)
+			text := string(content)
+			if file.Base64 || !utf8.Valid(content) {
+				text = base64.StdEncoding.EncodeToString(content)
+				entry.Encoding = jsonlBase64
+			}
+			entry.Content = &text
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ParseJSONL reads a document written by WriteJSONL: one JSON object per
+// line, blank lines aside. Paths, link targets and annotations are checked
+// so that the document can be written with WriteTo and read back
+// unchanged, base64 content is decoded into an entry marked Base64, and
+// unknown fields are ignored. The document's Delimiter is left empty, so writing it picks
+// one. Errors are *ParseError values giving the offending line.
+func ParseJSONL(r io.Reader) (*SiloDocument, error) {
+	doc := &SiloDocument{}
+	br := bufio.NewReader(r)
+	var offset int64
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			file, parseErr := parseJSONLEntry(trimmed)
+			if parseErr != nil {
+				return nil, &ParseError{Line: lineNum, Offset: offset, Err: parseErr}
+			}
+			doc.Files = append(doc.Files, file)
+		}
+		offset += int64(len(line))
+		if err == io.EOF {
+			return doc, nil
+		}
+	}
+}
+
+// parseJSONLEntry converts one JSON Lines object into an entry.
+func parseJSONLEntry(line []byte) (SiloFile, error) {
+	var entry jsonlEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return SiloFile{}, err
+	}
+	if err := ValidatePath(entry.Path); err != nil {
+		return SiloFile{}, err
+	}
+	file := SiloFile{
+		Path:       entry.Path,
+		LinkTarget: entry.Link,
+		Ref:        entry.Ref,
+		Attrs:      entry.Attrs,
+		Meta:       entry.Meta,
+		Truncated:  entry.Truncated,
+	}
+	if file.LinkTarget != "" && file.Ref != "" {
+		return SiloFile{}, fmt.Errorf("%s: an entry cannot be both a link and a reference", entry.Path)
+	}
+	if file.Ref != "" {
+		if err := validateRef(file.Ref); err != nil {
+			return SiloFile{}, err
+		}
+	}
+	if file.LinkTarget != "" {
+		if err := validateLinkTarget(".", file.Path, file.LinkTarget); err != nil {
+			return SiloFile{}, err
+		}
+	}
+	if entry.Content == nil {
+		if file.LinkTarget == "" && file.Ref == "" {
+			return SiloFile{}, fmt.Errorf("%s: entry has no content, link or ref", entry.Path)
+		}
+		return file, checkEntry(file)
+	}
+	if file.LinkTarget != "" || file.Ref != "" {
+		return SiloFile{}, fmt.Errorf("%s: a link or reference entry cannot have content", entry.Path)
+	}
+	switch entry.Encoding {
+	case "":
+		file.Content = *entry.Content
+	case jsonlBase64:
+		content, err := base64.StdEncoding.DecodeString(*entry.Content)
+		if err != nil {
+			return SiloFile{}, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		file.Content = string(content)
+		file.Base64 = true
+	default:
+		return SiloFile{}, fmt.Errorf("%s: unknown encoding %q (want base64)", entry.Path, entry.Encoding)
+	}
+	return file, checkEntry(file)
+}
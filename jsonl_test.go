@@ -0,0 +1,124 @@
+package silo
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "src/main.go", Content: "package main\n\nfunc main() {}\n", Attrs: map[string]string{"lang": "go"}, Meta: map[string]string{"owner": "core"}},
+			{Path: "windows.txt", Content: "one\ntwo\n", CRLF: true},
+			{Path: "mixed.txt", Content: "a\r\nb\n"},
+			{Path: "logo.png", Content: "\x89PNG\r\n\x1a\n\x00", Base64: true},
+			{Path: "current.txt", LinkTarget: "releases/v2.txt"},
+			{Path: "big.bin", Ref: "file:blobs/big.bin"},
+			{Path: "empty.txt", Content: ""},
+			{Path: "log.txt", Content: "first\n[... 9 lines truncated ...]\n", Truncated: 9},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := doc.WriteJSONL(&buf); err != nil {
+		t.Fatalf("WriteJSONL failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(doc.Files) {
+		t.Fatalf("Expected one line per entry, got:\n%s", buf.String())
+	}
+	if want := `{"path":"current.txt","link":"releases/v2.txt"}`; lines[4] != want {
+		t.Errorf("Expected %s, got %s", want, lines[4])
+	}
+	if want := `{"path":"windows.txt","content":"one\r\ntwo\r\n"}`; lines[1] != want {
+		t.Errorf("Expected CRLF restored, got %s", lines[1])
+	}
+
+	parsed, err := ParseJSONL(&buf)
+	if err != nil {
+		t.Fatalf("ParseJSONL failed: %v", err)
+	}
+	if parsed.Delimiter != "" {
+		t.Errorf("Expected no delimiter, got %q", parsed.Delimiter)
+	}
+	// CRLF content comes back as it unpacks, with its carriage returns.
+	doc.Files[1] = SiloFile{Path: "windows.txt", Content: "one\r\ntwo\r\n"}
+	if !doc.Equal(parsed) {
+		t.Errorf("Round trip changed the document: %s", doc.Diff(parsed))
+	}
+	if !parsed.Files[3].Base64 || parsed.Files[7].Truncated != 9 || parsed.Files[0].Attrs["lang"] != "go" || parsed.Files[0].Meta["owner"] != "core" {
+		t.Errorf("Expected Base64, Truncated, Attrs and Meta to survive, got %+v", parsed.Files)
+	}
+}
+
+func TestParseJSONLErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad json":        `{"path":`,
+		"unsafe path":     `{"path":"../etc/passwd","content":""}`,
+		"no content":      `{"path":"a.txt"}`,
+		"link and ref":    `{"path":"a","link":"b","ref":"file:c"}`,
+		"link content":    `{"path":"a","link":"b","content":"x"}`,
+		"bad ref":         `{"path":"a","ref":"ftp:x"}`,
+		"bad encoding":    `{"path":"a","content":"x","encoding":"hex"}`,
+		"bad base64":      `{"path":"a","content":"!!","encoding":"base64"}`,
+		"bad annotations": `{"path":"a","content":"","attrs":{"k":"has space"}}`,
+		"escaping link":   `{"path":"a","link":"../outside"}`,
+		"absolute link":   `{"path":"a","link":"/etc/passwd"}`,
+		"arrow in path":   `{"path":"a -> b","content":""}`,
+		"attr-like path":  `{"path":"notes x=1.txt","content":""}`,
+		"marker path":     `{"path":"logo.png @base64","content":""}`,
+		"attr-like link":  `{"path":"a","link":"b x=1"}`,
+		"marker content":  `{"path":"a.diff","content":"-x\n\\ No newline at end of file\n"}`,
+	}
+	for name, line := range tests {
+		input := "\n" + `{"path":"ok.txt","content":"ok\n"}` + "\n" + line + "\n"
+		_, err := ParseJSONL(strings.NewReader(input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a *ParseError, got %v", name, err)
+			continue
+		}
+		if parseErr.Line != 3 {
+			t.Errorf("%s: expected line 3, got %d", name, parseErr.Line)
+		}
+	}
+	if _, err := ParseJSONL(strings.NewReader(`{"path":"../x","content":""}`)); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func TestParseJSONLWithoutFinalNewline(t *testing.T) {
+	doc, err := ParseJSONL(strings.NewReader(`{"path":"a.txt","content":"a","extra":1}`))
+	if err != nil {
+		t.Fatalf("ParseJSONL failed: %v", err)
+	}
+	if want := []SiloFile{{Path: "a.txt", Content: "a"}}; !reflect.DeepEqual(doc.Files, want) {
+		t.Errorf("Expected %+v, got %+v", want, doc.Files)
+	}
+}
+
+func TestJSONLToSiloRoundTrip(t *testing.T) {
+	input := `{"path":"src/main.go","content":"package main\n","attrs":{"lang":"go","mode":"0755"}}
+{"path":"docs/current.md","link":"../README.md","attrs":{"owner":"docs"}}
+{"path":"README.md","content":"# Hi\n","meta":{"description":"Start here"}}
+`
+	doc, err := ParseJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseJSONL failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	parsed, err := ParseSiloFile(&buf)
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(parsed.Files, doc.Files) {
+		t.Errorf("Round trip changed the entries:\n%+v\nexpected\n%+v", parsed.Files, doc.Files)
+	}
+}
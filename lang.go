@@ -0,0 +1,189 @@
+package silo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// AttrLang is the annotation key holding an entry's language, as in
+// "> src/main.go lang=go".
+const AttrLang = "lang"
+
+// Language is a programming, markup or configuration language recognized
+// by file name. Name is the identifier Markdown code fences and common
+// syntax highlighters use.
+type Language struct {
+	Name string
+	// Extensions lists file extensions, with their dot, in lower case.
+	Extensions []string
+	// FileNames lists whole file names, such as "Makefile".
+	FileNames []string
+}
+
+// Languages lists the languages DetectLanguage recognizes.
+var Languages = []Language{
+	{Name: "go", Extensions: []string{".go"}},
+	{Name: "python", Extensions: []string{".py", ".pyi"}},
+	{Name: "javascript", Extensions: []string{".js", ".mjs", ".cjs", ".jsx"}},
+	{Name: "typescript", Extensions: []string{".ts", ".mts", ".cts", ".tsx"}},
+	{Name: "rust", Extensions: []string{".rs"}},
+	{Name: "java", Extensions: []string{".java"}},
+	{Name: "kotlin", Extensions: []string{".kt", ".kts"}},
+	{Name: "scala", Extensions: []string{".scala"}},
+	{Name: "c", Extensions: []string{".c", ".h"}},
+	{Name: "cpp", Extensions: []string{".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"}},
+	{Name: "csharp", Extensions: []string{".cs"}},
+	{Name: "swift", Extensions: []string{".swift"}},
+	{Name: "ruby", Extensions: []string{".rb"}, FileNames: []string{"Gemfile", "Rakefile"}},
+	{Name: "php", Extensions: []string{".php"}},
+	{Name: "lua", Extensions: []string{".lua"}},
+	{Name: "perl", Extensions: []string{".pl", ".pm"}},
+	{Name: "r", Extensions: []string{".r"}},
+	{Name: "dart", Extensions: []string{".dart"}},
+	{Name: "elixir", Extensions: []string{".ex", ".exs"}},
+	{Name: "erlang", Extensions: []string{".erl", ".hrl"}},
+	{Name: "haskell", Extensions: []string{".hs"}},
+	{Name: "clojure", Extensions: []string{".clj", ".cljs", ".cljc", ".edn"}},
+	{Name: "ocaml", Extensions: []string{".ml", ".mli"}},
+	{Name: "zig", Extensions: []string{".zig"}},
+	{Name: "bash", Extensions: []string{".sh", ".bash", ".zsh"}},
+	{Name: "powershell", Extensions: []string{".ps1"}},
+	{Name: "sql", Extensions: []string{".sql"}},
+	{Name: "html", Extensions: []string{".html", ".htm"}},
+	{Name: "css", Extensions: []string{".css"}},
+	{Name: "scss", Extensions: []string{".scss"}},
+	{Name: "vue", Extensions: []string{".vue"}},
+	{Name: "svelte", Extensions: []string{".svelte"}},
+	{Name: "markdown", Extensions: []string{".md", ".markdown"}},
+	{Name: "json", Extensions: []string{".json"}},
+	{Name: "yaml", Extensions: []string{".yaml", ".yml"}},
+	{Name: "toml", Extensions: []string{".toml"}},
+	{Name: "xml", Extensions: []string{".xml", ".svg"}},
+	{Name: "ini", Extensions: []string{".ini", ".cfg"}},
+	{Name: "protobuf", Extensions: []string{".proto"}},
+	{Name: "graphql", Extensions: []string{".graphql", ".gql"}},
+	{Name: "terraform", Extensions: []string{".tf"}},
+	{Name: "dockerfile", FileNames: []string{"Dockerfile", "Containerfile"}},
+	{Name: "makefile", Extensions: []string{".mk"}, FileNames: []string{"Makefile", "GNUmakefile"}},
+}
+
+// DetectLanguage returns the name of the language of the file at path,
+// judged by its name, or "" if it is not one of Languages.
+func DetectLanguage(filePath string) string {
+	base := path.Base(filePath)
+	ext := strings.ToLower(path.Ext(base))
+	for _, lang := range Languages {
+		for _, name := range lang.FileNames {
+			if base == name {
+				return lang.Name
+			}
+		}
+		if ext == "" {
+			continue
+		}
+		for _, candidate := range lang.Extensions {
+			if ext == candidate {
+				return lang.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Lang returns the entry's language: its lang annotation if it has one, or
+// else the language DetectLanguage infers from its path. It is "" for
+// links, base64 content and files of no known language.
+func (f SiloFile) Lang() string {
+	if lang := f.Attrs[AttrLang]; lang != "" {
+		return lang
+	}
+	if f.LinkTarget != "" || f.Base64 {
+		return ""
+	}
+	return DetectLanguage(f.Path)
+}
+
+// AnnotateLanguages adds a lang annotation to every entry whose language
+// DetectLanguage recognizes, so readers can highlight content without
+// knowing the mapping. Links, base64 entries and entries that already have
+// one are left alone. It returns how many entries were annotated.
+func (doc *SiloDocument) AnnotateLanguages() int {
+	annotated := 0
+	for i := range doc.Files {
+		file := &doc.Files[i]
+		if file.LinkTarget != "" || file.Base64 || file.Attrs[AttrLang] != "" {
+			continue
+		}
+		lang := DetectLanguage(file.Path)
+		if lang == "" {
+			continue
+		}
+		attrs := make(map[string]string, len(file.Attrs)+1)
+		for key, value := range file.Attrs {
+			attrs[key] = value
+		}
+		attrs[AttrLang] = lang
+		file.Attrs = attrs
+		annotated++
+	}
+	return annotated
+}
+
+// LanguagePatterns returns include patterns, such as "**/*.go", matching the
+// files of the named languages. A name is a Language.Name ("python") or one
+// of its extensions without the dot ("py"), in any case.
+func LanguagePatterns(names ...string) ([]string, error) {
+	var patterns []string
+	seen := make(map[string]bool)
+	add := func(pattern string) {
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	for _, name := range names {
+		lang, ok := findLanguage(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown language %q (want a name such as go or python, or an extension such as md)", name)
+		}
+		for _, ext := range lang.Extensions {
+			add("**/*" + ext)
+		}
+		for _, fileName := range lang.FileNames {
+			add("**/" + fileName)
+		}
+	}
+	return patterns, nil
+}
+
+// findLanguage looks up a language by name or extension.
+func findLanguage(name string) (Language, bool) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "."))
+	for _, lang := range Languages {
+		if lang.Name == name {
+			return lang, true
+		}
+	}
+	for _, lang := range Languages {
+		for _, ext := range lang.Extensions {
+			if ext == "."+name {
+				return lang, true
+			}
+		}
+	}
+	return Language{}, false
+}
+
+// TestFilePatterns match test files and test data by the usual conventions
+// of common languages, for use as exclude patterns.
+var TestFilePatterns = []string{
+	"**/*_test.go",
+	"**/test_*.py",
+	"**/*_test.py",
+	"**/*.test.*",
+	"**/*.spec.*",
+	"**/*Test.java",
+	"**/testdata",
+	"**/__tests__",
+}
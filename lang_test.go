@@ -0,0 +1,105 @@
+package silo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"main.go":             "go",
+		"src/App.TSX":         "typescript",
+		"docs/README.md":      "markdown",
+		"build/Dockerfile":    "dockerfile",
+		"Makefile":            "makefile",
+		"scripts/deploy.sh":   "bash",
+		"include/list.hpp":    "cpp",
+		"notes.txt":           "",
+		"LICENSE":             "",
+		".gitignore":          "",
+		"dir.go/unknown.bin":  "",
+		"config/settings.yml": "yaml",
+	}
+	for path, expected := range tests {
+		if got := DetectLanguage(path); got != expected {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", path, got, expected)
+		}
+	}
+}
+
+func TestAnnotateLanguages(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "main.go", Content: "package main\n"},
+		{Path: "notes.txt", Content: "hi\n"},
+		{Path: "query.sql", Content: "select 1;\n", Attrs: map[string]string{"lang": "postgresql"}},
+		{Path: "link.go", LinkTarget: "main.go"},
+		{Path: "blob.json", ContentBytes: []byte{0xff}, Base64: true},
+	}}
+	if n := doc.AnnotateLanguages(); n != 1 {
+		t.Errorf("Expected 1 entry annotated, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), " main.go lang=go\n") {
+		t.Errorf("Expected main.go to be annotated, got:\n%s", buf.String())
+	}
+	parsed, err := ParseSiloFile(&buf)
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+
+	expected := []string{"go", "", "postgresql", "", ""}
+	for i, file := range parsed.Files {
+		if got := file.Lang(); got != expected[i] {
+			t.Errorf("%s: Lang() = %q, want %q", file.Path, got, expected[i])
+		}
+	}
+	if got := (SiloFile{Path: "lib.rs"}).Lang(); got != "rust" {
+		t.Errorf("Expected an unannotated entry's language to be inferred, got %q", got)
+	}
+}
+
+func TestLanguagePatterns(t *testing.T) {
+	patterns, err := LanguagePatterns("go", "MD", ".yml", "makefile")
+	if err != nil {
+		t.Fatalf("LanguagePatterns failed: %v", err)
+	}
+	expected := []string{"**/*.go", "**/*.md", "**/*.markdown", "**/*.yaml", "**/*.yml", "**/*.mk", "**/Makefile", "**/GNUmakefile"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("LanguagePatterns = %v, want %v", patterns, expected)
+	}
+	if _, err := LanguagePatterns("go", "cobol"); err == nil || !strings.Contains(err.Error(), `"cobol"`) {
+		t.Errorf("Expected an error naming cobol, got %v", err)
+	}
+}
+
+func TestLanguagePatternsReadDirectoryTree(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "main_test.go", "pkg/util.go", "pkg/testdata/in.go", "README.md", "app.py"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	include, err := LanguagePatterns("go", "md")
+	if err != nil {
+		t.Fatalf("LanguagePatterns failed: %v", err)
+	}
+	doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Include: include, Exclude: TestFilePatterns})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if got, expected := docPaths(doc), []string{"README.md", "main.go", "pkg/util.go"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,251 @@
+package silo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LazyDocument is a silo file opened with OpenSiloFile. It records where
+// each entry starts rather than holding its content, which is read from the
+// file on demand, so listing an archive or reading one file from it does not
+// load the whole archive into memory. Its methods are safe for concurrent
+// use.
+type LazyDocument struct {
+	Delimiter string
+	// Header is the archive's format header line, if it has one.
+	Header *FormatHeader
+
+	f       *os.File
+	opts    ParseOptions
+	entries []lazyEntry
+	index   map[string]int
+}
+
+// lazyEntry locates one entry: its declaration line, the entry's content
+// and nothing else occupy size bytes starting at offset.
+type lazyEntry struct {
+	header EntryHeader
+	line   int
+	offset int64
+	size   int64
+}
+
+// OpenSiloFile opens the silo file at path and indexes its entries. It
+// reads the file once to find the declaration lines, reporting the same
+// structural errors ParseSiloFile would, but keeps none of the content.
+// The caller must Close the document.
+func OpenSiloFile(path string) (*LazyDocument, error) {
+	return OpenSiloFileWithOptions(path, ParseOptions{})
+}
+
+// OpenSiloFileWithOptions is OpenSiloFile with the limits and line ending
+// policy in opts, which also apply to each entry as it is read.
+func OpenSiloFileWithOptions(path string, opts ParseOptions) (*LazyDocument, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	doc := &LazyDocument{f: f, opts: opts, index: make(map[string]int)}
+	if err := doc.scan(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return doc, nil
+}
+
+// scan builds the entry index, checking the document structure the way
+// ParseSiloFileWithOptions does.
+func (doc *LazyDocument) scan() error {
+	opts := doc.opts
+	reader := bufio.NewReaderSize(doc.f, writeBufferSize)
+
+	var offset, lineStart int64
+	var lineNo int
+	var terminated bool
+	var headerLine int
+	trailer := int64(-1)
+	first := true
+
+	fail := func(suggestion string, err error) error {
+		return &ParseError{Line: lineNo, Offset: lineStart, Suggestion: suggestion, Err: err}
+	}
+
+	for {
+		line, n, _, err := readLine(reader, opts.MaxLineLength)
+		if err == io.EOF {
+			break
+		}
+		lineNo++
+		lineStart = offset
+		if errors.Is(err, errLineTooLong) {
+			return fail("", &LimitError{Limit: "MaxLineLength", Max: int64(opts.MaxLineLength)})
+		}
+		if err != nil {
+			return fmt.Errorf("error reading input: %w", err)
+		}
+		if lineNo == 1 && isEncryptedHeader(line) {
+			return ErrEncrypted
+		}
+		terminated = n > len(line)
+		offset += int64(n)
+		if opts.MaxTotalSize > 0 && offset > opts.MaxTotalSize {
+			return fail("", &LimitError{Limit: "MaxTotalSize", Max: opts.MaxTotalSize})
+		}
+
+		if opts.LineEndings != LineEndingsPreserve {
+			line = strings.ReplaceAll(line, "\r\n", "\n")
+			line = strings.ReplaceAll(line, "\r", "\n")
+		}
+		trailer = -1
+		if isSignatureTrailer(line) {
+			trailer = lineStart
+		}
+
+		var rest string
+		if doc.Delimiter == "" {
+			if isBlankLine(line) {
+				continue
+			}
+			if first && isFormatHeader(line) {
+				first = false
+				header, err := parseFormatHeader(line)
+				if err != nil {
+					return fail("", fmt.Errorf("invalid format header: %w", err))
+				}
+				doc.Header, headerLine = header, lineNo
+				continue
+			}
+			first = false
+			if trailer >= 0 {
+				continue
+			}
+			delim, path, err := detectDelimiter(line)
+			if err != nil {
+				return fail("the first line must declare a file, like \"> path/to/file\"", fmt.Errorf("error detecting delimiter: %w", err))
+			}
+			doc.Delimiter, rest = delim, path
+		} else if strings.HasPrefix(line, doc.Delimiter+" ") {
+			rest = strings.TrimSpace(line[len(doc.Delimiter)+1:])
+		} else {
+			continue
+		}
+
+		if opts.MaxFileCount > 0 && len(doc.entries) >= opts.MaxFileCount {
+			return fail("", &LimitError{Limit: "MaxFileCount", Max: int64(opts.MaxFileCount)})
+		}
+		header, err := parseEntryHeader(rest)
+		if err != nil {
+			hint := ""
+			if errors.Is(err, ErrInvalidPath) {
+				hint = "paths must be relative and stay inside the archive root"
+			}
+			return fail(hint, err)
+		}
+		if _, seen := doc.index[header.Path]; seen {
+			return fail("each path may appear only once", fmt.Errorf("%w: %s", ErrDuplicatePath, header.Path))
+		}
+		header.Delimiter = doc.Delimiter
+
+		if last := len(doc.entries) - 1; last >= 0 {
+			doc.entries[last].size = lineStart - doc.entries[last].offset
+		}
+		doc.index[header.Path] = len(doc.entries)
+		doc.entries = append(doc.entries, lazyEntry{header: header, line: lineNo, offset: lineStart})
+	}
+
+	if lineNo > 0 && !terminated && opts.RequireFinalNewline {
+		return fail("add a newline at the end of the input", errors.New("missing newline at end of line"))
+	}
+	if last := len(doc.entries) - 1; last >= 0 {
+		end := offset
+		if trailer >= 0 {
+			end = trailer
+		}
+		doc.entries[last].size = end - doc.entries[last].offset
+	}
+
+	if doc.Header != nil {
+		if doc.Delimiter == "" {
+			doc.Delimiter = doc.Header.Delimiter
+		}
+		if err := checkFormatHeader(&SiloDocument{Header: doc.Header, Delimiter: doc.Delimiter, Files: make([]SiloFile, len(doc.entries))}); err != nil {
+			return &ParseError{Line: headerLine, Err: err}
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (doc *LazyDocument) Close() error {
+	return doc.f.Close()
+}
+
+// Len returns the number of entries in the archive.
+func (doc *LazyDocument) Len() int {
+	return len(doc.entries)
+}
+
+// Entries returns the declaration of every entry, in archive order.
+func (doc *LazyDocument) Entries() []EntryHeader {
+	headers := make([]EntryHeader, len(doc.entries))
+	for i, entry := range doc.entries {
+		headers[i] = entry.header
+	}
+	return headers
+}
+
+// Paths returns the path of every entry, in archive order.
+func (doc *LazyDocument) Paths() []string {
+	paths := make([]string, len(doc.entries))
+	for i, entry := range doc.entries {
+		paths[i] = entry.header.Path
+	}
+	return paths
+}
+
+// File reads the entry with the given path from the archive, or returns an
+// error matching ErrNotFound if there is none. The entry is parsed as
+// ParseSiloFile would parse it, so base64 content is decoded and CRLF
+// endings normalized according to the options the document was opened
+// with.
+func (doc *LazyDocument) File(path string) (SiloFile, error) {
+	i, ok := doc.index[path]
+	if !ok {
+		return SiloFile{}, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	entry := doc.entries[i]
+
+	opts := doc.opts
+	opts.MaxTotalSize, opts.MaxFileCount, opts.RequireFinalNewline = 0, 0, false
+	parsed, err := ParseSiloFileWithOptions(io.NewSectionReader(doc.f, entry.offset, entry.size), opts)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Line += entry.line - 1
+			parseErr.Offset += entry.offset
+		}
+		return SiloFile{}, err
+	}
+	if len(parsed.Files) != 1 {
+		return SiloFile{}, fmt.Errorf("entry %s changed since the archive was opened", path)
+	}
+	return parsed.Files[0], nil
+}
+
+// ReadFile returns the content of the entry with the given path, reading
+// only that entry from the archive, as SiloDocument.ReadFile does for a
+// parsed document.
+func (doc *LazyDocument) ReadFile(path string) (string, error) {
+	file, err := doc.File(path)
+	if err != nil {
+		return "", err
+	}
+	if file.Ref != "" {
+		return "", fmt.Errorf("%w: %s @%s", ErrUnresolvedRef, path, file.Ref)
+	}
+	return file.Content, nil
+}
@@ -0,0 +1,129 @@
+package silo
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeLazyArchive(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.silo")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenSiloFileMatchesParse(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &SiloDocument{
+		Header: &FormatHeader{Version: FormatVersion, Delimiter: "=", Files: 5},
+		Files: []SiloFile{
+			{Path: "a.txt", Content: "> quoted\nline\n"},
+			{Path: "crlf.txt", Content: "one\ntwo\n", CRLF: true},
+			{Path: "data.bin", Content: "\x00\x01\xff", Base64: true},
+			{Path: "link", LinkTarget: "a.txt"},
+			{Path: "partial.txt", Content: "no newline", Attrs: map[string]string{"lang": "text"}},
+		},
+	}
+	doc.Sign(key)
+	var b strings.Builder
+	if err := doc.WriteToWithOptions(&b, WriteOptions{MarkMissingNewline: true}); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	path := writeLazyArchive(t, b.String())
+
+	want, err := ParseSiloFile(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+
+	lazy, err := OpenSiloFile(path)
+	if err != nil {
+		t.Fatalf("OpenSiloFile failed: %v", err)
+	}
+	defer lazy.Close()
+
+	if lazy.Delimiter != want.Delimiter || !reflect.DeepEqual(lazy.Header, want.Header) {
+		t.Errorf("Delimiter %q, header %+v; want %q, %+v", lazy.Delimiter, lazy.Header, want.Delimiter, want.Header)
+	}
+	if got := lazy.Paths(); !reflect.DeepEqual(got, docPaths(want)) {
+		t.Errorf("Paths() = %v, want %v", got, docPaths(want))
+	}
+	if got := lazy.Entries()[3]; got.LinkTarget != "a.txt" || got.Delimiter != "=" {
+		t.Errorf("Entries()[3] = %+v", got)
+	}
+	for _, file := range want.Files {
+		got, err := lazy.File(file.Path)
+		if err != nil {
+			t.Fatalf("File(%q) failed: %v", file.Path, err)
+		}
+		if !reflect.DeepEqual(got, file) {
+			t.Errorf("File(%q) = %+v, want %+v", file.Path, got, file)
+		}
+	}
+
+	if content, err := lazy.ReadFile("partial.txt"); err != nil || content != "no newline" {
+		t.Errorf("ReadFile(partial.txt) = %q, %v", content, err)
+	}
+	if _, err := lazy.ReadFile("missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestOpenSiloFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    ParseOptions
+		line    int
+		target  error
+	}{
+		{"duplicate path", "> a.txt\nx\n> b.txt\n> a.txt\n", ParseOptions{}, 4, ErrDuplicatePath},
+		{"unsafe path", "> a.txt\n> ../escape\n", ParseOptions{}, 2, ErrInvalidPath},
+		{"file count", "> a\n> b\n> c\n", ParseOptions{MaxFileCount: 2}, 3, nil},
+		{"final newline", "> a\nx", ParseOptions{RequireFinalNewline: true}, 2, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := OpenSiloFileWithOptions(writeLazyArchive(t, test.content), test.opts)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %v", err)
+			}
+			if parseErr.Line != test.line {
+				t.Errorf("Expected line %d, got %d", test.line, parseErr.Line)
+			}
+			if test.target != nil && !errors.Is(err, test.target) {
+				t.Errorf("Expected %v, got %v", test.target, err)
+			}
+		})
+	}
+}
+
+func TestLazyDocumentEntryErrorPosition(t *testing.T) {
+	content := "> a.txt\nfine\n> b.bin @base64\n!!!not base64\n"
+	lazy, err := OpenSiloFile(writeLazyArchive(t, content))
+	if err != nil {
+		t.Fatalf("OpenSiloFile failed: %v", err)
+	}
+	defer lazy.Close()
+
+	_, err = lazy.File("b.bin")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError, got %v", err)
+	}
+	if parseErr.Line != 3 || parseErr.Offset != int64(strings.Index(content, "> b.bin")) {
+		t.Errorf("Expected line 3 at offset %d, got line %d at offset %d", strings.Index(content, "> b.bin"), parseErr.Line, parseErr.Offset)
+	}
+}
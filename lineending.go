@@ -0,0 +1,140 @@
+package silo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LineEndingPolicy controls how line endings in file content are handled
+// when parsing an archive (ParseOptions) and when writing content out
+// (WriteOptions, UnpackOptions).
+type LineEndingPolicy int
+
+const (
+	// LineEndingsAuto normalizes content to LF when parsing and records in
+	// SiloFile.CRLF whether the file used CRLF line endings. When writing,
+	// files marked CRLF get CRLF line endings back and others are written
+	// as they are. Files with uniform line endings round-trip byte-exactly.
+	LineEndingsAuto LineEndingPolicy = iota
+	// LineEndingsPreserve keeps content bytes exactly as they are, including
+	// mixed line endings.
+	LineEndingsPreserve
+	// LineEndingsLF converts all content to LF line endings.
+	LineEndingsLF
+	// LineEndingsCRLF converts all content to CRLF line endings.
+	LineEndingsCRLF
+)
+
+// ParseLineEndingPolicy converts a policy name (auto, preserve, lf, crlf)
+// into a LineEndingPolicy.
+func ParseLineEndingPolicy(name string) (LineEndingPolicy, error) {
+	switch name {
+	case "auto":
+		return LineEndingsAuto, nil
+	case "preserve":
+		return LineEndingsPreserve, nil
+	case "lf":
+		return LineEndingsLF, nil
+	case "crlf":
+		return LineEndingsCRLF, nil
+	}
+	return 0, fmt.Errorf("unknown line ending policy %q (want auto, preserve, lf or crlf)", name)
+}
+
+// contentLine is a line of entry content as read from an archive.
+type contentLine struct {
+	text string
+	crlf bool
+}
+
+// joinContent rebuilds an entry's content from its lines according to
+// policy, reporting whether the lines mostly ended in CRLF. A final line
+// without a terminator is given one, as WriteTo would.
+func joinContent(lines []contentLine, policy LineEndingPolicy) (content string, crlf bool) {
+	var b strings.Builder
+	crlfLines := 0
+	for _, line := range lines {
+		b.WriteString(line.text)
+		if line.crlf {
+			crlfLines++
+		}
+		switch {
+		case policy == LineEndingsCRLF, policy == LineEndingsPreserve && line.crlf:
+			b.WriteString("\r\n")
+		default:
+			b.WriteString("\n")
+		}
+	}
+
+	content = b.String()
+	if len(lines) == 1 && lines[0].text == "" {
+		// A lone blank line separates entries rather than being content.
+		content = ""
+	}
+	return content, policy == LineEndingsAuto && crlfLines*2 > len(lines)
+}
+
+// convertLineEndings returns content as it should be written under policy.
+func convertLineEndings(content string, crlf bool, policy LineEndingPolicy) string {
+	switch policy {
+	case LineEndingsLF:
+		return strings.ReplaceAll(content, "\r\n", "\n")
+	case LineEndingsCRLF:
+		return toCRLF(content)
+	case LineEndingsAuto:
+		if crlf {
+			return toCRLF(content)
+		}
+	}
+	return content
+}
+
+// writeConverted writes content to w as convertLineEndings would return it,
+// a line at a time rather than building a converted copy.
+func writeConverted(w io.Writer, content string, crlf bool, policy LineEndingPolicy) error {
+	ending := ""
+	switch {
+	case policy == LineEndingsLF:
+		ending = "\n"
+	case policy == LineEndingsCRLF, policy == LineEndingsAuto && crlf:
+		ending = "\r\n"
+	}
+	if ending == "" || (ending == "\n" && !strings.Contains(content, "\r\n")) {
+		_, err := io.WriteString(w, content)
+		return err
+	}
+
+	for {
+		idx := strings.IndexByte(content, '\n')
+		if idx < 0 {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+		line := strings.TrimSuffix(content[:idx], "\r")
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, ending); err != nil {
+			return err
+		}
+		content = content[idx+1:]
+	}
+}
+
+func toCRLF(content string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// unpackedContent returns the bytes to write for file under policy. Base64
+// entries hold binary data and are never converted, and ContentBytes is
+// returned as is when there is nothing to convert.
+func unpackedContent(file SiloFile, policy LineEndingPolicy) []byte {
+	if file.Base64 {
+		return file.Bytes()
+	}
+	if file.ContentBytes != nil && (policy == LineEndingsPreserve || policy == LineEndingsAuto && !file.CRLF) {
+		return file.ContentBytes
+	}
+	return []byte(convertLineEndings(file.text(), file.CRLF, policy))
+}
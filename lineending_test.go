@@ -0,0 +1,111 @@
+package silo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineEndingsAutoRoundTrip(t *testing.T) {
+	input := "> dos.txt\r\none\r\ntwo\r\n> unix.txt\nthree\n"
+
+	doc, err := ParseSiloFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Files[0].Content != "one\ntwo\n" || !doc.Files[0].CRLF {
+		t.Errorf("Expected normalized CRLF file, got %+v", doc.Files[0])
+	}
+	if doc.Files[1].CRLF {
+		t.Errorf("unix.txt should not be marked CRLF")
+	}
+
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if out.String() != "> dos.txt\none\r\ntwo\r\n> unix.txt\nthree\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	dir := t.TempDir()
+	if err := doc.WriteToDirectory(dir); err != nil {
+		t.Fatalf("WriteToDirectory failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "dos.txt"))
+	if string(data) != "one\r\ntwo\r\n" {
+		t.Errorf("Expected CRLF restored on unpack, got %q", data)
+	}
+}
+
+func TestLineEndingsPreserve(t *testing.T) {
+	input := "> mixed.txt\none\r\ntwo\nthree\r\n"
+
+	doc, err := ParseSiloFileWithOptions(strings.NewReader(input), ParseOptions{LineEndings: LineEndingsPreserve})
+	if err != nil {
+		t.Fatalf("ParseSiloFileWithOptions failed: %v", err)
+	}
+	if doc.Files[0].Content != "one\r\ntwo\nthree\r\n" {
+		t.Errorf("Expected content preserved byte for byte, got %q", doc.Files[0].Content)
+	}
+
+	var out strings.Builder
+	if err := doc.WriteToWithOptions(&out, WriteOptions{LineEndings: LineEndingsPreserve}); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	if out.String() != input {
+		t.Errorf("Round trip mismatch: %q", out.String())
+	}
+}
+
+func TestLineEndingsConvert(t *testing.T) {
+	input := "> a.txt\none\r\ntwo\n"
+
+	doc, err := ParseSiloFileWithOptions(strings.NewReader(input), ParseOptions{LineEndings: LineEndingsCRLF})
+	if err != nil {
+		t.Fatalf("ParseSiloFileWithOptions failed: %v", err)
+	}
+	if doc.Files[0].Content != "one\r\ntwo\r\n" {
+		t.Errorf("Expected CRLF content, got %q", doc.Files[0].Content)
+	}
+
+	dir := t.TempDir()
+	if err := doc.WriteToDirectoryWithOptions(dir, UnpackOptions{LineEndings: LineEndingsLF}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("Expected LF on unpack, got %q", data)
+	}
+}
+
+func TestParseLineEndingPolicy(t *testing.T) {
+	for _, name := range []string{"auto", "preserve", "lf", "crlf"} {
+		if _, err := ParseLineEndingPolicy(name); err != nil {
+			t.Errorf("ParseLineEndingPolicy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseLineEndingPolicy("mac"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestWriteConvertedMatchesConvert(t *testing.T) {
+	contents := []string{"", "a\nb\n", "a\r\nb\r\n", "mixed\r\nends\nhere", "lone\rcarriage\n", "\r\n\r\n"}
+	policies := []LineEndingPolicy{LineEndingsAuto, LineEndingsPreserve, LineEndingsLF, LineEndingsCRLF}
+
+	for _, content := range contents {
+		for _, policy := range policies {
+			for _, crlf := range []bool{false, true} {
+				var b strings.Builder
+				if err := writeConverted(&b, content, crlf, policy); err != nil {
+					t.Fatalf("writeConverted failed: %v", err)
+				}
+				if want := convertLineEndings(content, crlf, policy); b.String() != want {
+					t.Errorf("writeConverted(%q, %v, %d) = %q, expected %q", content, crlf, policy, b.String(), want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package silo
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+)
+
+// LineIndex maps between line numbers in the archive WriteTo writes for a
+// document and lines within its entries, so tools working on the archive
+// as a whole, such as editor plugins and error reporters, can point at the
+// original files. Lines are 1-based. Build one with LineIndex or
+// LineIndexWithOptions; it is a snapshot of the document at that time.
+type LineIndex struct {
+	entries []lineIndexEntry
+	byPath  map[string]int
+}
+
+// lineIndexEntry is where one entry sits in the archive: its declaration
+// line and the number of content lines after it.
+type lineIndexEntry struct {
+	path  string
+	decl  int
+	lines int
+}
+
+// LineIndex returns the line index of the archive doc.WriteTo writes.
+func (doc *SiloDocument) LineIndex() *LineIndex {
+	index, _ := doc.LineIndexWithOptions(WriteOptions{})
+	return index
+}
+
+// LineIndexWithOptions returns the line index of the archive
+// doc.WriteToWithOptions writes with opts, which decide the entry order and
+// whether missing final newlines are marked. The error is that of sorting
+// with opts.Sort.
+func (doc *SiloDocument) LineIndexWithOptions(opts WriteOptions) (*LineIndex, error) {
+	files := doc.Files
+	if opts.Sort != SortInsertion {
+		files = append([]SiloFile(nil), doc.Files...)
+		if err := sortFiles(files, opts.Sort, opts.Less); err != nil {
+			return nil, err
+		}
+	}
+
+	index := &LineIndex{byPath: make(map[string]int, len(files))}
+	line := 1
+	if doc.Header != nil {
+		line++
+	}
+	for _, file := range files {
+		entry := lineIndexEntry{path: file.Path, decl: line}
+		switch {
+		case file.LinkTarget != "", file.Ref != "":
+		case file.Base64:
+			encoded := base64.StdEncoding.EncodedLen(len(file.Bytes()))
+			entry.lines = (encoded + base64LineLength - 1) / base64LineLength
+		default:
+			content := file.text()
+			entry.lines = strings.Count(content, "\n")
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				entry.lines++
+				if opts.MarkMissingNewline {
+					line++ // the marker follows the content but is not part of it
+				}
+			}
+		}
+		index.byPath[file.Path] = len(index.entries)
+		index.entries = append(index.entries, entry)
+		line += 1 + entry.lines
+	}
+	return index, nil
+}
+
+// Locate returns the entry that archive line archiveLine belongs to and the
+// line within the entry's content. line is 0 for the entry's declaration
+// line. ok is false for the format header, a missing-newline marker and
+// lines past the end. Lines of base64 entries count the encoded text.
+func (idx *LineIndex) Locate(archiveLine int) (path string, line int, ok bool) {
+	i := sort.Search(len(idx.entries), func(i int) bool {
+		return idx.entries[i].decl > archiveLine
+	}) - 1
+	if i < 0 {
+		return "", 0, false
+	}
+	entry := idx.entries[i]
+	line = archiveLine - entry.decl
+	if line > entry.lines {
+		return "", 0, false
+	}
+	return entry.path, line, true
+}
+
+// ArchiveLine returns the archive line holding line of path's content, or
+// its declaration line for line 0. ok is false if there is no such entry or
+// line.
+func (idx *LineIndex) ArchiveLine(path string, line int) (archiveLine int, ok bool) {
+	i, found := idx.byPath[path]
+	if !found || line < 0 || line > idx.entries[i].lines {
+		return 0, false
+	}
+	return idx.entries[i].decl + line, true
+}
@@ -0,0 +1,66 @@
+package silo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineIndex(t *testing.T) {
+	blob := SiloFile{Path: "blob.bin", Base64: true}
+	blob.SetBytes(bytes.Repeat([]byte{0, 1, 2}, 100))
+	doc := &SiloDocument{
+		Header: &FormatHeader{Created: time.Unix(0, 0)},
+		Files: []SiloFile{
+			{Path: "b.txt", Content: "one\ntwo\n"},
+			{Path: "link", LinkTarget: "b.txt"},
+			blob,
+			{Path: "a.txt", Content: "no newline"},
+			{Path: "last.txt", Content: "x\n"},
+		},
+	}
+	opts := WriteOptions{MarkMissingNewline: true}
+	var buf bytes.Buffer
+	if err := doc.WriteToWithOptions(&buf, opts); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	archive := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	index, err := doc.LineIndexWithOptions(opts)
+	if err != nil {
+		t.Fatalf("LineIndexWithOptions failed: %v", err)
+	}
+	for n, text := range archive {
+		archiveLine := n + 1
+		path, line, ok := index.Locate(archiveLine)
+		switch {
+		case archiveLine == 1 || text == noNewlineMarker:
+			if ok {
+				t.Errorf("line %d %q: expected no entry, got %s:%d", archiveLine, text, path, line)
+			}
+			continue
+		case !ok:
+			t.Errorf("line %d %q: not located", archiveLine, text)
+			continue
+		case line == 0 && !strings.HasPrefix(text, doc.Delimiter+" "+path):
+			t.Errorf("line %d %q: expected the declaration of %s", archiveLine, text, path)
+		}
+		if back, ok := index.ArchiveLine(path, line); !ok || back != archiveLine {
+			t.Errorf("ArchiveLine(%s, %d) = %d, %v, want %d", path, line, back, ok, archiveLine)
+		}
+	}
+
+	if got, _ := index.ArchiveLine("b.txt", 2); archive[got-1] != "two" {
+		t.Errorf("Expected b.txt:2 to be \"two\", got %q", archive[got-1])
+	}
+	if got, _ := index.ArchiveLine("a.txt", 1); archive[got-1] != "no newline" {
+		t.Errorf("Expected a.txt:1 to be \"no newline\", got %q", archive[got-1])
+	}
+	if _, _, ok := index.Locate(len(archive) + 1); ok {
+		t.Error("Expected a line past the end not to be located")
+	}
+	if _, ok := index.ArchiveLine("b.txt", 3); ok {
+		t.Error("Expected b.txt:3 not to exist")
+	}
+}
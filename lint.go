@@ -0,0 +1,168 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintSeverity ranks a LintIssue.
+type LintSeverity int
+
+const (
+	// LintWarning marks something that is probably a mistake but can still
+	// be written and unpacked.
+	LintWarning LintSeverity = iota
+	// LintError marks a problem Validate reports: the document cannot be
+	// written or unpacked as it is.
+	LintError
+)
+
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Lint rule names, as reported in LintIssue.Rule and accepted by
+// LintOptions.Disable.
+const (
+	LintRuleInvalid          = "invalid"
+	LintRuleLargeFile        = "large-file"
+	LintRuleBinary           = "binary"
+	LintRuleAbsolutePath     = "absolute-path"
+	LintRuleMixedLineEndings = "mixed-line-endings"
+	LintRuleDuplicateContent = "duplicate-content"
+)
+
+// LintRules lists every rule Lint applies, in the order they are checked.
+var LintRules = []string{
+	LintRuleInvalid,
+	LintRuleLargeFile,
+	LintRuleBinary,
+	LintRuleAbsolutePath,
+	LintRuleMixedLineEndings,
+	LintRuleDuplicateContent,
+}
+
+// LintIssue is one finding of Lint.
+type LintIssue struct {
+	// Path is the entry the issue belongs to, or "" for the document.
+	Path string
+	// Line is the 1-based line within the entry's content, when relevant.
+	Line     int
+	Rule     string
+	Severity LintSeverity
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	location := i.Path
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", i.Path, i.Line)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s: %s [%s]", i.Severity, i.Message, i.Rule)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, i.Severity, i.Message, i.Rule)
+}
+
+// defaultLintMaxFileSize is the entry size above which Lint warns.
+const defaultLintMaxFileSize = 1 << 20
+
+// LintOptions configures LintWithOptions.
+type LintOptions struct {
+	// MaxFileSize is the entry size, in bytes, above which an entry is
+	// reported as suspiciously large. Zero uses 1 MiB; a negative value
+	// turns the rule off.
+	MaxFileSize int64
+	// Disable lists rules, by name, that are not applied.
+	Disable []string
+}
+
+// Lint returns every problem Validate finds, as errors, followed by
+// warnings about things that are valid but usually a mistake: very large
+// entries, binary-looking content stored as text, paths that look absolute
+// on some platform (a drive letter, a leading "~", backslashes), content
+// mixing CRLF and LF line endings, and entries with identical content.
+// Mixed line endings are only visible in content read with
+// LineEndingsPreserve.
+func (doc *SiloDocument) Lint() []LintIssue {
+	return doc.LintWithOptions(LintOptions{})
+}
+
+// LintWithOptions is Lint with the limits and rules in opts.
+func (doc *SiloDocument) LintWithOptions(opts LintOptions) []LintIssue {
+	enabled := func(rule string) bool {
+		for _, disabled := range opts.Disable {
+			if disabled == rule {
+				return false
+			}
+		}
+		return true
+	}
+	maxFileSize := opts.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = defaultLintMaxFileSize
+	}
+
+	var issues []LintIssue
+	if enabled(LintRuleInvalid) {
+		for _, problem := range doc.Validate() {
+			issues = append(issues, LintIssue{Path: problem.Path, Line: problem.Line, Rule: LintRuleInvalid, Severity: LintError, Message: problem.Problem})
+		}
+	}
+
+	warn := func(path, rule, format string, args ...interface{}) {
+		if enabled(rule) {
+			issues = append(issues, LintIssue{Path: path, Rule: rule, Severity: LintWarning, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	for _, file := range doc.Files {
+		if reason := absolutePathLook(file.Path); reason != "" {
+			warn(file.Path, LintRuleAbsolutePath, "path %s", reason)
+		}
+		if file.LinkTarget != "" || file.Ref != "" {
+			continue
+		}
+		if maxFileSize > 0 && int64(file.Size()) > maxFileSize {
+			warn(file.Path, LintRuleLargeFile, "%d bytes, over %d", file.Size(), maxFileSize)
+		}
+		if !file.Base64 && IsBinary(file.Bytes()) {
+			warn(file.Path, LintRuleBinary, "content looks binary but is stored as text; pack it as base64")
+		}
+		if !file.Base64 && hasMixedLineEndings(file.text()) {
+			warn(file.Path, LintRuleMixedLineEndings, "content mixes CRLF and LF line endings")
+		}
+	}
+
+	if enabled(LintRuleDuplicateContent) {
+		for _, group := range doc.FindDuplicates() {
+			for _, path := range group.Paths[1:] {
+				warn(path, LintRuleDuplicateContent, "same content as %s", group.Paths[0])
+			}
+		}
+	}
+	return issues
+}
+
+// absolutePathLook describes why path would look absolute or rooted on some
+// platform, or returns "".
+func absolutePathLook(path string) string {
+	switch {
+	case len(path) >= 2 && path[1] == ':' && (path[0]|0x20 >= 'a' && path[0]|0x20 <= 'z'):
+		return "starts with a drive letter"
+	case strings.HasPrefix(path, "~"):
+		return "starts with ~, a home directory to shells"
+	case strings.Contains(path, `\`):
+		return `contains \, a separator on Windows`
+	}
+	return ""
+}
+
+// hasMixedLineEndings reports whether content has both CRLF and bare LF
+// line endings.
+func hasMixedLineEndings(content string) bool {
+	crlf := strings.Count(content, "\r\n")
+	return crlf > 0 && crlf < strings.Count(content, "\n")
+}
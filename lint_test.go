@@ -0,0 +1,106 @@
+package silo
+
+import (
+	"strings"
+	"testing"
+)
+
+// lintRules returns the rule of each issue for path, in order.
+func lintRules(issues []LintIssue, path string) []string {
+	var rules []string
+	for _, issue := range issues {
+		if issue.Path == path {
+			rules = append(rules, issue.Rule)
+		}
+	}
+	return rules
+}
+
+func TestLint(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "../escape.txt", Content: "x\n"},
+		{Path: "big.txt", Content: strings.Repeat("a", defaultLintMaxFileSize+1)},
+		{Path: "blob.bin", Content: "\x00\x01\x02\x03"},
+		{Path: `C:\windows.txt`, Content: "drive\n"},
+		{Path: "~/home.txt", Content: "home\n"},
+		{Path: "mixed.txt", Content: "one\r\ntwo\n"},
+		{Path: "copy/a.txt", Content: "same\n"},
+		{Path: "copy/b.txt", Content: "same\n"},
+		{Path: "fine.txt", Content: "fine\n"},
+	}}
+
+	issues := doc.Lint()
+	cases := []struct {
+		path  string
+		rules []string
+	}{
+		{"../escape.txt", []string{LintRuleInvalid}},
+		{"big.txt", []string{LintRuleLargeFile}},
+		{"blob.bin", []string{LintRuleBinary}},
+		{`C:\windows.txt`, []string{LintRuleAbsolutePath}},
+		{"~/home.txt", []string{LintRuleAbsolutePath}},
+		{"mixed.txt", []string{LintRuleMixedLineEndings}},
+		{"copy/a.txt", nil},
+		{"copy/b.txt", []string{LintRuleDuplicateContent}},
+		{"fine.txt", nil},
+	}
+	for _, c := range cases {
+		got := lintRules(issues, c.path)
+		if strings.Join(got, ",") != strings.Join(c.rules, ",") {
+			t.Errorf("%s: expected rules %v, got %v", c.path, c.rules, got)
+		}
+	}
+
+	for _, issue := range issues {
+		wantSeverity := LintWarning
+		if issue.Rule == LintRuleInvalid {
+			wantSeverity = LintError
+		}
+		if issue.Severity != wantSeverity {
+			t.Errorf("%s: expected severity %v, got %v", issue, wantSeverity, issue.Severity)
+		}
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "same content\n"},
+		{Path: "b.txt", Content: "same content\n"},
+	}}
+
+	issues := doc.LintWithOptions(LintOptions{MaxFileSize: 4})
+	if got := lintRules(issues, "a.txt"); len(got) != 1 || got[0] != LintRuleLargeFile {
+		t.Errorf("Expected a.txt to be large with MaxFileSize 4, got %v", got)
+	}
+
+	issues = doc.LintWithOptions(LintOptions{MaxFileSize: -1, Disable: []string{LintRuleDuplicateContent}})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestLintBase64NotBinary(t *testing.T) {
+	file := SiloFile{Path: "blob.bin", Base64: true}
+	file.SetBytes([]byte{0, 1, 2, 3})
+	doc := &SiloDocument{Files: []SiloFile{file}}
+
+	if issues := doc.Lint(); len(issues) != 0 {
+		t.Errorf("Expected base64 content to pass, got %v", issues)
+	}
+}
+
+func TestLintIssueString(t *testing.T) {
+	cases := []struct {
+		issue    LintIssue
+		expected string
+	}{
+		{LintIssue{Path: "a.txt", Line: 3, Rule: LintRuleInvalid, Severity: LintError, Message: "bad"}, "a.txt:3: error: bad [invalid]"},
+		{LintIssue{Path: "a.txt", Rule: LintRuleBinary, Message: "binary"}, "a.txt: warning: binary [binary]"},
+		{LintIssue{Rule: LintRuleInvalid, Severity: LintError, Message: "no files"}, "error: no files [invalid]"},
+	}
+	for _, c := range cases {
+		if got := c.issue.String(); got != c.expected {
+			t.Errorf("String() = %q, want %q", got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package silo
+
+import "log/slog"
+
+// logDebug records a per-file event on logger, if one was configured.
+func logDebug(logger *slog.Logger, msg string, args ...interface{}) {
+	if logger != nil {
+		logger.Debug(msg, args...)
+	}
+}
+
+// logPacked records that file was added to a document being packed.
+func logPacked(logger *slog.Logger, file SiloFile) {
+	if file.LinkTarget != "" {
+		logDebug(logger, "packed link", "path", file.Path, "target", file.LinkTarget)
+		return
+	}
+	logDebug(logger, "packed file", "path", file.Path, "bytes", file.Size())
+}
@@ -0,0 +1,72 @@
+package silo
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// logEvents returns a logger recording debug events as JSON, and a func
+// decoding what it recorded.
+func logEvents(t *testing.T) (*slog.Logger, func() []map[string]interface{}) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return logger, func() []map[string]interface{} {
+		var events []map[string]interface{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var event map[string]interface{}
+			if err := dec.Decode(&event); err != nil {
+				t.Fatalf("Invalid log output: %v", err)
+			}
+			events = append(events, event)
+		}
+		return events
+	}
+}
+
+func TestReadDirectoryTreeLogger(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("bb\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "skip.log"), []byte("x\n"), 0644)
+
+	logger, events := logEvents(t)
+	if _, err := ReadDirectoryTreeWithOptions(dir, ReadDirectoryTreeOptions{Exclude: []string{"*.log"}, Logger: logger}); err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+
+	got := events()
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 events, got %v", got)
+	}
+	if got[0]["msg"] != "skipped file" || got[0]["path"] != "skip.log" || got[0]["reason"] != "excluded" {
+		t.Errorf("Unexpected skip event: %v", got[0])
+	}
+	if got[1]["msg"] != "packed file" || got[1]["path"] != "a.txt" || got[1]["bytes"] != float64(2) {
+		t.Errorf("Unexpected pack event: %v", got[1])
+	}
+	if got[2]["path"] != "b.txt" {
+		t.Errorf("Pack events should follow document order: %v", got[2])
+	}
+}
+
+func TestWriteToDirectoryLogger(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "link", LinkTarget: "a.txt"},
+	}}
+
+	logger, events := logEvents(t)
+	if err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{Logger: logger}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+
+	got := events()
+	if len(got) != 2 || got[0]["msg"] != "unpacked file" || got[1]["msg"] != "unpacked link" || got[1]["target"] != "a.txt" {
+		t.Errorf("Unexpected events: %v", got)
+	}
+}
@@ -0,0 +1,36 @@
+package silo
+
+import "strings"
+
+// noNewlineMarker follows the last content line of an entry whose content
+// does not end in a newline, as in diff output. WriteToWithOptions emits it
+// when WriteOptions.MarkMissingNewline is set, and the parser always
+// recognizes it, so writing content whose last line reads as the marker is
+// an error.
+const noNewlineMarker = `\ No newline at end of file`
+
+// takeNoNewlineMarker removes a trailing marker line from lines, reporting
+// whether there was one. A marker with no content line before it is left
+// alone.
+func takeNoNewlineMarker(lines []contentLine) ([]contentLine, bool) {
+	n := len(lines)
+	if n < 2 || lines[n-1].text != noNewlineMarker {
+		return lines, false
+	}
+	return lines[:n-1], true
+}
+
+// trimFinalNewline removes one trailing LF or CRLF from content.
+func trimFinalNewline(content string) string {
+	if strings.HasSuffix(content, "\r\n") {
+		return content[:len(content)-2]
+	}
+	return strings.TrimSuffix(content, "\n")
+}
+
+// endsWithNoNewlineMarker reports whether content's last line would be read
+// back as the missing-newline marker. A marker that is the only line is read
+// as content, as takeNoNewlineMarker leaves it alone.
+func endsWithNoNewlineMarker(content string) bool {
+	return strings.HasSuffix(trimFinalNewline(content), "\n"+noNewlineMarker)
+}
@@ -0,0 +1,82 @@
+package silo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarkMissingNewlineRoundTrip(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "no newline"},
+		{Path: "b.txt", Content: "has newline\n"},
+		{Path: "c.txt", Content: "one\r\ntwo"},
+	}}
+
+	var out strings.Builder
+	if err := doc.WriteToWithOptions(&out, WriteOptions{MarkMissingNewline: true}); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	expected := "> a.txt\nno newline\n\\ No newline at end of file\n> b.txt\nhas newline\n> c.txt\none\r\ntwo\n\\ No newline at end of file\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected output:\n%q", out.String())
+	}
+
+	parsed, err := ParseSiloFileWithOptions(strings.NewReader(out.String()), ParseOptions{LineEndings: LineEndingsPreserve})
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	for i, file := range parsed.Files {
+		if file.Content != doc.Files[i].Content {
+			t.Errorf("%s: expected %q, got %q", file.Path, doc.Files[i].Content, file.Content)
+		}
+	}
+}
+
+func TestWriteWithoutMarkAddsNewline(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "no newline"}}}
+
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if out.String() != "> a.txt\nno newline\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
+func TestNoNewlineMarkerEdgeCases(t *testing.T) {
+	// A marker with nothing before it is ordinary content.
+	doc, err := ParseSiloFile(strings.NewReader("> a.txt\n\\ No newline at end of file\n"))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Files[0].Content != "\\ No newline at end of file\n" {
+		t.Errorf("Unexpected content: %q", doc.Files[0].Content)
+	}
+
+	doc = &SiloDocument{Files: []SiloFile{{Path: "a.diff", Content: "-x\n\\ No newline at end of file\n"}}}
+	problems := doc.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0].Problem, "missing-newline marker") {
+		t.Errorf("Expected Validate to report the ambiguous marker, got %v", problems)
+	}
+	if err := doc.WriteTo(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "missing-newline marker") {
+		t.Errorf("Expected WriteTo to refuse the ambiguous marker, got %v", err)
+	}
+	doc.Files[0].Base64 = true
+	var out bytes.Buffer
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if parsed, err := ParseSiloFile(&out); err != nil || parsed.Files[0].Content != doc.Files[0].Content {
+		t.Errorf("Expected base64 content to survive, got %+v, %v", parsed, err)
+	}
+}
+
+func TestNormalizeKeepMissingNewlines(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "./a.txt", Content: "x"}}}
+	doc.NormalizeWithOptions(NormalizeOptions{KeepMissingNewlines: true})
+	if doc.Files[0].Path != "a.txt" || doc.Files[0].Content != "x" {
+		t.Errorf("Unexpected normalized file: %+v", doc.Files[0])
+	}
+}
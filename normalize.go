@@ -0,0 +1,52 @@
+package silo
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Normalize puts doc into canonical form so that WriteTo produces the same
+// bytes for the same set of files regardless of how the document was built:
+//
+//   - paths use forward slashes, are cleaned, and lose any leading "./"
+//   - entries are sorted by path (byte-wise, stable)
+//   - non-empty text content ends with exactly the newline WriteTo would add
+//     (base64 entries keep their bytes unchanged)
+//
+// WriteTo itself is deterministic: given equal documents and delimiters it
+// always emits identical output, and auto-selected delimiters depend only on
+// file content. Together with Normalize, archives can be used as cache keys
+// and diffed reliably. A FormatHeader with a Created time is the only input
+// that varies between runs; leave it unset for reproducible output.
+func (doc *SiloDocument) Normalize() {
+	doc.NormalizeWithOptions(NormalizeOptions{})
+}
+
+// NormalizeOptions configures NormalizeWithOptions.
+type NormalizeOptions struct {
+	// KeepMissingNewlines leaves content without a final newline as it is,
+	// for documents written with WriteOptions.MarkMissingNewline.
+	KeepMissingNewlines bool
+}
+
+// NormalizeWithOptions is Normalize with the adjustments set in opts.
+func (doc *SiloDocument) NormalizeWithOptions(opts NormalizeOptions) {
+	for i := range doc.Files {
+		file := &doc.Files[i]
+		file.Path = canonicalPath(file.Path)
+		if content := file.text(); content != "" && !strings.HasSuffix(content, "\n") && !opts.KeepMissingNewlines && !file.Base64 {
+			file.SetText(file.Text() + "\n")
+		}
+	}
+
+	sort.SliceStable(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+}
+
+// canonicalPath converts p to a clean, slash-separated relative path.
+func canonicalPath(p string) string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	return strings.TrimPrefix(p, "./")
+}
@@ -0,0 +1,75 @@
+package silo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	doc := &SiloDocument{
+		Files: []SiloFile{
+			{Path: "src\\util.go", Content: "package src"},
+			{Path: "./README.md", Content: "# readme\n"},
+			{Path: "docs//guide.md", Content: ""},
+		},
+	}
+
+	doc.Normalize()
+
+	expected := []SiloFile{
+		{Path: "README.md", Content: "# readme\n"},
+		{Path: "docs/guide.md", Content: ""},
+		{Path: "src/util.go", Content: "package src\n"},
+	}
+	if len(doc.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(doc.Files))
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(doc.Files[i], want) {
+			t.Errorf("File %d: expected %+v, got %+v", i, want, doc.Files[i])
+		}
+	}
+}
+
+func TestReproducibleOutput(t *testing.T) {
+	build := func(order []int) string {
+		files := []SiloFile{
+			{Path: "b.txt", Content: "> quoted\nbeta"},
+			{Path: "a.txt", Content: "alpha\n"},
+			{Path: "c/d.txt", Content: "= heading\n"},
+		}
+		doc := &SiloDocument{}
+		for _, i := range order {
+			doc.Files = append(doc.Files, files[i])
+		}
+		doc.Normalize()
+
+		var buf strings.Builder
+		if err := doc.WriteTo(&buf); err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	first := build([]int{0, 1, 2})
+	for _, order := range [][]int{{2, 1, 0}, {1, 2, 0}, {0, 1, 2}} {
+		if got := build(order); got != first {
+			t.Errorf("Output for order %v differs:\n%s\nvs\n%s", order, got, first)
+		}
+	}
+
+	parsed, err := ParseSiloFile(strings.NewReader(first))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	parsed.Delimiter = ""
+	parsed.Normalize()
+	var buf strings.Builder
+	if err := parsed.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if buf.String() != first {
+		t.Errorf("Parse and rewrite changed output:\n%s\nvs\n%s", buf.String(), first)
+	}
+}
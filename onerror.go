@@ -0,0 +1,35 @@
+package silo
+
+import "sort"
+
+// SkippedFile is a file a read left out because it could not be read.
+type SkippedFile struct {
+	// Path is the entry path the file would have had.
+	Path string
+	// Err is the error reading it.
+	Err error
+}
+
+// tolerateFileError decides whether a read goes on past err, met reading
+// path. If onError returns nil, or there is no onError and continueOnError
+// is set, path is recorded in doc.Skipped and nil is returned; otherwise
+// the error to stop the read with is returned.
+func (doc *SiloDocument) tolerateFileError(path string, err error, onError func(path string, err error) error, continueOnError bool) error {
+	switch {
+	case onError != nil:
+		if stop := onError(path, err); stop != nil {
+			return stop
+		}
+	case !continueOnError:
+		return err
+	}
+	doc.Skipped = append(doc.Skipped, SkippedFile{Path: path, Err: err})
+	return nil
+}
+
+// sortSkipped sorts skipped by path, as read documents sort their files.
+func sortSkipped(skipped []SkippedFile) {
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Path < skipped[j].Path
+	})
+}
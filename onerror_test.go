@@ -0,0 +1,121 @@
+package silo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupUnreadableTree creates a tree with a readable file and a dangling
+// symlink, which fails to read even as root.
+func setupUnreadableTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "good.txt"), []byte("good\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink("missing.txt", filepath.Join(root, "broken.txt")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	return root
+}
+
+func TestReadDirectoryTreeStopsOnError(t *testing.T) {
+	root := setupUnreadableTree(t)
+
+	if _, err := ReadDirectoryTree(root); err == nil {
+		t.Fatal("Expected an error for the dangling symlink")
+	}
+}
+
+func TestReadDirectoryTreeContinueOnError(t *testing.T) {
+	root := setupUnreadableTree(t)
+
+	doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+	if paths := docPaths(doc); len(paths) != 1 || paths[0] != "good.txt" {
+		t.Errorf("Expected only good.txt, got %v", paths)
+	}
+	if len(doc.Skipped) != 1 || doc.Skipped[0].Path != "broken.txt" || !errors.Is(doc.Skipped[0].Err, os.ErrNotExist) {
+		t.Errorf("Expected broken.txt skipped as not existing, got %+v", doc.Skipped)
+	}
+}
+
+func TestReadDirectoryTreeOnError(t *testing.T) {
+	root := setupUnreadableTree(t)
+	stop := errors.New("stop")
+
+	var seen []string
+	_, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{
+		OnError: func(path string, err error) error {
+			seen = append(seen, path)
+			return stop
+		},
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected the handler's error, got %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "broken.txt" {
+		t.Errorf("Expected the handler to see broken.txt, got %v", seen)
+	}
+}
+
+func TestReadFilesContinueOnError(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	paths := []string{"a.txt", "missing.txt"}
+
+	if _, err := ReadFilesWithOptions(paths, ReadFilesOptions{WorkingDir: dir}); err == nil {
+		t.Fatal("Expected an error for missing.txt")
+	}
+
+	var seen []string
+	doc, err := ReadFilesWithOptions(paths, ReadFilesOptions{
+		WorkingDir: dir,
+		OnError: func(path string, err error) error {
+			seen = append(seen, path)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ReadFilesWithOptions failed: %v", err)
+	}
+	if got := docPaths(doc); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("Expected only a.txt, got %v", got)
+	}
+	if len(seen) != 1 || seen[0] != "missing.txt" || len(doc.Skipped) != 1 || doc.Skipped[0].Path != "missing.txt" {
+		t.Errorf("Expected missing.txt to be handled and skipped, got %v and %+v", seen, doc.Skipped)
+	}
+}
+
+func TestReadContentsOnError(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.txt")
+	if err := os.WriteFile(good, []byte("good\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	files := []SiloFile{{Path: "a"}, {Path: "b"}, {Path: "c"}}
+	fullPaths := []string{filepath.Join(dir, "gone-a"), good, filepath.Join(dir, "gone-c")}
+
+	var failed []int
+	err := readContents(context.Background(), files, fullPaths, 2, false, func(i int, err error) error {
+		failed = append(failed, i)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readContents failed: %v", err)
+	}
+	if len(failed) != 2 || failed[0] != 0 || failed[1] != 2 {
+		t.Errorf("Expected failures for files 0 and 2 in order, got %v", failed)
+	}
+	if !strings.Contains(files[1].Content, "good") {
+		t.Errorf("Expected file 1 to be read, got %q", files[1].Content)
+	}
+}
@@ -0,0 +1,243 @@
+package silo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// bodyPlaceholder replaces an elided function body.
+const bodyPlaceholder = "..."
+
+// Outline reduces code entries to their structure, keeping declarations
+// and signatures and replacing function bodies with "...", so a large
+// codebase fits an archive meant as an overview. Go files are parsed with
+// go/parser and left alone if they do not parse; Python is outlined by
+// indentation, and C-family languages (C, C++, C#, Java, JavaScript,
+// TypeScript, Rust, Kotlin, Swift and others) by matching braces after
+// lines that look like function signatures. Languages come from
+// SiloFile.Lang; entries of other languages, links, references and base64
+// entries are left alone. It returns the paths of the entries it changed.
+func (doc *SiloDocument) Outline() []string {
+	var outlined []string
+	for i := range doc.Files {
+		file := &doc.Files[i]
+		if file.LinkTarget != "" || file.Ref != "" || file.Base64 {
+			continue
+		}
+		var outline string
+		switch file.Lang() {
+		case "go":
+			outline = outlineGo(file.text())
+		case "python":
+			outline = outlinePython(file.text())
+		case "rust":
+			outline = outlineBraces(file.text(), true)
+		case "c", "cpp", "csharp", "java", "javascript", "typescript", "kotlin", "scala", "swift", "php", "dart":
+			outline = outlineBraces(file.text(), false)
+		default:
+			continue
+		}
+		if outline != file.text() {
+			file.SetText(outline)
+			outlined = append(outlined, file.Path)
+		}
+	}
+	return outlined
+}
+
+// outlineGo replaces the body of every function and method in src with
+// "{ ... }". Source that does not parse is returned unchanged.
+func outlineGo(src string) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return src
+	}
+	var b strings.Builder
+	last := 0
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		lbrace := fset.Position(fn.Body.Lbrace).Offset
+		rbrace := fset.Position(fn.Body.Rbrace).Offset
+		b.WriteString(src[last : lbrace+1])
+		b.WriteString(" " + bodyPlaceholder + " ")
+		last = rbrace
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// outlinePython replaces the body of every def in src with an indented
+// "...", keeping decorators, signatures and class bodies.
+func outlinePython(src string) string {
+	lines := strings.SplitAfter(src, "\n")
+	var b strings.Builder
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		b.WriteString(line)
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "def ") && !strings.HasPrefix(trimmed, "async def ") {
+			continue
+		}
+		indent := indentation(line)
+		// A signature may span lines, until its parentheses close.
+		depth := strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+		for depth > 0 && i+1 < len(lines) {
+			i++
+			b.WriteString(lines[i])
+			trimmed = strings.TrimSpace(lines[i])
+			depth += strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+		}
+		if !strings.HasSuffix(stripPythonComment(trimmed), ":") {
+			continue // a one-line def such as "def f(): return 1"
+		}
+		body := i + 1
+		for body < len(lines) && (strings.TrimSpace(lines[body]) == "" || len(indentation(lines[body])) > len(indent)) {
+			body++
+		}
+		// Blank lines before the next statement stay with it.
+		for body > i+1 && strings.TrimSpace(lines[body-1]) == "" {
+			body--
+		}
+		if body > i+1 {
+			b.WriteString(indent + "    " + bodyPlaceholder + "\n")
+			i = body - 1
+		}
+	}
+	return b.String()
+}
+
+// stripPythonComment removes a trailing "#" comment from a line. It does
+// not look inside strings, which is close enough for signatures.
+func stripPythonComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+// indentation returns the leading whitespace of line.
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// controlKeywords start lines whose braces open blocks that are not
+// function bodies.
+var controlKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "foreach": true, "while": true, "do": true,
+	"switch": true, "case": true, "try": true, "catch": true, "finally": true,
+	"return": true, "using": true, "lock": true, "synchronized": true, "match": true,
+	"loop": true, "unsafe": true, "when": true, "with": true, "guard": true, "defer": true,
+}
+
+// outlineBraces replaces the bodies of brace-delimited functions in src with
+// "{ ... }". A body starts with a "{" ending a line that looks like a
+// signature, or alone on the line after one, and ends at its matching
+// brace, counted outside string literals and line comments. With
+// lifetimes, as in Rust, a single quote only starts a character literal.
+func outlineBraces(src string, lifetimes bool) string {
+	lines := strings.SplitAfter(src, "\n")
+	var b strings.Builder
+	prevSignature := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		opensBody := strings.HasSuffix(trimmed, "{") &&
+			(looksLikeSignature(strings.TrimSuffix(trimmed, "{")) || trimmed == "{" && prevSignature)
+		if trimmed != "" {
+			prevSignature = looksLikeSignature(trimmed)
+		}
+		if !opensBody {
+			b.WriteString(line)
+			continue
+		}
+
+		depth := braceDelta(line, lifetimes)
+		end := i
+		for depth > 0 && end+1 < len(lines) {
+			end++
+			depth += braceDelta(lines[end], lifetimes)
+		}
+		if depth > 0 || end == i {
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(strings.TrimRight(line, " \t\r\n") + " " + bodyPlaceholder + " }")
+		// Keep whatever follows the closing brace, such as ");".
+		closing := strings.TrimRight(lines[end], "\r\n")
+		if rest := strings.TrimSpace(closing[strings.LastIndexByte(closing, '}')+1:]); rest != "" {
+			b.WriteString(rest)
+		}
+		if strings.HasSuffix(lines[end], "\n") {
+			b.WriteString("\n")
+		}
+		prevSignature = false
+		i = end
+	}
+	return b.String()
+}
+
+// looksLikeSignature reports whether line, without any trailing "{", looks
+// like the head of a function: it has a parameter list, does not start with
+// a control keyword and is not an assignment unless of an arrow function.
+func looksLikeSignature(line string) bool {
+	line = strings.TrimSpace(line)
+	paren := strings.IndexByte(line, '(')
+	if paren < 0 || !strings.Contains(line[paren:], ")") {
+		return false
+	}
+	word := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '('
+	})
+	if len(word) == 0 || controlKeywords[word[0]] || strings.HasPrefix(line, "}") {
+		return false
+	}
+	if strings.HasSuffix(line, ";") || strings.HasSuffix(line, ",") {
+		return false
+	}
+	if eq := strings.IndexByte(line[:paren], '='); eq >= 0 && !strings.Contains(line, "=>") {
+		return false
+	}
+	return true
+}
+
+// braceDelta returns the number of "{" minus the number of "}" in line,
+// skipping string and character literals and "//" comments. lifetimes is
+// as for outlineBraces.
+func braceDelta(line string, lifetimes bool) int {
+	delta := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'' && !lifetimes:
+			quote = c
+		case c == '\'':
+			// Only a character literal such as '{' or '\n'; a lone quote is
+			// a lifetime.
+			if i+2 < len(line) && line[i+2] == '\'' {
+				i += 2
+			} else if i+3 < len(line) && line[i+1] == '\\' && line[i+3] == '\'' {
+				i += 3
+			}
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return delta
+		case c == '{':
+			delta++
+		case c == '}':
+			delta--
+		}
+	}
+	return delta
+}
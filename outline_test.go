@@ -0,0 +1,60 @@
+package silo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		path, content, expected string
+	}{
+		{
+			"main.go",
+			"package main\n\nimport \"fmt\"\n\n// Greeter greets.\ntype Greeter struct{ Name string }\n\n// Greet says hello.\nfunc (g Greeter) Greet() string {\n\tif g.Name == \"\" {\n\t\treturn \"hi\"\n\t}\n\treturn fmt.Sprint(\"hi \", g.Name)\n}\n\nvar x = 1\n\nfunc main() {\n\tfmt.Println(Greeter{}.Greet())\n}\n",
+			"package main\n\nimport \"fmt\"\n\n// Greeter greets.\ntype Greeter struct{ Name string }\n\n// Greet says hello.\nfunc (g Greeter) Greet() string { ... }\n\nvar x = 1\n\nfunc main() { ... }\n",
+		},
+		{
+			"app.py",
+			"import os\n\nclass App:\n    \"\"\"An app.\"\"\"\n\n    @property\n    def name(self) -> str:\n        return os.name\n\n    async def run(self,\n                  port: int = 80):  # serve\n        await serve(port)\n\n        return None\n\ndef short(): return 1\n\nVALUE = 2\n",
+			"import os\n\nclass App:\n    \"\"\"An app.\"\"\"\n\n    @property\n    def name(self) -> str:\n        ...\n\n    async def run(self,\n                  port: int = 80):  # serve\n        ...\n\ndef short(): return 1\n\nVALUE = 2\n",
+		},
+		{
+			"web/app.ts",
+			"export class App {\n  private items: string[] = [];\n\n  add(item: string): void {\n    if (item) {\n      this.items.push('{' + item);\n    }\n  }\n}\n\nexport const handler = async (req: Request) => {\n  return new Response(\"}\");\n};\n\nconst config = configure({\n  debug: true,\n});\n",
+			"export class App {\n  private items: string[] = [];\n\n  add(item: string): void { ... }\n}\n\nexport const handler = async (req: Request) => { ... };\n\nconst config = configure({\n  debug: true,\n});\n",
+		},
+		{
+			"src/lib.rs",
+			"impl<'a> Parser<'a> {\n    pub fn next(&mut self) -> Option<char> {\n        let c = '{';\n        self.pos += 1;\n        Some(c)\n    }\n}\n",
+			"impl<'a> Parser<'a> {\n    pub fn next(&mut self) -> Option<char> { ... }\n}\n",
+		},
+		{
+			"Main.java",
+			"public class Main\n{\n    public static void main(String[] args)\n    {\n        System.out.println(\"hi\");\n    }\n}\n",
+			"public class Main\n{\n    public static void main(String[] args)\n    { ... }\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		doc := &SiloDocument{Files: []SiloFile{{Path: tt.path, Content: tt.content}}}
+		if got := doc.Outline(); !reflect.DeepEqual(got, []string{tt.path}) {
+			t.Errorf("%s: expected to be outlined, got %v", tt.path, got)
+		}
+		if got := doc.Files[0].Content; got != tt.expected {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestOutlineLeavesOthersAlone(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "README.md", Content: "func f() {\n}\n"},
+		{Path: "broken.go", Content: "package x\nfunc f() {\n"},
+		{Path: "types.go", Content: "package x\n\ntype T int\n"},
+		{Path: "link.go", LinkTarget: "types.go"},
+	}}
+	if got := doc.Outline(); got != nil {
+		t.Errorf("Expected nothing outlined, got %v", got)
+	}
+}
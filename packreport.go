@@ -0,0 +1,97 @@
+package silo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reasons a read leaves a file out, as passed to OnSkip and recorded in
+// PackReport.Skipped.
+const (
+	SkipExcluded    = "excluded"
+	SkipHidden      = "hidden"
+	SkipNotIncluded = "not included"
+	SkipBinary      = "binary"
+	SkipTooLarge    = "too large"
+	SkipIrregular   = "irregular"
+	SkipUnreadable  = "unreadable"
+)
+
+// SkipReason records a file, or a directory with a trailing "/", that a
+// read left out.
+type SkipReason struct {
+	Path   string
+	Reason string
+	// Err is the error reading the file, for SkipUnreadable.
+	Err error
+}
+
+func (s SkipReason) String() string {
+	if s.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", s.Path, s.Reason, s.Err)
+	}
+	return fmt.Sprintf("%s: %s", s.Path, s.Reason)
+}
+
+// PackReport records what a read packed and what it left out and why, so
+// callers can tell why a file is missing from an archive. Set
+// ReadDirectoryTreeOptions.Report or ReadFilesOptions.Report to have one
+// filled in.
+type PackReport struct {
+	// Included lists the paths of the packed entries, sorted.
+	Included []string
+	// Skipped lists what was left out, sorted by path.
+	Skipped []SkipReason
+}
+
+// Summary describes the report in one line, such as "12 files packed, 3
+// skipped (2 excluded, 1 binary)".
+func (r *PackReport) Summary() string {
+	summary := fmt.Sprintf("%d files packed, %d skipped", len(r.Included), len(r.Skipped))
+	if len(r.Skipped) == 0 {
+		return summary
+	}
+
+	counts := make(map[string]int)
+	var reasons []string
+	for _, skip := range r.Skipped {
+		if counts[skip.Reason] == 0 {
+			reasons = append(reasons, skip.Reason)
+		}
+		counts[skip.Reason]++
+	}
+	sort.Strings(reasons)
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", counts[reason], reason)
+	}
+	return summary + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// skip records path as left out for reason. It does nothing on a nil
+// report, so reads need not check whether one was asked for.
+func (r *PackReport) skip(path, reason string) {
+	if r != nil {
+		r.Skipped = append(r.Skipped, SkipReason{Path: path, Reason: reason})
+	}
+}
+
+// finish completes r once doc has been read: entries become Included,
+// files doc.Skipped holds are recorded as unreadable, and Skipped is sorted.
+func (r *PackReport) finish(doc *SiloDocument) {
+	if r == nil {
+		return
+	}
+	r.Included = make([]string, len(doc.Files))
+	for i, file := range doc.Files {
+		r.Included[i] = file.Path
+	}
+	sort.Strings(r.Included)
+	for _, unreadable := range doc.Skipped {
+		r.Skipped = append(r.Skipped, SkipReason{Path: unreadable.Path, Reason: SkipUnreadable, Err: unreadable.Err})
+	}
+	sort.SliceStable(r.Skipped, func(i, j int) bool {
+		return r.Skipped[i].Path < r.Skipped[j].Path
+	})
+}
@@ -0,0 +1,98 @@
+package silo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadDirectoryTreeReport(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"src/a.go":    "package a\n",
+		"src/b.bin":   "bin\x00",
+		"src/big.txt": "0123456789abcdef\n",
+		"logs/x.log":  "log\n",
+		"notes.md":    "notes\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	report := PackReport{Included: []string{"stale"}}
+	_, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{
+		Include:     []string{"src/**"},
+		Exclude:     []string{"logs"},
+		SkipBinary:  true,
+		MaxFileSize: 12,
+		SkipLarge:   true,
+		Report:      &report,
+	})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(report.Included, []string{"src/a.go"}) {
+		t.Errorf("Expected only src/a.go included, got %v", report.Included)
+	}
+	expected := []SkipReason{
+		{Path: "logs/", Reason: SkipExcluded},
+		{Path: "notes.md", Reason: SkipNotIncluded},
+		{Path: "src/b.bin", Reason: SkipBinary},
+		{Path: "src/big.txt", Reason: SkipTooLarge},
+	}
+	if !reflect.DeepEqual(report.Skipped, expected) {
+		t.Errorf("Expected skipped %v, got %v", expected, report.Skipped)
+	}
+	if got, want := report.Summary(), "1 files packed, 4 skipped (1 binary, 1 excluded, 1 not included, 1 too large)"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestReadFilesReport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "large.txt"), []byte("larger than the limit\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var report PackReport
+	doc, err := ReadFilesWithOptions([]string{"small.txt", "large.txt", "missing.txt"}, ReadFilesOptions{
+		WorkingDir:      dir,
+		MaxFileSize:     10,
+		SkipLarge:       true,
+		ContinueOnError: true,
+		Report:          &report,
+	})
+	if err != nil {
+		t.Fatalf("ReadFilesWithOptions failed: %v", err)
+	}
+	if paths := docPaths(doc); len(paths) != 1 || paths[0] != "small.txt" {
+		t.Errorf("Expected only small.txt, got %v", paths)
+	}
+	if len(report.Skipped) != 2 {
+		t.Fatalf("Expected 2 skipped files, got %v", report.Skipped)
+	}
+	if report.Skipped[0].Path != "large.txt" || report.Skipped[0].Reason != SkipTooLarge {
+		t.Errorf("Expected large.txt too large, got %v", report.Skipped[0])
+	}
+	if report.Skipped[1].Path != "missing.txt" || report.Skipped[1].Reason != SkipUnreadable || report.Skipped[1].Err == nil {
+		t.Errorf("Expected missing.txt unreadable with its error, got %v", report.Skipped[1])
+	}
+}
+
+func TestPackReportSummaryNothingSkipped(t *testing.T) {
+	report := PackReport{Included: []string{"a", "b"}}
+	if got, want := report.Summary(), "2 files packed, 0 skipped"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
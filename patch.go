@@ -0,0 +1,260 @@
+package silo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ApplyPatchToDocument applies the unified diff read from patch, as written
+// by diff -u, git diff or UnifiedDiff, to the files in fsys and returns a
+// document holding the patched version of every file it changes. fsys is
+// not modified; use os.DirFS for a directory. Paths are read from the ---
+// and +++ lines, dropping git's a/ and b/ prefixes. A hunk whose lines are
+// not found in the file fails with ErrPatchConflict; hunks may have moved,
+// but their context must match exactly. Deleted files cannot be expressed
+// in an archive, so they are left out and noted in the document's Warnings.
+func ApplyPatchToDocument(patch io.Reader, fsys fs.FS) (*SiloDocument, error) {
+	filePatches, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &SiloDocument{Delimiter: ">"}
+	seen := make(map[string]bool)
+	for _, fp := range filePatches {
+		if fp.newPath == "" {
+			doc.Warnings = append(doc.Warnings, fmt.Sprintf("patch deletes %s, which the archive leaves out", fp.oldPath))
+			continue
+		}
+		if err := validatePath(fp.newPath); err != nil {
+			return nil, err
+		}
+		if seen[fp.newPath] {
+			return nil, fmt.Errorf("%w: %s is patched more than once", ErrDuplicatePath, fp.newPath)
+		}
+		seen[fp.newPath] = true
+
+		var original []byte
+		if fp.oldPath != "" {
+			if err := validatePath(fp.oldPath); err != nil {
+				return nil, err
+			}
+			if original, err = fs.ReadFile(fsys, fp.oldPath); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", fp.oldPath, err)
+			}
+		}
+		patched, err := applyHunks(splitDiffLines(string(original)), fp.hunks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fp.newPath, err)
+		}
+		doc.Files = append(doc.Files, SiloFile{Path: fp.newPath, Content: strings.Join(patched, "")})
+	}
+
+	sort.Slice(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+	return doc, nil
+}
+
+// filePatch is the part of a patch that changes one file. oldPath is "" for
+// a new file and newPath is "" for a deleted one.
+type filePatch struct {
+	oldPath, newPath string
+	hunks            []hunk
+}
+
+// hunk is one @@ section of a patch. oldStart is the 1-based line the hunk
+// starts at, or the line before it when the hunk removes nothing from an
+// empty range. Lines keep their ' ', '-' or '+' prefix and, unless the
+// patch marks them as missing one, their newline.
+type hunk struct {
+	oldStart int
+	lines    []string
+}
+
+// parsePatch reads the file patches in a unified diff, ignoring anything
+// outside them such as commit messages and git's extended headers.
+func parsePatch(r io.Reader) ([]filePatch, error) {
+	var patches []filePatch
+	reader := bufio.NewReader(r)
+	lineNum := 0
+	var pending string // a --- line waiting for its +++ line
+	var current *filePatch
+	var oldLeft, newLeft int // lines still expected in the current hunk
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		lineNum++
+		text := strings.TrimSuffix(line, "\n")
+
+		if current != nil && (oldLeft > 0 || newLeft > 0) {
+			h := &current.hunks[len(current.hunks)-1]
+			switch {
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file" applies to the line before.
+				if len(h.lines) > 0 {
+					h.lines[len(h.lines)-1] = strings.TrimSuffix(h.lines[len(h.lines)-1], "\n")
+				}
+				continue
+			case text == "" || text[0] == ' ':
+				oldLeft--
+				newLeft--
+			case text[0] == '-':
+				oldLeft--
+			case text[0] == '+':
+				newLeft--
+			default:
+				return nil, fmt.Errorf("patch line %d: unexpected %q inside a hunk", lineNum, text)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("patch line %d: hunk is longer than its header says", lineNum)
+			}
+			if text == "" {
+				line = " " + line // some editors strip the space of blank context lines
+			}
+			h.lines = append(h.lines, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, `\`) && current != nil:
+			h := &current.hunks[len(current.hunks)-1]
+			if len(h.lines) > 0 {
+				h.lines[len(h.lines)-1] = strings.TrimSuffix(h.lines[len(h.lines)-1], "\n")
+			}
+		case strings.HasPrefix(text, "--- "):
+			pending = text[4:]
+			current = nil
+		case strings.HasPrefix(text, "+++ ") && pending != "":
+			patches = append(patches, filePatch{oldPath: patchPath(pending, "a/"), newPath: patchPath(text[4:], "b/")})
+			current = &patches[len(patches)-1]
+			pending = ""
+		case strings.HasPrefix(text, "@@ ") && current != nil:
+			h, oldCount, newCount, err := parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("patch line %d: %w", lineNum, err)
+			}
+			current.hunks = append(current.hunks, h)
+			oldLeft, newLeft = oldCount, newCount
+		case strings.HasPrefix(text, "GIT binary patch"), strings.HasPrefix(text, "Binary files "):
+			return nil, fmt.Errorf("patch line %d: binary patches are not supported", lineNum)
+		}
+	}
+	if oldLeft > 0 || newLeft > 0 {
+		return nil, fmt.Errorf("patch line %d: hunk is cut short", lineNum)
+	}
+	return patches, nil
+}
+
+// patchPath returns the path named on a --- or +++ line, without a trailing
+// timestamp or git's prefix, or "" for /dev/null.
+func patchPath(name, prefix string) string {
+	if tab := strings.IndexByte(name, '\t'); tab >= 0 {
+		name = name[:tab]
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, prefix)
+}
+
+// parseHunkHeader parses "@@ -start,count +start,count @@".
+func parseHunkHeader(text string) (hunk, int, int, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return hunk{}, 0, 0, fmt.Errorf("malformed hunk header %q", text)
+	}
+	oldStart, oldCount, err1 := parseHunkRange(fields[1][1:])
+	_, newCount, err2 := parseHunkRange(fields[2][1:])
+	if err1 != nil || err2 != nil {
+		return hunk{}, 0, 0, fmt.Errorf("malformed hunk header %q", text)
+	}
+	return hunk{oldStart: oldStart}, oldCount, newCount, nil
+}
+
+// parseHunkRange parses "start,count" or "start", which means a count of 1.
+func parseHunkRange(text string) (int, int, error) {
+	startText, countText, hasCount := strings.Cut(text, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil || start < 0 {
+		return 0, 0, errors.New("bad range")
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countText)
+	if err != nil || count < 0 {
+		return 0, 0, errors.New("bad range")
+	}
+	return start, count, nil
+}
+
+// applyHunks returns lines with hunks applied in order. Each hunk is placed
+// where its old lines match, as near as possible to where its header says,
+// once earlier hunks' shifts are accounted for.
+func applyHunks(lines []string, hunks []hunk) ([]string, error) {
+	var result []string
+	next := 0   // first line of lines not yet copied to result
+	offset := 0 // how far matches have been from their headers so far
+	for _, h := range hunks {
+		var old, replacement []string
+		for _, line := range h.lines {
+			if line[0] != '+' {
+				old = append(old, line[1:])
+			}
+			if line[0] != '-' {
+				replacement = append(replacement, line[1:])
+			}
+		}
+
+		want := h.oldStart - 1 + offset
+		if len(old) == 0 {
+			want = h.oldStart + offset
+		}
+		at := findLines(lines, old, want, next)
+		if at < 0 {
+			return nil, fmt.Errorf("%w: hunk at line %d does not match", ErrPatchConflict, h.oldStart)
+		}
+		offset += at - want
+		result = append(result, lines[next:at]...)
+		result = append(result, replacement...)
+		next = at + len(old)
+	}
+	return append(result, lines[next:]...), nil
+}
+
+// findLines returns the index at or after min where want appears in lines,
+// choosing the one closest to near, or -1.
+func findLines(lines, want []string, near, min int) int {
+	matches := func(at int) bool {
+		if at < min || at+len(want) > len(lines) {
+			return false
+		}
+		for i, line := range want {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for distance := 0; near-distance >= min || near+distance <= len(lines); distance++ {
+		if matches(near - distance) {
+			return near - distance
+		}
+		if matches(near + distance) {
+			return near + distance
+		}
+	}
+	return -1
+}
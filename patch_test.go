@@ -0,0 +1,87 @@
+package silo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestApplyPatchToDocument(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":   {Data: []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")},
+		"old.txt":   {Data: []byte("gone\n")},
+		"keep.txt":  {Data: []byte("untouched\n")},
+		"notes.txt": {Data: []byte("a\nb\nc\n")},
+	}
+	patch := "commit message lines are ignored\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -3,3 +3,3 @@ package main\n" +
+		" func main() {\n" +
+		"-\tprintln(\"hi\")\n" +
+		"+\tprintln(\"hello\")\n" +
+		" }\n" +
+		"diff --git a/new/file.txt b/new/file.txt\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/new/file.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+fresh\n" +
+		"\\ No newline at end of file\n" +
+		"--- a/old.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-gone\n" +
+		UnifiedDiff("notes.txt", "notes.txt", "a\nb\nc\n", "a\nB\nc\nd\n")
+
+	doc, err := ApplyPatchToDocument(strings.NewReader(patch), fsys)
+	if err != nil {
+		t.Fatalf("ApplyPatchToDocument failed: %v", err)
+	}
+	expected := map[string]string{
+		"main.go":      "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"new/file.txt": "fresh",
+		"notes.txt":    "a\nB\nc\nd\n",
+	}
+	if len(doc.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), docPaths(doc))
+	}
+	for _, file := range doc.Files {
+		if file.Content != expected[file.Path] {
+			t.Errorf("%s: got %q, want %q", file.Path, file.Content, expected[file.Path])
+		}
+	}
+	if len(doc.Warnings) != 1 || !strings.Contains(doc.Warnings[0], "old.txt") {
+		t.Errorf("Expected a warning about the deleted file, got %v", doc.Warnings)
+	}
+}
+
+func TestApplyPatchOffset(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("new first line\n1\n2\n3\n4\n")}}
+	patch := UnifiedDiff("a/a.txt", "b/a.txt", "1\n2\n3\n4\n", "1\n2\nthree\n4\n")
+
+	doc, err := ApplyPatchToDocument(strings.NewReader(patch), fsys)
+	if err != nil {
+		t.Fatalf("ApplyPatchToDocument failed: %v", err)
+	}
+	if got := doc.Files[0].Content; got != "new first line\n1\n2\nthree\n4\n" {
+		t.Errorf("Unexpected content %q", got)
+	}
+}
+
+func TestApplyPatchConflict(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("x\ny\n")}}
+	patch := UnifiedDiff("a/a.txt", "b/a.txt", "1\n2\n", "1\ntwo\n")
+
+	if _, err := ApplyPatchToDocument(strings.NewReader(patch), fsys); !errors.Is(err, ErrPatchConflict) {
+		t.Errorf("Expected ErrPatchConflict, got %v", err)
+	}
+
+	bad := "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n x\n"
+	if _, err := ApplyPatchToDocument(strings.NewReader(bad), fsys); err == nil {
+		t.Error("Expected an error for a truncated hunk")
+	}
+}
@@ -0,0 +1,88 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddPrefix puts every entry under the directory prefix, so "a.txt" becomes
+// "vendor/lib/a.txt" for the prefix "vendor/lib/". A trailing slash on
+// prefix is optional. Link targets are relative and move with their links,
+// so they are left alone. If any new path is invalid the document is left
+// unchanged.
+func (doc *SiloDocument) AddPrefix(prefix string) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if err := validatePath(prefix); err != nil {
+		return err
+	}
+	return doc.repath(func(path string) (string, bool, error) {
+		return prefix + "/" + path, true, nil
+	})
+}
+
+// StripPrefix removes the directory prefix from every entry path, so
+// "src/a.txt" becomes "a.txt" for the prefix "src/". A trailing slash on
+// prefix is optional. Every entry must be under prefix; otherwise an error
+// matching ErrInvalidPath is returned and the document is left unchanged.
+func (doc *SiloDocument) StripPrefix(prefix string) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if err := validatePath(prefix); err != nil {
+		return err
+	}
+	return doc.repath(func(path string) (string, bool, error) {
+		rest := strings.TrimPrefix(path, prefix+"/")
+		if rest == path {
+			return "", false, invalidPathError("%s is not under %s/", path, prefix)
+		}
+		return rest, true, nil
+	})
+}
+
+// StripComponents removes the first n directory components from every entry
+// path, as tar --strip-components does: with n = 1, "project/src/a.txt"
+// becomes "src/a.txt". Entries with n or fewer components are dropped. If
+// two entries end up with the same path an error matching ErrDuplicatePath
+// is returned and the document is left unchanged.
+func (doc *SiloDocument) StripComponents(n int) error {
+	if n < 0 {
+		return fmt.Errorf("negative component count %d", n)
+	}
+	if n == 0 {
+		return nil
+	}
+	return doc.repath(func(path string) (string, bool, error) {
+		parts := strings.SplitN(path, "/", n+1)
+		if len(parts) <= n {
+			return "", false, nil
+		}
+		return parts[n], true, nil
+	})
+}
+
+// repath rewrites every entry path with rewrite, dropping entries it does
+// not keep. New paths are validated and checked for duplicates before the
+// document is changed.
+func (doc *SiloDocument) repath(rewrite func(path string) (string, bool, error)) error {
+	files := make([]SiloFile, 0, len(doc.Files))
+	seen := make(map[string]bool, len(doc.Files))
+	for _, file := range doc.Files {
+		path, keep, err := rewrite(file.Path)
+		if err != nil {
+			return err
+		}
+		if !keep {
+			continue
+		}
+		if err := validatePath(path); err != nil {
+			return err
+		}
+		if seen[path] {
+			return fmt.Errorf("%w: %s", ErrDuplicatePath, path)
+		}
+		seen[path] = true
+		file.Path = path
+		files = append(files, file)
+	}
+	doc.Files = files
+	return nil
+}
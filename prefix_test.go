@@ -0,0 +1,75 @@
+package silo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddPrefix(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "dir/b.txt", LinkTarget: "../a.txt"},
+	}}
+
+	if err := doc.AddPrefix("vendor/lib/"); err != nil {
+		t.Fatalf("AddPrefix failed: %v", err)
+	}
+	if got := strings.Join(docPaths(doc), ","); got != "vendor/lib/a.txt,vendor/lib/dir/b.txt" {
+		t.Errorf("Unexpected paths: %s", got)
+	}
+	if doc.Files[1].LinkTarget != "../a.txt" {
+		t.Errorf("Expected link target to be kept, got %q", doc.Files[1].LinkTarget)
+	}
+
+	if err := doc.AddPrefix("../up"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "src/a.txt", Content: "a\n"},
+		{Path: "src/dir/b.txt", Content: "b\n"},
+	}}
+
+	if err := doc.StripPrefix("src"); err != nil {
+		t.Fatalf("StripPrefix failed: %v", err)
+	}
+	if got := strings.Join(docPaths(doc), ","); got != "a.txt,dir/b.txt" {
+		t.Errorf("Unexpected paths: %s", got)
+	}
+
+	if err := doc.StripPrefix("dir/"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for an entry outside the prefix, got %v", err)
+	}
+	if got := strings.Join(docPaths(doc), ","); got != "a.txt,dir/b.txt" {
+		t.Errorf("Expected document unchanged after a failed strip, got %s", got)
+	}
+}
+
+func TestStripComponents(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "README.md", Content: "top\n"},
+		{Path: "project/src/a.txt", Content: "a\n"},
+		{Path: "project/b.txt", Content: "b\n"},
+	}}
+
+	if err := doc.StripComponents(1); err != nil {
+		t.Fatalf("StripComponents failed: %v", err)
+	}
+	if got := strings.Join(docPaths(doc), ","); got != "src/a.txt,b.txt" {
+		t.Errorf("Unexpected paths: %s", got)
+	}
+
+	clash := &SiloDocument{Files: []SiloFile{
+		{Path: "x/a.txt", Content: "1\n"},
+		{Path: "y/a.txt", Content: "2\n"},
+	}}
+	if err := clash.StripComponents(1); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath, got %v", err)
+	}
+	if got := strings.Join(docPaths(clash), ","); got != "x/a.txt,y/a.txt" {
+		t.Errorf("Expected document unchanged after a clash, got %s", got)
+	}
+}
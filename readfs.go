@@ -0,0 +1,49 @@
+package silo
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// ReadFS packs every file under root in fsys, which lets documents be built
+// from embed.FS, zip.Reader, fstest.MapFS or any other fs.FS. Use "." as root
+// for the whole filesystem. Entry paths are relative to root, and the
+// document is sorted by path like ReadDirectoryTree's.
+func ReadFS(fsys fs.FS, root string) (*SiloDocument, error) {
+	doc := &SiloDocument{Delimiter: ">"}
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		relPath := name
+		switch {
+		case name == root:
+			// root names a single file.
+			relPath = path.Base(root)
+		case root != ".":
+			relPath = name[len(root)+1:]
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", name, err)
+		}
+		doc.Files = append(doc.Files, SiloFile{Path: relPath, Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+	return doc, nil
+}
@@ -0,0 +1,65 @@
+package silo
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site/index.html":     {Data: []byte("<h1>hi</h1>\n")},
+		"site/css/site.css":   {Data: []byte("body {}\n")},
+		"other/ignored.txt":   {Data: []byte("nope\n")},
+		"site/empty/.gitkeep": {Data: []byte{}},
+	}
+
+	doc, err := ReadFS(fsys, "site")
+	if err != nil {
+		t.Fatalf("ReadFS failed: %v", err)
+	}
+	expected := []string{"css/site.css", "empty/.gitkeep", "index.html"}
+	if paths := docPaths(doc); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+	if doc.Files[2].Content != "<h1>hi</h1>\n" {
+		t.Errorf("Unexpected content: %q", doc.Files[2].Content)
+	}
+
+	doc, err = ReadFS(fsys, ".")
+	if err != nil {
+		t.Fatalf("ReadFS of whole filesystem failed: %v", err)
+	}
+	if len(doc.Files) != 4 || doc.Files[0].Path != "other/ignored.txt" {
+		t.Errorf("Unexpected files: %v", docPaths(doc))
+	}
+
+	doc, err = ReadFS(fsys, "site/index.html")
+	if err != nil {
+		t.Fatalf("ReadFS of a single file failed: %v", err)
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Path != "index.html" {
+		t.Errorf("Expected only index.html, got %v", docPaths(doc))
+	}
+
+	if _, err := ReadFS(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestReadFSRoundTripsDocumentFS(t *testing.T) {
+	original := &SiloDocument{Delimiter: ">", Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "dir/b.txt", Content: "b\n"},
+	}}
+
+	doc, err := ReadFS(original.FS(), ".")
+	if err != nil {
+		t.Fatalf("ReadFS failed: %v", err)
+	}
+	if !reflect.DeepEqual(doc.Files, original.Files) {
+		t.Errorf("Expected %+v, got %+v", original.Files, doc.Files)
+	}
+}
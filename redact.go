@@ -0,0 +1,129 @@
+package silo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactionRule recognizes one kind of secret. If Pattern has a capturing
+// group, only the text of the first group is the secret; otherwise the
+// whole match is.
+type RedactionRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultRedactionRules recognize common credentials: cloud and SaaS API
+// keys, tokens, and PEM private keys.
+var DefaultRedactionRules = []RedactionRule{
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-access-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY-----[\s\S]*?-----END[A-Z ]* PRIVATE KEY-----`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+}
+
+// ParseRedactionRule parses a custom rule written as "name=regexp".
+func ParseRedactionRule(spec string) (RedactionRule, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || expr == "" {
+		return RedactionRule{}, fmt.Errorf("invalid redaction rule %q (want name=regexp)", spec)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return RedactionRule{}, fmt.Errorf("invalid redaction rule %q: %w", name, err)
+	}
+	return RedactionRule{Name: name, Pattern: pattern}, nil
+}
+
+// SecretMatch locates a secret found by FindSecrets or Redact. The secret
+// itself is deliberately not recorded.
+type SecretMatch struct {
+	Path string
+	// Line is the 1-based line within the file's content where the secret
+	// starts.
+	Line int
+	Rule string
+}
+
+func (m SecretMatch) String() string {
+	return fmt.Sprintf("%s:%d: %s", m.Path, m.Line, m.Rule)
+}
+
+// FindSecrets reports every match of rules in the content of text entries,
+// in document order. Links, references and binary content are not scanned.
+func (doc *SiloDocument) FindSecrets(rules []RedactionRule) []SecretMatch {
+	var matches []SecretMatch
+	for _, file := range doc.Files {
+		_, found := redactContent(file, rules)
+		matches = append(matches, found...)
+	}
+	return matches
+}
+
+// Redact replaces every secret found by rules with "[REDACTED:<rule>]" and
+// reports what was replaced, as FindSecrets would.
+func (doc *SiloDocument) Redact(rules []RedactionRule) []SecretMatch {
+	var matches []SecretMatch
+	for i, file := range doc.Files {
+		content, found := redactContent(file, rules)
+		if len(found) > 0 {
+			doc.Files[i].SetText(content)
+		}
+		matches = append(matches, found...)
+	}
+	return matches
+}
+
+// redactContent returns file's content with secrets masked, and where they
+// were. Matches are found in the original content; where matches of
+// different rules overlap, the one starting first is kept.
+func redactContent(file SiloFile, rules []RedactionRule) (string, []SecretMatch) {
+	content := file.Text()
+	if file.LinkTarget != "" || file.Ref != "" || file.Base64 || IsBinary(file.Bytes()) {
+		return content, nil
+	}
+
+	type span struct {
+		start, end int
+		rule       string
+	}
+	var spans []span
+	for _, rule := range rules {
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			spans = append(spans, span{start, end, rule.Name})
+		}
+	}
+	if len(spans) == 0 {
+		return content, nil
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	var matches []SecretMatch
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		matches = append(matches, SecretMatch{
+			Path: file.Path,
+			Line: strings.Count(content[:s.start], "\n") + 1,
+			Rule: s.rule,
+		})
+		b.WriteString(content[last:s.start])
+		b.WriteString("[REDACTED:" + s.rule + "]")
+		last = s.end
+	}
+	b.WriteString(content[last:])
+	return b.String(), matches
+}
@@ -0,0 +1,134 @@
+package silo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// refMarker separates an entry's path from a content reference in a header
+// line, as in "> big.bin @file:blobs/big.bin".
+const refMarker = " @"
+
+const (
+	fileRefPrefix   = "file:"
+	sha256RefPrefix = "sha256:"
+)
+
+// RefResolver returns the content a reference entry stands for. ref is the
+// text after the "@", such as "file:blobs/big.bin" or "sha256:<hex>".
+type RefResolver func(ref string) ([]byte, error)
+
+// splitRef splits a header path of the form "path @ref", where ref may also
+// be the base64 marker. For entries without one ref is empty.
+func splitRef(header string) (path, ref string) {
+	idx := strings.LastIndex(header, refMarker)
+	if idx < 0 {
+		return header, ""
+	}
+	candidate := strings.TrimSpace(header[idx+len(refMarker):])
+	if candidate != base64Marker && !strings.HasPrefix(candidate, fileRefPrefix) && !strings.HasPrefix(candidate, sha256RefPrefix) {
+		return header, ""
+	}
+	return strings.TrimSpace(header[:idx]), candidate
+}
+
+// validateRef checks the syntax of a reference: file references must be
+// relative paths inside the base directory, and sha256 references must be
+// 64 lowercase hex digits. Neither may hold control characters, which a
+// header line cannot carry.
+func validateRef(ref string) error {
+	if strings.IndexFunc(ref, unicode.IsControl) >= 0 {
+		return fmt.Errorf("reference %q contains a control character", ref)
+	}
+	switch {
+	case strings.HasPrefix(ref, fileRefPrefix):
+		name := path.Clean(strings.TrimPrefix(ref, fileRefPrefix))
+		if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("file reference %q must be a relative path inside the base directory", ref)
+		}
+		return nil
+	case strings.HasPrefix(ref, sha256RefPrefix):
+		sum := strings.TrimPrefix(ref, sha256RefPrefix)
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != 2*sha256.Size || strings.ToLower(sum) != sum {
+			return fmt.Errorf("sha256 reference %q must be 64 lowercase hex digits", ref)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown reference %q (want file: or sha256:)", ref)
+}
+
+// DirRefResolver resolves references against dir: "file:" references are
+// paths relative to dir, and "sha256:" references name a file in dir called
+// by the hex digest, as in a content-addressed blob store. A reference that
+// resolves outside dir through a symlink is refused with an error matching
+// ErrInvalidPath.
+func DirRefResolver(dir string) RefResolver {
+	return func(ref string) ([]byte, error) {
+		if err := validateRef(ref); err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(ref, fileRefPrefix)
+		if strings.HasPrefix(ref, sha256RefPrefix) {
+			name = strings.TrimPrefix(ref, sha256RefPrefix)
+		}
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil, err
+		}
+		resolved, err := filepath.EvalSymlinks(filepath.Join(realDir, filepath.FromSlash(path.Clean(name))))
+		if err != nil {
+			return nil, err
+		}
+		if !withinRoot(resolved, realDir) {
+			return nil, invalidPathError("reference %s resolves outside %s through a symlink", ref, dir)
+		}
+		return os.ReadFile(resolved)
+	}
+}
+
+// resolveRef fetches the content of a reference entry, checking the digest
+// of sha256 references.
+func resolveRef(file SiloFile, resolve RefResolver) ([]byte, error) {
+	if resolve == nil {
+		return nil, fmt.Errorf("%w: %s @%s", ErrUnresolvedRef, file.Path, file.Ref)
+	}
+	data, err := resolve(file.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s @%s: %w", file.Path, file.Ref, err)
+	}
+	if sum, ok := strings.CutPrefix(file.Ref, sha256RefPrefix); ok {
+		actual := sha256.Sum256(data)
+		if hex.EncodeToString(actual[:]) != sum {
+			return nil, fmt.Errorf("content for %s does not match %s", file.Path, file.Ref)
+		}
+	}
+	return data, nil
+}
+
+// ResolveRefs replaces every reference entry with a regular entry holding
+// the resolved content. The document is unchanged if any reference fails to
+// resolve.
+func (doc *SiloDocument) ResolveRefs(resolve RefResolver) error {
+	contents := make(map[int]string)
+	for i, file := range doc.Files {
+		if file.Ref == "" {
+			continue
+		}
+		data, err := resolveRef(file, resolve)
+		if err != nil {
+			return err
+		}
+		contents[i] = string(data)
+	}
+	for i, content := range contents {
+		doc.Files[i].Content = content
+		doc.Files[i].Ref = ""
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package silo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAndWriteRefs(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	input := "> big.bin @file:./blobs/big.bin\n> data.bin @sha256:" + sum + "\n> notes.txt\nmail me @ home\n"
+
+	doc, err := ParseSiloFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Files[0].Path != "big.bin" || doc.Files[0].Ref != "file:./blobs/big.bin" {
+		t.Errorf("Unexpected file reference entry: %+v", doc.Files[0])
+	}
+	if doc.Files[1].Ref != "sha256:"+sum {
+		t.Errorf("Unexpected sha256 reference entry: %+v", doc.Files[1])
+	}
+	if doc.Files[2].Ref != "" {
+		t.Errorf("Content lines should not be treated as references: %+v", doc.Files[2])
+	}
+
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if out.String() != input {
+		t.Errorf("Round trip mismatch:\n%s", out.String())
+	}
+}
+
+func TestParseRefErrors(t *testing.T) {
+	inputs := map[string]string{
+		"content":    "> a.bin @file:a.bin\nnot allowed\n",
+		"escape":     "> a.bin @file:../a.bin\n",
+		"bad digest": "> a.bin @sha256:xyz\n",
+		"upper hex":  "> a.bin @sha256:" + strings.Repeat("AB", 32) + "\n",
+		"empty file": "> a.bin @file:\n",
+	}
+	for name, input := range inputs {
+		if _, err := ParseSiloFile(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected parse error for %q", name, input)
+		}
+	}
+
+	// An "@" that is not followed by a known scheme is part of the path.
+	doc, err := ParseSiloFile(strings.NewReader("> me @home.txt\nhi\n"))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if doc.Files[0].Path != "me @home.txt" || doc.Files[0].Ref != "" {
+		t.Errorf("Unexpected entry: %+v", doc.Files[0])
+	}
+}
+
+func TestWriteMarkerLikePath(t *testing.T) {
+	for _, file := range []SiloFile{
+		{Path: "logo.png @base64", Content: "x\n"},
+		{Path: "a @file:b", Content: "x\n"},
+		{Path: "a @sha256:" + strings.Repeat("ab", 32), Content: "x\n"},
+		{Path: "big.bin", Ref: "file:blobs/big x=1"},
+	} {
+		doc := &SiloDocument{Files: []SiloFile{file}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+			t.Errorf("Expected WriteTo to refuse %+v, wrote %q", file, out.String())
+		}
+	}
+	doc := &SiloDocument{Files: []SiloFile{{Path: "me @home.txt", Content: "hi\n"}}}
+	if err := doc.WriteTo(&strings.Builder{}); err != nil {
+		t.Errorf("Expected an ordinary @ to be written, got %v", err)
+	}
+	if problems := (&SiloDocument{Files: []SiloFile{{Path: "a @base64", Content: "x\n"}}}).Validate(); len(problems) != 1 {
+		t.Errorf("Expected Validate to report the marker, got %v", problems)
+	}
+}
+
+func TestUnpackRefs(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "blobs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "blobs", "big.bin"), []byte("big"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("stored"))
+	sum := hex.EncodeToString(digest[:])
+	if err := os.WriteFile(filepath.Join(base, sum), []byte("stored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "big.bin", Ref: "file:./blobs/big.bin"},
+		{Path: "stored.bin", Ref: "sha256:" + sum},
+	}}
+
+	out := t.TempDir()
+	if err := doc.WriteToDirectory(out); !errors.Is(err, ErrUnresolvedRef) {
+		t.Fatalf("Expected ErrUnresolvedRef without a resolver, got %v", err)
+	}
+
+	opts := UnpackOptions{ResolveRef: DirRefResolver(base)}
+	if err := doc.WriteToDirectoryWithOptions(out, opts); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	for name, expected := range map[string]string{"big.bin": "big", "stored.bin": "stored"} {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil || string(data) != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", name, expected, data, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(base, sum), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.WriteToDirectoryWithOptions(t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "inline\n"},
+		{Path: "b.bin", Ref: "file:b.bin"},
+	}}
+	if _, err := doc.ReadFile("b.bin"); !errors.Is(err, ErrUnresolvedRef) {
+		t.Errorf("Expected ErrUnresolvedRef from ReadFile, got %v", err)
+	}
+
+	failing := func(string) ([]byte, error) { return nil, os.ErrNotExist }
+	if err := doc.ResolveRefs(failing); err == nil {
+		t.Fatal("Expected error from failing resolver")
+	}
+	if doc.Files[1].Ref == "" {
+		t.Error("Document changed after failed ResolveRefs")
+	}
+
+	resolver := func(ref string) ([]byte, error) { return []byte("from " + ref), nil }
+	if err := doc.ResolveRefs(resolver); err != nil {
+		t.Fatalf("ResolveRefs failed: %v", err)
+	}
+	content, err := doc.ReadFile("b.bin")
+	if err != nil || content != "from file:b.bin" {
+		t.Errorf("Unexpected resolved content %q (%v)", content, err)
+	}
+}
+
+func TestWriteInvalidRef(t *testing.T) {
+	for _, ref := range []string{
+		"file:b\n> injected.txt\npwned",
+		"file:b\rc",
+		"file:../outside",
+		"sha256:abc",
+		"http://example.com/b",
+	} {
+		doc := &SiloDocument{Files: []SiloFile{{Path: "a", Ref: ref}}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+			t.Errorf("Expected WriteTo to refuse reference %q, wrote %q", ref, out.String())
+		}
+		if problems := doc.Validate(); len(problems) == 0 {
+			t.Errorf("Expected Validate to report reference %q", ref)
+		}
+	}
+	if _, err := ParseSiloFile(strings.NewReader("> a @file:b\rc\n")); err == nil {
+		t.Error("Expected a line break in a reference to fail parsing")
+	}
+}
+
+func TestDirRefResolverSymlinks(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "base")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{filepath.Join(base, "blobs"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "blobs", "real.bin"), []byte("real"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(base, "out")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(base, "key")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("blobs/real.bin", filepath.Join(base, "alias.bin")); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := DirRefResolver(base)
+	for _, ref := range []string{"file:out/secret", "file:key"} {
+		if data, err := resolve(ref); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected %s to be refused, got %q, %v", ref, data, err)
+		}
+	}
+	if data, err := resolve("file:alias.bin"); err != nil || string(data) != "real" {
+		t.Errorf("Expected a link inside the directory to resolve, got %q, %v", data, err)
+	}
+
+	// The directory itself may be reached through a symlink.
+	linkedBase := filepath.Join(root, "linked")
+	if err := os.Symlink(base, linkedBase); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := DirRefResolver(linkedBase)("file:blobs/real.bin"); err != nil || string(data) != "real" {
+		t.Errorf("Expected a symlinked directory to resolve, got %q, %v", data, err)
+	}
+}
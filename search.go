@@ -0,0 +1,165 @@
+package silo
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchMatch is a line of an entry's content that contains a search query.
+type SearchMatch struct {
+	// Path is the entry's path.
+	Path string
+	// Line is the 1-based line number the match starts on.
+	Line int
+	// Text is that line, without its line ending.
+	Text string
+}
+
+// Search returns the lines of entry content that contain query, in document
+// order, each line once however many times query occurs on it. The search is
+// a plain, case-sensitive substring match; a query spanning lines reports
+// the line it starts on, and an empty query matches nothing. Links and
+// references have no content to search.
+// Search scans every entry; for many queries over a large archive, build a
+// SearchIndex once with BuildIndex.
+func (doc *SiloDocument) Search(query string) []SearchMatch {
+	var matches []SearchMatch
+	for _, file := range doc.Files {
+		matches = appendMatches(matches, file, query)
+	}
+	return matches
+}
+
+// SearchIndex is a trigram index of a document's entry content that answers
+// Search queries without scanning entries that cannot match. It is a
+// snapshot: entries changed after BuildIndex are not seen.
+type SearchIndex struct {
+	files []SiloFile
+	// trigrams maps every three-byte sequence in some entry to the indices,
+	// in ascending order, of the entries in files that contain it.
+	trigrams map[trigram][]int32
+}
+
+// trigram is three consecutive bytes of content.
+type trigram [3]byte
+
+// BuildIndex indexes doc's entry content for SearchIndex.Search. Building
+// reads all content once and keeps, for every distinct trigram, the list of
+// entries containing it, so it pays off for archives searched repeatedly.
+func (doc *SiloDocument) BuildIndex() *SearchIndex {
+	idx := &SearchIndex{
+		files:    append([]SiloFile(nil), doc.Files...),
+		trigrams: make(map[trigram][]int32),
+	}
+	seen := make(map[trigram]bool)
+	for i, file := range idx.files {
+		if !searchable(file) {
+			continue
+		}
+		content := file.text()
+		for j := 0; j+3 <= len(content); j++ {
+			t := trigram{content[j], content[j+1], content[j+2]}
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+			idx.trigrams[t] = append(idx.trigrams[t], int32(i))
+		}
+		for t := range seen {
+			delete(seen, t)
+		}
+	}
+	return idx
+}
+
+// Search returns what doc.Search(query) returns for the indexed document.
+// Queries of three bytes or more only look at entries holding all of their
+// trigrams; shorter queries scan every entry.
+func (idx *SearchIndex) Search(query string) []SearchMatch {
+	if len(query) < 3 {
+		var matches []SearchMatch
+		for _, file := range idx.files {
+			matches = appendMatches(matches, file, query)
+		}
+		return matches
+	}
+
+	lists := make([][]int32, 0, len(query)-2)
+	for j := 0; j+3 <= len(query); j++ {
+		postings, ok := idx.trigrams[trigram{query[j], query[j+1], query[j+2]}]
+		if !ok {
+			return nil
+		}
+		lists = append(lists, postings)
+	}
+	// Starting from the rarest trigram keeps the intersections small.
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	candidates := lists[0]
+	for _, postings := range lists[1:] {
+		if len(candidates) == 0 {
+			return nil
+		}
+		candidates = intersectPostings(candidates, postings)
+	}
+	var matches []SearchMatch
+	for _, i := range candidates {
+		matches = appendMatches(matches, idx.files[i], query)
+	}
+	return matches
+}
+
+// intersectPostings returns the indices in both sorted lists.
+func intersectPostings(a, b []int32) []int32 {
+	var out []int32
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			out = append(out, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return out
+}
+
+// searchable reports whether file has content to search.
+func searchable(file SiloFile) bool {
+	return file.LinkTarget == "" && file.Ref == ""
+}
+
+// appendMatches appends the lines of file's content containing query.
+func appendMatches(matches []SearchMatch, file SiloFile, query string) []SearchMatch {
+	if !searchable(file) || query == "" {
+		return matches
+	}
+	content := file.text()
+	line, lineStart := 1, 0
+	for offset := 0; offset <= len(content); {
+		i := strings.Index(content[offset:], query)
+		if i < 0 {
+			break
+		}
+		at := offset + i
+		line += strings.Count(content[lineStart:at], "\n")
+		if nl := strings.LastIndexByte(content[:at], '\n'); nl >= 0 {
+			lineStart = nl + 1
+		}
+		lineEnd := strings.IndexByte(content[at:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += at
+		}
+		matches = append(matches, SearchMatch{
+			Path: file.Path,
+			Line: line,
+			Text: strings.TrimSuffix(content[lineStart:lineEnd], "\r"),
+		})
+		// Continue after this line so each line is reported once.
+		offset = lineEnd + 1
+	}
+	return matches
+}
@@ -0,0 +1,52 @@
+package silo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.go", Content: "package a\n\nfunc Hello() {}\nfunc hello() { Hello(); Hello() }\n"},
+		{Path: "b.txt", Content: "Hello\r\nworld\r\n"},
+		{Path: "link", LinkTarget: "a.go"},
+		{Path: "ref.bin", Ref: "file:Hello"},
+		{Path: "c.bin", ContentBytes: []byte("x\x00Hello"), Base64: true},
+	}}
+
+	expected := []SearchMatch{
+		{Path: "a.go", Line: 3, Text: "func Hello() {}"},
+		{Path: "a.go", Line: 4, Text: "func hello() { Hello(); Hello() }"},
+		{Path: "b.txt", Line: 1, Text: "Hello"},
+		{Path: "c.bin", Line: 1, Text: "x\x00Hello"},
+	}
+	if got := doc.Search("Hello"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Search(Hello) = %+v, want %+v", got, expected)
+	}
+	if got := doc.Search("Hello\r\nworld"); len(got) != 1 || got[0].Line != 1 {
+		t.Errorf("Expected a query spanning lines to report its first line, got %+v", got)
+	}
+	if got := doc.Search(""); got != nil {
+		t.Errorf("Expected an empty query to match nothing, got %+v", got)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.go", Content: "package a\n\nfunc Hello() {}\n"},
+		{Path: "b.go", Content: "package b\n\nvar greeting = \"hello, world\"\n"},
+		{Path: "c.md", Content: "# Hello, world\n"},
+		{Path: "link", LinkTarget: "a.go"},
+	}}
+	idx := doc.BuildIndex()
+	for _, query := range []string{"Hello", "hello", "package", "world\"", "o", "ld", "", "nowhere", "\n\n", "Hello, world\n"} {
+		if got, want := idx.Search(query), doc.Search(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("index Search(%q) = %+v, want %+v", query, got, want)
+		}
+	}
+
+	doc.Files[0].Content = "changed\n"
+	if got := idx.Search("Hello"); len(got) != 2 || got[0].Path != "a.go" {
+		t.Errorf("Expected the index to be a snapshot, got %+v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package silo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// signaturePrefix starts the trailer line of a signed archive:
+//
+//	silo-signature/1 ed25519 <base64 signature>
+//
+// The signature covers every byte of the archive before the trailer line.
+const signaturePrefix = "silo-signature/1 ed25519 "
+
+// Sign makes later calls to WriteTo append a signature trailer made with
+// key. Readers that do not check signatures ignore the trailer; use
+// VerifySignature to check it.
+func (doc *SiloDocument) Sign(key ed25519.PrivateKey) {
+	doc.signingKey = key
+}
+
+// writeSigned writes doc followed by a signature trailer over its bytes.
+func (doc *SiloDocument) writeSigned(w io.Writer, opts WriteOptions) error {
+	var buf bytes.Buffer
+	if err := doc.writeTo(&buf, opts); err != nil {
+		return err
+	}
+	signature := ed25519.Sign(doc.signingKey, buf.Bytes())
+	buf.WriteString(signaturePrefix + base64.StdEncoding.EncodeToString(signature) + "\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// isSignatureTrailer reports whether line is a signature trailer.
+func isSignatureTrailer(line string) bool {
+	return strings.HasPrefix(line, signaturePrefix)
+}
+
+// endsWithSignatureTrailer reports whether content's last line would be read
+// back as a signature trailer, and dropped, if it ended the archive.
+func endsWithSignatureTrailer(content string) bool {
+	content = trimFinalNewline(content)
+	return isSignatureTrailer(content[strings.LastIndexByte(content, '\n')+1:])
+}
+
+// VerifySignature reads a signed archive from r, checks its trailer against
+// publicKey and parses it. It fails with ErrUnsigned if there is no trailer
+// and ErrBadSignature if the archive does not match the signature.
+func VerifySignature(r io.Reader, publicKey ed25519.PublicKey) (*SiloDocument, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body := bytes.TrimRight(data, "\r\n")
+	start := bytes.LastIndexByte(body, '\n') + 1
+	trailer := string(body[start:])
+	if !isSignatureTrailer(trailer) {
+		return nil, ErrUnsigned
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(trailer, signaturePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature trailer", ErrBadSignature)
+	}
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, data[:start], signature) {
+		return nil, ErrBadSignature
+	}
+
+	return ParseSiloFile(bytes.NewReader(data))
+}
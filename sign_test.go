@@ -0,0 +1,79 @@
+package silo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "hello\n"},
+		{Path: "b.txt", Content: "world\n"},
+	}}
+	doc.Sign(privateKey)
+
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "\nsilo-signature/1 ed25519 ") {
+		t.Fatalf("Missing signature trailer:\n%s", buf.String())
+	}
+
+	verified, err := VerifySignature(bytes.NewReader(buf.Bytes()), publicKey)
+	if err != nil {
+		t.Fatalf("VerifySignature failed: %v", err)
+	}
+	if len(verified.Files) != 2 || verified.Files[1].Content != "world\n" {
+		t.Errorf("Trailer leaked into content: %+v", verified.Files)
+	}
+
+	// Readers that do not verify still parse the archive.
+	parsed, err := ParseSiloFile(bytes.NewReader(buf.Bytes()))
+	if err != nil || parsed.Files[1].Content != "world\n" {
+		t.Errorf("ParseSiloFile on signed archive: %+v, %v", parsed, err)
+	}
+
+	tampered := bytes.Replace(buf.Bytes(), []byte("hello"), []byte("HELLO"), 1)
+	if _, err := VerifySignature(bytes.NewReader(tampered), publicKey); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for tampered archive, got %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	if _, err := VerifySignature(bytes.NewReader(buf.Bytes()), otherKey); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for wrong key, got %v", err)
+	}
+}
+
+func TestVerifyUnsigned(t *testing.T) {
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	if _, err := VerifySignature(strings.NewReader("> a.txt\nhello\n"), publicKey); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Expected ErrUnsigned, got %v", err)
+	}
+}
+
+func TestWriteTrailerLikeContent(t *testing.T) {
+	content := "notes\n" + signaturePrefix + "AAAA\n"
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: content}}}
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+		t.Errorf("Expected WriteTo to refuse the content, wrote %q", out.String())
+	}
+	if problems := doc.Validate(); len(problems) != 1 {
+		t.Errorf("Expected Validate to report the content, got %v", problems)
+	}
+
+	// Earlier lines are ordinary content.
+	doc.Files[0].Content = signaturePrefix + "AAAA\nnotes\n"
+	if err := doc.WriteTo(&out); err != nil {
+		t.Errorf("WriteTo failed: %v", err)
+	}
+}
@@ -2,23 +2,80 @@ package silo
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 )
 
 type SiloFile struct {
 	Path    string
 	Content string
+	// ContentBytes, when non-nil, holds the file's content in place of
+	// Content, which is then ignored. It lets callers that already have the
+	// data in a []byte, such as a memory-mapped file or a read buffer, pack
+	// it without a copy; writers read it in place. Use Bytes or Text to read
+	// content regardless of which field holds it.
+	ContentBytes []byte
+	// LinkTarget, when set, makes the entry a symbolic link to this target
+	// instead of a regular file. Content is empty for links.
+	LinkTarget string
+	// Ref, when set, makes the entry a reference to content stored outside
+	// the archive ("file:<path>" or "sha256:<hex>"), fetched when unpacking.
+	// Content is empty for references.
+	Ref string
+	// CRLF records that the file used CRLF line endings when its content
+	// was normalized to LF. Writers restore CRLF endings for such files
+	// under LineEndingsAuto.
+	CRLF bool
+	// Base64 makes WriteTo store Content base64-encoded, marked with
+	// "@base64" after the path, so binary data survives as text. Content
+	// itself always holds the raw bytes.
+	Base64 bool
+	// Attrs holds "key=value" annotations written after the path in the
+	// entry's header line, as in "> src/main.go mode=0755 lang=go". Keys
+	// start with a letter and use letters, digits, "_", "." and "-";
+	// values are non-empty and contain no whitespace. Older readers see
+	// annotations as part of the path.
+	Attrs map[string]string
+	// Truncated is the number of lines TruncateLines left out of the
+	// content, which then ends with a marker line saying so. WriteTo
+	// records it as a "truncated=N" annotation, read back into this field.
+	Truncated int
+	// Meta holds metadata such as a description, tags or an owner. WriteTo
+	// writes it as a YAML front-matter block of "key: value" lines between
+	// "---" lines after the entry's header line, which it marks with a
+	// "meta=yaml" annotation so content starting with "---" stays content.
+	// Keys follow the annotation key syntax; values are single lines.
+	Meta map[string]string
 }
 
 type SiloDocument struct {
 	Files     []SiloFile
 	Delimiter string
+	// Warnings holds non-fatal problems noticed while parsing.
+	Warnings []string
+	// Header, when set, is written by WriteTo as a leading format header
+	// line. ParseSiloFile fills it in when the input starts with one.
+	Header *FormatHeader
+	// Skipped lists files a read left out because they could not be read,
+	// when an OnError handler or ContinueOnError let it go on. Writers
+	// ignore it.
+	Skipped []SkippedFile
+	
+	// signingKey, set by Sign, makes WriteTo append a signature trailer.
+	signingKey ed25519.PrivateKey
 }
 
 func detectDelimiter(line string) (string, string, error) {
@@ -27,7 +84,6 @@ func detectDelimiter(line string) (string, string, error) {
 		return "", "", fmt.Errorf("empty line cannot contain delimiter")
 	}
 
-	delim := ""
 	byteIdx := 0
 	
 	// Process the line rune by rune to handle Unicode properly
@@ -41,10 +97,12 @@ func detectDelimiter(line string) (string, string, error) {
 			break
 		}
 		
-		delim += string(r)
 		byteIdx += size
 	}
 	
+	// Sliced rather than built up rune by rune, which is quadratic in the
+	// length of a long first line.
+	delim := line[:byteIdx]
 	if delim == "" {
 		return "", "", fmt.Errorf("invalid file declaration format")
 	}
@@ -71,36 +129,281 @@ func isValidDelimiterChar(r rune) bool {
 
 func validatePath(path string) error {
 	if path == "" || path == "." {
-		return fmt.Errorf("invalid path: %s", path)
+		return invalidPathError("invalid path: %s", path)
 	}
 	if filepath.IsAbs(path) {
-		return fmt.Errorf("absolute paths not allowed: %s", path)
+		return invalidPathError("absolute paths not allowed: %s", path)
 	}
 	if strings.Contains(path, "..") {
-		return fmt.Errorf("parent directory references not allowed: %s", path)
+		return invalidPathError("parent directory references not allowed: %s", path)
 	}
 	if strings.ContainsRune(path, 0) {
-		return fmt.Errorf("null character in path: %s", path)
+		return invalidPathError("null character in path: %s", path)
+	}
+	if strings.ContainsAny(path, "\r\n") {
+		return invalidPathError("line break in path: %q", path)
+	}
+	if strings.Contains(path, linkArrow) {
+		return invalidPathError("%q in path would be read back as a link: %s", linkArrow, path)
 	}
 	return nil
 }
 
+// ParseOptions bounds the resources ParseSiloFileWithOptions may consume.
+// A zero value for any limit means that dimension is unlimited.
+type ParseOptions struct {
+	// MaxFileSize is the largest content size, in bytes, of a single entry.
+	MaxFileSize int64
+	// MaxTotalSize is the largest number of input bytes that will be read.
+	MaxTotalSize int64
+	// MaxFileCount is the largest number of entries the document may hold.
+	MaxFileCount int
+	// MaxLineLength is the longest input line, in bytes, that will be
+	// accepted. When zero, lines of any length are read, such as minified
+	// JavaScript or JSON on a single line.
+	MaxLineLength int
+	// RequireFinalNewline makes input whose last line is unterminated an
+	// error instead of a warning.
+	RequireFinalNewline bool
+	// LineEndings controls how CRLF line endings in content are read. The
+	// default, LineEndingsAuto, normalizes content to LF and records CRLF
+	// files in SiloFile.CRLF.
+	LineEndings LineEndingPolicy
+}
+
+// LimitError is returned when input exceeds one of the ParseOptions limits.
+type LimitError struct {
+	// Limit names the options field that was exceeded, such as
+	// ParseOptions.MaxFileCount or ReadDirectoryTreeOptions.MaxFiles.
+	Limit string
+	// Max is the configured value of that limit.
+	Max int64
+	// Path is the entry being parsed when the limit was hit, if any.
+	Path string
+}
+
+func (e *LimitError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s of %d exceeded in %s", e.Limit, e.Max, e.Path)
+	}
+	return fmt.Sprintf("%s of %d exceeded", e.Limit, e.Max)
+}
+
+// ParseError reports where in the input parsing failed. It wraps the
+// underlying error, so errors.Is and errors.As see through it.
+type ParseError struct {
+	// Line is the 1-based line number of the offending line.
+	Line int
+	// Offset is the byte offset of the start of that line.
+	Offset int64
+	// Text is the offending line, if it was read.
+	Text string
+	// Suggestion is a hint for fixing the input, if one applies.
+	Suggestion string
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	if e.Suggestion != "" {
+		msg += " (" + e.Suggestion + ")"
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// countingReader counts the bytes read through it and remembers the last one.
+type countingReader struct {
+	r    io.Reader
+	n    int64
+	last byte
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if n > 0 {
+		c.last = p[n-1]
+	}
+	return n, err
+}
+
+// errLineTooLong is returned by readLine for a line over its limit.
+var errLineTooLong = errors.New("line too long")
+
+// readLine reads the next line from r as bufio.ScanLines would split it,
+// without its terminator, but with no limit on its length unless max is
+// positive. It also returns the number of bytes consumed and whether the
+// line ended in CRLF. At the end of the input it returns io.EOF.
+func readLine(r *bufio.Reader, max int) (line string, n int, crlf bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		// Leave room for the terminator, so that the caller can report a
+		// long line after line endings are normalized.
+		if max > 0 && len(buf) > max+2 {
+			return "", len(buf), false, errLineTooLong
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(buf) > 0 {
+			break
+		}
+		if err != nil {
+			return "", len(buf), false, err
+		}
+		break
+	}
+	
+	n = len(buf)
+	if buf[len(buf)-1] == '\n' {
+		buf = buf[:len(buf)-1]
+		crlf = len(buf) > 0 && buf[len(buf)-1] == '\r'
+	}
+	if len(buf) > 0 && buf[len(buf)-1] == '\r' {
+		buf = buf[:len(buf)-1]
+	}
+	return string(buf), n, crlf, nil
+}
+
 func ParseSiloFile(r io.Reader) (*SiloDocument, error) {
-	scanner := bufio.NewScanner(r)
+	return ParseSiloFileWithOptions(r, ParseOptions{})
+}
+
+// MustParse parses an archive held in memory and panics if it is malformed.
+// It is meant for archives built into a program, such as one embedded with
+// go:embed, where a parse error is a build mistake rather than bad input.
+func MustParse(data []byte) *SiloDocument {
+	doc, err := ParseSiloFile(bytes.NewReader(data))
+	if err != nil {
+		panic("silo: MustParse: " + err.Error())
+	}
+	return doc
+}
+
+// ParseSiloFileWithOptions parses a silo file like ParseSiloFile, but stops
+// with a *LimitError as soon as the input exceeds any limit set in opts.
+func ParseSiloFileWithOptions(r io.Reader, opts ParseOptions) (*SiloDocument, error) {
+	counter := &countingReader{r: r}
+	if opts.MaxTotalSize > 0 {
+		counter.r = io.LimitReader(r, opts.MaxTotalSize+1)
+	}
+	
+	// offsets[i] is the byte offset at which line i starts in the input.
+	var offsets []int64
+	var nextOffset int64
+	// crlfs[i] records whether line i ended in CRLF.
+	var crlfs []bool
+	
+	reader := bufio.NewReader(counter)
 	lines := []string{}
 	
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.ReplaceAll(line, "\r\n", "\n")
-		line = strings.ReplaceAll(line, "\r", "\n")
+	// Entries are counted and sized as lines are read, so that MaxFileCount
+	// and MaxFileSize stop the read itself. Malformed lines are left for the
+	// parse below to report.
+	var readDelim, readPath string
+	var readStarted bool
+	var readFiles int
+	var readSize int64
+	headerPath := func(text string) string {
+		header, _ := parseEntryHeader(text)
+		return header.Path
+	}
+	
+	// fail wraps err in a *ParseError positioned at the 0-based line lineIdx.
+	fail := func(lineIdx int, suggestion string, err error) error {
+		parseErr := &ParseError{Line: lineIdx + 1, Suggestion: suggestion, Err: err}
+		if lineIdx < len(offsets) {
+			parseErr.Offset = offsets[lineIdx]
+		} else {
+			parseErr.Offset = nextOffset
+		}
+		if lineIdx < len(lines) {
+			parseErr.Text = lines[lineIdx]
+		}
+		return parseErr
+	}
+	
+	for {
+		line, n, crlf, err := readLine(reader, opts.MaxLineLength)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, errLineTooLong) {
+			return nil, fail(len(lines), "", &LimitError{Limit: "MaxLineLength", Max: int64(opts.MaxLineLength)})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading input: %w", err)
+		}
+		if len(offsets) == 0 && isEncryptedHeader(line) {
+			// Stop before treating ciphertext as lines.
+			return nil, ErrEncrypted
+		}
+		offsets = append(offsets, nextOffset)
+		nextOffset += int64(n)
+		crlfs = append(crlfs, crlf)
+		
+		if opts.MaxTotalSize > 0 && counter.n > opts.MaxTotalSize {
+			return nil, fail(len(lines), "", &LimitError{Limit: "MaxTotalSize", Max: opts.MaxTotalSize})
+		}
+		
+		if opts.LineEndings != LineEndingsPreserve {
+			line = strings.ReplaceAll(line, "\r\n", "\n")
+			line = strings.ReplaceAll(line, "\r", "\n")
+		}
+		
+		if opts.MaxLineLength > 0 && len(line) > opts.MaxLineLength {
+			return nil, fail(len(lines), "", &LimitError{Limit: "MaxLineLength", Max: int64(opts.MaxLineLength)})
+		}
+		
 		lines = append(lines, line)
+		
+		switch idx := len(lines) - 1; {
+		case readDelim == "" && isBlankLine(line):
+		case readDelim == "" && !readStarted && isFormatHeader(line):
+			readStarted = true
+		case readDelim == "":
+			readStarted = true
+			var first string
+			if readDelim, first, err = detectDelimiter(line); err == nil {
+				readFiles, readSize, readPath = 1, 0, headerPath(first)
+			}
+		case strings.HasPrefix(line, readDelim+" "):
+			readFiles++
+			if opts.MaxFileCount > 0 && readFiles > opts.MaxFileCount {
+				return nil, fail(idx, "", &LimitError{Limit: "MaxFileCount", Max: int64(opts.MaxFileCount)})
+			}
+			readSize, readPath = 0, headerPath(strings.TrimSpace(line[len(readDelim)+1:]))
+		default:
+			readSize += int64(len(line)) + 1
+			if opts.MaxFileSize > 0 && readSize > opts.MaxFileSize {
+				return nil, fail(idx, "", &LimitError{Limit: "MaxFileSize", Max: opts.MaxFileSize, Path: readPath})
+			}
+		}
 	}
 	
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %w", err)
+	if opts.MaxTotalSize > 0 && counter.n > opts.MaxTotalSize {
+		return nil, fail(len(lines), "", &LimitError{Limit: "MaxTotalSize", Max: opts.MaxTotalSize})
 	}
 
+	// A signature trailer is checked by VerifySignature, not part of the
+	// content of the last file.
+	if len(lines) > 0 && isSignatureTrailer(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	
 	doc := &SiloDocument{}
+	if counter.n > 0 && counter.last != '\n' && counter.last != '\r' {
+		if opts.RequireFinalNewline {
+			return nil, fail(len(lines)-1, "add a newline at the end of the input", errors.New("missing newline at end of line"))
+		}
+		doc.Warnings = append(doc.Warnings, fmt.Sprintf("missing newline at end of line %d", len(lines)))
+	}
 	pathsSeen := make(map[string]bool)
 	
 	lineIdx := 0
@@ -108,255 +411,1084 @@ func ParseSiloFile(r io.Reader) (*SiloDocument, error) {
 		lineIdx++
 	}
 	
+	headerIdx := -1
+	if lineIdx < len(lines) && isFormatHeader(lines[lineIdx]) {
+		header, err := parseFormatHeader(lines[lineIdx])
+		if err != nil {
+			return nil, fail(lineIdx, "", fmt.Errorf("invalid format header: %w", err))
+		}
+		doc.Header = header
+		headerIdx = lineIdx
+		
+		lineIdx++
+		for lineIdx < len(lines) && isBlankLine(lines[lineIdx]) {
+			lineIdx++
+		}
+	}
+	
 	if lineIdx >= len(lines) {
+		if doc.Header != nil {
+			doc.Delimiter = doc.Header.Delimiter
+			if err := checkFormatHeader(doc); err != nil {
+				return nil, fail(headerIdx, "", err)
+			}
+		}
 		return doc, nil
 	}
 
-	delim, firstPath, err := detectDelimiter(lines[lineIdx])
+	delim, firstHeader, err := detectDelimiter(lines[lineIdx])
 	if err != nil {
-		return nil, fmt.Errorf("error detecting delimiter on line %d: %w", lineIdx+1, err)
+		return nil, fail(lineIdx, "the first line must declare a file, like \"> path/to/file\"", fmt.Errorf("error detecting delimiter: %w", err))
 	}
 	
 	doc.Delimiter = delim
-	lineIdx++
 
-	if err := validatePath(firstPath); err != nil {
-		return nil, fmt.Errorf("invalid path on line %d: %w", lineIdx, err)
+	var currentFile *SiloFile
+	var currentHasMeta bool
+	var currentIdx int
+	var contentLines []contentLine
+	
+	startFile := func(line string, idx int) error {
+		header, err := parseEntryHeader(line)
+		if err != nil {
+			hint := ""
+			if errors.Is(err, ErrInvalidPath) {
+				hint = "paths must be relative and stay inside the archive root"
+			}
+			return fail(idx, hint, err)
+		}
+		path := header.Path
+		
+		if pathsSeen[path] {
+			return fail(idx, "each path may appear only once", fmt.Errorf("%w: %s", ErrDuplicatePath, path))
+		}
+		pathsSeen[path] = true
+		
+		currentFile = &SiloFile{Path: path, LinkTarget: header.LinkTarget, Ref: header.Ref, Base64: header.Base64, Attrs: header.Attrs}
+		currentFile.takeTruncatedAttr()
+		currentHasMeta = currentFile.takeMetaAttr()
+		currentIdx = idx
+		contentLines = []contentLine{}
+		return nil
 	}
 	
-	if pathsSeen[firstPath] {
-		return nil, fmt.Errorf("duplicate path: %s", firstPath)
+	finishFile := func() error {
+		if currentHasMeta {
+			meta, rest, err := takeFrontMatter(contentLines)
+			if err != nil {
+				return fail(currentIdx, "", fmt.Errorf("%s: %w", currentFile.Path, err))
+			}
+			currentFile.Meta, contentLines = meta, rest
+		}
+		lines, noNewline := takeNoNewlineMarker(contentLines)
+		currentFile.Content, currentFile.CRLF = joinContent(lines, opts.LineEndings)
+		if noNewline {
+			currentFile.Content = trimFinalNewline(currentFile.Content)
+		}
+		if currentFile.Base64 {
+			decoded, err := decodeBase64Content(currentFile.Content)
+			if err != nil {
+				return fail(currentIdx, "", fmt.Errorf("invalid base64 content in %s: %w", currentFile.Path, err))
+			}
+			currentFile.Content, currentFile.CRLF = decoded, false
+		}
+		if currentFile.LinkTarget != "" {
+			if !isBlankLine(currentFile.Content) {
+				return fail(currentIdx, "", fmt.Errorf("symlink entry %s must not have content", currentFile.Path))
+			}
+			currentFile.Content, currentFile.CRLF = "", false
+		}
+		if currentFile.Ref != "" {
+			if !isBlankLine(currentFile.Content) {
+				return fail(currentIdx, "", fmt.Errorf("reference entry %s must not have content", currentFile.Path))
+			}
+			currentFile.Content, currentFile.CRLF = "", false
+		}
+		doc.Files = append(doc.Files, *currentFile)
+		return nil
 	}
-	pathsSeen[firstPath] = true
 
-	currentFile := &SiloFile{Path: firstPath}
-	contentLines := []string{}
+	if err := startFile(firstHeader, lineIdx); err != nil {
+		return nil, err
+	}
+	lineIdx++
 	
 	for lineIdx < len(lines) {
 		line := lines[lineIdx]
 		
 		if strings.HasPrefix(line, delim+" ") {
-			currentFile.Content = strings.Join(contentLines, "\n")
-			if currentFile.Content != "" {
-				currentFile.Content += "\n"
-			}
-			doc.Files = append(doc.Files, *currentFile)
-			
-			path := strings.TrimSpace(line[len(delim)+1:])
-			if err := validatePath(path); err != nil {
-				return nil, fmt.Errorf("invalid path on line %d: %w", lineIdx+1, err)
+			if err := finishFile(); err != nil {
+				return nil, err
 			}
-			
-			if pathsSeen[path] {
-				return nil, fmt.Errorf("duplicate path: %s", path)
+			if err := startFile(strings.TrimSpace(line[len(delim)+1:]), lineIdx); err != nil {
+				return nil, err
 			}
-			pathsSeen[path] = true
-			
-			currentFile = &SiloFile{Path: path}
-			contentLines = []string{}
 		} else {
-			contentLines = append(contentLines, line)
+			contentLines = append(contentLines, contentLine{text: line, crlf: crlfs[lineIdx]})
 		}
 		lineIdx++
 	}
 	
-	currentFile.Content = strings.Join(contentLines, "\n")
-	if currentFile.Content != "" {
-		currentFile.Content += "\n"
+	if err := finishFile(); err != nil {
+		return nil, err
+	}
+	
+	if doc.Header != nil {
+		if err := checkFormatHeader(doc); err != nil {
+			return nil, fail(headerIdx, "", err)
+		}
 	}
-	doc.Files = append(doc.Files, *currentFile)
 	
 	return doc, nil
 }
 
-func findSafeDelimiter(doc *SiloDocument) (string, error) {
-	baseChars := []rune{'🌾', '🐿', '🐲', '👽', '>', '=', '*', '-'}
-	candidates := make(map[string]bool)
-	
-	for _, char := range baseChars {
-		for length := 1; length <= 50; length++ {
-			delimiter := strings.Repeat(string(char), length)
-			candidates[delimiter] = true
+func (doc *SiloDocument) WriteTo(w io.Writer) error {
+	return doc.WriteToWithOptions(w, WriteOptions{})
+}
+
+// WriteOptions configures WriteToWithOptions.
+type WriteOptions struct {
+	// LineEndings controls the line endings of file content in the archive.
+	LineEndings LineEndingPolicy
+	// MarkMissingNewline writes a "\ No newline at end of file" line after
+	// content that does not end in a newline, so parsing restores it byte
+	// for byte. Otherwise such content gains a newline.
+	MarkMissingNewline bool
+	// DelimiterCandidates lists delimiters that auto-selection tries, in
+	// order, ahead of DefaultDelimiterCandidates at each length, so
+	// archives can keep a house style such as "🌾" or "---".
+	DelimiterCandidates []string
+	// Sort sets the order entries are written in; doc.Files itself is not
+	// reordered. The default, SortInsertion, writes them in slice order.
+	Sort SortPolicy
+	// Less orders entries under SortCustom.
+	Less func(a, b *SiloFile) bool
+}
+
+// delimiterOptions returns the auto-selection options opts asks for.
+func (opts WriteOptions) delimiterOptions() DelimiterOptions {
+	if len(opts.DelimiterCandidates) == 0 {
+		return DelimiterOptions{}
+	}
+	candidates := append([]string(nil), opts.DelimiterCandidates...)
+	return DelimiterOptions{Candidates: append(candidates, DefaultDelimiterCandidates...)}
+}
+
+// WriteToWithOptions writes doc like WriteTo, converting file content as
+// set in opts.
+func (doc *SiloDocument) WriteToWithOptions(w io.Writer, opts WriteOptions) error {
+	if doc.signingKey != nil {
+		return doc.writeSigned(w, opts)
+	}
+	return doc.writeTo(w, opts)
+}
+
+// writeBufferSize is the size of the buffer writeTo writes through.
+const writeBufferSize = 64 << 10
+
+func (doc *SiloDocument) writeTo(w io.Writer, opts WriteOptions) error {
+	wasAutoDetected := doc.Delimiter == ""
+	if doc.Delimiter == "" {
+		delimiter, err := FindSafeDelimiter(doc, opts.delimiterOptions())
+		if err != nil {
+			return err
 		}
+		doc.Delimiter = delimiter
+	}
+	if isReservedDelimiter(doc.Delimiter) {
+		return fmt.Errorf("delimiter %q would be read back as a header line", doc.Delimiter)
 	}
 	
-	for _, file := range doc.Files {
-		for _, line := range strings.Split(file.Content, "\n") {
-			if line == "" {
+	if !wasAutoDetected {
+		for _, file := range doc.Files {
+			if file.Base64 {
 				continue
 			}
-			
-			for delimiter := range candidates {
-				if strings.HasPrefix(line, delimiter+" ") {
-					delete(candidates, delimiter)
+			if lines := delimiterLines(file.text(), doc.Delimiter, 1); len(lines) > 0 {
+				autoDelimiter, autoErr := FindSafeDelimiter(doc, opts.delimiterOptions())
+				return &DelimiterConflictError{
+					Delimiter:     doc.Delimiter,
+					Path:          file.Path,
+					Line:          lines[0],
+					Suggestion:    autoDelimiter,
+					SuggestionErr: autoErr,
 				}
 			}
 		}
 	}
 	
-	if len(candidates) == 0 {
-		return "", fmt.Errorf("unable to find safe delimiter: all delimiters up to 50 characters conflict with file content")
-	}
-	
-	shortestLength := 51
-	for delimiter := range candidates {
-		if len(delimiter) < shortestLength {
-			shortestLength = len(delimiter)
+	for _, file := range doc.Files {
+		if err := checkEntry(file); err != nil {
+			return err
 		}
 	}
 	
-	preferences := []rune{'>', '=', '*', '-'}
-	for _, char := range preferences {
-		delimiter := strings.Repeat(string(char), shortestLength)
-		if candidates[delimiter] {
-			return delimiter, nil
+	files := doc.Files
+	if opts.Sort != SortInsertion {
+		files = append([]SiloFile(nil), doc.Files...)
+		if err := sortFiles(files, opts.Sort, opts.Less); err != nil {
+			return err
 		}
 	}
 	
-	for delimiter := range candidates {
-		if len(delimiter) == shortestLength {
-			return delimiter, nil
-		}
-	}
+	// Buffered, so that many small entries do not mean many small writes;
+	// content is copied from the document straight into the buffer.
+	bw := bufio.NewWriterSize(w, writeBufferSize)
 	
-	return "", fmt.Errorf("internal error: no delimiter found despite having candidates")
-}
-
-func (doc *SiloDocument) WriteTo(w io.Writer) error {
-	wasAutoDetected := doc.Delimiter == ""
-	if doc.Delimiter == "" {
-		delimiter, err := findSafeDelimiter(doc)
-		if err != nil {
+	if doc.Header != nil {
+		header := *doc.Header
+		header.Version = FormatVersion
+		header.Delimiter = doc.Delimiter
+		header.Files = len(files)
+		if _, err := fmt.Fprintf(bw, "%s\n", header.String()); err != nil {
 			return err
 		}
-		doc.Delimiter = delimiter
 	}
 	
-	if !wasAutoDetected {
-		for _, file := range doc.Files {
-			for _, line := range strings.Split(file.Content, "\n") {
-				if line != "" && strings.HasPrefix(line, doc.Delimiter+" ") {
-					autoDelimiter, autoErr := findSafeDelimiter(doc)
-					if autoErr != nil {
-						return fmt.Errorf("delimiter %q conflicts with content in file %s, and no safe delimiter could be auto-generated: %v", doc.Delimiter, file.Path, autoErr)
-					}
-					return fmt.Errorf("delimiter %q conflicts with content in file %s. Try using auto-generated delimiter %q (remove -d flag) or choose a different delimiter", doc.Delimiter, file.Path, autoDelimiter)
-				}
+	for _, file := range files {
+		attrs := formatAttrs(entryAttrs(file))
+		
+		if file.LinkTarget != "" {
+			if _, err := fmt.Fprintf(bw, "%s %s%s%s%s\n", doc.Delimiter, file.Path, linkArrow, file.LinkTarget, attrs); err != nil {
+				return err
 			}
+			if err := writeFrontMatter(bw, file.Meta); err != nil {
+				return err
+			}
+			continue
 		}
-	}
-	
-	for _, file := range doc.Files {
-		_, err := fmt.Fprintf(w, "%s %s\n", doc.Delimiter, file.Path)
-		if err != nil {
-			return err
+		if file.Ref != "" {
+			if _, err := fmt.Fprintf(bw, "%s %s%s%s%s\n", doc.Delimiter, file.Path, refMarker, file.Ref, attrs); err != nil {
+				return err
+			}
+			if err := writeFrontMatter(bw, file.Meta); err != nil {
+				return err
+			}
+			continue
 		}
 		
-		content := file.Content
-		if !strings.HasSuffix(content, "\n") && content != "" {
-			content += "\n"
+		if file.Base64 {
+			if _, err := fmt.Fprintf(bw, "%s %s%s%s%s\n", doc.Delimiter, file.Path, refMarker, base64Marker, attrs); err != nil {
+				return err
+			}
+			if err := writeFrontMatter(bw, file.Meta); err != nil {
+				return err
+			}
+			if err := writeBase64Content(bw, file.text()); err != nil {
+				return err
+			}
+			continue
 		}
 		
-		_, err = w.Write([]byte(content))
-		if err != nil {
+		if _, err := fmt.Fprintf(bw, "%s %s%s\n", doc.Delimiter, file.Path, attrs); err != nil {
+			return err
+		}
+		if err := writeFrontMatter(bw, file.Meta); err != nil {
+			return err
+		}
+		
+		content := file.text()
+		if err := writeConverted(bw, content, file.CRLF, opts.LineEndings); err != nil {
 			return err
 		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+			if opts.MarkMissingNewline {
+				if _, err := bw.WriteString(noNewlineMarker + "\n"); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	
-	return nil
+	return bw.Flush()
 }
 
 func isBlankLine(line string) bool {
 	return strings.TrimSpace(line) == ""
 }
 
+// ReadDirectoryTreeOptions configures ReadDirectoryTreeWithOptions.
+type ReadDirectoryTreeOptions struct {
+	// Parallelism is the number of files read concurrently. Zero or less
+	// uses runtime.NumCPU().
+	Parallelism int
+	// Symlinks controls how symbolic links inside the tree are packed.
+	Symlinks SymlinkPolicy
+	// NoFollowRoot fails the read when rootPath itself is a symlink,
+	// instead of reading the directory it points to.
+	NoFollowRoot bool
+	// Include, when non-empty, limits the document to files whose path
+	// matches at least one of these patterns.
+	Include []string
+	// Exclude leaves out files, and whole directories, whose path matches
+	// any of these patterns, in addition to DefaultExcludes.
+	Exclude []string
+	// NoDefaultExcludes packs what DefaultExcludes would leave out, such as
+	// .git and node_modules.
+	NoDefaultExcludes bool
+	// SkipHidden leaves out hidden files and directories, those whose name
+	// starts with a dot, such as .env or .vscode. The root itself is read
+	// whatever its name. Hidden files are packed by default.
+	SkipHidden bool
+	// MaxFileSize, when positive, fails the read with a *LimitError naming
+	// the first file larger than this many bytes.
+	MaxFileSize int64
+	// SkipLarge leaves out files larger than MaxFileSize instead of
+	// failing the read.
+	SkipLarge bool
+	// MaxFiles, when positive, fails the read with a *LimitError once more
+	// than this many files would be packed, before any content is read, so
+	// that packing a home directory or node_modules by mistake stops early.
+	MaxFiles int
+	// Binary controls how files whose content looks binary (see IsBinary)
+	// are packed.
+	Binary BinaryPolicy
+	// SkipBinary is shorthand for Binary: BinarySkip.
+	SkipBinary bool
+	// Irregular controls how named pipes, sockets and devices are handled.
+	// They are never read.
+	Irregular IrregularPolicy
+	// LinkHardLinks packs every name of a hard-linked file after the first
+	// as a link entry to the first, rather than repeating its content.
+	LinkHardLinks bool
+	// OnSkip, if set, is called with the path of each file left out by
+	// Include, Exclude, SkipBinary, SkipLarge or Irregular and the reason it
+	// was skipped, one of the Skip constants.
+	OnSkip func(path, reason string)
+	// Report, if set, is filled in with the paths packed and everything
+	// left out, with reasons, including excluded directories and
+	// unreadable files.
+	Report *PackReport
+	// OnError, if set, is called with the path and error of each file or
+	// directory that cannot be read, such as one without read permission.
+	// Returning nil leaves it out, recording it in SiloDocument.Skipped, and
+	// the read goes on; returning an error, such as err itself, stops the
+	// read with it. Limits, symlink policy errors and cancellation always
+	// stop the read.
+	OnError func(path string, err error) error
+	// ContinueOnError is shorthand for an OnError that returns nil.
+	ContinueOnError bool
+	// Logger, if set, receives a debug event for each file packed or
+	// skipped.
+	Logger *slog.Logger
+	// ContentBytes stores each file's content in SiloFile.ContentBytes as
+	// it was read, instead of copying it into Content.
+	ContentBytes bool
+}
+
 func ReadDirectoryTree(rootPath string) (*SiloDocument, error) {
+	return ReadDirectoryTreeWithOptions(rootPath, ReadDirectoryTreeOptions{})
+}
+
+// ReadDirectoryTreeWithOptions packs every file under rootPath, reading file
+// contents with a bounded pool of workers. The resulting document is sorted by
+// path, so output does not depend on the order in which reads complete.
+func ReadDirectoryTreeWithOptions(rootPath string, opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+	return ReadDirectoryTreeContext(context.Background(), rootPath, opts)
+}
+
+// ReadDirectoryTreeContext is ReadDirectoryTreeWithOptions with cancellation.
+// Once ctx is done no further files are opened and the returned error wraps
+// ctx.Err() along with how far the read got.
+func ReadDirectoryTreeContext(ctx context.Context, rootPath string, opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+	return readDirectoryTree(ctx, rootPath, opts, nil)
+}
+
+// readDirectoryTree implements ReadDirectoryTreeContext. If reuse is non-nil
+// it is asked about each regular file before it is read; when it returns
+// true its content is used instead of reading the file.
+func readDirectoryTree(ctx context.Context, rootPath string, opts ReadDirectoryTreeOptions, reuse func(relPath string, info os.FileInfo) (string, bool)) (*SiloDocument, error) {
+	if err := validateFilterPatterns(opts.Include, opts.Exclude); err != nil {
+		return nil, err
+	}
+	if !opts.NoDefaultExcludes {
+		opts.Exclude = append(DefaultExcludes[:len(DefaultExcludes):len(DefaultExcludes)], opts.Exclude...)
+	}
+	if opts.Report != nil {
+		*opts.Report = PackReport{}
+	}
+	skip := func(relPath, reason string) {
+		logDebug(opts.Logger, "skipped file", "path", relPath, "reason", reason)
+		if opts.OnSkip != nil {
+			opts.OnSkip(relPath, reason)
+		}
+		opts.Report.skip(relPath, reason)
+	}
+	
 	doc := &SiloDocument{Delimiter: ">"}
+	var fullPaths []string
+	var linked hardLinks
 	
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		if info.IsDir() {
+	var walk func(dir, prefix string, followed []string) error
+	walk = func(dir, prefix string, followed []string) error {
+		return filepath.Walk(dir, func(path string, info os.FileInfo, walkErr error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return fmt.Errorf("walked %d files before stopping: %w", len(fullPaths), ctxErr)
+			}
+			
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			relPath = filepath.ToSlash(filepath.Join(prefix, relPath))
+			
+			if walkErr != nil {
+				if path == dir {
+					return walkErr
+				}
+				if matchesAnyPattern(opts.Exclude, relPath) {
+					return nil
+				}
+				return doc.tolerateFileError(relPath, walkErr, opts.OnError, opts.ContinueOnError)
+			}
+			
+			if info.IsDir() {
+				if path != dir && matchesAnyPattern(opts.Exclude, relPath) {
+					logDebug(opts.Logger, "skipped directory", "path", relPath, "reason", SkipExcluded)
+					opts.Report.skip(relPath+"/", SkipExcluded)
+					return filepath.SkipDir
+				}
+				if path != dir && opts.SkipHidden && isHidden(info.Name()) {
+					logDebug(opts.Logger, "skipped directory", "path", relPath, "reason", SkipHidden)
+					opts.Report.skip(relPath+"/", SkipHidden)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			
+			if matchesAnyPattern(opts.Exclude, relPath) {
+				skip(relPath, SkipExcluded)
+				return nil
+			}
+			if path != dir && opts.SkipHidden && isHidden(info.Name()) {
+				skip(relPath, SkipHidden)
+				return nil
+			}
+			
+			if info.Mode()&os.ModeSymlink != 0 {
+				switch opts.Symlinks {
+				case SymlinkSkip:
+					return nil
+				case SymlinkError:
+					return fmt.Errorf("symlink not allowed: %s", relPath)
+				case SymlinkPreserve:
+					target, err := os.Readlink(path)
+					if err != nil {
+						return err
+					}
+					if opts.MaxFiles > 0 && len(doc.Files) >= opts.MaxFiles {
+						return &LimitError{Limit: "MaxFiles", Max: int64(opts.MaxFiles), Path: relPath}
+					}
+					doc.Files = append(doc.Files, SiloFile{Path: relPath, LinkTarget: filepath.ToSlash(target)})
+					fullPaths = append(fullPaths, "")
+					return nil
+				}
+				
+				targetInfo, err := os.Stat(path)
+				if err != nil {
+					return doc.tolerateFileError(relPath, fmt.Errorf("failed to follow symlink %s: %w", relPath, err), opts.OnError, opts.ContinueOnError)
+				}
+				if targetInfo.IsDir() {
+					realPath, err := filepath.EvalSymlinks(path)
+					if err != nil {
+						return err
+					}
+					for _, seen := range followed {
+						if seen == realPath {
+							return fmt.Errorf("symlink cycle at %s", relPath)
+						}
+					}
+					return walk(path+string(filepath.Separator), relPath, append(followed, realPath))
+				}
+				info = targetInfo
+			}
+			
+			if !info.Mode().IsRegular() {
+				if opts.Irregular == IrregularError {
+					return fmt.Errorf("%s is a %s, not a regular file", relPath, fileKind(info.Mode()))
+				}
+				skip(relPath, SkipIrregular)
+				return nil
+			}
+			if len(opts.Include) > 0 && !matchesAnyPattern(opts.Include, relPath) {
+				skip(relPath, SkipNotIncluded)
+				return nil
+			}
+			if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+				if opts.SkipLarge {
+					skip(relPath, SkipTooLarge)
+					return nil
+				}
+				return &LimitError{Limit: "MaxFileSize", Max: opts.MaxFileSize, Path: relPath}
+			}
+			if opts.MaxFiles > 0 && len(doc.Files) >= opts.MaxFiles {
+				return &LimitError{Limit: "MaxFiles", Max: int64(opts.MaxFiles), Path: relPath}
+			}
+			
+			if opts.LinkHardLinks {
+				if target, ok := linked.target(relPath, info); ok {
+					doc.Files = append(doc.Files, SiloFile{Path: relPath, LinkTarget: target})
+					fullPaths = append(fullPaths, "")
+					return nil
+				}
+			}
+			
+			if reuse != nil {
+				if content, ok := reuse(relPath, info); ok {
+					doc.Files = append(doc.Files, SiloFile{Path: relPath, Content: content})
+					fullPaths = append(fullPaths, "")
+					return nil
+				}
+			}
+			
+			doc.Files = append(doc.Files, SiloFile{Path: relPath})
+			fullPaths = append(fullPaths, path)
+			
 			return nil
+		})
+	}
+	
+	rootReal, err := filepath.EvalSymlinks(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	// A symlinked root is walked as the directory it points to; links
+	// inside it are still subject to Symlinks.
+	if info, err := os.Lstat(filepath.Clean(rootPath)); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if opts.NoFollowRoot {
+			return nil, fmt.Errorf("%s is a symlink to %s", rootPath, rootReal)
 		}
-		
-		relPath, err := filepath.Rel(rootPath, path)
-		if err != nil {
-			return err
-		}
-		
-		relPath = filepath.ToSlash(relPath)
-		
-		content, err := os.ReadFile(path)
-		if err != nil {
+		rootPath = rootReal
+	}
+	if err := walk(rootPath, "", []string{rootReal}); err != nil {
+		return nil, err
+	}
+	
+	unreadable := make(map[int]bool)
+	if err := readContents(ctx, doc.Files, fullPaths, opts.Parallelism, opts.ContentBytes, func(i int, err error) error {
+		if err := doc.tolerateFileError(doc.Files[i].Path, err, opts.OnError, opts.ContinueOnError); err != nil {
 			return err
 		}
-		
-		doc.Files = append(doc.Files, SiloFile{
-			Path:    relPath,
-			Content: string(content),
-		})
-		
+		unreadable[i] = true
 		return nil
-	})
+	}); err != nil {
+		return nil, err
+	}
+	if len(unreadable) > 0 {
+		kept := doc.Files[:0]
+		for i, file := range doc.Files {
+			if !unreadable[i] {
+				kept = append(kept, file)
+			}
+		}
+		doc.Files = kept
+	}
 	
+	binary := opts.Binary
+	if opts.SkipBinary {
+		binary = BinarySkip
+	}
+	skippedBinary, err := doc.ApplyBinaryPolicy(binary)
 	if err != nil {
 		return nil, err
 	}
+	for _, path := range skippedBinary {
+		skip(path, SkipBinary)
+	}
 	
 	sort.Slice(doc.Files, func(i, j int) bool {
 		return doc.Files[i].Path < doc.Files[j].Path
 	})
+	sortSkipped(doc.Skipped)
+	opts.Report.finish(doc)
+	for _, file := range doc.Files {
+		logPacked(opts.Logger, file)
+	}
 	
 	return doc, nil
 }
 
+// readContents fills files[i].Content, or files[i].ContentBytes if asBytes
+// is set, from fullPaths[i] using up to
+// parallelism concurrent readers, skipping entries with an empty full path.
+// Once all reads are done, onError is called for each failed file in order;
+// the first error it returns is returned.
+func readContents(ctx context.Context, files []SiloFile, fullPaths []string, parallelism int, asBytes bool, onError func(i int, err error) error) error {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+	if parallelism > len(files) {
+		parallelism = len(files)
+	}
+	
+	errs := make([]error, len(files))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if fullPaths[i] == "" {
+					continue
+				}
+				content, err := os.ReadFile(fullPaths[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				if asBytes {
+					files[i].SetBytes(content)
+				} else {
+					files[i].Content = string(content)
+				}
+			}
+		}()
+	}
+	
+	queued := 0
+feed:
+	for i := range files {
+		select {
+		case indices <- i:
+			queued++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+	
+	if queued < len(files) {
+		return fmt.Errorf("read %d of %d files before stopping: %w", queued, len(files), ctx.Err())
+	}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if err := onError(i, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func ReadFiles(filePaths []string) (*SiloDocument, error) {
+	return ReadFilesContext(context.Background(), filePaths, ReadFilesOptions{})
+}
+
+// ReadFilesOptions configures ReadFilesWithOptions.
+type ReadFilesOptions struct {
+	// MaxFileSize, when positive, fails the read with a *LimitError naming
+	// the first file larger than this many bytes, before it is read.
+	MaxFileSize int64
+	// SkipLarge leaves out files larger than MaxFileSize instead of
+	// failing the read.
+	SkipLarge bool
+	// Report, if set, is filled in with the paths packed and the files
+	// left out, with reasons.
+	Report *PackReport
+	// WorkingDir, if set, is the directory relative paths are read from.
+	// Entry paths are still the paths as given.
+	WorkingDir string
+	// OnError and ContinueOnError let the read go on past files that
+	// cannot be read, as for ReadDirectoryTreeOptions. A path that is a
+	// directory always stops the read.
+	OnError         func(path string, err error) error
+	ContinueOnError bool
+	// Logger, if set, receives a debug event for each file packed.
+	Logger *slog.Logger
+}
+
+// ReadFilesWithOptions is ReadFiles with limits on what is read.
+func ReadFilesWithOptions(filePaths []string, opts ReadFilesOptions) (*SiloDocument, error) {
+	return ReadFilesContext(context.Background(), filePaths, opts)
+}
+
+// ReadFilesContext is ReadFilesWithOptions with cancellation. Once ctx is done
+// no further files are read and the returned error wraps ctx.Err().
+func ReadFilesContext(ctx context.Context, filePaths []string, opts ReadFilesOptions) (*SiloDocument, error) {
 	doc := &SiloDocument{Delimiter: ">"}
+	if opts.Report != nil {
+		*opts.Report = PackReport{}
+	}
 	
 	for _, filePath := range filePaths {
-		info, err := os.Stat(filePath)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("read %d of %d files before stopping: %w", len(doc.Files), len(filePaths), err)
+		}
+		
+		name := filePath
+		if opts.WorkingDir != "" && !filepath.IsAbs(filePath) {
+			name = filepath.Join(opts.WorkingDir, filePath)
+		}
+		
+		info, err := os.Stat(name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+			if err := doc.tolerateFileError(filepath.ToSlash(filePath), fmt.Errorf("failed to stat file %s: %w", filePath, err), opts.OnError, opts.ContinueOnError); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		
 		if info.IsDir() {
 			return nil, fmt.Errorf("path %s is a directory, not a file", filePath)
 		}
+		if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+			if opts.SkipLarge {
+				logDebug(opts.Logger, "skipped file", "path", filepath.ToSlash(filePath), "reason", SkipTooLarge)
+				opts.Report.skip(filepath.ToSlash(filePath), SkipTooLarge)
+				continue
+			}
+			return nil, &LimitError{Limit: "MaxFileSize", Max: opts.MaxFileSize, Path: filepath.ToSlash(filePath)}
+		}
 		
-		content, err := os.ReadFile(filePath)
+		content, err := os.ReadFile(name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+			if err := doc.tolerateFileError(filepath.ToSlash(filePath), fmt.Errorf("failed to read file %s: %w", filePath, err), opts.OnError, opts.ContinueOnError); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		
 		doc.Files = append(doc.Files, SiloFile{
 			Path:    filepath.ToSlash(filePath),
 			Content: string(content),
 		})
+		logPacked(opts.Logger, doc.Files[len(doc.Files)-1])
 	}
 	
 	sort.Slice(doc.Files, func(i, j int) bool {
 		return doc.Files[i].Path < doc.Files[j].Path
 	})
+	sortSkipped(doc.Skipped)
+	opts.Report.finish(doc)
 	
 	return doc, nil
 }
 
+// UnpackOptions configures WriteToDirectoryWithOptions.
+type UnpackOptions struct {
+	// WindowsPaths controls handling of paths that are illegal on Windows.
+	WindowsPaths WindowsPathPolicy
+	// CaseCollisions controls handling of paths that differ only in case.
+	CaseCollisions CaseCollisionPolicy
+	// NameChecks selects special file names to refuse: device names, a
+	// leading '-', control characters and overlong names. Zero checks
+	// nothing.
+	NameChecks NameCheck
+	// SanitizePaths rewrites names failing NameChecks with
+	// SanitizeSpecialNames instead of refusing them.
+	SanitizePaths bool
+	// Rename, if set, maps each entry path to the path it is unpacked to,
+	// before any other path handling. Entries for which it returns false
+	// are not unpacked. Renamed paths must be valid entry paths.
+	Rename func(path string) (string, bool)
+	// FileMode is the permission for written files. Zero means 0644.
+	FileMode os.FileMode
+	// DirMode is the permission for created directories. Zero means 0755.
+	DirMode os.FileMode
+	// HonorUmask lets the process umask narrow an explicit FileMode or
+	// DirMode. By default explicit modes are applied exactly, including to
+	// files that already exist; with HonorUmask existing files keep their
+	// current permissions.
+	HonorUmask bool
+	// ResolveRef fetches the content of reference entries. Without it,
+	// unpacking a reference entry fails with ErrUnresolvedRef.
+	ResolveRef RefResolver
+	// LineEndings controls the line endings of written files.
+	LineEndings LineEndingPolicy
+	// Logger, if set, receives a debug event for each file written.
+	Logger *slog.Logger
+	// Parallelism is the number of files written concurrently. Zero or
+	// less uses runtime.NumCPU(). ResolveRef and Logger may be called from
+	// several goroutines at once.
+	Parallelism int
+}
+
+const (
+	defaultFileMode os.FileMode = 0644
+	defaultDirMode  os.FileMode = 0755
+)
+
+// mkdirAll creates dir and any missing parents with mode. When exact is set,
+// directories it creates are chmod-ed to mode so the umask does not apply.
+func mkdirAll(dir string, mode os.FileMode, exact bool) error {
+	var missing []string
+	if exact {
+		for d := dir; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(d); err == nil {
+				break
+			}
+			missing = append(missing, d)
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (doc *SiloDocument) WriteToDirectory(rootPath string) error {
-	for _, file := range doc.Files {
-		fullPath := filepath.Join(rootPath, filepath.FromSlash(file.Path))
-		
-		dir := filepath.Dir(fullPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	return doc.WriteToDirectoryWithOptions(rootPath, UnpackOptions{})
+}
+
+// WriteToDirectoryWithOptions writes every entry under rootPath, applying
+// opts to each path before it is created.
+func (doc *SiloDocument) WriteToDirectoryWithOptions(rootPath string, opts UnpackOptions) error {
+	return doc.WriteToDirectoryContext(context.Background(), rootPath, opts)
+}
+
+// WriteToDirectoryContext is WriteToDirectoryWithOptions with cancellation.
+// Files written before ctx is done are left in place, and the returned error
+// wraps ctx.Err() along with how many were written.
+func (doc *SiloDocument) WriteToDirectoryContext(ctx context.Context, rootPath string, opts UnpackOptions) error {
+	fileMode, dirMode := opts.FileMode, opts.DirMode
+	if fileMode == 0 {
+		fileMode = defaultFileMode
+	}
+	if dirMode == 0 {
+		dirMode = defaultDirMode
+	}
+	exactFileMode := opts.FileMode != 0 && !opts.HonorUmask
+	exactDirMode := opts.DirMode != 0 && !opts.HonorUmask
+	
+	paths, err := unpackPaths(doc.Files, opts)
+	if err != nil {
+		return err
+	}
+	
+	u := &unpacker{
+		root:          rootPath,
+		opts:          opts,
+		fileMode:      fileMode,
+		dirMode:       dirMode,
+		exactFileMode: exactFileMode,
+		exactDirMode:  exactDirMode,
+		dirs:          make(map[string]bool),
+	}
+	
+	// Links are created once every regular file is in place, so no file is
+	// written through a link the archive itself created.
+	var files, links []int
+	for i, file := range doc.Files {
+		switch {
+		case paths[i] == "":
+		case file.LinkTarget != "":
+			links = append(links, i)
+		default:
+			files = append(files, i)
 		}
-		
-		if err := os.WriteFile(fullPath, []byte(file.Content), 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", fullPath, err)
+	}
+	
+	if len(files)+len(links) > 0 {
+		if err := u.init(); err != nil {
+			return err
+		}
+	}
+	
+	written, err := u.writeFiles(ctx, doc.Files, paths, files)
+	if err != nil {
+		return err
+	}
+	for _, i := range links {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("wrote %d of %d files before stopping: %w", written, len(doc.Files), err)
+		}
+		if err := u.writeLink(doc.Files[i], paths[i]); err != nil {
+			return err
+		}
+		written++
+	}
+	
+	return nil
+}
+
+// unpacker writes the entries of a document under root for
+// WriteToDirectoryContext.
+type unpacker struct {
+	root                        string
+	absRoot, realRoot           string // root made absolute, and with symlinks resolved
+	opts                        UnpackOptions
+	fileMode, dirMode           os.FileMode
+	exactFileMode, exactDirMode bool
+	
+	mu   sync.Mutex
+	dirs map[string]bool // directories known to exist
+}
+
+// init creates the output directory and resolves where it really is.
+func (u *unpacker) init() error {
+	var err error
+	if u.absRoot, err = filepath.Abs(u.root); err != nil {
+		return err
+	}
+	if err := u.mkdir(u.absRoot); err != nil {
+		return err
+	}
+	u.realRoot, err = filepath.EvalSymlinks(u.absRoot)
+	return err
+}
+
+// mkdir creates dir as mkdirAll does, remembering it so that files sharing
+// a directory create it only once.
+func (u *unpacker) mkdir(dir string) error {
+	u.mu.Lock()
+	done := u.dirs[dir]
+	u.mu.Unlock()
+	if done {
+		return nil
+	}
+	
+	if err := mkdirAll(dir, u.dirMode, u.exactDirMode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	u.mu.Lock()
+	for d := dir; !u.dirs[d]; d = filepath.Dir(d) {
+		u.dirs[d] = true
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	u.mu.Unlock()
+	return nil
+}
+
+// writeFiles writes files[k] for each index k in indices, using up to
+// opts.Parallelism concurrent writers, and returns how many were written.
+// When several writes fail, the error for the earliest file is returned.
+func (u *unpacker) writeFiles(ctx context.Context, files []SiloFile, paths []string, indices []int) (int, error) {
+	parallelism := u.opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+	if parallelism > len(indices) {
+		parallelism = len(indices)
+	}
+	
+	errs := make([]error, len(indices))
+	var failed atomic.Bool
+	work := make(chan int)
+	var wg sync.WaitGroup
+	
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range work {
+				i := indices[k]
+				if errs[k] = u.writeFile(files[i], paths[i]); errs[k] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	
+	queued := 0
+feed:
+	for k := range indices {
+		if ctx.Err() != nil || failed.Load() {
+			break
+		}
+		select {
+		case work <- k:
+			queued++
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(work)
+	wg.Wait()
 	
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+	if queued < len(indices) {
+		return queued, fmt.Errorf("wrote %d of %d files before stopping: %w", queued, len(files), ctx.Err())
+	}
+	return queued, nil
+}
+
+// writeFile writes a regular or reference entry to its unpacked path.
+func (u *unpacker) writeFile(file SiloFile, path string) error {
+	fullPath, err := u.target(path)
+	if err != nil {
+		return err
+	}
+	if err := u.confine(path, fullPath); err != nil {
+		return err
+	}
+	if err := u.mkdir(filepath.Dir(fullPath)); err != nil {
+		return err
+	}
+	
+	content := unpackedContent(file, u.opts.LineEndings)
+	if file.Ref != "" {
+		if content, err = resolveRef(file, u.opts.ResolveRef); err != nil {
+			return err
+		}
+	}
+	
+	if err := os.WriteFile(fullPath, content, u.fileMode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", fullPath, err)
+	}
+	if u.exactFileMode {
+		if err := os.Chmod(fullPath, u.fileMode); err != nil {
+			return fmt.Errorf("failed to set mode on %s: %w", fullPath, err)
+		}
+	}
+	logDebug(u.opts.Logger, "unpacked file", "path", path, "bytes", len(content))
+	return nil
+}
+
+// writeLink creates a link entry at its unpacked path, replacing whatever
+// is there.
+func (u *unpacker) writeLink(file SiloFile, path string) error {
+	fullPath, err := u.target(path)
+	if err != nil {
+		return err
+	}
+	if err := u.confine(path, fullPath); err != nil {
+		return err
+	}
+	if err := u.mkdir(filepath.Dir(fullPath)); err != nil {
+		return err
+	}
+	
+	if err := validateLinkTarget(u.root, path, file.LinkTarget); err != nil {
+		return err
+	}
+	// The target is resolved again from where the link's directory really
+	// is, which differs from its path when the archive reaches it through
+	// another link, as in "x -> ." followed by "x/y -> ..".
+	realDir, err := filepath.EvalSymlinks(filepath.Dir(fullPath))
+	if err != nil {
+		return err
+	}
+	if !withinRoot(filepath.Join(realDir, filepath.FromSlash(file.LinkTarget)), u.realRoot) {
+		return invalidPathError("symlink %s -> %s escapes the output directory", path, file.LinkTarget)
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", fullPath, err)
+	}
+	if err := os.Symlink(filepath.FromSlash(file.LinkTarget), fullPath); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", fullPath, err)
+	}
+	logDebug(u.opts.Logger, "unpacked link", "path", path, "target", file.LinkTarget)
 	return nil
 }
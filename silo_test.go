@@ -8,9 +8,15 @@ package silo
 // - Verified existing ASCII delimiter functionality remains intact
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -621,6 +627,41 @@ func TestReadFilesNonexistent(t *testing.T) {
 	}
 }
 
+func TestReadFilesMaxFileSize(t *testing.T) {
+	tempDir := t.TempDir()
+	small := filepath.Join(tempDir, "small.txt")
+	big := filepath.Join(tempDir, "big.log")
+	os.WriteFile(small, []byte("ok\n"), 0644)
+	os.WriteFile(big, []byte(strings.Repeat("x", 100)), 0644)
+	
+	if _, err := ReadFilesWithOptions([]string{small}, ReadFilesOptions{MaxFileSize: 10}); err != nil {
+		t.Fatalf("ReadFilesWithOptions failed: %v", err)
+	}
+	
+	_, err := ReadFilesWithOptions([]string{small, big}, ReadFilesOptions{MaxFileSize: 10})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected *LimitError, got %v", err)
+	}
+	if limitErr.Limit != "MaxFileSize" || limitErr.Path != filepath.ToSlash(big) {
+		t.Errorf("Unexpected limit error: %+v", limitErr)
+	}
+}
+
+func TestReadFilesWorkingDir(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "src"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.go"), []byte("package main\n"), 0644)
+	
+	doc, err := ReadFilesWithOptions([]string{"src/main.go"}, ReadFilesOptions{WorkingDir: tempDir})
+	if err != nil {
+		t.Fatalf("ReadFilesWithOptions failed: %v", err)
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Path != "src/main.go" || doc.Files[0].Content != "package main\n" {
+		t.Errorf("Unexpected files: %+v", doc.Files)
+	}
+}
+
 func TestFindSafeDelimiter(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -728,9 +769,9 @@ func TestFindSafeDelimiterNoSolution(t *testing.T) {
 		},
 	}
 	
-	_, err := findSafeDelimiter(doc)
+	_, err := FindSafeDelimiter(doc, DelimiterOptions{Random: -1})
 	if err == nil {
-		t.Error("Expected error when no safe delimiter can be found")
+		t.Fatal("Expected error when no safe delimiter can be found")
 	}
 	
 	if !strings.Contains(err.Error(), "unable to find safe delimiter") {
@@ -738,7 +779,7 @@ func TestFindSafeDelimiterNoSolution(t *testing.T) {
 	}
 }
 
-func TestWriteToNoSafeDelimiter(t *testing.T) {
+func TestWriteToFallsBackToRandomDelimiter(t *testing.T) {
 	content := ""
 	for _, char := range []rune{'>', '=', '*', '-'} {
 		for length := 1; length <= 50; length++ {
@@ -754,9 +795,16 @@ func TestWriteToNoSafeDelimiter(t *testing.T) {
 	}
 	
 	var buf strings.Builder
-	err := doc.WriteTo(&buf)
-	if err == nil {
-		t.Error("Expected error when no safe delimiter can be found")
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("Expected a random fallback delimiter, got error: %v", err)
+	}
+	
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if len(parsed.Files) != 1 || parsed.Files[0].Content != content {
+		t.Error("Content did not round-trip with the fallback delimiter")
 	}
 }
 
@@ -1191,8 +1239,8 @@ func TestImprovedErrorMessages(t *testing.T) {
 		}
 	})
 	
-	t.Run("error when auto-generation impossible", func(t *testing.T) {
-		// Create content that conflicts with ALL possible delimiters
+	t.Run("suggests a fallback when every ASCII delimiter conflicts", func(t *testing.T) {
+		// Create content that conflicts with every default candidate
 		content := ""
 		for _, char := range []rune{'>', '=', '*', '-'} {
 			for length := 1; length <= 50; length++ {
@@ -1218,8 +1266,7 @@ func TestImprovedErrorMessages(t *testing.T) {
 		expectedParts := []string{
 			"delimiter \">\" conflicts with content",
 			"impossible.txt",
-			"no safe delimiter could be auto-generated",
-			"all delimiters up to 50 characters conflict",
+			"Try using auto-generated delimiter",
 		}
 		
 		for _, part := range expectedParts {
@@ -1229,3 +1276,418 @@ func TestImprovedErrorMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestParseSiloFileWithOptionsLimits(t *testing.T) {
+	input := "> a.txt\nhello\n\n> b.txt\nworld\n"
+
+	tests := []struct {
+		name  string
+		opts  ParseOptions
+		limit string
+	}{
+		{"max file size", ParseOptions{MaxFileSize: 6}, "MaxFileSize"},
+		{"max total size", ParseOptions{MaxTotalSize: 10}, "MaxTotalSize"},
+		{"max file count", ParseOptions{MaxFileCount: 1}, "MaxFileCount"},
+		{"max line length", ParseOptions{MaxLineLength: 5}, "MaxLineLength"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSiloFileWithOptions(strings.NewReader(input), test.opts)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Expected *LimitError, got %v", err)
+			}
+			if limitErr.Limit != test.limit {
+				t.Errorf("Expected limit %s, got %s", test.limit, limitErr.Limit)
+			}
+		})
+	}
+}
+
+func TestParseSiloFileWithOptionsWithinLimits(t *testing.T) {
+	input := "> a.txt\nhello\n\n> b.txt\nworld\n"
+	opts := ParseOptions{
+		MaxFileSize:   7,
+		MaxTotalSize:  int64(len(input)),
+		MaxFileCount:  2,
+		MaxLineLength: 7,
+	}
+
+	doc, err := ParseSiloFileWithOptions(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatalf("ParseSiloFileWithOptions failed: %v", err)
+	}
+	if len(doc.Files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(doc.Files))
+	}
+}
+
+func TestParseSiloFileWithOptionsLongLineBeyondBuffer(t *testing.T) {
+	input := "> a.txt\n" + strings.Repeat("x", 100000) + "\n"
+
+	_, err := ParseSiloFileWithOptions(strings.NewReader(input), ParseOptions{MaxLineLength: 1000})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxLineLength" {
+		t.Fatalf("Expected MaxLineLength *LimitError, got %v", err)
+	}
+}
+
+func TestParseSiloFileMultiMegabyteLines(t *testing.T) {
+	minified := strings.Repeat("var a=1;", 1<<19) // 4 MiB on one line
+	json := `{"data":"` + strings.Repeat("y", 3<<20) + `"}`
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "app.min.js", Content: minified + "\n"},
+			{Path: "data.json", Content: json + "\n"},
+			{Path: "crlf.txt", Content: strings.Repeat("z", 1<<20) + "\r\nend\r\n"},
+		},
+	}
+	
+	var buf strings.Builder
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if len(parsed.Files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(parsed.Files))
+	}
+	if parsed.Files[0].Content != minified+"\n" || parsed.Files[1].Content != json+"\n" {
+		t.Error("Long lines were not read back intact")
+	}
+	if !parsed.Files[2].CRLF || parsed.Files[2].Content != strings.Repeat("z", 1<<20)+"\nend\n" {
+		t.Error("Expected a long CRLF line to be read back with its line ending recorded")
+	}
+	
+	// An unterminated long final line is still read.
+	parsed, err = ParseSiloFile(strings.NewReader("> a.txt\n" + minified))
+	if err != nil || parsed.Files[0].Content != minified+"\n" {
+		t.Errorf("Expected unterminated long line to parse, got %v", err)
+	}
+}
+
+func TestReadDirectoryTreeParallelismDeterministic(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 200; i++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("dir%d", i%7), fmt.Sprintf("file%03d.txt", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("content %d\n", i)), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	var outputs []string
+	for _, parallelism := range []int{1, 4, 0} {
+		doc, err := ReadDirectoryTreeWithOptions(tempDir, ReadDirectoryTreeOptions{Parallelism: parallelism})
+		if err != nil {
+			t.Fatalf("ReadDirectoryTreeWithOptions(%d) failed: %v", parallelism, err)
+		}
+		if len(doc.Files) != 200 {
+			t.Fatalf("Expected 200 files, got %d", len(doc.Files))
+		}
+
+		var buf strings.Builder
+		if err := doc.WriteTo(&buf); err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+		outputs = append(outputs, buf.String())
+	}
+
+	for i := 1; i < len(outputs); i++ {
+		if outputs[i] != outputs[0] {
+			t.Errorf("Output with parallelism variant %d differs from sequential output", i)
+		}
+	}
+}
+
+func TestParseMissingFinalNewline(t *testing.T) {
+	terminated := "> a.txt\nhello\n\n> b.txt\nlast line\n"
+	unterminated := strings.TrimSuffix(terminated, "\n")
+
+	want, err := ParseSiloFile(strings.NewReader(terminated))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if len(want.Warnings) != 0 {
+		t.Errorf("Expected no warnings for terminated input, got %v", want.Warnings)
+	}
+
+	got, err := ParseSiloFile(strings.NewReader(unterminated))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+
+	if len(got.Files) != len(want.Files) {
+		t.Fatalf("Expected %d files, got %d", len(want.Files), len(got.Files))
+	}
+	for i := range want.Files {
+		if !reflect.DeepEqual(got.Files[i], want.Files[i]) {
+			t.Errorf("File %d differs: expected %+v, got %+v", i, want.Files[i], got.Files[i])
+		}
+	}
+
+	if len(got.Warnings) != 1 || !strings.Contains(got.Warnings[0], "line 5") {
+		t.Errorf("Expected one warning about line 5, got %v", got.Warnings)
+	}
+}
+
+func TestParseMissingFinalNewlineStrict(t *testing.T) {
+	input := "> a.txt\nhello"
+
+	_, err := ParseSiloFileWithOptions(strings.NewReader(input), ParseOptions{RequireFinalNewline: true})
+	if err == nil {
+		t.Fatal("Expected error for missing final newline in strict mode")
+	}
+	if !strings.Contains(err.Error(), "missing newline") {
+		t.Errorf("Expected missing newline error, got: %v", err)
+	}
+
+	if _, err := ParseSiloFileWithOptions(strings.NewReader(input+"\n"), ParseOptions{RequireFinalNewline: true}); err != nil {
+		t.Errorf("Expected terminated input to parse in strict mode, got: %v", err)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("content\n"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ReadDirectoryTreeContext(ctx, tempDir, ReadDirectoryTreeOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadDirectoryTreeContext: expected context.Canceled, got %v", err)
+	}
+
+	if _, err := ReadFilesContext(ctx, []string{filepath.Join(tempDir, "a.txt")}, ReadFilesOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadFilesContext: expected context.Canceled, got %v", err)
+	}
+
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "content\n"}}}
+	outputDir := t.TempDir()
+	err := doc.WriteToDirectoryContext(ctx, outputDir, UnpackOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteToDirectoryContext: expected context.Canceled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "wrote 0 of 1 files") {
+		t.Errorf("Expected partial progress in error, got %v", err)
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		offset int64
+		text   string
+	}{
+		{"bad first line", "\nnodeclaration\n", 2, 1, "nodeclaration"},
+		{"invalid path", "> a.txt\nhello\n> ../b.txt\n", 3, 14, "> ../b.txt"},
+		{"duplicate path", "> a.txt\r\nx\r\n> a.txt\r\n", 3, 12, "> a.txt"},
+		{"limit exceeded", "> a.txt\n> b.txt\n> c.txt\n", 3, 16, "> c.txt"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSiloFileWithOptions(strings.NewReader(test.input), ParseOptions{MaxFileCount: 2})
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError, got %v", err)
+			}
+			if parseErr.Line != test.line || parseErr.Offset != test.offset || parseErr.Text != test.text {
+				t.Errorf("Expected line %d offset %d text %q, got line %d offset %d text %q",
+					test.line, test.offset, test.text, parseErr.Line, parseErr.Offset, parseErr.Text)
+			}
+			if !strings.HasPrefix(err.Error(), fmt.Sprintf("line %d: ", test.line)) {
+				t.Errorf("Expected message to start with the line number, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestParseErrorUnwraps(t *testing.T) {
+	_, err := ParseSiloFile(strings.NewReader("> /abs\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("Expected *ParseError wrapping ErrInvalidPath, got %v", err)
+	}
+	if parseErr.Suggestion == "" {
+		t.Error("Expected a suggestion for an invalid path")
+	}
+}
+
+func TestWriteToDirectoryModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions not supported on Windows")
+	}
+
+	outputDir := t.TempDir()
+	existing := filepath.Join(outputDir, "secret.env")
+	if err := os.WriteFile(existing, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	doc := &SiloDocument{
+		Files: []SiloFile{
+			{Path: "secret.env", Content: "TOKEN=x\n"},
+			{Path: "nested/dir/key.pem", Content: "key\n"},
+		},
+	}
+
+	opts := UnpackOptions{FileMode: 0600, DirMode: 0700}
+	if err := doc.WriteToDirectoryWithOptions(outputDir, opts); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+
+	expected := map[string]os.FileMode{
+		"secret.env":         0600,
+		"nested/dir/key.pem": 0600,
+		"nested":             0700,
+		"nested/dir":         0700,
+	}
+	for path, mode := range expected {
+		info, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("Expected %s to have mode %o, got %o", path, mode, info.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteToDirectoryParallel(t *testing.T) {
+	doc := &SiloDocument{}
+	for i := 0; i < 500; i++ {
+		doc.Files = append(doc.Files, SiloFile{Path: fmt.Sprintf("d%02d/sub/f%03d.txt", i%20, i), Content: fmt.Sprintf("file %d\n", i)})
+	}
+
+	for _, parallelism := range []int{1, 8} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			outputDir := t.TempDir()
+			if err := doc.WriteToDirectoryWithOptions(outputDir, UnpackOptions{Parallelism: parallelism}); err != nil {
+				t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+			}
+			read, err := ReadDirectoryTree(outputDir)
+			if err != nil {
+				t.Fatalf("ReadDirectoryTree failed: %v", err)
+			}
+			if len(read.Files) != len(doc.Files) {
+				t.Fatalf("Expected %d files, got %d", len(doc.Files), len(read.Files))
+			}
+			for _, file := range read.Files {
+				var i int
+				fmt.Sscanf(path.Base(file.Path), "f%03d.txt", &i)
+				if file.Content != doc.Files[i].Content {
+					t.Errorf("%s: expected %q, got %q", file.Path, doc.Files[i].Content, file.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteToDirectoryParallelEarliestError(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a", Content: "file\n"},
+		{Path: "b", Content: "file\n"},
+		{Path: "ok.txt", Content: "fine\n"},
+		{Path: "a/x.txt", Content: "under a file\n"},
+		{Path: "b/y.txt", Content: "under a file\n"},
+	}}
+	outputDir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(outputDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := doc.WriteToDirectoryWithOptions(outputDir, UnpackOptions{Parallelism: 4})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(outputDir, "a")) {
+		t.Errorf("Expected the error for a/x.txt, got %v", err)
+	}
+}
+
+func TestWriteToDirectoryLinksAfterFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "current", LinkTarget: "v1"},
+		{Path: "v1/app.txt", Content: "app\n"},
+	}}
+	outputDir := t.TempDir()
+	if err := doc.WriteToDirectoryWithOptions(outputDir, UnpackOptions{Parallelism: 2}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "current", "app.txt"))
+	if err != nil || string(content) != "app\n" {
+		t.Errorf("Expected app.txt through the link, got %q, %v", content, err)
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	doc := MustParse([]byte("> a.txt\nhello\n"))
+	if len(doc.Files) != 1 || doc.Files[0].Content != "hello\n" {
+		t.Errorf("Unexpected document: %+v", doc.Files)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustParse to panic on a malformed archive")
+		}
+	}()
+	MustParse([]byte("> ../escape.txt\nx\n"))
+}
+
+// repeatReader yields line over and over, and fails once more than 1 MB has
+// been read, as a parser that stops at a limit never gets that far.
+type repeatReader struct {
+	line string
+	off  int
+	read int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.read > 1<<20 {
+		return 0, errors.New("read past the limit")
+	}
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.line[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.line)
+	}
+	r.read += n
+	return n, nil
+}
+
+func TestParseLimitsStopReading(t *testing.T) {
+	tests := []struct {
+		name  string
+		input io.Reader
+		opts  ParseOptions
+		limit string
+		path  string
+	}{
+		{"max file size", io.MultiReader(strings.NewReader("> big.txt\n"), &repeatReader{line: "x\n"}), ParseOptions{MaxFileSize: 1000}, "MaxFileSize", "big.txt"},
+		{"max file count", &repeatReader{line: "> a.txt\n"}, ParseOptions{MaxFileCount: 1000}, "MaxFileCount", ""},
+	}
+	for _, test := range tests {
+		_, err := ParseSiloFileWithOptions(test.input, test.opts)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != test.limit || limitErr.Path != test.path {
+			t.Errorf("%s: expected a %s limit error for %q, got %v", test.name, test.limit, test.path, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package silofs mounts silo archives as read-only file systems, so a
+// program can embed a whole directory tree as one packed file with go:embed
+// and serve or read it through fs.FS:
+//
+//	//go:generate silo pack -q -if-changed -o assets.silo assets/
+//
+//	//go:embed assets.silo
+//	var assetsArchive []byte
+//
+//	var assets = silofs.MustMount(assetsArchive)
+package silofs
+
+import (
+	"bytes"
+	"io/fs"
+
+	"github.com/escherize/go-silo"
+)
+
+// Mount parses archive and returns its entries as an fs.FS, as
+// SiloDocument.FS does. Reference entries cannot be opened, since there is
+// nothing to resolve them against.
+func Mount(archive []byte) (fs.FS, error) {
+	doc, err := silo.ParseSiloFile(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	return doc.FS(), nil
+}
+
+// MustMount is Mount for archives built into the program, panicking if
+// archive is malformed.
+func MustMount(archive []byte) fs.FS {
+	return silo.MustParse(archive).FS()
+}
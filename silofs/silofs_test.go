@@ -0,0 +1,31 @@
+package silofs
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+const testArchive = "> index.html\n<h1>hi</h1>\n> css/site.css\nbody {}\n"
+
+func TestMount(t *testing.T) {
+	fsys, err := Mount([]byte(testArchive))
+	if err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "index.html", "css/site.css"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Mount([]byte("> ../escape.txt\nx\n")); err == nil {
+		t.Error("Expected an error for a malformed archive")
+	}
+}
+
+func ExampleMustMount() {
+	assets := MustMount([]byte(testArchive))
+	page, _ := fs.ReadFile(assets, "index.html")
+	fmt.Print(string(page))
+	// Output: <h1>hi</h1>
+}
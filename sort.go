@@ -0,0 +1,194 @@
+package silo
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SortPolicy controls the order in which entries are written (WriteOptions)
+// or arranged by SortFiles.
+type SortPolicy int
+
+const (
+	// SortInsertion keeps entries in the order of doc.Files.
+	SortInsertion SortPolicy = iota
+	// SortLexical orders entries by path, byte by byte, as
+	// ReadDirectoryTree and ReadFiles do.
+	SortLexical
+	// SortDirsFirst orders each directory's subdirectories before its
+	// files, each group by name, as a file tree is usually shown.
+	SortDirsFirst
+	// SortCustom orders entries with WriteOptions.Less, or the less
+	// function given to SortFilesFunc.
+	SortCustom
+	// SortSmart orders entries for a reader going front to back, such as an
+	// LLM: see SmartLess.
+	SortSmart
+)
+
+// ParseSortPolicy converts a policy name (insertion, lexical, dirs-first,
+// smart) into a SortPolicy. SortCustom has no name, since it needs a function.
+func ParseSortPolicy(name string) (SortPolicy, error) {
+	switch name {
+	case "insertion":
+		return SortInsertion, nil
+	case "lexical":
+		return SortLexical, nil
+	case "dirs-first":
+		return SortDirsFirst, nil
+	case "smart":
+		return SortSmart, nil
+	}
+	return 0, fmt.Errorf("unknown sort policy %q (want insertion, lexical, dirs-first or smart)", name)
+}
+
+// SortFiles reorders doc.Files by policy. The sort is stable, so entries
+// that compare equal keep their order. SortCustom needs a function; use
+// SortFilesFunc.
+func (doc *SiloDocument) SortFiles(policy SortPolicy) error {
+	return sortFiles(doc.Files, policy, nil)
+}
+
+// SortFilesFunc reorders doc.Files so that a comes before b whenever
+// less(a, b), keeping the order of entries that compare equal.
+func (doc *SiloDocument) SortFilesFunc(less func(a, b *SiloFile) bool) {
+	sortFiles(doc.Files, SortCustom, less)
+}
+
+// sortFiles stably sorts files in place by policy, using less for
+// SortCustom.
+func sortFiles(files []SiloFile, policy SortPolicy, less func(a, b *SiloFile) bool) error {
+	switch policy {
+	case SortInsertion:
+		return nil
+	case SortLexical:
+		less = func(a, b *SiloFile) bool { return a.Path < b.Path }
+	case SortDirsFirst:
+		less = func(a, b *SiloFile) bool { return dirsFirstLess(a.Path, b.Path) }
+	case SortSmart:
+		less = SmartLess
+	case SortCustom:
+		if less == nil {
+			return fmt.Errorf("SortCustom needs a less function")
+		}
+	default:
+		return fmt.Errorf("unknown sort policy %d", policy)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return less(&files[i], &files[j])
+	})
+	return nil
+}
+
+// dirsFirstLess reports whether path a comes before path b when, at the
+// first component where they differ, directories come before files.
+func dirsFirstLess(a, b string) bool {
+	for {
+		aName, aRest, aDir := strings.Cut(a, "/")
+		bName, bRest, bDir := strings.Cut(b, "/")
+		if aName != bName {
+			if aDir != bDir {
+				return aDir
+			}
+			return aName < bName
+		}
+		if !aDir || !bDir {
+			return !aDir && bDir
+		}
+		a, b = aRest, bRest
+	}
+}
+
+// SmartLess orders entries the way SortSmart does, so a reader meets the
+// overview before the details: the top-level README, then build manifests
+// such as go.mod and package.json, then the rest of the code, then tests,
+// and lock files such as go.sum and licenses last. Within each group
+// entries are ordered by path, except that a directory's README comes
+// before its other files. Use it with SortFilesFunc to build on it.
+func SmartLess(a, b *SiloFile) bool {
+	aRank, bRank := smartRank(a.Path), smartRank(b.Path)
+	if aRank != bRank {
+		return aRank < bRank
+	}
+	return smartKey(a.Path) < smartKey(b.Path)
+}
+
+// Groups of SmartLess, in order.
+const (
+	smartReadme = iota
+	smartManifest
+	smartCode
+	smartTest
+	smartLast
+)
+
+// smartManifests are the build files that say what a project is and
+// depends on.
+var smartManifests = map[string]bool{
+	"go.mod": true, "go.work": true, "package.json": true, "tsconfig.json": true,
+	"Cargo.toml": true, "pyproject.toml": true, "setup.py": true, "setup.cfg": true,
+	"requirements.txt": true, "Gemfile": true, "pom.xml": true, "build.gradle": true,
+	"build.gradle.kts": true, "composer.json": true, "mix.exs": true, "Makefile": true,
+	"CMakeLists.txt": true, "Dockerfile": true,
+}
+
+// smartLastFiles are generated or boilerplate files a reader rarely needs.
+var smartLastFiles = map[string]bool{
+	"go.sum": true, "go.work.sum": true, "package-lock.json": true, "yarn.lock": true,
+	"pnpm-lock.yaml": true, "Cargo.lock": true, "poetry.lock": true, "Gemfile.lock": true,
+	"composer.lock": true, "LICENSE": true, "LICENSE.md": true, "LICENSE.txt": true,
+	"COPYING": true, "NOTICE": true,
+}
+
+// smartTestDirs are directories holding tests or their fixtures.
+var smartTestDirs = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "spec": true, "testdata": true, "fixtures": true,
+}
+
+// smartRank returns the SmartLess group of path.
+func smartRank(filePath string) int {
+	dir, base := path.Split(filePath)
+	switch {
+	case dir == "" && isReadme(base):
+		return smartReadme
+	case smartLastFiles[base]:
+		return smartLast
+	case smartManifests[base]:
+		return smartManifest
+	case isTestFile(base):
+		return smartTest
+	}
+	for _, component := range strings.Split(strings.TrimSuffix(dir, "/"), "/") {
+		if smartTestDirs[component] {
+			return smartTest
+		}
+	}
+	return smartCode
+}
+
+// smartKey is the path SmartLess compares within a group, with a README's
+// name replaced so that it sorts before its siblings.
+func smartKey(filePath string) string {
+	dir, base := path.Split(filePath)
+	if isReadme(base) {
+		return dir + "\x00" + base
+	}
+	return filePath
+}
+
+// isReadme reports whether name is a README, such as README.md.
+func isReadme(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "readme")
+}
+
+// isTestFile reports whether name follows a common test file naming
+// convention: foo_test.go, test_foo.py, foo_test.py, foo.test.ts or
+// foo.spec.js.
+func isTestFile(name string) bool {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.HasSuffix(stem, "_test") || strings.HasPrefix(stem, "test_") ||
+		strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+		(strings.HasSuffix(stem, "Test") && path.Ext(name) == ".java")
+}
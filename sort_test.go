@@ -0,0 +1,127 @@
+package silo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sortTestDoc() *SiloDocument {
+	return &SiloDocument{Files: []SiloFile{
+		{Path: "z.txt", Content: "z\n"},
+		{Path: "src/main.go", Content: "main\n"},
+		{Path: "README.md", Content: "readme\n"},
+		{Path: "src/util/str.go", Content: "str\n"},
+		{Path: "a.txt", Content: "a\n"},
+	}}
+}
+
+func TestSortFiles(t *testing.T) {
+	cases := []struct {
+		policy   SortPolicy
+		expected string
+	}{
+		{SortInsertion, "z.txt,src/main.go,README.md,src/util/str.go,a.txt"},
+		{SortLexical, "README.md,a.txt,src/main.go,src/util/str.go,z.txt"},
+		{SortDirsFirst, "src/util/str.go,src/main.go,README.md,a.txt,z.txt"},
+	}
+	for _, c := range cases {
+		doc := sortTestDoc()
+		if err := doc.SortFiles(c.policy); err != nil {
+			t.Fatalf("SortFiles(%d) failed: %v", c.policy, err)
+		}
+		if got := strings.Join(docPaths(doc), ","); got != c.expected {
+			t.Errorf("SortFiles(%d) = %s, want %s", c.policy, got, c.expected)
+		}
+	}
+
+	if err := sortTestDoc().SortFiles(SortCustom); err == nil {
+		t.Error("Expected SortCustom without a function to fail")
+	}
+}
+
+func TestSortFilesFunc(t *testing.T) {
+	doc := sortTestDoc()
+	doc.SortFilesFunc(func(a, b *SiloFile) bool { return len(a.Path) < len(b.Path) })
+
+	// a.txt and z.txt have the same length and keep their order.
+	if got := strings.Join(docPaths(doc), ","); got != "z.txt,a.txt,README.md,src/main.go,src/util/str.go" {
+		t.Errorf("Unexpected order %s", got)
+	}
+}
+
+func TestWriteToSort(t *testing.T) {
+	doc := sortTestDoc()
+
+	var buf strings.Builder
+	if err := doc.WriteToWithOptions(&buf, WriteOptions{Sort: SortLexical}); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	if got := strings.Join(docPaths(parsed), ","); got != "README.md,a.txt,src/main.go,src/util/str.go,z.txt" {
+		t.Errorf("Expected lexical order, got %s", got)
+	}
+	if doc.Files[0].Path != "z.txt" {
+		t.Error("Expected WriteTo to leave doc.Files in insertion order")
+	}
+
+	buf.Reset()
+	reverse := WriteOptions{Sort: SortCustom, Less: func(a, b *SiloFile) bool { return a.Path > b.Path }}
+	if err := doc.WriteToWithOptions(&buf, reverse); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "> z.txt\n") {
+		t.Errorf("Expected reverse order, got %q", buf.String())
+	}
+}
+
+func TestParseSortPolicy(t *testing.T) {
+	for name, expected := range map[string]SortPolicy{"insertion": SortInsertion, "lexical": SortLexical, "dirs-first": SortDirsFirst, "smart": SortSmart} {
+		if got, err := ParseSortPolicy(name); err != nil || got != expected {
+			t.Errorf("ParseSortPolicy(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseSortPolicy("random"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestSortSmart(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "LICENSE"},
+		{Path: "go.sum"},
+		{Path: "internal/store/store_test.go"},
+		{Path: "internal/store/store.go"},
+		{Path: "internal/store/README.md"},
+		{Path: "testdata/input.txt"},
+		{Path: "main.go"},
+		{Path: "web/app.spec.ts"},
+		{Path: "web/app.ts"},
+		{Path: "go.mod"},
+		{Path: "README.md"},
+		{Path: "web/package.json"},
+	}}
+	if err := doc.SortFiles(SortSmart); err != nil {
+		t.Fatalf("SortFiles failed: %v", err)
+	}
+	expected := []string{
+		"README.md",
+		"go.mod",
+		"web/package.json",
+		"internal/store/README.md",
+		"internal/store/store.go",
+		"main.go",
+		"web/app.ts",
+		"internal/store/store_test.go",
+		"testdata/input.txt",
+		"web/app.spec.ts",
+		"LICENSE",
+		"go.sum",
+	}
+	if got := docPaths(doc); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected smart order:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,177 @@
+package silo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameCheck selects kinds of special file names that unpacking refuses, or
+// rewrites when UnpackOptions.SanitizePaths is set. Checks combine with |.
+type NameCheck int
+
+const (
+	// NameCheckDevice flags device names such as CON, aux.txt or COM1 on
+	// every platform, not only on Windows, so an archive unpacked elsewhere
+	// can still be copied there.
+	NameCheckDevice NameCheck = 1 << iota
+	// NameCheckDash flags components starting with '-', which a tool later
+	// given the path as an argument may read as an option.
+	NameCheckDash
+	// NameCheckControl flags components holding control characters, which
+	// can hide or spoof text when a path is printed to a terminal.
+	NameCheckControl
+	// NameCheckLength flags components longer than 255 bytes and paths
+	// longer than 4096 bytes, the usual filesystem limits, which Validate
+	// also reports.
+	NameCheckLength
+
+	// NameCheckAll enables every check.
+	NameCheckAll = NameCheckDevice | NameCheckDash | NameCheckControl | NameCheckLength
+)
+
+var nameCheckNames = []struct {
+	name  string
+	check NameCheck
+}{
+	{"device", NameCheckDevice},
+	{"dash", NameCheckDash},
+	{"control", NameCheckControl},
+	{"length", NameCheckLength},
+}
+
+// ParseNameChecks converts a comma-separated list of check names (device,
+// dash, control, length), or "all" or "none", into a NameCheck.
+func ParseNameChecks(list string) (NameCheck, error) {
+	var checks NameCheck
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "", "none":
+			continue
+		case "all":
+			checks |= NameCheckAll
+			continue
+		}
+		found := false
+		for _, known := range nameCheckNames {
+			if known.name == name {
+				checks |= known.check
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown name check %q (want device, dash, control, length, all or none)", name)
+		}
+	}
+	return checks, nil
+}
+
+// specialNameProblem describes why component fails checks, or returns "".
+func specialNameProblem(component string, checks NameCheck) string {
+	if checks&NameCheckControl != 0 {
+		for _, r := range component {
+			if unicode.IsControl(r) {
+				return fmt.Sprintf("control character %q in name", r)
+			}
+		}
+	}
+	if checks&NameCheckDash != 0 && strings.HasPrefix(component, "-") {
+		return "name starts with '-' and could be read as an option"
+	}
+	if checks&NameCheckDevice != 0 {
+		base, _, _ := strings.Cut(component, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			return fmt.Sprintf("%s is a device name", base)
+		}
+	}
+	if checks&NameCheckLength != 0 && len(component) > maxPathComponentLength {
+		return fmt.Sprintf("name is %d bytes, over the limit of %d", len(component), maxPathComponentLength)
+	}
+	return ""
+}
+
+// CheckSpecialNames returns an error matching ErrInvalidPath if the
+// slash-separated path has a component failing checks, or is too long
+// under NameCheckLength.
+func CheckSpecialNames(path string, checks NameCheck) error {
+	for _, component := range strings.Split(path, "/") {
+		if problem := specialNameProblem(component, checks); problem != "" {
+			return invalidPathError("%q: %s", path, problem)
+		}
+	}
+	if checks&NameCheckLength != 0 && len(path) > maxPathLength {
+		return invalidPathError("%q: path is %d bytes, over the limit of %d", path, len(path), maxPathLength)
+	}
+	return nil
+}
+
+// SanitizeSpecialNames rewrites each component of the slash-separated path
+// that fails checks: control characters become '_', a leading '-' gets a
+// '_' prefix, device names get a '_' suffix (aux.txt becomes aux_.txt), and
+// overlong names are shortened, keeping their extension. Other components
+// are returned unchanged. A path that is too long overall may still fail
+// CheckSpecialNames.
+func SanitizeSpecialNames(path string, checks NameCheck) string {
+	components := strings.Split(path, "/")
+	for i, component := range components {
+		if specialNameProblem(component, checks) == "" {
+			continue
+		}
+
+		if checks&NameCheckControl != 0 {
+			component = strings.Map(func(r rune) rune {
+				if unicode.IsControl(r) {
+					return '_'
+				}
+				return r
+			}, component)
+		}
+		if checks&NameCheckDash != 0 && strings.HasPrefix(component, "-") {
+			component = "_" + component
+		}
+		if checks&NameCheckDevice != 0 {
+			base, ext, hasExt := strings.Cut(component, ".")
+			if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+				component = base + "_"
+				if hasExt {
+					component += "." + ext
+				}
+			}
+		}
+		if checks&NameCheckLength != 0 && len(component) > maxPathComponentLength {
+			component = shortenName(component, maxPathComponentLength)
+		}
+		components[i] = component
+	}
+	return strings.Join(components, "/")
+}
+
+// shortenName cuts name to at most max bytes on a rune boundary, keeping a
+// short extension.
+func shortenName(name string, max int) string {
+	ext := path.Ext(name)
+	if len(ext) > max/4 {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+	cut := max - len(ext)
+	for cut > 0 && !utf8.RuneStart(stem[cut]) {
+		cut--
+	}
+	return stem[:cut] + ext
+}
+
+// resolveSpecialNames applies checks to path as unpacking does, returning
+// the path to write.
+func resolveSpecialNames(path string, checks NameCheck, sanitize bool) (string, error) {
+	if checks == 0 {
+		return path, nil
+	}
+	if sanitize {
+		path = SanitizeSpecialNames(path, checks)
+	}
+	return path, CheckSpecialNames(path, checks)
+}
@@ -0,0 +1,96 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNameChecks(t *testing.T) {
+	tests := []struct {
+		list     string
+		expected NameCheck
+	}{
+		{"", 0},
+		{"none", 0},
+		{"all", NameCheckAll},
+		{"dash", NameCheckDash},
+		{"control, length", NameCheckControl | NameCheckLength},
+		{"device,dash,control,length", NameCheckAll},
+	}
+	for _, test := range tests {
+		got, err := ParseNameChecks(test.list)
+		if err != nil {
+			t.Errorf("ParseNameChecks(%q) failed: %v", test.list, err)
+		} else if got != test.expected {
+			t.Errorf("ParseNameChecks(%q) = %b, want %b", test.list, got, test.expected)
+		}
+	}
+	if _, err := ParseNameChecks("dash,bogus"); err == nil {
+		t.Error("Expected an error for an unknown check")
+	}
+}
+
+func TestCheckAndSanitizeSpecialNames(t *testing.T) {
+	long := strings.Repeat("é", 200) + ".txt"
+	tests := []struct {
+		path      string
+		checks    NameCheck
+		bad       bool
+		sanitized string
+	}{
+		{"src/main.go", NameCheckAll, false, "src/main.go"},
+		{"docs/-rf", NameCheckDash, true, "docs/_-rf"},
+		{"docs/a-b", NameCheckDash, false, "docs/a-b"},
+		{"-rf", NameCheckControl, false, "-rf"},
+		{"bell\a/x\x1b[31m.txt", NameCheckControl, true, "bell_/x_[31m.txt"},
+		{"nel\u0085.txt", NameCheckControl, true, "nel_.txt"},
+		{"lib/aux.c", NameCheckDevice, true, "lib/aux_.c"},
+		{"COM1", NameCheckDevice, true, "COM1_"},
+		{"console.log", NameCheckDevice, false, "console.log"},
+		{"dir/" + long, NameCheckLength, true, "dir/" + strings.Repeat("é", 125) + ".txt"},
+	}
+
+	for _, test := range tests {
+		err := CheckSpecialNames(test.path, test.checks)
+		if test.bad != (err != nil) {
+			t.Errorf("CheckSpecialNames(%q) = %v, want failure %v", test.path, err, test.bad)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("CheckSpecialNames(%q) = %v, want ErrInvalidPath", test.path, err)
+		}
+		got := SanitizeSpecialNames(test.path, test.checks)
+		if got != test.sanitized {
+			t.Errorf("SanitizeSpecialNames(%q) = %q, want %q", test.path, got, test.sanitized)
+		}
+		if err := CheckSpecialNames(got, test.checks); err != nil {
+			t.Errorf("Sanitized %q still fails: %v", got, err)
+		}
+	}
+
+	tooLong := strings.Repeat(strings.Repeat("a", 200)+"/", 21) + "f"
+	if err := CheckSpecialNames(SanitizeSpecialNames(tooLong, NameCheckLength), NameCheckLength); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected an overlong path to fail even when sanitized, got %v", err)
+	}
+}
+
+func TestWriteToDirectoryNameChecks(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "ok.txt", Content: "ok\n"},
+		{Path: "-n", Content: "dash\n"},
+	}}
+
+	if err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{NameChecks: NameCheckDash}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if err := doc.WriteToDirectoryWithOptions(outputDir, UnpackOptions{NameChecks: NameCheckDash, SanitizePaths: true}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(outputDir, "_-n")); err != nil || string(content) != "dash\n" {
+		t.Errorf("Expected _-n to be written, got %q, %v", content, err)
+	}
+}
@@ -0,0 +1,70 @@
+package silo
+
+// byteCounter is an io.Writer that only counts what is written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// Split divides doc into parts that are each at most maxBytes when written
+// with opts, so an archive can be shared through something with a message
+// size limit. Entries are kept whole and in order. Every part is a complete
+// archive: parts share doc's delimiter, or the one auto-selection chooses
+// for the whole document, which is safe for each part, and each gets a copy
+// of doc's format header. AppendFrom merges them back. An entry that does
+// not fit in a part on its own fails the split with a *LimitError naming
+// it.
+func (doc *SiloDocument) Split(maxBytes int64, opts WriteOptions) ([]*SiloDocument, error) {
+	delimiter := doc.Delimiter
+	if delimiter == "" {
+		var err error
+		if delimiter, err = FindSafeDelimiter(doc, opts.delimiterOptions()); err != nil {
+			return nil, err
+		}
+	}
+
+	newPart := func() *SiloDocument {
+		part := &SiloDocument{Delimiter: delimiter}
+		if doc.Header != nil {
+			header := *doc.Header
+			part.Header = &header
+		}
+		return part
+	}
+	// headerSize is the size of the header line of a part with n entries.
+	headerSize := func(n int) int64 {
+		if doc.Header == nil {
+			return 0
+		}
+		header := *doc.Header
+		header.Version = FormatVersion
+		header.Delimiter = delimiter
+		header.Files = n
+		return int64(len(header.String()) + 1)
+	}
+
+	parts := []*SiloDocument{newPart()}
+	var size int64 // of the current part's entries
+	for _, file := range doc.Files {
+		var entry byteCounter
+		single := &SiloDocument{Delimiter: delimiter, Files: []SiloFile{file}}
+		if err := single.writeTo(&entry, opts); err != nil {
+			return nil, err
+		}
+
+		part := parts[len(parts)-1]
+		if len(part.Files) > 0 && headerSize(len(part.Files)+1)+size+int64(entry) > maxBytes {
+			part = newPart()
+			parts = append(parts, part)
+			size = 0
+		}
+		if len(part.Files) == 0 && headerSize(1)+int64(entry) > maxBytes {
+			return nil, &LimitError{Limit: "Split", Max: maxBytes, Path: file.Path}
+		}
+		part.Files = append(part.Files, file)
+		size += int64(entry)
+	}
+	return parts, nil
+}
@@ -0,0 +1,84 @@
+package silo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	doc := &SiloDocument{Header: &FormatHeader{}}
+	for i := 0; i < 20; i++ {
+		doc.Files = append(doc.Files, SiloFile{
+			Path:    fmt.Sprintf("file%02d.txt", i),
+			Content: strings.Repeat(fmt.Sprintf("line %d\n", i), i+1),
+		})
+	}
+	doc.Files = append(doc.Files, SiloFile{Path: "quoted.txt", Content: "> not an entry\n"})
+
+	const maxBytes = 200
+	parts, err := doc.Split(maxBytes, WriteOptions{})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("Expected several parts, got %d", len(parts))
+	}
+
+	merged := &SiloDocument{}
+	for i, part := range parts {
+		var buf bytes.Buffer
+		if err := part.WriteTo(&buf); err != nil {
+			t.Fatalf("Writing part %d failed: %v", i, err)
+		}
+		if buf.Len() > maxBytes {
+			t.Errorf("Part %d is %d bytes, over %d", i, buf.Len(), maxBytes)
+		}
+		parsed, err := ParseSiloFile(&buf)
+		if err != nil {
+			t.Fatalf("Part %d does not parse: %v", i, err)
+		}
+		if parsed.Header == nil || parsed.Header.Files != len(part.Files) {
+			t.Errorf("Part %d: expected a header counting %d files, got %+v", i, len(part.Files), parsed.Header)
+		}
+		if err := merged.AppendFrom(parsed); err != nil {
+			t.Fatalf("Merging part %d failed: %v", i, err)
+		}
+	}
+	if !reflect.DeepEqual(docPaths(merged), docPaths(doc)) {
+		t.Errorf("Merged paths %v, want %v", docPaths(merged), docPaths(doc))
+	}
+	for i, file := range merged.Files {
+		if file.Content != doc.Files[i].Content {
+			t.Errorf("%s: content changed to %q", file.Path, file.Content)
+		}
+	}
+}
+
+func TestSplitSinglePart(t *testing.T) {
+	doc := &SiloDocument{Delimiter: "=", Files: []SiloFile{{Path: "a.txt", Content: "a\n"}, {Path: "b.txt", Content: "b\n"}}}
+
+	parts, err := doc.Split(1<<20, WriteOptions{})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) != 1 || len(parts[0].Files) != 2 || parts[0].Delimiter != "=" {
+		t.Errorf("Expected one part with both files and delimiter =, got %+v", parts)
+	}
+}
+
+func TestSplitEntryTooLarge(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "small.txt", Content: "small\n"},
+		{Path: "big.txt", Content: strings.Repeat("x", 100) + "\n"},
+	}}
+
+	_, err := doc.Split(50, WriteOptions{})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Path != "big.txt" {
+		t.Errorf("Expected a *LimitError for big.txt, got %v", err)
+	}
+}
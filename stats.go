@@ -0,0 +1,152 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TokenEstimator approximates how many LLM tokens a piece of text costs.
+// Exact counts depend on the model's tokenizer; these heuristics are meant
+// for budgeting, not billing.
+type TokenEstimator func(text string) int
+
+// EstimateTokensByBytes assumes four bytes per token, a common rule of thumb
+// for English text and source code.
+func EstimateTokensByBytes(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// EstimateTokensByWords counts runs of letters and digits as words at four
+// tokens per three words, and every other non-space character as a token of
+// its own. It tracks real tokenizers more closely than byte counting for
+// punctuation-heavy code.
+func EstimateTokensByWords(text string) int {
+	words, symbols := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case unicode.IsSpace(r):
+			inWord = false
+		default:
+			symbols++
+			inWord = false
+		}
+	}
+	return (words*4+2)/3 + symbols
+}
+
+// ParseTokenEstimator converts an estimator name (bytes, words) into a
+// TokenEstimator.
+func ParseTokenEstimator(name string) (TokenEstimator, error) {
+	switch name {
+	case "bytes":
+		return EstimateTokensByBytes, nil
+	case "words":
+		return EstimateTokensByWords, nil
+	}
+	return nil, fmt.Errorf("unknown token estimator %q (want bytes or words)", name)
+}
+
+// StatsOptions configures StatsWithOptions.
+type StatsOptions struct {
+	// Tokenizer estimates token counts. Nil uses EstimateTokensByBytes.
+	Tokenizer TokenEstimator
+}
+
+// FileStats describes the content of one entry.
+type FileStats struct {
+	Path   string
+	Bytes  int
+	Lines  int
+	Tokens int
+}
+
+// DocumentStats describes a whole document. Bytes, Lines and Tokens sum the
+// file contents; OverheadTokens estimates the delimiter lines (and format
+// header) that WriteTo adds around them.
+type DocumentStats struct {
+	Files          []FileStats
+	Bytes          int
+	Lines          int
+	Tokens         int
+	OverheadTokens int
+}
+
+// TotalTokens estimates the token cost of the archive as written.
+func (s DocumentStats) TotalTokens() int {
+	return s.Tokens + s.OverheadTokens
+}
+
+// Stats returns per-file and total size figures, estimating tokens with
+// EstimateTokensByBytes.
+func (doc *SiloDocument) Stats() DocumentStats {
+	return doc.StatsWithOptions(StatsOptions{})
+}
+
+// StatsWithOptions returns per-file and total size figures using the
+// estimator in opts. If the document has no delimiter yet, overhead is
+// estimated as if it were ">".
+func (doc *SiloDocument) StatsWithOptions(opts StatsOptions) DocumentStats {
+	estimate := opts.Tokenizer
+	if estimate == nil {
+		estimate = EstimateTokensByBytes
+	}
+	delimiter := doc.Delimiter
+	if delimiter == "" {
+		delimiter = ">"
+	}
+
+	var stats DocumentStats
+	var overhead strings.Builder
+	if doc.Header != nil {
+		overhead.WriteString(doc.Header.String() + "\n")
+	}
+	for _, file := range doc.Files {
+		fileStats := FileStats{
+			Path:   file.Path,
+			Bytes:  file.Size(),
+			Lines:  countLines(file.text()),
+			Tokens: estimate(file.text()),
+		}
+		if file.Base64 {
+			encoded := encodeBase64Content(file.text())
+			fileStats.Lines = countLines(encoded)
+			fileStats.Tokens = estimate(encoded)
+		}
+		stats.Files = append(stats.Files, fileStats)
+		stats.Bytes += fileStats.Bytes
+		stats.Lines += fileStats.Lines
+		stats.Tokens += fileStats.Tokens
+
+		overhead.WriteString(delimiter + " " + file.Path)
+		if file.LinkTarget != "" {
+			overhead.WriteString(linkArrow + file.LinkTarget)
+		}
+		if file.Ref != "" {
+			overhead.WriteString(refMarker + file.Ref)
+		}
+		if file.Base64 {
+			overhead.WriteString(refMarker + base64Marker)
+		}
+		overhead.WriteString(formatAttrs(file.Attrs))
+		overhead.WriteString("\n")
+	}
+	stats.OverheadTokens = estimate(overhead.String())
+	return stats
+}
+
+// countLines counts content lines, including a final line without a
+// trailing newline.
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,63 @@
+package silo
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "a.txt", Content: "hello\nworld\n"},
+			{Path: "b.txt", Content: "no newline"},
+			{Path: "link", LinkTarget: "a.txt"},
+		},
+	}
+
+	stats := doc.Stats()
+	if len(stats.Files) != 3 {
+		t.Fatalf("Expected 3 file stats, got %d", len(stats.Files))
+	}
+	if stats.Files[0].Bytes != 12 || stats.Files[0].Lines != 2 || stats.Files[0].Tokens != 3 {
+		t.Errorf("Unexpected stats for a.txt: %+v", stats.Files[0])
+	}
+	if stats.Files[1].Lines != 1 {
+		t.Errorf("Expected unterminated final line to count, got %d lines", stats.Files[1].Lines)
+	}
+	if stats.Bytes != 22 || stats.Lines != 3 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	// "> a.txt\n> b.txt\n> link -> a.txt\n" is 32 bytes.
+	if stats.OverheadTokens != 8 {
+		t.Errorf("Expected 8 overhead tokens, got %d", stats.OverheadTokens)
+	}
+	if stats.TotalTokens() != stats.Tokens+8 {
+		t.Errorf("TotalTokens should add overhead, got %d", stats.TotalTokens())
+	}
+}
+
+func TestStatsWithCustomTokenizer(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.go", Content: "func main() {}\n"}}}
+
+	stats := doc.StatsWithOptions(StatsOptions{Tokenizer: func(string) int { return 7 }})
+	if stats.Tokens != 7 || stats.OverheadTokens != 7 {
+		t.Errorf("Expected custom tokenizer to be used, got %+v", stats)
+	}
+}
+
+func TestTokenEstimators(t *testing.T) {
+	if got := EstimateTokensByBytes("12345678"); got != 2 {
+		t.Errorf("EstimateTokensByBytes = %d, expected 2", got)
+	}
+	// 2 words -> 3 tokens, plus "(", ")", "{", "}" -> 7.
+	if got := EstimateTokensByWords("func main() {}"); got != 7 {
+		t.Errorf("EstimateTokensByWords = %d, expected 7", got)
+	}
+
+	for _, name := range []string{"bytes", "words"} {
+		if _, err := ParseTokenEstimator(name); err != nil {
+			t.Errorf("ParseTokenEstimator(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseTokenEstimator("gpt"); err == nil {
+		t.Error("Expected error for unknown estimator")
+	}
+}
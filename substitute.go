@@ -0,0 +1,45 @@
+package silo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Substitute replaces {{name}} placeholders in every entry's path, link
+// target, reference and content with the matching value from vars.
+// Placeholders with no value are left as they are. The document is unchanged
+// if a substituted path is invalid or two entries end up with the same path.
+func (doc *SiloDocument) Substitute(vars map[string]string) error {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, "{{"+name+"}}", vars[name])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	files := make([]SiloFile, len(doc.Files))
+	seen := make(map[string]bool, len(doc.Files))
+	for i, file := range doc.Files {
+		file.Path = replacer.Replace(file.Path)
+		if err := validatePath(file.Path); err != nil {
+			return err
+		}
+		if seen[file.Path] {
+			return fmt.Errorf("%w: %s", ErrDuplicatePath, file.Path)
+		}
+		seen[file.Path] = true
+		file.LinkTarget = replacer.Replace(file.LinkTarget)
+		file.Ref = replacer.Replace(file.Ref)
+		file.SetText(replacer.Replace(file.Text()))
+		files[i] = file
+	}
+
+	doc.Files = files
+	return nil
+}
@@ -0,0 +1,52 @@
+package silo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "go.mod", Content: "module {{module}}\n"},
+		{Path: "cmd/{{name}}/main.go", Content: "// {{name}} does things. {{unknown}} stays.\n"},
+		{Path: "current", LinkTarget: "cmd/{{name}}"},
+	}}
+
+	if err := doc.Substitute(map[string]string{"name": "tool", "module": "example.com/tool"}); err != nil {
+		t.Fatalf("Substitute failed: %v", err)
+	}
+
+	if doc.Files[0].Content != "module example.com/tool\n" {
+		t.Errorf("Unexpected go.mod content: %q", doc.Files[0].Content)
+	}
+	if doc.Files[1].Path != "cmd/tool/main.go" {
+		t.Errorf("Unexpected path: %q", doc.Files[1].Path)
+	}
+	if doc.Files[1].Content != "// tool does things. {{unknown}} stays.\n" {
+		t.Errorf("Unexpected content: %q", doc.Files[1].Content)
+	}
+	if doc.Files[2].LinkTarget != "cmd/tool" {
+		t.Errorf("Unexpected link target: %q", doc.Files[2].LinkTarget)
+	}
+}
+
+func TestSubstituteRejectsBadPaths(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "{{name}}/main.go", Content: "package main\n"},
+	}}
+
+	if err := doc.Substitute(map[string]string{"name": "../escape"}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+	if doc.Files[0].Path != "{{name}}/main.go" {
+		t.Errorf("Document should be unchanged after a failed Substitute, got %q", doc.Files[0].Path)
+	}
+
+	doc = &SiloDocument{Files: []SiloFile{
+		{Path: "{{a}}.txt"},
+		{Path: "{{b}}.txt"},
+	}}
+	if err := doc.Substitute(map[string]string{"a": "x", "b": "x"}); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package silo
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// summaryLargest is the number of files listed in Summary.Largest.
+const summaryLargest = 10
+
+// Summary is an overview of a document's contents, for auditing an archive
+// before sharing it.
+type Summary struct {
+	// Files is the number of entries, including links and references.
+	Files int
+	// Links and Refs count link and reference entries.
+	Links int
+	Refs  int
+	// Binary counts entries whose content is binary or base64-encoded.
+	Binary int
+	// Bytes and Lines total the content of all entries.
+	Bytes int
+	Lines int
+	// Tokens estimates the token cost of the archive as written.
+	Tokens int
+	// Largest lists up to ten entries with the most content, largest first.
+	Largest []FileStats
+	// Extensions totals entries by lower-cased file extension, most bytes
+	// first. Files without an extension are grouped under "".
+	Extensions []ExtensionStats
+}
+
+// ExtensionStats totals the entries sharing a file extension.
+type ExtensionStats struct {
+	Extension string
+	Files     int
+	Bytes     int
+	Lines     int
+}
+
+// Summary returns an overview of the document, estimating tokens with
+// EstimateTokensByBytes.
+func (doc *SiloDocument) Summary() Summary {
+	return doc.SummaryWithOptions(StatsOptions{})
+}
+
+// SummaryWithOptions returns an overview of the document using the
+// estimator in opts.
+func (doc *SiloDocument) SummaryWithOptions(opts StatsOptions) Summary {
+	stats := doc.StatsWithOptions(opts)
+	summary := Summary{
+		Files:  len(doc.Files),
+		Bytes:  stats.Bytes,
+		Lines:  stats.Lines,
+		Tokens: stats.TotalTokens(),
+	}
+
+	byExtension := make(map[string]*ExtensionStats)
+	for i, file := range doc.Files {
+		fileStats := stats.Files[i]
+		switch {
+		case file.LinkTarget != "":
+			summary.Links++
+			continue
+		case file.Ref != "":
+			summary.Refs++
+			continue
+		}
+		if file.Base64 || IsBinary(file.Bytes()) {
+			summary.Binary++
+		}
+		summary.Largest = append(summary.Largest, fileStats)
+
+		ext := fileExtension(file.Path)
+		group := byExtension[ext]
+		if group == nil {
+			group = &ExtensionStats{Extension: ext}
+			byExtension[ext] = group
+		}
+		group.Files++
+		group.Bytes += fileStats.Bytes
+		group.Lines += fileStats.Lines
+	}
+
+	sort.SliceStable(summary.Largest, func(i, j int) bool {
+		return summary.Largest[i].Bytes > summary.Largest[j].Bytes
+	})
+	if len(summary.Largest) > summaryLargest {
+		summary.Largest = summary.Largest[:summaryLargest]
+	}
+
+	for _, group := range byExtension {
+		summary.Extensions = append(summary.Extensions, *group)
+	}
+	sort.Slice(summary.Extensions, func(i, j int) bool {
+		a, b := summary.Extensions[i], summary.Extensions[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Extension < b.Extension
+	})
+	return summary
+}
+
+// fileExtension returns the lower-cased extension of p, or "" if it has
+// none. A leading dot, as in ".gitignore", does not start an extension.
+func fileExtension(p string) string {
+	base := strings.TrimLeft(path.Base(p), ".")
+	return strings.ToLower(path.Ext(base))
+}
@@ -0,0 +1,57 @@
+package silo
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "README.md", Content: "# Title\n"},
+		{Path: "main.go", Content: "package main\n\nfunc main() {}\n"},
+		{Path: "util.GO", Content: "package main\n"},
+		{Path: ".gitignore", Content: "*.log\n"},
+		{Path: "logo.png", Content: "\x89PNG\x00", Base64: true},
+		{Path: "current", LinkTarget: "main.go"},
+		{Path: "big.bin", Ref: "file:./big.bin"},
+	}}
+
+	summary := doc.Summary()
+	if summary.Files != 7 || summary.Links != 1 || summary.Refs != 1 || summary.Binary != 1 {
+		t.Errorf("Unexpected counts: %+v", summary)
+	}
+	if summary.Bytes != 8+29+13+6+5 {
+		t.Errorf("Bytes = %d", summary.Bytes)
+	}
+	if summary.Tokens != doc.Stats().TotalTokens() {
+		t.Errorf("Tokens = %d, want %d", summary.Tokens, doc.Stats().TotalTokens())
+	}
+	if len(summary.Largest) != 5 || summary.Largest[0].Path != "main.go" {
+		t.Errorf("Unexpected largest files: %+v", summary.Largest)
+	}
+
+	want := []ExtensionStats{
+		{Extension: ".go", Files: 2, Bytes: 42, Lines: 4},
+		{Extension: ".md", Files: 1, Bytes: 8, Lines: 1},
+		{Extension: "", Files: 1, Bytes: 6, Lines: 1},
+		{Extension: ".png", Files: 1, Bytes: 5, Lines: 1},
+	}
+	if fmt.Sprint(summary.Extensions) != fmt.Sprint(want) {
+		t.Errorf("Extensions = %+v, want %+v", summary.Extensions, want)
+	}
+}
+
+func TestSummaryLargestLimit(t *testing.T) {
+	doc := &SiloDocument{}
+	for i := 0; i < 15; i++ {
+		doc.Files = append(doc.Files, SiloFile{Path: fmt.Sprintf("f%02d.txt", i), Content: fmt.Sprintf("%*s\n", i, "")})
+	}
+
+	largest := doc.Summary().Largest
+	if len(largest) != summaryLargest {
+		t.Fatalf("Expected %d largest files, got %d", summaryLargest, len(largest))
+	}
+	if largest[0].Path != "f14.txt" || largest[9].Path != "f05.txt" {
+		t.Errorf("Unexpected order: %v, ..., %v", largest[0].Path, largest[9].Path)
+	}
+}
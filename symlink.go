@@ -0,0 +1,101 @@
+package silo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SymlinkPolicy controls how ReadDirectoryTreeWithOptions handles symbolic
+// links found inside the tree.
+type SymlinkPolicy int
+
+const (
+	// SymlinkFollow packs the content a link points to. Links to directories
+	// are walked, with cycles reported as errors.
+	SymlinkFollow SymlinkPolicy = iota
+	// SymlinkSkip leaves links out of the document.
+	SymlinkSkip
+	// SymlinkPreserve records links as link entries (`> path -> target`).
+	SymlinkPreserve
+	// SymlinkError fails the read when a link is found.
+	SymlinkError
+)
+
+// linkArrow separates an entry's path from its link target in a header line.
+const linkArrow = " -> "
+
+// ParseSymlinkPolicy converts a policy name (follow, skip, preserve, error)
+// into a SymlinkPolicy.
+func ParseSymlinkPolicy(name string) (SymlinkPolicy, error) {
+	switch name {
+	case "follow":
+		return SymlinkFollow, nil
+	case "skip":
+		return SymlinkSkip, nil
+	case "preserve":
+		return SymlinkPreserve, nil
+	case "error":
+		return SymlinkError, nil
+	}
+	return 0, fmt.Errorf("unknown symlink policy %q (want follow, skip, preserve or error)", name)
+}
+
+// splitLinkTarget splits a header path of the form "path -> target". For
+// regular entries target is empty.
+func splitLinkTarget(header string) (path, target string) {
+	idx := strings.Index(header, linkArrow)
+	if idx < 0 {
+		return header, ""
+	}
+	return strings.TrimSpace(header[:idx]), strings.TrimSpace(header[idx+len(linkArrow):])
+}
+
+// checkLinkText reports a link target that a header line cannot hold: a
+// control character, a line break in particular, would end the line early
+// or change what is read back.
+func checkLinkText(target string) error {
+	if strings.IndexFunc(target, unicode.IsControl) >= 0 {
+		return invalidPathError("control character in link target: %q", target)
+	}
+	return nil
+}
+
+// validateLinkTarget checks that a link at linkPath (relative to rootPath)
+// pointing at target cannot resolve outside rootPath. ".." may only lead the
+// target: after a name it would step back out of wherever that name
+// resolves, which for a link is not where the text suggests.
+func validateLinkTarget(rootPath, linkPath, target string) error {
+	if err := checkLinkText(target); err != nil {
+		return fmt.Errorf("symlink %s: %w", linkPath, err)
+	}
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return invalidPathError("symlink %s has absolute target %s", linkPath, target)
+	}
+	named := false
+	for _, component := range strings.Split(filepath.ToSlash(target), "/") {
+		switch component {
+		case "..":
+			if named {
+				return invalidPathError("symlink %s -> %s has .. after a name", linkPath, target)
+			}
+		case "", ".":
+		default:
+			named = true
+		}
+	}
+
+	absRoot, err := filepath.Abs(rootPath)
+	if err != nil {
+		return err
+	}
+	linkDir := filepath.Dir(filepath.Join(absRoot, filepath.FromSlash(linkPath)))
+	resolved := filepath.Join(linkDir, filepath.FromSlash(target))
+
+	rel, err := filepath.Rel(absRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return invalidPathError("symlink %s -> %s escapes the output directory", linkPath, target)
+	}
+	return nil
+}
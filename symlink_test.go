@@ -0,0 +1,232 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setupSymlinkTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "dir", "target.txt"), []byte("target\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink("dir/target.txt", filepath.Join(root, "link.txt")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("dir", filepath.Join(root, "dirlink")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	return root
+}
+
+func docPaths(doc *SiloDocument) []string {
+	var paths []string
+	for _, file := range doc.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func TestReadDirectoryTreeSymlinkPolicies(t *testing.T) {
+	root := setupSymlinkTree(t)
+
+	t.Run("follow", func(t *testing.T) {
+		doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: SymlinkFollow})
+		if err != nil {
+			t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+		}
+		got := strings.Join(docPaths(doc), ",")
+		if got != "dir/target.txt,dirlink/target.txt,link.txt" {
+			t.Errorf("Unexpected paths: %s", got)
+		}
+		for _, file := range doc.Files {
+			if file.Content != "target\n" {
+				t.Errorf("Expected followed content for %s, got %q", file.Path, file.Content)
+			}
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: SymlinkSkip})
+		if err != nil {
+			t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+		}
+		if got := strings.Join(docPaths(doc), ","); got != "dir/target.txt" {
+			t.Errorf("Unexpected paths: %s", got)
+		}
+	})
+
+	t.Run("preserve", func(t *testing.T) {
+		doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: SymlinkPreserve})
+		if err != nil {
+			t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+		}
+		links := map[string]string{}
+		for _, file := range doc.Files {
+			links[file.Path] = file.LinkTarget
+		}
+		if links["link.txt"] != "dir/target.txt" || links["dirlink"] != "dir" {
+			t.Errorf("Expected preserved link targets, got %v", links)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: SymlinkError})
+		if err == nil || !strings.Contains(err.Error(), "symlink not allowed") {
+			t.Errorf("Expected symlink error, got %v", err)
+		}
+	})
+}
+
+func TestReadDirectoryTreeSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Symlink("..", filepath.Join(root, "a", "up")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	_, err := ReadDirectoryTree(root)
+	if err == nil || !strings.Contains(err.Error(), "symlink cycle") {
+		t.Errorf("Expected symlink cycle error, got %v", err)
+	}
+}
+
+func TestReadDirectoryTreeSymlinkedRoot(t *testing.T) {
+	root := setupSymlinkTree(t)
+	rootLink := filepath.Join(t.TempDir(), "project")
+	if err := os.Symlink(root, rootLink); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	for _, policy := range []SymlinkPolicy{SymlinkFollow, SymlinkSkip, SymlinkPreserve} {
+		direct, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: policy})
+		if err != nil {
+			t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+		}
+		for _, path := range []string{rootLink, rootLink + string(filepath.Separator)} {
+			viaLink, err := ReadDirectoryTreeWithOptions(path, ReadDirectoryTreeOptions{Symlinks: policy})
+			if err != nil {
+				t.Fatalf("Reading %s with policy %d failed: %v", path, policy, err)
+			}
+			if !reflect.DeepEqual(viaLink.Files, direct.Files) {
+				t.Errorf("Policy %d: expected %s to read like its target, got %v", policy, path, docPaths(viaLink))
+			}
+		}
+	}
+
+	_, err := ReadDirectoryTreeWithOptions(rootLink, ReadDirectoryTreeOptions{NoFollowRoot: true})
+	if err == nil || !strings.Contains(err.Error(), "is a symlink") {
+		t.Errorf("Expected a symlinked root error with NoFollowRoot, got %v", err)
+	}
+}
+
+func TestSymlinkRoundTrip(t *testing.T) {
+	root := setupSymlinkTree(t)
+
+	doc, err := ReadDirectoryTreeWithOptions(root, ReadDirectoryTreeOptions{Symlinks: SymlinkPreserve})
+	if err != nil {
+		t.Fatalf("ReadDirectoryTreeWithOptions failed: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "> link.txt -> dir/target.txt\n") {
+		t.Errorf("Expected link header in output, got:\n%s", buf.String())
+	}
+
+	parsed, err := ParseSiloFile(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if err := parsed.WriteToDirectory(outputDir); err != nil {
+		t.Fatalf("WriteToDirectory failed: %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(outputDir, "link.txt"))
+	if err != nil {
+		t.Fatalf("Expected link.txt to be a symlink: %v", err)
+	}
+	if target != filepath.FromSlash("dir/target.txt") {
+		t.Errorf("Expected target dir/target.txt, got %s", target)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "link.txt"))
+	if err != nil || string(content) != "target\n" {
+		t.Errorf("Expected link to resolve to target content, got %q (%v)", content, err)
+	}
+}
+
+func TestUnpackRejectsEscapingSymlinks(t *testing.T) {
+	tests := []string{
+		"> evil -> ../../etc/passwd\n",
+		"> sub/evil -> ../..\n",
+		"> evil -> /etc/passwd\n",
+	}
+
+	for _, input := range tests {
+		doc, err := ParseSiloFile(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("ParseSiloFile failed for %q: %v", input, err)
+		}
+		if err := doc.WriteToDirectory(t.TempDir()); err == nil {
+			t.Errorf("Expected escaping symlink to be refused: %q", input)
+		}
+	}
+}
+
+func TestParseSymlinkWithContentFails(t *testing.T) {
+	input := "> link -> target\nunexpected content\n"
+	if _, err := ParseSiloFile(strings.NewReader(input)); err == nil {
+		t.Error("Expected error for symlink entry with content")
+	}
+}
+
+func TestWriteArrowInPath(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a -> b", Content: "x\n"}}}
+	var out strings.Builder
+	if err := doc.WriteTo(&out); err == nil || out.Len() != 0 {
+		t.Errorf("Expected WriteTo to refuse the path, wrote %q", out.String())
+	}
+	if problems := doc.Validate(); len(problems) == 0 {
+		t.Error("Expected Validate to report the path")
+	}
+}
+
+func TestLinkTargetControlCharacters(t *testing.T) {
+	for _, target := range []string{"b\n> injected.txt\npwned", "b\rc", "b\x00c", "b\x1bc"} {
+		doc := &SiloDocument{Files: []SiloFile{{Path: "a", LinkTarget: target}}}
+		var out strings.Builder
+		if err := doc.WriteTo(&out); !errors.Is(err, ErrInvalidPath) || out.Len() != 0 {
+			t.Errorf("Expected WriteTo to refuse target %q, got %v, wrote %q", target, err, out.String())
+		}
+		if problems := doc.Validate(); len(problems) == 0 {
+			t.Errorf("Expected Validate to report target %q", target)
+		}
+		if err := validateLinkTarget(t.TempDir(), "a", target); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected validateLinkTarget to refuse target %q, got %v", target, err)
+		}
+	}
+
+	// A lone CR is read as a line break, which must not end up in a target.
+	for _, input := range []string{"0 0 -> 0\r0", "> a -> b\x01c\n"} {
+		if _, err := ParseSiloFile(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected parsing %q to fail", input)
+		}
+	}
+	if _, err := ParseHeaderLine("> a -> b\tc"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ParseHeaderLine to refuse a tab in the target, got %v", err)
+	}
+}
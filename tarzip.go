@@ -0,0 +1,425 @@
+package silo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteTar writes doc's entries to w as a tar archive, as if unpacked with
+// WriteToDirectoryWithOptions and then archived, without touching the file
+// system. Paths, line endings, references and file and directory modes are
+// handled as opts says, and paths that would collide are errors there too.
+// Directories get entries of their own and links become symlink entries.
+// Entries are stamped with the header's creation time, or the current time
+// without a header. HonorUmask and Parallelism do not apply.
+func (doc *SiloDocument) WriteTar(w io.Writer, opts UnpackOptions) error {
+	tw := tar.NewWriter(w)
+	if err := doc.writeArchive(tarEntryWriter{tw}, opts); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// WriteZip is WriteTar for a zip archive. Links are stored as symlinks, as
+// Info-ZIP does, with the target as their content.
+func (doc *SiloDocument) WriteZip(w io.Writer, opts UnpackOptions) error {
+	zw := zip.NewWriter(w)
+	if err := doc.writeArchive(zipEntryWriter{zw}, opts); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// entryWriter adds entries to a tar or zip archive.
+type entryWriter interface {
+	dir(name string, mode os.FileMode, modTime time.Time) error
+	file(name string, content []byte, mode os.FileMode, modTime time.Time) error
+	link(name, target string, modTime time.Time) error
+}
+
+// writeArchive writes doc's entries to aw for WriteTar and WriteZip.
+func (doc *SiloDocument) writeArchive(aw entryWriter, opts UnpackOptions) error {
+	paths, err := unpackPaths(doc.Files, opts)
+	if err != nil {
+		return err
+	}
+	fileMode, dirMode := opts.FileMode, opts.DirMode
+	if fileMode == 0 {
+		fileMode = defaultFileMode
+	}
+	if dirMode == 0 {
+		dirMode = defaultDirMode
+	}
+	modTime := time.Now()
+	if doc.Header != nil && !doc.Header.Created.IsZero() {
+		modTime = doc.Header.Created
+	}
+
+	dirs := make(map[string]bool)
+	var addDirs func(dir string) error
+	addDirs = func(dir string) error {
+		if dir == "." || dirs[dir] {
+			return nil
+		}
+		if err := addDirs(path.Dir(dir)); err != nil {
+			return err
+		}
+		dirs[dir] = true
+		return aw.dir(dir+"/", dirMode, modTime)
+	}
+
+	for i, file := range doc.Files {
+		name := paths[i]
+		if name == "" {
+			continue
+		}
+		if err := addDirs(path.Dir(name)); err != nil {
+			return err
+		}
+		if file.LinkTarget != "" {
+			if err := validateLinkTarget(".", name, file.LinkTarget); err != nil {
+				return err
+			}
+			if err := aw.link(name, file.LinkTarget, modTime); err != nil {
+				return err
+			}
+			logDebug(opts.Logger, "archived link", "path", name, "target", file.LinkTarget)
+			continue
+		}
+		content := unpackedContent(file, opts.LineEndings)
+		if file.Ref != "" {
+			if content, err = resolveRef(file, opts.ResolveRef); err != nil {
+				return err
+			}
+		}
+		if err := aw.file(name, content, fileMode, modTime); err != nil {
+			return err
+		}
+		logDebug(opts.Logger, "archived file", "path", name, "bytes", len(content))
+	}
+	return nil
+}
+
+// tarEntryWriter is an entryWriter for a tar archive.
+type tarEntryWriter struct {
+	tw *tar.Writer
+}
+
+func (t tarEntryWriter) dir(name string, mode os.FileMode, modTime time.Time) error {
+	return t.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: int64(mode.Perm()), ModTime: modTime})
+}
+
+func (t tarEntryWriter) file(name string, content []byte, mode os.FileMode, modTime time.Time) error {
+	err := t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode.Perm()),
+		Size:     int64(len(content)),
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = t.tw.Write(content)
+	return err
+}
+
+func (t tarEntryWriter) link(name, target string, modTime time.Time) error {
+	return t.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: name, Linkname: target, Mode: 0777, ModTime: modTime})
+}
+
+// zipEntryWriter is an entryWriter for a zip archive.
+type zipEntryWriter struct {
+	zw *zip.Writer
+}
+
+func (z zipEntryWriter) dir(name string, mode os.FileMode, modTime time.Time) error {
+	header := &zip.FileHeader{Name: name, Modified: modTime}
+	header.SetMode(os.ModeDir | mode.Perm())
+	_, err := z.zw.CreateHeader(header)
+	return err
+}
+
+func (z zipEntryWriter) file(name string, content []byte, mode os.FileMode, modTime time.Time) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+	header.SetMode(mode.Perm())
+	w, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func (z zipEntryWriter) link(name, target string, modTime time.Time) error {
+	header := &zip.FileHeader{Name: name, Modified: modTime}
+	header.SetMode(os.ModeSymlink | 0777)
+	w, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, target)
+	return err
+}
+
+// ReadTar packs the members of the tar archive read from r, which may be
+// gzip-compressed, without extracting them. Regular files become entries
+// and symlinks link entries; directories are implied by the paths, and
+// other members, such as hard links and devices, are left out with a
+// warning in doc.Warnings. Member paths must be valid entry paths once a
+// leading "./" is removed. A path stored more than once keeps its last
+// member, as extracting would. The document is sorted by path like
+// ReadDirectoryTree's. Every member is packed; use ReadTarWithOptions to
+// filter and limit them.
+func ReadTar(r io.Reader) (*SiloDocument, error) {
+	return ReadTarWithOptions(r, ReadDirectoryTreeOptions{NoDefaultExcludes: true})
+}
+
+// ReadTarWithOptions is ReadTar with the filters and limits of
+// ReadDirectoryTreeWithOptions applied to member paths, as if the archive
+// had been extracted and the tree read: Include, Exclude and
+// DefaultExcludes, SkipHidden, MaxFileSize and SkipLarge, and MaxFiles, as
+// well as OnSkip, Report and Logger. MaxFileSize also bounds how much of a
+// member is decompressed, whatever its header claims. Other options do not
+// apply.
+func ReadTarWithOptions(r io.Reader, opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+	members, err := newMemberSet(opts)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar archive: %w", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+		case tar.TypeReg, tar.TypeRegA:
+			path, err := members.want(header.Name, header.Size)
+			if err != nil {
+				return nil, err
+			}
+			if path == "" {
+				continue
+			}
+			content, err := members.read(path, tr)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", header.Name, err)
+			}
+			members.add(path, SiloFile{Content: string(content)})
+		case tar.TypeSymlink:
+			path, err := members.want(header.Name, 0)
+			if err != nil {
+				return nil, err
+			}
+			if path != "" {
+				members.add(path, SiloFile{LinkTarget: header.Linkname})
+			}
+		default:
+			members.skip(header.Name)
+		}
+	}
+	return members.document(), nil
+}
+
+// ReadZip is ReadTar for the zip archive of size bytes in r. Symlinks
+// stored as Info-ZIP does, with the target as content, become link entries.
+func ReadZip(r io.ReaderAt, size int64) (*SiloDocument, error) {
+	return ReadZipWithOptions(r, size, ReadDirectoryTreeOptions{NoDefaultExcludes: true})
+}
+
+// ReadZipWithOptions is ReadTarWithOptions for the zip archive of size
+// bytes in r.
+func ReadZipWithOptions(r io.ReaderAt, size int64, opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+	members, err := newMemberSet(opts)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading zip archive: %w", err)
+	}
+	for _, f := range zr.File {
+		mode := f.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+			members.skip(f.Name)
+			continue
+		}
+		isLink := mode&os.ModeSymlink != 0
+		memberSize := int64(f.UncompressedSize64)
+		if isLink {
+			memberSize = 0
+		}
+		path, err := members.want(f.Name, memberSize)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		var content []byte
+		if isLink {
+			content, err = readLinkMember(path, rc)
+		} else {
+			content, err = members.read(path, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		file := SiloFile{Content: string(content)}
+		if isLink {
+			file = SiloFile{LinkTarget: string(content)}
+		}
+		members.add(path, file)
+	}
+	return members.document(), nil
+}
+
+// readLinkMember reads the target of a zip symlink, which is never longer
+// than a path may be.
+func readLinkMember(path string, r io.Reader) ([]byte, error) {
+	target, err := io.ReadAll(io.LimitReader(r, maxPathLength+1))
+	if err == nil && len(target) > maxPathLength {
+		return nil, invalidPathError("symlink %s has a target longer than %d bytes", path, maxPathLength)
+	}
+	return target, err
+}
+
+// memberSet collects the members of a tar or zip archive for ReadTar and
+// ReadZip.
+type memberSet struct {
+	files    map[string]SiloFile
+	warnings []string
+	opts     ReadDirectoryTreeOptions
+}
+
+func newMemberSet(opts ReadDirectoryTreeOptions) (*memberSet, error) {
+	if err := validateFilterPatterns(opts.Include, opts.Exclude); err != nil {
+		return nil, err
+	}
+	if !opts.NoDefaultExcludes {
+		opts.Exclude = append(DefaultExcludes[:len(DefaultExcludes):len(DefaultExcludes)], opts.Exclude...)
+	}
+	if opts.Report != nil {
+		*opts.Report = PackReport{}
+	}
+	return &memberSet{files: make(map[string]SiloFile), opts: opts}, nil
+}
+
+// want returns the entry path for the member called name, of size bytes,
+// or "" if the options leave it out. It fails when the name is not a valid
+// entry path or the member breaks MaxFileSize or MaxFiles.
+func (m *memberSet) want(name string, size int64) (string, error) {
+	path := strings.TrimPrefix(name, "./")
+	if err := ValidatePath(path); err != nil {
+		return "", err
+	}
+	components := strings.Split(path, "/")
+	for i, component := range components {
+		if matchesAnyPattern(m.opts.Exclude, strings.Join(components[:i+1], "/")) {
+			m.leaveOut(path, SkipExcluded)
+			return "", nil
+		}
+		if m.opts.SkipHidden && isHidden(component) {
+			m.leaveOut(path, SkipHidden)
+			return "", nil
+		}
+	}
+	if len(m.opts.Include) > 0 && !matchesAnyPattern(m.opts.Include, path) {
+		m.leaveOut(path, SkipNotIncluded)
+		return "", nil
+	}
+	if m.opts.MaxFileSize > 0 && size > m.opts.MaxFileSize {
+		if m.opts.SkipLarge {
+			m.leaveOut(path, SkipTooLarge)
+			return "", nil
+		}
+		return "", &LimitError{Limit: "MaxFileSize", Max: m.opts.MaxFileSize, Path: path}
+	}
+	if _, replaces := m.files[path]; !replaces && m.opts.MaxFiles > 0 && len(m.files) >= m.opts.MaxFiles {
+		return "", &LimitError{Limit: "MaxFiles", Max: int64(m.opts.MaxFiles), Path: path}
+	}
+	return path, nil
+}
+
+// read reads the content of the member at path, stopping past MaxFileSize
+// so that a member larger than its header says cannot exhaust memory.
+func (m *memberSet) read(path string, r io.Reader) ([]byte, error) {
+	if m.opts.MaxFileSize <= 0 {
+		return io.ReadAll(r)
+	}
+	content, err := io.ReadAll(io.LimitReader(r, m.opts.MaxFileSize+1))
+	if err == nil && int64(len(content)) > m.opts.MaxFileSize {
+		return nil, &LimitError{Limit: "MaxFileSize", Max: m.opts.MaxFileSize, Path: path}
+	}
+	return content, err
+}
+
+// add records file under path, replacing an earlier member with the same
+// path.
+func (m *memberSet) add(path string, file SiloFile) {
+	file.Path = path
+	logPacked(m.opts.Logger, file)
+	m.files[path] = file
+}
+
+// leaveOut notes a member the options leave out.
+func (m *memberSet) leaveOut(path, reason string) {
+	logDebug(m.opts.Logger, "skipped file", "path", path, "reason", reason)
+	if m.opts.OnSkip != nil {
+		m.opts.OnSkip(path, reason)
+	}
+	m.opts.Report.skip(path, reason)
+}
+
+// skip notes a member that is neither a regular file nor a symlink.
+func (m *memberSet) skip(name string) {
+	m.warnings = append(m.warnings, fmt.Sprintf("skipped %s: not a regular file or symlink", name))
+}
+
+// document returns the collected members as a document sorted by path.
+func (m *memberSet) document() *SiloDocument {
+	doc := &SiloDocument{Delimiter: ">", Warnings: m.warnings}
+	for _, file := range m.files {
+		doc.Files = append(doc.Files, file)
+	}
+	sort.Slice(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+	m.opts.Report.finish(doc)
+	return doc
+}
@@ -0,0 +1,299 @@
+package silo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func tarZipTestDoc() *SiloDocument {
+	return &SiloDocument{
+		Header: &FormatHeader{Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		Files: []SiloFile{
+			{Path: "src/pkg/util.go", Content: "package pkg\n"},
+			{Path: "README.md", Content: "hi\r\n", CRLF: true},
+			{Path: "latest", LinkTarget: "src/main.go"},
+			{Path: "src/main.go", Content: "package main\n"},
+			{Path: "empty", Content: ""},
+		},
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tarZipTestDoc().WriteTar(&buf, UnpackOptions{FileMode: 0600}); err != nil {
+		t.Fatalf("WriteTar failed: %v", err)
+	}
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		names = append(names, header.Name)
+		content, _ := io.ReadAll(tr)
+		switch header.Name {
+		case "src/main.go":
+			if string(content) != "package main\n" || header.Mode != 0600 {
+				t.Errorf("Expected src/main.go with mode 0600, got %q %o", content, header.Mode)
+			}
+			if !header.ModTime.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("Expected the header's creation time, got %v", header.ModTime)
+			}
+		case "README.md":
+			if string(content) != "hi\r\n" {
+				t.Errorf("Expected CRLF to be restored, got %q", content)
+			}
+		case "latest":
+			if header.Typeflag != tar.TypeSymlink || header.Linkname != "src/main.go" {
+				t.Errorf("Expected a symlink to src/main.go, got type %c -> %q", header.Typeflag, header.Linkname)
+			}
+		case "src/", "src/pkg/":
+			if header.Typeflag != tar.TypeDir || header.Mode != 0755 {
+				t.Errorf("Expected %s to be a directory with mode 0755, got type %c %o", header.Name, header.Typeflag, header.Mode)
+			}
+		}
+	}
+	expected := []string{"src/", "src/pkg/", "src/pkg/util.go", "README.md", "latest", "src/main.go", "empty"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, names)
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tarZipTestDoc().WriteZip(&buf, UnpackOptions{}); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	contents := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(content)
+		switch {
+		case f.Name == "latest" && f.Mode()&os.ModeSymlink == 0:
+			t.Errorf("Expected latest to be a symlink, got mode %v", f.Mode())
+		case f.Name == "src/" && !f.Mode().IsDir():
+			t.Errorf("Expected src/ to be a directory, got mode %v", f.Mode())
+		}
+	}
+	expected := map[string]string{
+		"src/":            "",
+		"src/pkg/":        "",
+		"src/pkg/util.go": "package pkg\n",
+		"README.md":       "hi\r\n",
+		"latest":          "src/main.go",
+		"src/main.go":     "package main\n",
+		"empty":           "",
+	}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("Expected %v, got %v", expected, contents)
+	}
+}
+
+func TestWriteTarRejects(t *testing.T) {
+	escaping := &SiloDocument{Files: []SiloFile{{Path: "link", LinkTarget: "../outside"}}}
+	if err := escaping.WriteTar(io.Discard, UnpackOptions{}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for an escaping link, got %v", err)
+	}
+	duplicate := &SiloDocument{Files: []SiloFile{{Path: "a.txt"}, {Path: "a.txt"}}}
+	if err := duplicate.WriteTar(io.Discard, UnpackOptions{}); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath, got %v", err)
+	}
+	ref := &SiloDocument{Files: []SiloFile{{Path: "big.bin", Ref: "file:big.bin"}}}
+	if err := ref.WriteZip(io.Discard, UnpackOptions{}); !errors.Is(err, ErrUnresolvedRef) {
+		t.Errorf("Expected ErrUnresolvedRef without a resolver, got %v", err)
+	}
+}
+
+func TestReadTarRoundTrip(t *testing.T) {
+	doc := tarZipTestDoc()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := doc.WriteTar(zw, UnpackOptions{}); err != nil {
+		t.Fatalf("WriteTar failed: %v", err)
+	}
+	zw.Close()
+
+	read, err := ReadTar(&buf)
+	if err != nil {
+		t.Fatalf("ReadTar failed: %v", err)
+	}
+	if !doc.Equal(read) {
+		t.Errorf("Expected the gzipped tar to read back the same, got:\n%s", doc.Diff(read))
+	}
+	expected := []string{"README.md", "empty", "latest", "src/main.go", "src/pkg/util.go"}
+	if got := docPaths(read); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected sorted paths %v, got %v", expected, got)
+	}
+
+	buf.Reset()
+	if err := doc.WriteZip(&buf, UnpackOptions{}); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	read, err = ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadZip failed: %v", err)
+	}
+	if !doc.Equal(read) {
+		t.Errorf("Expected the zip to read back the same, got:\n%s", doc.Diff(read))
+	}
+}
+
+func TestReadTarMembers(t *testing.T) {
+	build := func(headers ...tar.Header) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, header := range headers {
+			header := header
+			content := header.Name
+			if header.Typeflag == tar.TypeReg {
+				header.Size = int64(len(content))
+			}
+			tw.WriteHeader(&header)
+			if header.Typeflag == tar.TypeReg {
+				io.WriteString(tw, content)
+			}
+		}
+		tw.Close()
+		return &buf
+	}
+
+	doc, err := ReadTar(build(
+		tar.Header{Typeflag: tar.TypeReg, Name: "./a.txt"},
+		tar.Header{Typeflag: tar.TypeLink, Name: "hard", Linkname: "a.txt"},
+		tar.Header{Typeflag: tar.TypeReg, Name: "a.txt"},
+	))
+	if err != nil {
+		t.Fatalf("ReadTar failed: %v", err)
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Path != "a.txt" || doc.Files[0].Content != "a.txt" {
+		t.Errorf("Expected the last a.txt member only, got %+v", doc.Files)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0] != "skipped hard: not a regular file or symlink" {
+		t.Errorf("Expected a warning for the hard link, got %q", doc.Warnings)
+	}
+
+	if _, err := ReadTar(build(tar.Header{Typeflag: tar.TypeReg, Name: "../evil"})); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for a member outside the archive, got %v", err)
+	}
+}
+
+func TestReadTarWithOptions(t *testing.T) {
+	files := map[string]string{
+		"a.txt":             "a\n",
+		"app.log":           "log\n",
+		"big.txt":           string(bytes.Repeat([]byte("x"), 1<<20)),
+		".env":              "SECRET=1\n",
+		"src/.hidden/x.go":  "package x\n",
+		"node_modules/m.js": "m\n",
+		"src/main.go":       "package main\n",
+	}
+	var tarBuf bytes.Buffer
+	zw := gzip.NewWriter(&tarBuf)
+	tw := tar.NewWriter(zw)
+	var zipBuf bytes.Buffer
+	zipW := zip.NewWriter(&zipBuf)
+	for _, name := range []string{"a.txt", "app.log", "big.txt", ".env", "src/.hidden/x.go", "node_modules/m.js", "src/main.go"} {
+		content := files[name]
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0644}); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(tw, content)
+		w, err := zipW.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	tw.Close()
+	zw.Close()
+	zipW.Close()
+
+	read := map[string]func(ReadDirectoryTreeOptions) (*SiloDocument, error){
+		"tar": func(opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+			return ReadTarWithOptions(bytes.NewReader(tarBuf.Bytes()), opts)
+		},
+		"zip": func(opts ReadDirectoryTreeOptions) (*SiloDocument, error) {
+			return ReadZipWithOptions(bytes.NewReader(zipBuf.Bytes()), int64(zipBuf.Len()), opts)
+		},
+	}
+	for kind, readArchive := range read {
+		var report PackReport
+		doc, err := readArchive(ReadDirectoryTreeOptions{
+			Exclude:     []string{"*.log"},
+			SkipHidden:  true,
+			MaxFileSize: 1024,
+			SkipLarge:   true,
+			Report:      &report,
+		})
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", kind, err)
+		}
+		var paths []string
+		for _, file := range doc.Files {
+			paths = append(paths, file.Path)
+		}
+		if want := []string{"a.txt", "src/main.go"}; !reflect.DeepEqual(paths, want) {
+			t.Errorf("%s: expected %q, got %q", kind, want, paths)
+		}
+		if got, want := report.Summary(), "2 files packed, 5 skipped (2 excluded, 2 hidden, 1 too large)"; got != want {
+			t.Errorf("%s: expected report %q, got %q", kind, want, got)
+		}
+
+		if _, err := readArchive(ReadDirectoryTreeOptions{MaxFileSize: 1024}); !isLimit(err, "MaxFileSize") {
+			t.Errorf("%s: expected a MaxFileSize error, got %v", kind, err)
+		}
+		if _, err := readArchive(ReadDirectoryTreeOptions{MaxFiles: 2}); !isLimit(err, "MaxFiles") {
+			t.Errorf("%s: expected a MaxFiles error, got %v", kind, err)
+		}
+		doc, err = readArchive(ReadDirectoryTreeOptions{Include: []string{"src/**"}, NoDefaultExcludes: true})
+		if err != nil || len(doc.Files) != 2 {
+			t.Errorf("%s: expected the two src files, got %+v, %v", kind, doc, err)
+		}
+		if _, err := readArchive(ReadDirectoryTreeOptions{Exclude: []string{"["}}); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("%s: expected ErrInvalidPattern, got %v", kind, err)
+		}
+	}
+}
+
+// isLimit reports whether err is a *LimitError for limit.
+func isLimit(err error, limit string) bool {
+	var limitErr *LimitError
+	return errors.As(err, &limitErr) && limitErr.Limit == limit
+}
+
+func TestReadTarMemberLargerThanHeader(t *testing.T) {
+	// A member read through a limit stops at MaxFileSize even if the
+	// reader holds more than the size checked up front.
+	members, err := newMemberSet(ReadDirectoryTreeOptions{MaxFileSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.read("a", bytes.NewReader(make([]byte, 1<<20))); !isLimit(err, "MaxFileSize") {
+		t.Errorf("Expected a MaxFileSize error, got %v", err)
+	}
+	if content, err := members.read("a", bytes.NewReader([]byte("abcd"))); err != nil || string(content) != "abcd" {
+		t.Errorf("Expected the member up to the limit, got %q, %v", content, err)
+	}
+}
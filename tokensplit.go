@@ -0,0 +1,129 @@
+package silo
+
+// SplitByTokens partitions doc into chunks of at most n estimated tokens
+// each, as StatsWithOptions counts them (content plus declaration lines),
+// so a large codebase can be given to a model over several prompts. Files
+// are kept whole and in order, and chunks are balanced: doc is cut into as
+// few chunks as fit under n, sized as evenly as possible. A file estimated
+// above n on its own gets a chunk of its own. Chunks keep doc's delimiter
+// and a copy of its format header; n of zero or less puts everything in
+// one chunk.
+func (doc *SiloDocument) SplitByTokens(n int) []*SiloDocument {
+	return doc.SplitByTokensWithOptions(n, StatsOptions{})
+}
+
+// SplitByTokensWithOptions is SplitByTokens estimating tokens with the
+// tokenizer in opts.
+func (doc *SiloDocument) SplitByTokensWithOptions(n int, opts StatsOptions) []*SiloDocument {
+	newChunk := func() *SiloDocument {
+		chunk := &SiloDocument{Delimiter: doc.Delimiter}
+		if doc.Header != nil {
+			header := *doc.Header
+			chunk.Header = &header
+		}
+		return chunk
+	}
+	if n <= 0 || len(doc.Files) == 0 {
+		chunk := newChunk()
+		chunk.Files = append([]SiloFile(nil), doc.Files...)
+		return []*SiloDocument{chunk}
+	}
+
+	budget := n
+	if doc.Header != nil {
+		budget -= (&SiloDocument{Header: doc.Header}).StatsWithOptions(opts).OverheadTokens
+	}
+	costs := make([]int, len(doc.Files))
+	for i, file := range doc.Files {
+		single := &SiloDocument{Delimiter: doc.Delimiter, Files: []SiloFile{file}}
+		costs[i] = single.StatsWithOptions(opts).TotalTokens()
+	}
+
+	// chunkStarts packs files greedily into chunks of at most capacity
+	// tokens and returns the index of each chunk's first file.
+	chunkStarts := func(capacity int) []int {
+		starts := []int{0}
+		load := 0
+		for i, cost := range costs {
+			if i > 0 && load+cost > capacity {
+				starts = append(starts, i)
+				load = 0
+			}
+			load += cost
+		}
+		return starts
+	}
+
+	// Greedy packing under the budget gives the fewest chunks. They are
+	// evened out by cutting where the running total is nearest each equal
+	// share, or, if that overfills a chunk, by packing to the smallest
+	// capacity that still needs no more chunks.
+	want := len(chunkStarts(budget))
+	starts := evenChunkStarts(costs, want, budget)
+	if starts == nil {
+		lo, hi := 1, budget
+		if hi < lo {
+			hi = lo
+		}
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			if len(chunkStarts(mid)) <= want {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		starts = chunkStarts(lo)
+	}
+	starts = append(starts, len(doc.Files))
+
+	chunks := make([]*SiloDocument, 0, len(starts)-1)
+	for i := 0; i+1 < len(starts); i++ {
+		chunk := newChunk()
+		chunk.Files = append([]SiloFile(nil), doc.Files[starts[i]:starts[i+1]]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// evenChunkStarts cuts costs into k chunks where the running total is
+// nearest to each multiple of total/k, returning each chunk's first index,
+// or nil if a chunk would be empty or, holding more than one item, exceed
+// budget.
+func evenChunkStarts(costs []int, k, budget int) []int {
+	total := 0
+	for _, cost := range costs {
+		total += cost
+	}
+
+	starts := []int{0}
+	i, sum := 0, 0
+	for j := 1; j < k; j++ {
+		target := float64(total) * float64(j) / float64(k)
+		for i < len(costs) && float64(sum+costs[i]) <= target {
+			sum += costs[i]
+			i++
+		}
+		if i < len(costs) && float64(sum+costs[i])-target < target-float64(sum) {
+			sum += costs[i]
+			i++
+		}
+		starts = append(starts, i)
+	}
+
+	bounds := append(starts, len(costs))
+	for c := 0; c+1 < len(bounds); c++ {
+		from, to := bounds[c], bounds[c+1]
+		if to <= from {
+			return nil
+		}
+		load := 0
+		for _, cost := range costs[from:to] {
+			load += cost
+		}
+		if load > budget && to-from > 1 {
+			return nil
+		}
+	}
+	return starts
+}
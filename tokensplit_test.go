@@ -0,0 +1,67 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitByTokens(t *testing.T) {
+	doc := &SiloDocument{Delimiter: ">"}
+	for i := 0; i < 10; i++ {
+		doc.Files = append(doc.Files, SiloFile{Path: fmt.Sprintf("f%d.txt", i), Content: strings.Repeat("x", 396) + "\n"})
+	}
+
+	// Each file is about 100 tokens plus its declaration line: greedy
+	// packing under 450 would give 4+4+2.
+	chunks := doc.SplitByTokens(450)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	var sizes []int
+	var paths []string
+	for _, chunk := range chunks {
+		if tokens := chunk.Stats().TotalTokens(); tokens > 450 {
+			t.Errorf("Chunk is %d tokens, over 450", tokens)
+		}
+		if chunk.Delimiter != ">" {
+			t.Errorf("Expected delimiter > kept, got %q", chunk.Delimiter)
+		}
+		sizes = append(sizes, len(chunk.Files))
+		paths = append(paths, docPaths(chunk)...)
+	}
+	if fmt.Sprint(sizes) != "[3 4 3]" {
+		t.Errorf("Expected balanced chunks [3 4 3], got %v", sizes)
+	}
+	if strings.Join(paths, ",") != strings.Join(docPaths(doc), ",") {
+		t.Errorf("Expected every file once, in order, got %v", paths)
+	}
+}
+
+func TestSplitByTokensOversizedFile(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "small\n"},
+		{Path: "big.txt", Content: strings.Repeat("x", 4000) + "\n"},
+		{Path: "c.txt", Content: "small\n"},
+	}}
+
+	chunks := doc.SplitByTokens(100)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected the big file in a chunk of its own, got %d chunks", len(chunks))
+	}
+	if paths := docPaths(chunks[1]); len(paths) != 1 || paths[0] != "big.txt" {
+		t.Errorf("Expected big.txt alone, got %v", paths)
+	}
+}
+
+func TestSplitByTokensNoLimit(t *testing.T) {
+	doc := &SiloDocument{Header: &FormatHeader{}, Files: []SiloFile{{Path: "a.txt", Content: "a\n"}, {Path: "b.txt", Content: "b\n"}}}
+
+	chunks := doc.SplitByTokens(0)
+	if len(chunks) != 1 || len(chunks[0].Files) != 2 || chunks[0].Header == nil {
+		t.Errorf("Expected one chunk with both files and a header, got %+v", chunks)
+	}
+	if chunks[0].Header == doc.Header {
+		t.Error("Expected the chunk to have its own copy of the header")
+	}
+}
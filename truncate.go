@@ -0,0 +1,101 @@
+package silo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// attrTruncated is the annotation WriteTo records SiloFile.Truncated in, as
+// in "> big.log truncated=1234".
+const attrTruncated = "truncated"
+
+// TruncateLines shortens every text entry longer than maxLines lines to its
+// first maxLines lines followed by a marker line such as
+// "[... 1234 lines truncated ...]", and records the number of lines left
+// out in the entry's Truncated field. It is meant for prompt-sized archives
+// that should still show where content was omitted. Links, references,
+// base64 entries and binary content are left alone, as is everything when
+// maxLines is not positive. It returns the paths of the truncated entries.
+func (doc *SiloDocument) TruncateLines(maxLines int) []string {
+	if maxLines <= 0 {
+		return nil
+	}
+	var truncated []string
+	for i := range doc.Files {
+		file := &doc.Files[i]
+		if file.LinkTarget != "" || file.Ref != "" || file.Base64 {
+			continue
+		}
+		if IsBinary(file.Bytes()) {
+			continue
+		}
+		content := file.text()
+		lines := strings.Count(content, "\n")
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			lines++
+		}
+		if lines <= maxLines {
+			continue
+		}
+
+		end := 0
+		for n := 0; n < maxLines; n++ {
+			end += strings.IndexByte(content[end:], '\n') + 1
+		}
+		removed := lines - maxLines
+		file.SetText(content[:end] + truncationMarker(removed) + "\n")
+		file.Truncated += removed
+		truncated = append(truncated, file.Path)
+	}
+	return truncated
+}
+
+// truncationMarker is the line TruncateLines puts in place of removed
+// lines.
+func truncationMarker(removed int) string {
+	if removed == 1 {
+		return "[... 1 line truncated ...]"
+	}
+	return fmt.Sprintf("[... %d lines truncated ...]", removed)
+}
+
+// entryAttrs returns the annotations to write for file: its Attrs, plus
+// Truncated when set and the meta marker when it has Meta.
+func entryAttrs(file SiloFile) map[string]string {
+	if file.Truncated <= 0 && len(file.Meta) == 0 {
+		return file.Attrs
+	}
+	attrs := make(map[string]string, len(file.Attrs)+2)
+	for key, value := range file.Attrs {
+		attrs[key] = value
+	}
+	if file.Truncated > 0 {
+		attrs[attrTruncated] = strconv.Itoa(file.Truncated)
+	}
+	if len(file.Meta) > 0 {
+		attrs[attrMeta] = metaYAML
+	}
+	return attrs
+}
+
+// takeTruncatedAttr moves a truncated annotation read from an entry's
+// header line into Truncated. A value that is not a positive count stays
+// an ordinary annotation.
+func (f *SiloFile) takeTruncatedAttr() {
+	n, err := strconv.Atoi(f.Attrs[attrTruncated])
+	if err != nil || n <= 0 {
+		return
+	}
+	f.Truncated = n
+	attrs := make(map[string]string, len(f.Attrs)-1)
+	for key, value := range f.Attrs {
+		if key != attrTruncated {
+			attrs[key] = value
+		}
+	}
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	f.Attrs = attrs
+}
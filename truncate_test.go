@@ -0,0 +1,72 @@
+package silo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTruncateLines(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "long.log", Content: "1\n2\n3\n4\n5\n"},
+		{Path: "short.txt", Content: "1\n2\n"},
+		{Path: "no-newline.txt", Content: "1\n2\n3\n4"},
+		{Path: "one-over.txt", Content: "1\n2\n3\n"},
+		{Path: "blob.bin", ContentBytes: []byte("\x00\n\n\n\n"), Base64: true},
+		{Path: "link", LinkTarget: "long.log"},
+	}}
+	truncated := doc.TruncateLines(2)
+	if expected := []string{"long.log", "no-newline.txt", "one-over.txt"}; !reflect.DeepEqual(truncated, expected) {
+		t.Errorf("Expected %v truncated, got %v", expected, truncated)
+	}
+
+	expected := map[string]struct {
+		content   string
+		truncated int
+	}{
+		"long.log":       {"1\n2\n[... 3 lines truncated ...]\n", 3},
+		"short.txt":      {"1\n2\n", 0},
+		"no-newline.txt": {"1\n2\n[... 2 lines truncated ...]\n", 2},
+		"one-over.txt":   {"1\n2\n[... 1 line truncated ...]\n", 1},
+	}
+	for _, file := range doc.Files {
+		want, ok := expected[file.Path]
+		if !ok {
+			continue
+		}
+		if file.Content != want.content || file.Truncated != want.truncated {
+			t.Errorf("%s: got %q truncated %d, want %q truncated %d", file.Path, file.Content, file.Truncated, want.content, want.truncated)
+		}
+	}
+	if got := doc.TruncateLines(0); got != nil {
+		t.Errorf("Expected no truncation without a limit, got %v", got)
+	}
+}
+
+func TestTruncatedRoundTrip(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "big.log", Content: strings.Repeat("line\n", 10), Attrs: map[string]string{"lang": "log"}},
+		{Path: "small.txt", Content: "x\n"},
+	}}
+	doc.TruncateLines(4)
+
+	var buf bytes.Buffer
+	if err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "> big.log lang=log truncated=6\n") {
+		t.Errorf("Expected a truncated annotation, got:\n%s", buf.String())
+	}
+	parsed, err := ParseSiloFile(&buf)
+	if err != nil {
+		t.Fatalf("ParseSiloFile failed: %v", err)
+	}
+	big := parsed.Files[0]
+	if big.Truncated != 6 || !reflect.DeepEqual(big.Attrs, map[string]string{"lang": "log"}) {
+		t.Errorf("Expected Truncated 6 and only the lang annotation, got %d %v", big.Truncated, big.Attrs)
+	}
+	if parsed.Files[1].Truncated != 0 || parsed.Files[1].Attrs != nil {
+		t.Errorf("Expected small.txt untouched, got %+v", parsed.Files[1])
+	}
+}
@@ -0,0 +1,82 @@
+package silo
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// UpdateFromDirectory refreshes doc, a previous pack of rootPath, so that it
+// matches the directory's current contents. See UpdateFromDirectoryContext.
+func (doc *SiloDocument) UpdateFromDirectory(rootPath string) error {
+	return doc.UpdateFromDirectoryContext(context.Background(), rootPath, ReadDirectoryTreeOptions{})
+}
+
+// UpdateFromDirectoryContext walks rootPath like ReadDirectoryTreeContext but
+// reuses content from doc instead of reading files that have not changed
+// since doc was created. A file is considered unchanged when its size matches
+// the entry's content and it was last modified before doc.Header.Created.
+// Without a header recording when the baseline was created every file is
+// re-read. Entries for files that no longer exist are dropped.
+//
+// On success doc.Files holds the new tree and, if doc has a header, its
+// Created time is set to when the update started, so the result can serve
+// as the baseline for the next update. On error doc is unchanged.
+func (doc *SiloDocument) UpdateFromDirectoryContext(ctx context.Context, rootPath string, opts ReadDirectoryTreeOptions) error {
+	started := time.Now()
+	updated, err := readDirectoryTree(ctx, rootPath, opts, doc.unchangedFiles())
+	if err != nil {
+		return err
+	}
+
+	doc.Files = updated.Files
+	doc.Skipped = updated.Skipped
+	if doc.Header != nil {
+		doc.Header.Created = started
+	}
+	return nil
+}
+
+// unchangedFiles returns a reuse function for readDirectoryTree that hands
+// back doc's content for files of the same size last modified before
+// doc.Header.Created, or nil if doc has no header to say when that was.
+func (doc *SiloDocument) unchangedFiles() func(relPath string, info os.FileInfo) (string, bool) {
+	if doc.Header == nil || doc.Header.Created.IsZero() {
+		return nil
+	}
+	baseline := doc.Header.Created
+	previous := make(map[string]*SiloFile, len(doc.Files))
+	for i := range doc.Files {
+		if doc.Files[i].LinkTarget == "" && doc.Files[i].Ref == "" {
+			previous[doc.Files[i].Path] = &doc.Files[i]
+		}
+	}
+	return func(relPath string, info os.FileInfo) (string, bool) {
+		old, ok := previous[relPath]
+		if !ok || info.Size() != int64(old.Size()) || !info.ModTime().Before(baseline) {
+			return "", false
+		}
+		return old.Text(), true
+	}
+}
+
+// CompareToDirectory reports how the files under dir differ from doc, a
+// snapshot of it, like git status: Removed lists entries whose file is
+// missing, Added files on disk the archive lacks, and Modified entries
+// whose file has changed. See CompareToDirectoryContext.
+func (doc *SiloDocument) CompareToDirectory(dir string) (DocumentDiff, error) {
+	return doc.CompareToDirectoryContext(context.Background(), dir, ReadDirectoryTreeOptions{})
+}
+
+// CompareToDirectoryContext walks dir like ReadDirectoryTreeContext with
+// opts and compares the result with doc as Diff does. As for
+// UpdateFromDirectoryContext, a file of the entry's size last modified
+// before doc.Header.Created is taken to be unchanged without being read;
+// without a header every file is read and compared. doc is not changed.
+func (doc *SiloDocument) CompareToDirectoryContext(ctx context.Context, dir string, opts ReadDirectoryTreeOptions) (DocumentDiff, error) {
+	current, err := readDirectoryTree(ctx, dir, opts, doc.unchangedFiles())
+	if err != nil {
+		return DocumentDiff{}, err
+	}
+	return doc.Diff(current), nil
+}
@@ -0,0 +1,130 @@
+package silo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpdateFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"same.txt":    "new",
+		"resized.txt": "longer now",
+		"added.txt":   "added",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	created := time.Now().Add(time.Hour)
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Header:    &FormatHeader{Created: created},
+		Files: []SiloFile{
+			// Same size and older than the baseline, so the stale content
+			// is kept: that proves the file was not re-read.
+			{Path: "same.txt", Content: "old"},
+			{Path: "resized.txt", Content: "short"},
+			{Path: "removed.txt", Content: "gone"},
+		},
+	}
+
+	if err := doc.UpdateFromDirectory(dir); err != nil {
+		t.Fatalf("UpdateFromDirectory failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"added.txt":   "added",
+		"resized.txt": "longer now",
+		"same.txt":    "old",
+	}
+	if len(doc.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), docPaths(doc))
+	}
+	for _, file := range doc.Files {
+		if file.Content != expected[file.Path] {
+			t.Errorf("%s: expected %q, got %q", file.Path, expected[file.Path], file.Content)
+		}
+	}
+	if !doc.Header.Created.Before(created) {
+		t.Errorf("Expected Created to be reset to the update time, got %v", doc.Header.Created)
+	}
+}
+
+func TestUpdateFromDirectoryWithoutHeader(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "old"}}}
+	if err := doc.UpdateFromDirectory(dir); err != nil {
+		t.Fatalf("UpdateFromDirectory failed: %v", err)
+	}
+	if doc.Files[0].Content != "new" {
+		t.Errorf("Expected a.txt to be re-read without a baseline time, got %q", doc.Files[0].Content)
+	}
+	if doc.Header != nil {
+		t.Error("UpdateFromDirectory should not add a header")
+	}
+}
+
+func TestUpdateFromDirectoryLeavesDocOnError(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{{Path: "a.txt", Content: "old"}}}
+	if err := doc.UpdateFromDirectory(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("Expected error for missing directory")
+	}
+	if len(doc.Files) != 1 || doc.Files[0].Content != "old" {
+		t.Errorf("Document changed after failed update: %+v", doc.Files)
+	}
+}
+
+func TestCompareToDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"same.txt":    "same\n",
+		"changed.txt": "changed on disk\n",
+		"extra.txt":   "new\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "changed.txt", Content: "as archived\n"},
+			{Path: "missing.txt", Content: "gone\n"},
+			{Path: "same.txt", Content: "same\n"},
+		},
+	}
+
+	diff, err := doc.CompareToDirectory(dir)
+	if err != nil {
+		t.Fatalf("CompareToDirectory failed: %v", err)
+	}
+	want := DocumentDiff{Added: []string{"extra.txt"}, Removed: []string{"missing.txt"}, Modified: []string{"changed.txt"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Expected %+v, got %+v", want, diff)
+	}
+	if len(doc.Files) != 3 || doc.Files[0].Content != "as archived\n" {
+		t.Errorf("Expected the document to be left alone, got %+v", doc.Files)
+	}
+
+	// With a header, a file of the same size older than the archive is
+	// trusted without being read.
+	doc.Header = &FormatHeader{Created: time.Now().Add(time.Hour)}
+	doc.Files[2].Content = "SAME\n"
+	diff, err = doc.CompareToDirectory(dir)
+	if err != nil {
+		t.Fatalf("CompareToDirectory failed: %v", err)
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Expected %+v with a header, got %+v", want, diff)
+	}
+}
@@ -0,0 +1,122 @@
+package silo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxPathLength is the longest entry path Validate accepts, in bytes.
+	maxPathLength = 4096
+	// maxPathComponentLength is the longest single path component Validate
+	// accepts, matching the common filesystem NAME_MAX.
+	maxPathComponentLength = 255
+)
+
+// ValidationError describes one problem found by Validate.
+type ValidationError struct {
+	// Path is the entry the problem belongs to, or "" for document-level
+	// problems.
+	Path string
+	// Line is the 1-based line within the entry's content, when relevant.
+	Line    int
+	Problem string
+}
+
+func (e ValidationError) Error() string {
+	switch {
+	case e.Path == "":
+		return e.Problem
+	case e.Line > 0:
+		return fmt.Sprintf("%s line %d: %s", e.Path, e.Line, e.Problem)
+	default:
+		return fmt.Sprintf("%s: %s", e.Path, e.Problem)
+	}
+}
+
+// Validate checks every entry and returns all problems found, in document
+// order, rather than stopping at the first as parsing does. It reports
+// illegal and overly long paths, duplicate paths, paths that differ only in
+// case, invalid delimiters and ones read as a format header, content lines
+// that collide with the document's delimiter, content ending in a line that
+// reads as the missing-newline marker or a signature trailer, link targets
+// holding control characters, malformed references, and annotations that
+// cannot be written. A nil result means the document can be written and
+// unpacked.
+func (doc *SiloDocument) Validate() []ValidationError {
+	var problems []ValidationError
+
+	if doc.Delimiter != "" {
+		for _, r := range doc.Delimiter {
+			if !isValidDelimiterChar(r) {
+				problems = append(problems, ValidationError{Problem: fmt.Sprintf("delimiter %q contains whitespace", doc.Delimiter)})
+				break
+			}
+		}
+		if isReservedDelimiter(doc.Delimiter) {
+			problems = append(problems, ValidationError{Problem: fmt.Sprintf("delimiter %q would be read back as a header line", doc.Delimiter)})
+		}
+	}
+
+	seen := make(map[string]bool)
+	folded := make(map[string]string)
+
+	for _, file := range doc.Files {
+		if err := validatePath(file.Path); err != nil {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: err.Error()})
+		}
+
+		if len(file.Path) > maxPathLength {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: fmt.Sprintf("path is longer than %d bytes", maxPathLength)})
+		}
+		for _, component := range strings.Split(file.Path, "/") {
+			if len(component) > maxPathComponentLength {
+				problems = append(problems, ValidationError{Path: file.Path, Problem: fmt.Sprintf("path component is longer than %d bytes", maxPathComponentLength)})
+				break
+			}
+		}
+
+		key := strings.ToLower(file.Path)
+		if seen[file.Path] {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: "duplicate path"})
+		} else if other, collides := folded[key]; collides {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: fmt.Sprintf("differs only in case from %s", other)})
+		} else {
+			folded[key] = file.Path
+		}
+		seen[file.Path] = true
+
+		if doc.Delimiter != "" && !file.Base64 {
+			for _, line := range delimiterLines(file.text(), doc.Delimiter, 0) {
+				problems = append(problems, ValidationError{Path: file.Path, Line: line, Problem: fmt.Sprintf("line collides with delimiter %q", doc.Delimiter)})
+			}
+		}
+
+		if err := validateAttrs(file.Attrs); err != nil {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: err.Error()})
+		}
+		if err := checkLinkText(file.LinkTarget); err != nil {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: err.Error()})
+		}
+		if endsWithAttr(file.Path) || endsWithAttr(file.LinkTarget) {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: "path ends in a word that would be read back as an annotation"})
+		}
+		if file.Ref != "" {
+			if err := validateRef(file.Ref); err != nil {
+				problems = append(problems, ValidationError{Path: file.Path, Problem: err.Error()})
+			}
+		}
+		if _, ref := splitRef(file.Path); ref != "" {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: fmt.Sprintf("path ends in %q, which would be read back as a marker", refMarker+ref)})
+		}
+
+		if !file.Base64 && endsWithNoNewlineMarker(file.text()) {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: "last line would be read back as the missing-newline marker"})
+		}
+		if !file.Base64 && endsWithSignatureTrailer(file.text()) {
+			problems = append(problems, ValidationError{Path: file.Path, Problem: "last line would be read back as a signature trailer"})
+		}
+	}
+
+	return problems
+}
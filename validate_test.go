@@ -0,0 +1,61 @@
+package silo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: ">",
+		Files: []SiloFile{
+			{Path: "README.md", Content: "ok\n"},
+			{Path: "../escape.txt", Content: "x\n"},
+			{Path: "Readme.md", Content: "case\n"},
+			{Path: "README.md", Content: "again\n"},
+			{Path: "notes.txt", Content: "fine\n> collides\n"},
+			{Path: strings.Repeat("a", 300) + ".txt", Content: ""},
+		},
+	}
+
+	problems := doc.Validate()
+
+	expected := []string{
+		"../escape.txt: parent directory references not allowed",
+		"Readme.md: differs only in case from README.md",
+		"README.md: duplicate path",
+		"notes.txt line 2: line collides with delimiter \">\"",
+		"path component is longer than 255 bytes",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, want := range expected {
+		if !strings.Contains(problems[i].Error(), want) {
+			t.Errorf("Problem %d: expected %q, got %q", i, want, problems[i].Error())
+		}
+	}
+}
+
+func TestValidateCleanDocument(t *testing.T) {
+	doc := &SiloDocument{
+		Delimiter: "🌾",
+		Files: []SiloFile{
+			{Path: "a.txt", Content: "> not a collision\n"},
+			{Path: "dir/b.txt", Content: "text\n"},
+		},
+	}
+
+	if problems := doc.Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateInvalidDelimiter(t *testing.T) {
+	doc := &SiloDocument{Delimiter: "a b", Files: []SiloFile{{Path: "a.txt"}}}
+
+	problems := doc.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "contains whitespace") {
+		t.Errorf("Expected whitespace delimiter problem, got %v", problems)
+	}
+}
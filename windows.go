@@ -0,0 +1,132 @@
+package silo
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// WindowsPathPolicy controls how WriteToDirectoryWithOptions treats entry
+// paths that cannot be created on Windows: reserved device names such as CON
+// or aux.txt, characters like ':' or '?', and names ending in a dot or space.
+type WindowsPathPolicy int
+
+const (
+	// WindowsPathsAuto behaves like WindowsPathsError when running on
+	// Windows and like WindowsPathsAllow elsewhere.
+	WindowsPathsAuto WindowsPathPolicy = iota
+	// WindowsPathsAllow writes paths unchanged.
+	WindowsPathsAllow
+	// WindowsPathsError refuses to unpack Windows-illegal paths.
+	WindowsPathsError
+	// WindowsPathsRename rewrites Windows-illegal paths with
+	// SanitizeWindowsPath before writing.
+	WindowsPathsRename
+)
+
+// ParseWindowsPathPolicy converts a policy name (auto, allow, error, rename)
+// into a WindowsPathPolicy.
+func ParseWindowsPathPolicy(name string) (WindowsPathPolicy, error) {
+	switch name {
+	case "auto":
+		return WindowsPathsAuto, nil
+	case "allow":
+		return WindowsPathsAllow, nil
+	case "error":
+		return WindowsPathsError, nil
+	case "rename":
+		return WindowsPathsRename, nil
+	}
+	return 0, fmt.Errorf("unknown Windows path policy %q (want auto, allow, error or rename)", name)
+}
+
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+const windowsIllegalChars = `<>:"|?*\`
+
+// windowsComponentProblem describes why a single path component is illegal
+// on Windows, or returns "" if it is fine.
+func windowsComponentProblem(component string) string {
+	for _, r := range component {
+		if r < 0x20 || strings.ContainsRune(windowsIllegalChars, r) {
+			return fmt.Sprintf("character %q is not allowed on Windows", r)
+		}
+	}
+	if strings.HasSuffix(component, ".") || strings.HasSuffix(component, " ") {
+		return "names ending in a dot or space are not allowed on Windows"
+	}
+	base, _, _ := strings.Cut(component, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		return fmt.Sprintf("%s is a reserved device name on Windows", base)
+	}
+	return ""
+}
+
+// CheckWindowsPath returns an error if any component of the slash-separated
+// path cannot be created on Windows.
+func CheckWindowsPath(path string) error {
+	for _, component := range strings.Split(path, "/") {
+		if problem := windowsComponentProblem(component); problem != "" {
+			return invalidPathError("%s: %s", path, problem)
+		}
+	}
+	return nil
+}
+
+// SanitizeWindowsPath rewrites each component of the slash-separated path so
+// it can be created on Windows: illegal characters become '_', trailing dots
+// and spaces are dropped, and reserved device names get a '_' suffix
+// (aux.txt becomes aux_.txt). Legal paths are returned unchanged.
+func SanitizeWindowsPath(path string) string {
+	components := strings.Split(path, "/")
+	for i, component := range components {
+		if windowsComponentProblem(component) == "" {
+			continue
+		}
+
+		component = strings.Map(func(r rune) rune {
+			if r < 0x20 || strings.ContainsRune(windowsIllegalChars, r) {
+				return '_'
+			}
+			return r
+		}, component)
+		component = strings.TrimRight(component, ". ")
+		if component == "" {
+			component = "_"
+		}
+
+		base, ext, hasExt := strings.Cut(component, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			component = base + "_"
+			if hasExt {
+				component += "." + ext
+			}
+		}
+		components[i] = component
+	}
+	return strings.Join(components, "/")
+}
+
+// resolveWindowsPath applies policy to path, returning the path to write.
+func resolveWindowsPath(path string, policy WindowsPathPolicy) (string, error) {
+	if policy == WindowsPathsAuto {
+		policy = WindowsPathsAllow
+		if runtime.GOOS == "windows" {
+			policy = WindowsPathsError
+		}
+	}
+
+	switch policy {
+	case WindowsPathsError:
+		return path, CheckWindowsPath(path)
+	case WindowsPathsRename:
+		return SanitizeWindowsPath(path), nil
+	}
+	return path, nil
+}
@@ -0,0 +1,103 @@
+package silo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckWindowsPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"src/main.go", true},
+		{"docs/console.txt", true},
+		{"CON", false},
+		{"dir/aux.txt", false},
+		{"Com1.log", false},
+		{"lpt9", false},
+		{"a:b.txt", false},
+		{"what?.md", false},
+		{"trailing.", false},
+		{"trailing /file", false},
+		{"back\\slash", false},
+	}
+
+	for _, test := range tests {
+		err := CheckWindowsPath(test.path)
+		if test.valid && err != nil {
+			t.Errorf("Expected %q to be valid, got %v", test.path, err)
+		}
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Expected %q to be rejected", test.path)
+			} else if !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("Expected ErrInvalidPath for %q, got %v", test.path, err)
+			}
+		}
+	}
+}
+
+func TestSanitizeWindowsPath(t *testing.T) {
+	tests := map[string]string{
+		"src/main.go":    "src/main.go",
+		"CON":            "CON_",
+		"dir/aux.txt":    "dir/aux_.txt",
+		"a:b.txt":        "a_b.txt",
+		"what?.md":       "what_.md",
+		"trailing.":      "trailing",
+		"trailing /file": "trailing/file",
+		"nul.tar.gz":     "nul_.tar.gz",
+		"...":            "_",
+	}
+
+	for input, expected := range tests {
+		got := SanitizeWindowsPath(input)
+		if got != expected {
+			t.Errorf("SanitizeWindowsPath(%q) = %q, expected %q", input, got, expected)
+		}
+		if err := CheckWindowsPath(got); err != nil {
+			t.Errorf("Sanitized path %q is still invalid: %v", got, err)
+		}
+	}
+}
+
+func TestWriteToDirectoryWindowsPolicies(t *testing.T) {
+	doc := &SiloDocument{
+		Files: []SiloFile{
+			{Path: "ok.txt", Content: "ok\n"},
+			{Path: "dir/aux.txt", Content: "aux\n"},
+		},
+	}
+
+	err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{WindowsPaths: WindowsPathsError})
+	if err == nil || !strings.Contains(err.Error(), "reserved device name") {
+		t.Errorf("Expected reserved name error, got %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if err := doc.WriteToDirectoryWithOptions(outputDir, UnpackOptions{WindowsPaths: WindowsPathsRename}); err != nil {
+		t.Fatalf("WriteToDirectoryWithOptions failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "dir", "aux_.txt"))
+	if err != nil || string(content) != "aux\n" {
+		t.Errorf("Expected renamed file dir/aux_.txt, got %q (%v)", content, err)
+	}
+}
+
+func TestWriteToDirectoryWindowsRenameCollision(t *testing.T) {
+	doc := &SiloDocument{
+		Files: []SiloFile{
+			{Path: "a:b", Content: "one\n"},
+			{Path: "a?b", Content: "two\n"},
+		},
+	}
+
+	err := doc.WriteToDirectoryWithOptions(t.TempDir(), UnpackOptions{WindowsPaths: WindowsPathsRename})
+	if !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("Expected ErrDuplicatePath for colliding renames, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package silo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// WriteFS is a writable filesystem that documents can be unpacked into. Names
+// are slash-separated and relative to the filesystem's root, as with fs.FS.
+// Adapters for libraries such as afero or billy only need to wrap these two
+// methods.
+type WriteFS interface {
+	MkdirAll(name string, perm os.FileMode) error
+	OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error)
+}
+
+// SymlinkFS is implemented by a WriteFS that can create symbolic links.
+// Unpacking link entries into a WriteFS without it fails.
+type SymlinkFS interface {
+	WriteFS
+	Symlink(target, name string) error
+}
+
+// DirWriteFS returns a WriteFS that writes under the OS directory root.
+func DirWriteFS(root string) WriteFS {
+	return dirWriteFS(root)
+}
+
+type dirWriteFS string
+
+func (dir dirWriteFS) path(name string) string {
+	return filepath.Join(string(dir), filepath.FromSlash(name))
+}
+
+func (dir dirWriteFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(dir.path(name), perm)
+}
+
+func (dir dirWriteFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(dir.path(name), flag, perm)
+}
+
+func (dir dirWriteFS) Symlink(target, name string) error {
+	full := dir.path(name)
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(filepath.FromSlash(target), full)
+}
+
+// WriteToFS writes every entry into fsys. See WriteToFSWithOptions.
+func (doc *SiloDocument) WriteToFS(fsys WriteFS) error {
+	return doc.WriteToFSWithOptions(fsys, UnpackOptions{})
+}
+
+// WriteToFSWithOptions writes every entry into fsys, applying opts as
+// WriteToDirectoryWithOptions does. FileMode and DirMode are passed to fsys;
+// HonorUmask has no effect, since applying them is up to the filesystem.
+func (doc *SiloDocument) WriteToFSWithOptions(fsys WriteFS, opts UnpackOptions) error {
+	fileMode, dirMode := opts.FileMode, opts.DirMode
+	if fileMode == 0 {
+		fileMode = defaultFileMode
+	}
+	if dirMode == 0 {
+		dirMode = defaultDirMode
+	}
+
+	names, err := unpackPaths(doc.Files, opts)
+	if err != nil {
+		return err
+	}
+	for i, file := range doc.Files {
+		name := names[i]
+		if name == "" {
+			continue
+		}
+
+		if dir := path.Dir(name); dir != "." {
+			if err := fsys.MkdirAll(dir, dirMode); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", dir, err)
+			}
+		}
+
+		if file.LinkTarget != "" {
+			linker, ok := fsys.(SymlinkFS)
+			if !ok {
+				return fmt.Errorf("cannot create symlink %s: filesystem does not support symlinks", name)
+			}
+			if err := validateLinkTarget(".", name, file.LinkTarget); err != nil {
+				return err
+			}
+			if err := linker.Symlink(file.LinkTarget, name); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", name, err)
+			}
+			logDebug(opts.Logger, "unpacked link", "path", name, "target", file.LinkTarget)
+			continue
+		}
+
+		content := unpackedContent(file, opts.LineEndings)
+		if file.Ref != "" {
+			if content, err = resolveRef(file, opts.ResolveRef); err != nil {
+				return err
+			}
+		}
+		if err := writeFSFile(fsys, name, content, fileMode); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", name, err)
+		}
+		logDebug(opts.Logger, "unpacked file", "path", name, "bytes", len(content))
+	}
+	return nil
+}
+
+func writeFSFile(fsys WriteFS, name string, content []byte, mode os.FileMode) error {
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,74 @@
+package silo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memWriteFS is a minimal in-memory WriteFS.
+type memWriteFS struct {
+	files map[string]*bytes.Buffer
+	dirs  map[string]bool
+}
+
+func newMemWriteFS() *memWriteFS {
+	return &memWriteFS{files: make(map[string]*bytes.Buffer), dirs: make(map[string]bool)}
+}
+
+func (m *memWriteFS) MkdirAll(name string, perm os.FileMode) error {
+	m.dirs[name] = true
+	return nil
+}
+
+type memFile struct{ *bytes.Buffer }
+
+func (memFile) Close() error { return nil }
+
+func (m *memWriteFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	buf := &bytes.Buffer{}
+	m.files[name] = buf
+	return memFile{buf}, nil
+}
+
+func TestWriteToFS(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "a.txt", Content: "a\n"},
+		{Path: "deep/dir/b.txt", Content: "b\n"},
+	}}
+
+	fsys := newMemWriteFS()
+	if err := doc.WriteToFS(fsys); err != nil {
+		t.Fatalf("WriteToFS failed: %v", err)
+	}
+	if fsys.files["a.txt"].String() != "a\n" || fsys.files["deep/dir/b.txt"].String() != "b\n" {
+		t.Errorf("Unexpected files: %v", fsys.files)
+	}
+	if !fsys.dirs["deep/dir"] {
+		t.Errorf("Expected deep/dir to be created, got %v", fsys.dirs)
+	}
+}
+
+func TestWriteToFSSymlinks(t *testing.T) {
+	doc := &SiloDocument{Files: []SiloFile{
+		{Path: "target.txt", Content: "t\n"},
+		{Path: "link.txt", LinkTarget: "target.txt"},
+	}}
+
+	err := doc.WriteToFS(newMemWriteFS())
+	if err == nil || !strings.Contains(err.Error(), "does not support symlinks") {
+		t.Errorf("Expected symlink support error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := doc.WriteToFS(DirWriteFS(dir)); err != nil {
+		t.Fatalf("WriteToFS into DirWriteFS failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "link.txt"))
+	if err != nil || string(data) != "t\n" {
+		t.Errorf("Expected link to resolve to target.txt, got %q (%v)", data, err)
+	}
+}